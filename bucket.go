@@ -41,6 +41,13 @@ type Bucket struct {
 	Description         string        `json:"description"`
 	RetentionPolicyName string        `json:"rp,omitempty"` // This to support v1 sources
 	RetentionPeriod     time.Duration `json:"retentionPeriod"`
+	SchemaType          SchemaType    `json:"schemaType,omitempty"`
+	// Fields are the field keys that may be written
+	// to a bucket with an explicit schema.
+	Fields []string `json:"fields,omitempty"`
+	// MeasurementRetention overrides the retention period
+	// of the measurements of a bucket that expire sooner.
+	MeasurementRetention map[string]time.Duration `json:"measurementRetention,omitempty"`
 	CRUDLog
 }
 
@@ -63,6 +70,43 @@ func ParseBucketType(s string) BucketType {
 	return BucketTypeUser
 }
 
+// SchemaType determines whether the fields written to a bucket must be declared.
+type SchemaType string
+
+const (
+	// SchemaTypeImplicit accepts any field written to the bucket.
+	SchemaTypeImplicit = SchemaType("implicit")
+	// SchemaTypeExplicit only accepts the fields declared for the bucket.
+	SchemaTypeExplicit = SchemaType("explicit")
+)
+
+// ParseSchemaType parses a schema type from a string.
+func ParseSchemaType(s string) (SchemaType, error) {
+	switch st := SchemaType(s); st {
+	case SchemaTypeImplicit, SchemaTypeExplicit:
+		return st, nil
+	default:
+		return "", &Error{
+			Code: EInvalid,
+			Msg:  fmt.Sprintf("invalid schema type %q: must be %q or %q", s, SchemaTypeImplicit, SchemaTypeExplicit),
+		}
+	}
+}
+
+// HasField returns true if the field may be written to the bucket.
+// Every field may be written to a bucket without an explicit schema.
+func (b *Bucket) HasField(field string) bool {
+	if b.SchemaType != SchemaTypeExplicit {
+		return true
+	}
+	for _, f := range b.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
 // ops for buckets error and buckets op logs.
 var (
 	OpFindBucketByID = "FindBucketByID"
@@ -104,6 +148,11 @@ type BucketUpdate struct {
 	Name            *string        `json:"name,omitempty"`
 	Description     *string        `json:"description,omitempty"`
 	RetentionPeriod *time.Duration `json:"retentionPeriod,omitempty"`
+	SchemaType      *SchemaType    `json:"schemaType,omitempty"`
+	Fields          *[]string      `json:"fields,omitempty"`
+	// MeasurementRetention replaces the retention periods
+	// of the measurements of the bucket when it is set.
+	MeasurementRetention *map[string]time.Duration `json:"measurementRetention,omitempty"`
 }
 
 // BucketFilter represents a set of filter that restrict the returned results.
@@ -0,0 +1,9 @@
+package influxdb
+
+import "context"
+
+// BucketSizeService estimates the amount of disk space used by buckets.
+type BucketSizeService interface {
+	// BucketSize returns an estimate of the number of bytes used by the bucket.
+	BucketSize(ctx context.Context, orgID, bucketID ID) (int64, error)
+}
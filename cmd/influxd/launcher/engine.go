@@ -30,12 +30,15 @@ type Engine interface {
 	storage.BucketDeleter
 	prom.PrometheusCollector
 	influxdb.BackupService
+	influxdb.BucketSizeService
 
 	SeriesCardinality() int64
+	Degraded() error
 
 	WithLogger(log *zap.Logger)
 	Open(context.Context) error
 	Close() error
+	CloseContext(context.Context) error
 }
 
 var _ Engine = (*TemporaryEngine)(nil)
@@ -95,11 +98,17 @@ func (t *TemporaryEngine) Open(ctx context.Context) error {
 
 // Close will remove the directory containing the time-series files.
 func (t *TemporaryEngine) Close() error {
+	return t.CloseContext(context.Background())
+}
+
+// CloseContext closes the engine within ctx and removes
+// the directory containing the time-series files.
+func (t *TemporaryEngine) CloseContext(ctx context.Context) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	t.opened = false
-	err := t.engine.Close()
+	err := t.engine.CloseContext(ctx)
 	_ = os.RemoveAll(t.path)
 	return err
 }
@@ -114,12 +123,22 @@ func (t *TemporaryEngine) SeriesCardinality() int64 {
 	return t.engine.SeriesCardinality()
 }
 
+// Degraded returns the reason the engine opened in a degraded state.
+func (t *TemporaryEngine) Degraded() error {
+	return t.engine.Degraded()
+}
+
 // DeleteBucketRangePredicate will delete a bucket from the range and predicate.
 func (t *TemporaryEngine) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID influxdb.ID, min, max int64, pred influxdb.Predicate) error {
 	return t.engine.DeleteBucketRangePredicate(ctx, orgID, bucketID, min, max, pred)
 
 }
 
+// BucketSize returns an estimate of the number of bytes used by the bucket.
+func (t *TemporaryEngine) BucketSize(ctx context.Context, orgID, bucketID influxdb.ID) (int64, error) {
+	return t.engine.BucketSize(ctx, orgID, bucketID)
+}
+
 // DeleteBucket deletes a bucket from the time-series data.
 func (t *TemporaryEngine) DeleteBucket(ctx context.Context, orgID, bucketID influxdb.ID) error {
 	return t.engine.DeleteBucket(ctx, orgID, bucketID)
@@ -141,6 +160,11 @@ func (t *TemporaryEngine) CreateCursorIterator(ctx context.Context) (cursors.Cur
 	return t.engine.CreateCursorIterator(ctx)
 }
 
+// ReadBlockStats calls into the underlying engines ReadBlockStats.
+func (t *TemporaryEngine) ReadBlockStats(ctx context.Context, orgID, bucketID influxdb.ID, start, end int64) ([]cursors.BlockStat, error) {
+	return t.engine.ReadBlockStats(ctx, orgID, bucketID, start, end)
+}
+
 // CreateSeriesCursor calls into the underlying engines CreateSeriesCursor.
 func (t *TemporaryEngine) CreateSeriesCursor(ctx context.Context, orgID, bucketID influxdb.ID, cond influxql.Expr) (storage.SeriesCursor, error) {
 	return t.engine.CreateSeriesCursor(ctx, orgID, bucketID, cond)
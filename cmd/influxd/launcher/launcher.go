@@ -6,13 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
 	"net"
 	nethttp "net/http"
 	_ "net/http/pprof" // needed to add pprof to our binary.
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/influxdata/flux"
@@ -63,17 +67,20 @@ import (
 	"github.com/influxdata/influxdb/v2/task/backend/scheduler"
 	"github.com/influxdata/influxdb/v2/telemetry"
 	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/influxdata/influxdb/v2/toml"
 	_ "github.com/influxdata/influxdb/v2/tsdb/tsi1" // needed for tsi1
-	_ "github.com/influxdata/influxdb/v2/tsdb/tsm1" // needed for tsm1
+	"github.com/influxdata/influxdb/v2/tsdb/tsm1"
 	"github.com/influxdata/influxdb/v2/vault"
 	pzap "github.com/influxdata/influxdb/v2/zap"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
+	"github.com/uber/jaeger-client-go"
 	jaegerconfig "github.com/uber/jaeger-client-go/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -137,15 +144,7 @@ func cmdRunE(ctx context.Context, l *Launcher) func() error {
 		}
 
 		var wg sync.WaitGroup
-		if !l.ReportingDisabled() {
-			reporter := telemetry.NewReporter(l.Log(), l.Registry())
-			reporter.Interval = 8 * time.Hour
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				reporter.Report(ctx)
-			}()
-		}
+		startReporter(ctx, l, &wg)
 
 		<-ctx.Done()
 
@@ -159,6 +158,26 @@ func cmdRunE(ctx context.Context, l *Launcher) func() error {
 	}
 }
 
+// startReporter starts reporting telemetry until ctx is done in a
+// goroutine tracked by wg, and returns its reporter. When reporting is
+// disabled, the reporter is never constructed so that no outbound
+// connection is ever attempted, and nil is returned.
+func startReporter(ctx context.Context, l *Launcher, wg *sync.WaitGroup) *telemetry.Reporter {
+	if l.ReportingDisabled() {
+		l.Log().Info("Telemetry reporting is disabled, no telemetry data will be sent")
+		return nil
+	}
+
+	reporter := telemetry.NewReporter(l.Log(), l.Registry())
+	reporter.Interval = 8 * time.Hour
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		reporter.Report(ctx)
+	}()
+	return reporter
+}
+
 var vaultConfig vault.Config
 
 func setLauncherCMDOpts(l *Launcher, cmd *cobra.Command) {
@@ -179,18 +198,36 @@ func launcherOpts(l *Launcher) []cli.Opt {
 			Default: zapcore.InfoLevel.String(),
 			Desc:    "supported log levels are debug, info, and error",
 		},
+		{
+			DestP:   &l.logFormat,
+			Flag:    "log-format",
+			Default: "auto",
+			Desc:    "supported log formats are auto, json, and console. The console format falls back to logfmt when the output is not a terminal",
+		},
 		{
 			DestP:   &l.tracingType,
 			Flag:    "tracing-type",
 			Default: "",
 			Desc:    fmt.Sprintf("supported tracing types are %s, %s", LogTracing, JaegerTracing),
 		},
+		{
+			DestP:   &l.tracingSampleRate,
+			Flag:    "tracing-sample-rate",
+			Default: 1.0,
+			Desc:    fmt.Sprintf("the fraction of traces to record between 0 and 1; only supported by %s tracing", JaegerTracing),
+		},
 		{
 			DestP:   &l.httpBindAddress,
 			Flag:    "http-bind-address",
 			Default: ":9999",
 			Desc:    "bind address for the REST HTTP API",
 		},
+		{
+			DestP:   &l.httpMaxWriteBatchBytes,
+			Flag:    "http-max-write-batch-bytes",
+			Default: 0,
+			Desc:    "the maximum number of bytes of line protocol accepted by a single write, after any decompression. A write that exceeds it is rejected with 413 Request Entity Too Large. If this is unset, then there is no limit",
+		},
 		{
 			DestP:   &l.boltPath,
 			Flag:    "bolt-path",
@@ -214,6 +251,12 @@ func launcherOpts(l *Launcher) []cli.Opt {
 			Default: false,
 			Desc:    "add /debug/flush endpoint to clear stores; used for end-to-end tests",
 		},
+		{
+			DestP:   &l.debugVars,
+			Flag:    "debug-vars",
+			Default: false,
+			Desc:    "add /debug/vars endpoint with runtime stats of the process and query counters as JSON",
+		},
 		{
 			DestP:   &l.enginePath,
 			Flag:    "engine-path",
@@ -244,6 +287,18 @@ func launcherOpts(l *Launcher) []cli.Opt {
 			Default: false,
 			Desc:    "disables automatically extending session ttl on request",
 		},
+		{
+			DestP:   &l.sessionStore,
+			Flag:    "session-store",
+			Default: MemoryStore,
+			Desc:    "backing store for sessions (memory or bolt). Sessions in bolt persist across restarts",
+		},
+		{
+			DestP:   &l.sessionReapInterval,
+			Flag:    "session-reap-interval",
+			Default: time.Minute,
+			Desc:    "the interval at which expired sessions are removed from the session store",
+		},
 		{
 			DestP: &vaultConfig.Address,
 			Flag:  "vault-addr",
@@ -318,6 +373,12 @@ func launcherOpts(l *Launcher) []cli.Opt {
 			Default: false,
 			Desc:    "Restrict accept ciphers to: ECDHE_RSA_WITH_AES_256_GCM_SHA384, ECDHE_RSA_WITH_AES_256_CBC_SHA, RSA_WITH_AES_256_GCM_SHA384, RSA_WITH_AES_256_CBC_SHA",
 		},
+		{
+			DestP:   &l.httpTLSCiphers,
+			Flag:    "tls-ciphers",
+			Default: "",
+			Desc:    "Comma-separated list of accepted cipher suites, such as TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384. Overrides --tls-strict-ciphers when set",
+		},
 		{
 			DestP:   &l.noTasks,
 			Flag:    "no-tasks",
@@ -354,17 +415,177 @@ func launcherOpts(l *Launcher) []cli.Opt {
 			Default: 10,
 			Desc:    "the number of queries that are allowed to be awaiting execution before new queries are rejected",
 		},
+		{
+			DestP:   &l.admitByEstimatedMemory,
+			Flag:    "query-admit-by-estimated-memory",
+			Default: false,
+			Desc:    "queue each query until the memory estimated for its storage reads fits in query-max-memory-bytes, which must be set",
+		},
+		{
+			DestP:   &l.fallbackEstimatedMemoryBytes,
+			Flag:    "query-fallback-estimated-memory-bytes",
+			Default: 0,
+			Desc:    "the memory estimated for the admission of a query whose storage reads cannot be estimated. If this is unset, then this number is query-memory-bytes",
+		},
+		{
+			DestP:   &l.compileCacheSize,
+			Flag:    "query-compile-cache-size",
+			Default: 0,
+			Desc:    "the number of compiled Flux queries that are kept so that an identical query with a now time in the same query-compile-cache-now-precision interval is not compiled again. If this is unset, then queries are not cached",
+		},
+		{
+			DestP:   &l.compileCacheTTL,
+			Flag:    "query-compile-cache-ttl",
+			Default: time.Minute,
+			Desc:    "how long a compiled Flux query is kept after it was compiled",
+		},
+		{
+			DestP:   &l.compileCacheNowPrecision,
+			Flag:    "query-compile-cache-now-precision",
+			Default: time.Duration(0),
+			Desc:    "the interval that the now times of Flux queries are truncated to for the compile cache, so that a query repeated within the same interval reuses the compiled query and its now time. If this is unset, then this is query-compile-cache-ttl",
+		},
+		{
+			DestP: &l.metricQuerySources,
+			Flag:  "query-metric-sources",
+			Desc:  "the values of the X-Influx-Query-Source header that the query duration metrics are labeled with. The metrics of any other source are labeled as other",
+		},
+		{
+			DestP:   &l.maxCPUTime,
+			Flag:    "query-max-cpu-time",
+			Default: time.Duration(0),
+			Desc:    "the maximum time a single query is allowed to spend scanning storage. A query that exceeds it is canceled with an error. If this is unset, then there is no limit",
+		},
+		{
+			DestP:   &l.maxResponseBytes,
+			Flag:    "query-max-response-bytes",
+			Default: 0,
+			Desc:    "the maximum number of bytes written in the response of a single query. A query that exceeds it fails with an error. If this is unset, then there is no limit",
+		},
+		{
+			DestP:   &l.floatNaNPolicy,
+			Flag:    "query-float-nan-policy",
+			Default: string(http.FloatNaNPassthrough),
+			Desc:    "how NaN and infinite float values are encoded in the response of a query. Valid options are passthrough to encode them as they are, null to encode them as null and error to fail the query",
+		},
+		{
+			DestP:   &l.maxTables,
+			Flag:    "query-max-tables",
+			Default: 0,
+			Desc:    "the maximum number of tables produced by a single storage read of a query. A query that exceeds it fails with an error. If this is unset, then there is no limit",
+		},
+		{
+			DestP:   &l.queryDefaultRange,
+			Flag:    "query-default-range",
+			Default: time.Duration(0),
+			Desc:    "the range read by queries that call from() without a range(), counted back from now. If this is unset, then such queries fail with an error",
+		},
+		{
+			DestP:   &l.storageReadParallelism,
+			Flag:    "query-storage-read-parallelism",
+			Default: 1,
+			Desc:    "the number of tables that a storage read prepares concurrently. A value of 1 reads tables serially",
+		},
+		{
+			DestP:   &l.storageDecodeParallelism,
+			Flag:    "storage-decode-parallelism",
+			Default: 1,
+			Desc:    "the number of TSM blocks that storage reads decode concurrently ahead of the tables reading them. A value of 1 decodes blocks as they are read",
+		},
+		{
+			DestP:   &l.queryMaxCPUs,
+			Flag:    "query-max-cpus",
+			Default: 0,
+			Desc:    "the maximum number of workers that storage reads of queries use to prepare tables and decode blocks, which bounds query-storage-read-parallelism and storage-decode-parallelism independently of GOMAXPROCS. If this is unset, then there is no bound",
+		},
+		{
+			DestP:   &l.storageMaxOpenCursors,
+			Flag:    "storage-max-open-cursors",
+			Default: 0,
+			Desc:    "the maximum number of cursors that each storage read has open at once. Opening another cursor waits until an open cursor of the same read is closed. If this is unset, then there is no limit",
+		},
+		{
+			DestP:   &l.defaultDBRP,
+			Flag:    "influxql-default-dbrp",
+			Default: "",
+			Desc:    "a database and retention policy, in the form db/rp, that is mapped to the bucket of the onboarded organization so that InfluxQL queries against it resolve to that bucket. The retention policy defaults to autogen",
+		},
 		{
 			DestP:   &l.pageFaultRate,
 			Flag:    "page-fault-rate",
 			Default: 0,
 			Desc:    "the number of page faults allowed per second in the storage engine",
 		},
+		{
+			DestP:   &l.pageFaultRatePerBucket,
+			Flag:    "page-fault-rate-per-bucket",
+			Default: 0,
+			Desc:    "the number of page faults allowed per second when reading the data of each bucket, separately from other buckets",
+		},
+		{
+			DestP:   &l.cacheSnapshotMemorySize,
+			Flag:    "storage-cache-snapshot-memory-size",
+			Default: int(l.StorageConfig.Engine.Cache.SnapshotMemorySize),
+			Desc:    "the size in bytes at which the storage engine will snapshot the cache and write it to a TSM file",
+		},
+		{
+			DestP:   &l.cacheSnapshotWriteColdDuration,
+			Flag:    "storage-cache-snapshot-write-cold-duration",
+			Default: time.Duration(l.StorageConfig.Engine.Cache.SnapshotWriteColdDuration),
+			Desc:    "the length of time at which the storage engine will snapshot the cache and write it to a new TSM file if it has not received writes or deletes",
+		},
+		{
+			DestP:   &l.walFsyncDelay,
+			Flag:    "storage-wal-fsync-delay",
+			Default: time.Duration(l.StorageConfig.WAL.FsyncDelay),
+			Desc:    "the amount of time that a write will wait before fsyncing the WAL. A value of 0 fsyncs every write",
+		},
+		{
+			DestP:   &l.allowPartialOpen,
+			Flag:    "storage-allow-partial-open",
+			Default: false,
+			Desc:    "start the storage engine even if write entries of the WAL cannot be replayed. The entries are skipped, logged and reported by the readiness endpoint, and their points are lost for good once the WAL is next snapshotted. Delete entries that cannot be replayed still fail the start",
+		},
+		{
+			DestP:   &l.snapshotOnShutdown,
+			Flag:    "storage-snapshot-on-shutdown",
+			Default: false,
+			Desc:    "snapshot the cache to TSM files when the storage engine is shut down so that the WAL does not need to be replayed on startup. The snapshot is interrupted if it does not complete within the shutdown timeout",
+		},
+		{
+			DestP:   &l.maxSeriesPerBucket,
+			Flag:    "storage-max-series-per-bucket",
+			Default: 0,
+			Desc:    "the number of series a bucket may have before writes that create new series in it are rejected. Points of existing series are still written. A value of 0 disables the limit",
+		},
+		{
+			DestP:   &l.writeRateLimitPerBucket,
+			Flag:    "storage-write-rate-limit-per-bucket",
+			Default: 0,
+			Desc:    "the number of points per second that may be written to each bucket. Writes over the limit are rejected with 429 Too Many Requests. A value of 0 disables the limit",
+		},
+		{
+			DestP:   &l.defaultRetention,
+			Flag:    "storage-default-retention",
+			Default: time.Duration(0),
+			Desc:    "the retention period of buckets created without one. A value of 0 keeps data forever",
+		},
+		{
+			DestP:   &l.defaultSchemaType,
+			Flag:    "storage-default-schema-type",
+			Default: string(platform.SchemaTypeImplicit),
+			Desc:    "the schema type of buckets created without one. Buckets with an explicit schema reject writes of fields that are not declared for them. Valid options are implicit and explicit",
+		},
 		{
 			DestP: &l.featureFlags,
 			Flag:  "feature-flags",
 			Desc:  "feature flag overrides",
 		},
+		{
+			DestP: &l.featureFlagsPath,
+			Flag:  "feature-flags-path",
+			Desc:  "path to a YAML file of feature flag overrides. Overrides are reloaded from the file on SIGHUP and --feature-flags takes precedence",
+		},
 	}
 }
 
@@ -377,20 +598,28 @@ type Launcher struct {
 	storeType            string
 	assetsPath           string
 	testing              bool
+	debugVars            bool
 	sessionLength        int // in minutes
 	sessionRenewDisabled bool
+	sessionReapInterval  time.Duration
+	sessionStore         string
 
 	logLevel          string
+	logFormat         string
 	tracingType       string
+	tracingSampleRate float64
 	reportingDisabled bool
 
-	httpBindAddress string
-	boltPath        string
-	enginePath      string
-	secretStore     string
+	httpBindAddress        string
+	httpMaxWriteBatchBytes int
+	boltPath               string
+	enginePath             string
+	secretStore            string
 
-	featureFlags map[string]string
-	flagger      feature.Flagger
+	featureFlags     map[string]string
+	featureFlagsPath string
+	flagger          feature.Flagger
+	atomicFlagger    *feature.AtomicFlagger
 
 	// Query options.
 	concurrencyQuota                int
@@ -398,6 +627,21 @@ type Launcher struct {
 	memoryBytesQuotaPerQuery        int
 	maxMemoryBytes                  int
 	queueSize                       int
+	admitByEstimatedMemory          bool
+	fallbackEstimatedMemoryBytes    int
+	compileCacheSize                int
+	compileCacheTTL                 time.Duration
+	compileCacheNowPrecision        time.Duration
+	maxCPUTime                      time.Duration
+	metricQuerySources              []string
+	maxResponseBytes                int
+	floatNaNPolicy                  string
+	maxTables                       int
+	queryDefaultRange               time.Duration
+	storageReadParallelism          int
+	storageDecodeParallelism        int
+	queryMaxCPUs                    int
+	storageMaxOpenCursors           int
 
 	boltClient    *bolt.Client
 	kvStore       kv.SchemaStore
@@ -413,6 +657,7 @@ type Launcher struct {
 	httpTLSKey           string
 	httpTLSMinVersion    string
 	httpTLSStrictCiphers bool
+	httpTLSCiphers       string
 
 	natsServer *nats.Server
 	natsPort   int
@@ -431,7 +676,30 @@ type Launcher struct {
 	Stderr     io.Writer
 	apibackend *http.APIBackend
 
-	pageFaultRate int
+	pageFaultRate          int
+	pageFaultRatePerBucket int
+
+	// Storage cache options.
+	cacheSnapshotMemorySize        int
+	cacheSnapshotWriteColdDuration time.Duration
+
+	// Storage WAL options.
+	walFsyncDelay      time.Duration
+	allowPartialOpen   bool
+	snapshotOnShutdown bool
+
+	// Number of series a bucket may have. Zero is unlimited.
+	maxSeriesPerBucket int
+
+	// Points per second that may be written to a bucket. Zero is unlimited.
+	writeRateLimitPerBucket int
+
+	// Retention period of buckets created without one.
+	defaultRetention  time.Duration
+	defaultSchemaType string
+
+	// Database and retention policy mapped to the onboarded bucket.
+	defaultDBRP string
 }
 
 type stoppingScheduler interface {
@@ -502,12 +770,13 @@ func (m *Launcher) Shutdown(ctx context.Context) {
 	}
 
 	m.log.Info("Stopping", zap.String("service", "query"))
+	m.queryController.CancelAll(ctx)
 	if err := m.queryController.Shutdown(ctx); err != nil && err != context.Canceled {
 		m.log.Info("Failed closing query service", zap.Error(err))
 	}
 
 	m.log.Info("Stopping", zap.String("service", "storage-engine"))
-	if err := m.engine.Close(); err != nil {
+	if err := m.engine.CloseContext(ctx); err != nil {
 		m.log.Error("Failed to close engine", zap.Error(err))
 	}
 
@@ -522,6 +791,37 @@ func (m *Launcher) Shutdown(ctx context.Context) {
 	m.log.Sync()
 }
 
+// validateTracingSampleRate checks that the tracing sample rate is
+// between 0 and 1 and that it is supported by the tracing type. Zap
+// logging records every span so it only supports a sample rate of 1.
+func validateTracingSampleRate(tracingType string, rate float64) error {
+	if rate < 0 || rate > 1 {
+		return fmt.Errorf("invalid tracing sample rate; must be between 0 and 1")
+	}
+	if tracingType == LogTracing && rate < 1 {
+		return fmt.Errorf("invalid tracing sample rate; only supported by %s tracing", JaegerTracing)
+	}
+	return nil
+}
+
+// newJaegerConfig returns the Jaeger client config from the environment
+// variables. If the environment does not specify a sampler, a probabilistic
+// sampler is configured to record the given fraction of traces.
+func newJaegerConfig(sampleRate float64) (*jaegerconfig.Configuration, error) {
+	cfg, err := jaegerconfig.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Sampler == nil {
+		cfg.Sampler = &jaegerconfig.SamplerConfig{}
+	}
+	if cfg.Sampler.Type == "" {
+		cfg.Sampler.Type = jaeger.SamplerTypeProbabilistic
+		cfg.Sampler.Param = sampleRate
+	}
+	return cfg, nil
+}
+
 // Cancel executes the context cancel on the program. Used for testing.
 func (m *Launcher) Cancel() { m.cancel() }
 
@@ -553,9 +853,15 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		return fmt.Errorf("unknown log level; supported levels are debug, info, and error")
 	}
 
+	switch m.logFormat {
+	case "auto", "json", "console":
+	default:
+		return fmt.Errorf("unknown log format; supported formats are auto, json, and console")
+	}
+
 	// Create top level logger
 	logconf := &influxlogger.Config{
-		Format: "auto",
+		Format: m.logFormat,
 		Level:  lvl,
 	}
 	m.log, err = logconf.New(m.Stdout)
@@ -570,6 +876,10 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		zap.String("build_date", info.Date),
 	)
 
+	if err := validateTracingSampleRate(m.tracingType, m.tracingSampleRate); err != nil {
+		return err
+	}
+
 	switch m.tracingType {
 	case LogTracing:
 		m.log.Info("Tracing via zap logging")
@@ -578,7 +888,7 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 
 	case JaegerTracing:
 		m.log.Info("Tracing via Jaeger")
-		cfg, err := jaegerconfig.FromEnv()
+		cfg, err := newJaegerConfig(m.tracingSampleRate)
 		if err != nil {
 			m.log.Error("Failed to get Jaeger client config from environment variables", zap.Error(err))
 			break
@@ -706,6 +1016,18 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 	if m.pageFaultRate > 0 {
 		pageFaultLimiter = rate.NewLimiter(rate.Limit(m.pageFaultRate), 1)
 	}
+	var bucketPageFaultLimiter *tsm1.BucketPageFaultLimiter
+	if m.pageFaultRatePerBucket > 0 {
+		bucketPageFaultLimiter = tsm1.NewBucketPageFaultLimiter(rate.Limit(m.pageFaultRatePerBucket), nil)
+	}
+
+	m.StorageConfig.Engine.Cache.SnapshotMemorySize = toml.Size(m.cacheSnapshotMemorySize)
+	m.StorageConfig.Engine.Cache.SnapshotWriteColdDuration = toml.Duration(m.cacheSnapshotWriteColdDuration)
+	m.StorageConfig.WAL.FsyncDelay = toml.Duration(m.walFsyncDelay)
+	m.StorageConfig.AllowPartialOpen = m.allowPartialOpen
+	m.StorageConfig.SnapshotOnShutdown = m.snapshotOnShutdown
+	m.StorageConfig.MaxSeriesPerBucket = m.maxSeriesPerBucket
+	m.StorageConfig.WriteRateLimitPerBucket = m.writeRateLimitPerBucket
 
 	if m.testing {
 		// the testing engine will write/read into a temporary directory
@@ -718,6 +1040,7 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 			m.StorageConfig,
 			storage.WithRetentionEnforcer(ts.BucketSvc),
 			storage.WithPageFaultLimiter(pageFaultLimiter),
+			storage.WithBucketPageFaultLimiter(bucketPageFaultLimiter),
 		)
 	}
 	m.engine.WithLogger(m.log)
@@ -734,8 +1057,24 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		backupService platform.BackupService = m.engine
 	)
 
+	// Writes of the HTTP API and of materialized windows reject the fields
+	// that are not declared by buckets with an explicit schema.
+	metricsPointsWriter := storage.NewMetricsPointsWriter(pointsWriter)
+	m.reg.MustRegister(metricsPointsWriter.PrometheusCollectors()...)
+	schemaPointsWriter := &storage.SchemaPointsWriter{
+		Underlying:    metricsPointsWriter,
+		BucketService: ts.BucketSvc,
+	}
+
 	deps, err := influxdb.NewDependencies(
-		storageflux.NewReader(readservice.NewStore(m.engine)),
+		storageflux.NewReader(
+			readservice.NewStore(m.engine, readservice.WithMaxOpenCursors(m.storageMaxOpenCursors)),
+			storageflux.WithReadParallelism(m.storageReadParallelism),
+			storageflux.WithDecodeParallelism(m.storageDecodeParallelism),
+			storageflux.WithMaxCPUs(m.queryMaxCPUs),
+			storageflux.WithMaxTables(m.maxTables),
+			storageflux.WithPointsWriter(schemaPointsWriter, ts.BucketSvc),
+		),
 		m.engine,
 		authorizer.NewBucketService(ts.BucketSvc, ts.UrmSvc),
 		authorizer.NewOrgService(ts.OrgSvc),
@@ -746,6 +1085,7 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		m.log.Error("Failed to get query controller dependencies", zap.Error(err))
 		return err
 	}
+	deps.StorageDeps.FromDeps.DefaultRange = m.queryDefaultRange
 
 	m.queryController, err = control.New(control.Config{
 		ConcurrencyQuota:                m.concurrencyQuota,
@@ -753,6 +1093,13 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		MemoryBytesQuotaPerQuery:        int64(m.memoryBytesQuotaPerQuery),
 		MaxMemoryBytes:                  int64(m.maxMemoryBytes),
 		QueueSize:                       m.queueSize,
+		AdmitByEstimatedMemory:          m.admitByEstimatedMemory,
+		FallbackEstimatedMemoryBytes:    int64(m.fallbackEstimatedMemoryBytes),
+		CompileCacheSize:                m.compileCacheSize,
+		CompileCacheTTL:                 m.compileCacheTTL,
+		CompileCacheNowPrecision:        m.compileCacheNowPrecision,
+		MaxCPUTime:                      m.maxCPUTime,
+		MetricQuerySources:              m.metricQuerySources,
 		Logger:                          m.log.With(zap.String("service", "storage-reads")),
 		ExecutorDependencies:            []flux.Dependency{deps},
 	})
@@ -923,28 +1270,47 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 	}
 
 	if m.flagger == nil {
-		m.flagger = feature.DefaultFlagger()
-		if len(m.featureFlags) > 0 {
-			f, err := overrideflagger.Make(m.featureFlags, feature.ByKey)
-			if err != nil {
-				m.log.Error("Failed to configure feature flag overrides",
-					zap.Error(err), zap.Any("overrides", m.featureFlags))
-				return err
-			}
-			m.log.Info("Running with feature flag overrides", zap.Any("overrides", m.featureFlags))
-			m.flagger = f
+		if err := m.initFeatureFlags(); err != nil {
+			return err
 		}
+
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			m.reloadFeatureFlagsOnSignal(ctx)
+		}()
 	}
 
 	var sessionSvc platform.SessionService
 	{
-		sessionSvc = session.NewService(
-			session.NewStorage(inmem.NewSessionStore()),
+		var sessionStore session.Store
+		switch m.sessionStore {
+		case MemoryStore:
+			sessionStore = inmem.NewSessionStore()
+		case BoltStore:
+			sessionStore = session.NewKVStore(m.kvStore)
+		default:
+			err := fmt.Errorf("unknown session store %s; expected memory or bolt", m.sessionStore)
+			m.log.Error("Failed creating session store", zap.Error(err))
+			return err
+		}
+
+		svc := session.NewService(
+			session.NewStorage(sessionStore),
 			ts.UserSvc,
 			ts.UrmSvc,
 			authSvc,
 			session.WithSessionLength(time.Duration(m.sessionLength)*time.Minute),
 		)
+		if m.sessionReapInterval > 0 {
+			m.wg.Add(1)
+			go func(log *zap.Logger) {
+				defer m.wg.Done()
+				svc.RunReaper(ctx, log.With(zap.String("service", "session-reaper")), m.sessionReapInterval)
+			}(m.log)
+		}
+
+		sessionSvc = svc
 		sessionSvc = session.NewSessionMetrics(m.reg, sessionSvc)
 		sessionSvc = session.NewSessionLogger(m.log.With(zap.String("service", "session")), sessionSvc)
 	}
@@ -960,22 +1326,43 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		labelSvc = label.NewLabelController(m.flagger, m.kvService, ls)
 	}
 
-	ts.BucketSvc = storage.NewBucketService(ts.BucketSvc, m.engine)
+	defaultSchemaType, err := platform.ParseSchemaType(m.defaultSchemaType)
+	if err != nil {
+		m.log.Error("Failed to parse default bucket schema type", zap.Error(err))
+		return err
+	}
+	ts.BucketSvc = storage.NewBucketService(ts.BucketSvc, m.engine,
+		storage.WithDefaultRetention(m.defaultRetention),
+		storage.WithDefaultSchemaType(defaultSchemaType),
+	)
 	ts.BucketSvc = dbrp.NewBucketService(m.log, ts.BucketSvc, dbrpSvc)
 
+	writeBatchMetrics := storage.NewWriteBatchMetrics()
+	m.reg.MustRegister(writeBatchMetrics.PrometheusCollectors()...)
+
+	floatNaNPolicy, err := http.ParseFloatNaNPolicy(m.floatNaNPolicy)
+	if err != nil {
+		m.log.Error("Failed to parse query float NaN policy", zap.Error(err))
+		return err
+	}
+
 	m.apibackend = &http.APIBackend{
 		AssetsPath:           m.assetsPath,
 		HTTPErrorHandler:     kithttp.ErrorHandler(0),
 		Logger:               m.log,
 		SessionRenewDisabled: m.sessionRenewDisabled,
+		MaxBatchSizeBytes:    int64(m.httpMaxWriteBatchBytes),
 		NewBucketService:     source.NewBucketService,
 		NewQueryService:      source.NewQueryService,
 		PointsWriter: &storage.LoggingPointsWriter{
-			Underlying:    pointsWriter,
+			Underlying:    schemaPointsWriter,
 			BucketFinder:  ts.BucketSvc,
 			LogBucketName: platform.MonitoringSystemBucketName,
+			Logger:        m.log.With(zap.String("service", "storage-writer")),
+			Metrics:       writeBatchMetrics,
 		},
 		DeleteService:        deleteService,
+		BucketSizeService:    m.engine,
 		BackupService:        backupService,
 		KVBackupService:      m.kvService,
 		AuthorizationService: authSvc,
@@ -998,6 +1385,10 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		PasswordsService:                ts.PasswordSvc,
 		InfluxQLService:                 storageQueryService,
 		FluxService:                     storageQueryService,
+		QueryCostEstimator:              m.queryController,
+		ActiveQueryService:              m.queryController,
+		QueryMaxResponseBytes:           int64(m.maxResponseBytes),
+		QueryFloatNaNPolicy:             floatNaNPolicy,
 		FluxLanguageService:             fluxlang.DefaultService,
 		TaskService:                     taskSvc,
 		TelegrafService:                 telegrafSvc,
@@ -1068,6 +1459,23 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		onboardSvc = tenant.NewOnboardingMetrics(m.reg, onboardSvc, metric.WithSuffix("new"))             // with metrics
 		onboardSvc = tenant.NewOnboardingLogger(m.log.With(zap.String("handler", "onboard")), onboardSvc) // with logging
 
+		if m.defaultDBRP != "" {
+			db, rp, err := dbrp.ParseDatabaseRetentionPolicy(m.defaultDBRP)
+			if err != nil {
+				m.log.Error("Failed to parse default DBRP", zap.Error(err))
+				return err
+			}
+
+			// The mapping is created outside of any authorization, the same as
+			// the onboarding itself, so it uses an unauthorized service.
+			defaultDBRPSvc := dbrp.NewService(ctx, ts.BucketSvc, m.kvStore)
+			if err := ensureDefaultDBRP(ctx, onboardSvc, ts.OrgSvc, ts.BucketSvc, defaultDBRPSvc, db, rp); err != nil {
+				m.log.Error("Failed to create default DBRP mapping", zap.Error(err))
+				return err
+			}
+			onboardSvc = dbrp.NewOnboardingService(m.log.With(zap.String("service", "dbrp")), onboardSvc, defaultDBRPSvc, db, rp)
+		}
+
 		onboardHTTPServer = tenant.NewHTTPOnboardHandler(m.log, onboardSvc)
 	}
 
@@ -1139,11 +1547,15 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 			m.reg,
 			http.WithLog(httpLogger),
 			http.WithAPIHandler(platformHandler),
+			http.WithReadyHandler(http.ReadyHandler(m.engine.Degraded)),
 		)
 
 		if logconf.Level == zap.DebugLevel {
 			m.httpServer.Handler = http.LoggingMW(httpLogger)(m.httpServer.Handler)
 		}
+		if m.debugVars {
+			m.httpServer.Handler = http.DebugVars(m.httpServer.Handler, m.queryController)
+		}
 		// If we are in testing mode we allow all data to be flushed and removed.
 		if m.testing {
 			m.httpServer.Handler = http.DebugFlush(ctx, m.httpServer.Handler, flushers)
@@ -1171,42 +1583,11 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		}
 		transport = "https"
 
-		// Sensible default
-		var tlsMinVersion uint16 = tls.VersionTLS12
-
-		switch m.httpTLSMinVersion {
-		case "1.0":
-			m.log.Warn("Setting the minimum version of TLS to 1.0 - this is discouraged. Please use 1.2 or 1.3")
-			tlsMinVersion = tls.VersionTLS10
-		case "1.1":
-			m.log.Warn("Setting the minimum version of TLS to 1.1 - this is discouraged. Please use 1.2 or 1.3")
-			tlsMinVersion = tls.VersionTLS11
-		case "1.2":
-			tlsMinVersion = tls.VersionTLS12
-		case "1.3":
-			tlsMinVersion = tls.VersionTLS13
-		}
-
-		strictCiphers := []uint16{
-			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-			tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
-			tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
-			tls.TLS_RSA_WITH_AES_256_CBC_SHA,
-		}
-
-		// nil uses the default cipher suite
-		var cipherConfig []uint16 = nil
-
-		// TLS 1.3 does not support configuring the Cipher suites
-		if tlsMinVersion != tls.VersionTLS13 && m.httpTLSStrictCiphers {
-			cipherConfig = strictCiphers
-		}
-
-		m.httpServer.TLSConfig = &tls.Config{
-			CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
-			PreferServerCipherSuites: true,
-			MinVersion:               tlsMinVersion,
-			CipherSuites:             cipherConfig,
+		m.httpServer.TLSConfig, err = m.newTLSConfig()
+		if err != nil {
+			m.log.Error("failed to configure tls", zap.Error(err))
+			m.log.Info("Stopping")
+			return err
 		}
 	}
 
@@ -1234,6 +1615,179 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 	return nil
 }
 
+// initFeatureFlags sets the flagger of the launcher to one that
+// applies the feature flag overrides and can be reloaded.
+func (m *Launcher) initFeatureFlags() error {
+	f, err := m.newFlagger()
+	if err != nil {
+		return err
+	}
+	m.atomicFlagger = feature.NewAtomicFlagger(f)
+	m.flagger = m.atomicFlagger
+	return nil
+}
+
+// reloadFeatureFlagsOnSignal reloads the feature flag overrides
+// each time SIGHUP is received until ctx is done.
+func (m *Launcher) reloadFeatureFlagsOnSignal(ctx context.Context) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigCh:
+			m.reloadFeatureFlags()
+		}
+	}
+}
+
+// reloadFeatureFlags rereads the feature flag overrides and replaces the
+// flagger used by all services. The current overrides are kept on error.
+func (m *Launcher) reloadFeatureFlags() {
+	f, err := m.newFlagger()
+	if err != nil {
+		return
+	}
+	m.atomicFlagger.Store(f)
+	m.log.Info("Reloaded feature flags")
+}
+
+// newFlagger returns a flagger with the overrides from --feature-flags-path
+// and --feature-flags, or the default flagger if there are no overrides.
+func (m *Launcher) newFlagger() (feature.Flagger, error) {
+	overrides := make(map[string]string)
+	if m.featureFlagsPath != "" {
+		data, err := ioutil.ReadFile(m.featureFlagsPath)
+		if err != nil {
+			m.log.Error("Failed to read feature flag overrides", zap.Error(err), zap.String("path", m.featureFlagsPath))
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &overrides); err != nil {
+			m.log.Error("Failed to parse feature flag overrides", zap.Error(err), zap.String("path", m.featureFlagsPath))
+			return nil, err
+		}
+	}
+	for k, v := range m.featureFlags {
+		overrides[k] = v
+	}
+
+	if len(overrides) == 0 {
+		return feature.DefaultFlagger(), nil
+	}
+
+	f, err := overrideflagger.Make(overrides, feature.ByKey)
+	if err != nil {
+		m.log.Error("Failed to configure feature flag overrides",
+			zap.Error(err), zap.Any("overrides", overrides))
+		return nil, err
+	}
+	m.log.Info("Running with feature flag overrides", zap.Any("overrides", overrides))
+	return f, nil
+}
+
+// newTLSConfig returns the TLS configuration of the HTTP server.
+func (m *Launcher) newTLSConfig() (*tls.Config, error) {
+	// Sensible default
+	var tlsMinVersion uint16 = tls.VersionTLS12
+
+	switch m.httpTLSMinVersion {
+	case "1.0":
+		m.log.Warn("Setting the minimum version of TLS to 1.0 - this is discouraged. Please use 1.2 or 1.3")
+		tlsMinVersion = tls.VersionTLS10
+	case "1.1":
+		m.log.Warn("Setting the minimum version of TLS to 1.1 - this is discouraged. Please use 1.2 or 1.3")
+		tlsMinVersion = tls.VersionTLS11
+	case "1.2":
+		tlsMinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsMinVersion = tls.VersionTLS13
+	}
+
+	strictCiphers := []uint16{
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+		tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	}
+
+	// nil uses the default cipher suite
+	var cipherConfig []uint16 = nil
+
+	// TLS 1.3 does not support configuring the Cipher suites
+	if tlsMinVersion != tls.VersionTLS13 {
+		if m.httpTLSCiphers != "" {
+			ciphers, err := parseTLSCiphers(m.httpTLSCiphers)
+			if err != nil {
+				return nil, err
+			}
+			cipherConfig = ciphers
+		} else if m.httpTLSStrictCiphers {
+			cipherConfig = strictCiphers
+		}
+	}
+
+	return &tls.Config{
+		CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
+		PreferServerCipherSuites: true,
+		MinVersion:               tlsMinVersion,
+		CipherSuites:             cipherConfig,
+	}, nil
+}
+
+// parseTLSCiphers parses a comma-separated list of cipher suite names.
+// Only the suites returned by tls.CipherSuites are accepted.
+func parseTLSCiphers(s string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	var ciphers []uint16
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown tls cipher suite %q", name)
+		}
+		ciphers = append(ciphers, id)
+	}
+	return ciphers, nil
+}
+
+// ensureDefaultDBRP maps db and rp to the bucket of the onboarded
+// organization if the instance has already been onboarded. The onboarded
+// organization is the first one created and its bucket the first user
+// bucket of that organization.
+func ensureDefaultDBRP(ctx context.Context, onboardSvc platform.OnboardingService, orgSvc platform.OrganizationService, bucketSvc platform.BucketService, dbrpSvc platform.DBRPMappingServiceV2, db, rp string) error {
+	onboarding, err := onboardSvc.IsOnboarding(ctx)
+	if err != nil || onboarding {
+		return err
+	}
+
+	orgs, _, err := orgSvc.FindOrganizations(ctx, platform.OrganizationFilter{}, platform.FindOptions{Limit: 1})
+	if err != nil || len(orgs) == 0 {
+		return err
+	}
+	org := orgs[0]
+
+	buckets, _, err := bucketSvc.FindBuckets(ctx, platform.BucketFilter{OrganizationID: &org.ID})
+	if err != nil {
+		return err
+	}
+	for _, b := range buckets {
+		if b.Type == platform.BucketTypeUser {
+			return dbrp.EnsureMapping(ctx, dbrpSvc, org.ID, b.ID, db, rp)
+		}
+	}
+	return nil
+}
+
 // isAddressPortAvailable checks whether the address:port is available to listen,
 // by using net.Listen to verify that the port opens successfully, then closes the listener.
 func isAddressPortAvailable(address string, port int) (bool, error) {
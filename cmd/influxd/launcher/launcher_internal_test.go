@@ -0,0 +1,185 @@
+package launcher
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/uber/jaeger-client-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewJaegerConfig_SampleRate(t *testing.T) {
+	cfg, err := newJaegerConfig(0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, exp := cfg.Sampler.Type, jaeger.SamplerTypeProbabilistic; got != exp {
+		t.Errorf("unexpected sampler type: got %q, exp %q", got, exp)
+	}
+	if got, exp := cfg.Sampler.Param, 0.25; got != exp {
+		t.Errorf("unexpected sampler param: got %v, exp %v", got, exp)
+	}
+}
+
+func TestNewJaegerConfig_SamplerFromEnv(t *testing.T) {
+	os.Setenv("JAEGER_SAMPLER_TYPE", jaeger.SamplerTypeConst)
+	os.Setenv("JAEGER_SAMPLER_PARAM", "1")
+	defer os.Unsetenv("JAEGER_SAMPLER_TYPE")
+	defer os.Unsetenv("JAEGER_SAMPLER_PARAM")
+
+	cfg, err := newJaegerConfig(0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The environment takes precedence over the flag.
+	if got, exp := cfg.Sampler.Type, jaeger.SamplerTypeConst; got != exp {
+		t.Errorf("unexpected sampler type: got %q, exp %q", got, exp)
+	}
+	if got, exp := cfg.Sampler.Param, 1.0; got != exp {
+		t.Errorf("unexpected sampler param: got %v, exp %v", got, exp)
+	}
+}
+
+func TestValidateTracingSampleRate(t *testing.T) {
+	for _, tt := range []struct {
+		tracingType string
+		rate        float64
+		wantErr     bool
+	}{
+		{tracingType: JaegerTracing, rate: 0.25},
+		{tracingType: JaegerTracing, rate: 1.5, wantErr: true},
+		{tracingType: LogTracing, rate: 1},
+		{tracingType: LogTracing, rate: 0.25, wantErr: true},
+		{tracingType: "", rate: -1, wantErr: true},
+	} {
+		err := validateTracingSampleRate(tt.tracingType, tt.rate)
+		if got := err != nil; got != tt.wantErr {
+			t.Errorf("unexpected error for %s tracing with sample rate %v: %v", tt.tracingType, tt.rate, err)
+		}
+	}
+}
+
+func TestStartReporter_Disabled(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	m := NewLauncher()
+	m.log = zap.New(core)
+	m.reportingDisabled = true
+
+	var wg sync.WaitGroup
+	if reporter := startReporter(context.Background(), m, &wg); reporter != nil {
+		t.Fatal("expected no reporter when reporting is disabled")
+	}
+
+	// No reporter goroutine is tracked, so the wait returns immediately.
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a reporter goroutine was started")
+	}
+
+	if got := logs.FilterMessageSnippet("Telemetry reporting is disabled").Len(); got != 1 {
+		t.Errorf("unexpected number of telemetry disabled log lines: got %d, exp 1", got)
+	}
+}
+
+func TestLauncher_NewTLSConfig_Ciphers(t *testing.T) {
+	m := NewLauncher()
+	m.log = zap.NewNop()
+	m.httpTLSStrictCiphers = true
+	m.httpTLSCiphers = "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305"
+
+	cfg, err := m.newTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The explicit list overrides the strict ciphers.
+	exp := []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305}
+	if !reflect.DeepEqual(cfg.CipherSuites, exp) {
+		t.Errorf("unexpected cipher suites: got %v, exp %v", cfg.CipherSuites, exp)
+	}
+}
+
+func TestLauncher_NewTLSConfig_UnknownCipher(t *testing.T) {
+	m := NewLauncher()
+	m.log = zap.NewNop()
+	m.httpTLSCiphers = "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_NOT_A_CIPHER"
+
+	if _, err := m.newTLSConfig(); err == nil {
+		t.Fatal("expected error for unknown cipher suite")
+	} else if !strings.Contains(err.Error(), "TLS_NOT_A_CIPHER") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLauncher_ReloadFeatureFlags(t *testing.T) {
+	f, err := ioutil.TempFile("", "feature-flags-*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Close()
+
+	writeOverrides := func(s string) {
+		t.Helper()
+		if err := ioutil.WriteFile(f.Name(), []byte(s), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	flag, found := feature.ByKey("backendExample")
+	if !found {
+		t.Fatal("missing backendExample feature flag")
+	}
+	value := func(flagger feature.Flagger) interface{} {
+		t.Helper()
+		flags, err := flagger.Flags(context.Background(), flag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return flags[flag.Key()]
+	}
+
+	m := NewLauncher()
+	m.log = zap.NewNop()
+	m.featureFlagsPath = f.Name()
+
+	writeOverrides("backendExample: false\n")
+	if err := m.initFeatureFlags(); err != nil {
+		t.Fatal(err)
+	}
+	flagger := m.flagger
+	if got, exp := value(flagger), false; got != exp {
+		t.Fatalf("unexpected flag value: got %v, exp %v", got, exp)
+	}
+
+	// Services holding the flagger observe the reloaded overrides.
+	writeOverrides("backendExample: true\n")
+	m.reloadFeatureFlags()
+	if got, exp := value(flagger), true; got != exp {
+		t.Errorf("unexpected flag value after reload: got %v, exp %v", got, exp)
+	}
+
+	// Invalid overrides are rejected and the current ones are kept.
+	writeOverrides("notAFlag: true\n")
+	m.reloadFeatureFlags()
+	if got, exp := value(flagger), true; got != exp {
+		t.Errorf("unexpected flag value after invalid reload: got %v, exp %v", got, exp)
+	}
+}
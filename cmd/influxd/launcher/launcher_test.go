@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	nethttp "net/http"
+	"os"
+	"strings"
 	"testing"
 
 	platform "github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/launcher"
+	"github.com/influxdata/influxdb/v2/dbrp"
 	"github.com/influxdata/influxdb/v2/http"
 	_ "github.com/influxdata/influxdb/v2/query/builtin"
 )
@@ -42,6 +45,51 @@ func TestLauncher_Setup(t *testing.T) {
 	}
 }
 
+func TestLauncher_Setup_DefaultDBRP(t *testing.T) {
+	l := launcher.RunTestLauncherOrFail(t, ctx, nil, "--influxql-default-dbrp", "db0/rp0")
+	defer l.ShutdownOrFail(t, ctx)
+
+	svc := &http.SetupService{Addr: l.URL()}
+	results, err := svc.OnboardInitialUser(ctx, &platform.OnboardingRequest{
+		User:     "USER",
+		Password: "PASSWORD",
+		Org:      "ORG",
+		Bucket:   "BUCKET",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := http.NewHTTPClient(l.URL(), results.Auth.Token, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := "db0"
+	mappings, _, err := dbrp.NewClient(client).FindMany(ctx, platform.DBRPMappingFilterV2{
+		OrgID:    &results.Org.ID,
+		Database: &db,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mappings) != 1 {
+		t.Fatalf("expected 1 mapping, got %d", len(mappings))
+	}
+
+	// InfluxQL resolves a database without a retention
+	// policy to the default mapping of the database.
+	if !mappings[0].Default {
+		t.Error("expected mapping to be the default for the database")
+	}
+	if got, want := mappings[0].BucketID, results.Bucket.ID; got != want {
+		t.Errorf("unexpected bucket -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+	if got, want := mappings[0].RetentionPolicy, "rp0"; got != want {
+		t.Errorf("unexpected retention policy -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+}
+
 // This is to mimic chronograf using cookies as sessions
 // rather than authorizations
 func TestLauncher_SetupWithUsers(t *testing.T) {
@@ -139,3 +187,46 @@ func TestLauncher_SetupWithUsers(t *testing.T) {
 		t.Fatalf("unexpected 2 users: %#+v", exp)
 	}
 }
+
+func TestLauncher_LogFormat(t *testing.T) {
+	l := launcher.RunTestLauncherOrFail(t, ctx, nil, "--log-format", "json")
+	l.ShutdownOrFail(t, ctx)
+
+	line, err := l.Stdout.ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entry struct {
+		Msg string `json:"msg"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatalf("expected json log entry, got %q: %v", line, err)
+	}
+	if got, exp := entry.Msg, "Welcome to InfluxDB"; got != exp {
+		t.Fatalf("unexpected log message: got %q, exp %q", got, exp)
+	}
+}
+
+func TestLauncher_LogFormat_ConsoleNotTerminal(t *testing.T) {
+	l := launcher.RunTestLauncherOrFail(t, ctx, nil, "--log-format", "console")
+	l.ShutdownOrFail(t, ctx)
+
+	// The output is not a terminal, so the entries are logfmt.
+	line, err := l.Stdout.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(line, `msg="Welcome to InfluxDB"`) {
+		t.Fatalf("expected logfmt log entry, got %q", line)
+	}
+}
+
+func TestLauncher_LogFormat_Unknown(t *testing.T) {
+	l := launcher.NewTestLauncher(nil)
+	defer os.RemoveAll(l.Path)
+
+	if err := l.Run(ctx, "--log-format", "xml"); err == nil {
+		t.Fatal("expected error for unknown log format")
+	}
+}
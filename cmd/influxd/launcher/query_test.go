@@ -3,6 +3,7 @@ package launcher_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
@@ -15,6 +16,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/execute"
@@ -115,6 +117,32 @@ func TestLauncher_WriteV2_Query(t *testing.T) {
 	res.HasTableCount(t, 1)
 }
 
+func TestLauncher_Query_DefaultRange(t *testing.T) {
+	l := launcher.RunTestLauncherOrFail(t, ctx, nil, "--query-default-range", "1h")
+	l.SetupOrFail(t)
+	defer l.ShutdownOrFail(t, ctx)
+
+	now := time.Now()
+	l.WritePointsOrFail(t, fmt.Sprintf("m,k=v f=1i %d\nm,k=v f=2i %d",
+		now.Add(-2*time.Hour).UnixNano(), now.Add(-10*time.Minute).UnixNano()))
+
+	// Only the point within the default range is read without a range.
+	qs := fmt.Sprintf(`from(bucket: "%s") |> sum() |> keep(columns: ["_value"])`, l.Bucket.Name)
+	exp := `,result,table,_value` + "\r\n" +
+		`,_result,0,2` + "\r\n\r\n"
+	if got := l.FluxQueryOrFail(t, l.Org, l.Auth.Token, qs); !cmp.Equal(got, exp) {
+		t.Errorf("unexpected query results -got/+exp\n%s", cmp.Diff(got, exp))
+	}
+
+	// A range is not narrowed by the default range.
+	qs = fmt.Sprintf(`from(bucket: "%s") |> range(start: -3h) |> sum() |> keep(columns: ["_value"])`, l.Bucket.Name)
+	exp = `,result,table,_value` + "\r\n" +
+		`,_result,0,3` + "\r\n\r\n"
+	if got := l.FluxQueryOrFail(t, l.Org, l.Auth.Token, qs); !cmp.Equal(got, exp) {
+		t.Errorf("unexpected query results -got/+exp\n%s", cmp.Diff(got, exp))
+	}
+}
+
 func getMemoryUnused(t *testing.T, reg *prom.Registry) int64 {
 	t.Helper()
 
@@ -2333,3 +2361,98 @@ buckets()
 		t.Fatalf("unexpected output -want/+got:\n%s", diff)
 	}
 }
+
+func TestLauncher_Query_EstimateCost(t *testing.T) {
+	l := launcher.RunTestLauncherOrFail(t, ctx, nil)
+	l.SetupOrFail(t)
+	defer l.ShutdownOrFail(t, ctx)
+
+	// Generate 10 x 5 series of m0 with two fields each,
+	// along with series of m1 that are not queried.
+	var sb strings.Builder
+	now := time.Now().Add(-time.Minute)
+	for t0 := 0; t0 < 10; t0++ {
+		for t1 := 0; t1 < 5; t1++ {
+			for i := 0; i < 3; i++ {
+				ts := now.Add(time.Duration(i) * time.Second).UnixNano()
+				_, _ = fmt.Fprintf(&sb, "m0,t0=a-%d,t1=b-%d f0=%d,f1=%di %d\n", t0, t1, i, i, ts)
+			}
+		}
+	}
+	for t0 := 0; t0 < 3; t0++ {
+		_, _ = fmt.Fprintf(&sb, "m1,t0=a-%d f0=1 %d\n", t0, now.UnixNano())
+	}
+	l.WritePointsOrFail(t, sb.String())
+
+	// The measurement option is defined by the extern.
+	extern := json.RawMessage(`{
+		"type": "File",
+		"body": [
+			{
+				"type": "OptionStatement",
+				"assignment": {
+					"type": "VariableAssignment",
+					"id": {"type": "Identifier", "name": "measurement"},
+					"init": {"type": "StringLiteral", "value": "m0"}
+				}
+			}
+		]
+	}`)
+	for _, tt := range []struct {
+		name   string
+		query  string
+		extern json.RawMessage
+	}{
+		{
+			name: "query",
+			query: fmt.Sprintf(`from(bucket: "%s")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement == "m0")`, l.Bucket.Name),
+		},
+		{
+			name: "extern",
+			query: fmt.Sprintf(`from(bucket: "%s")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement == measurement)`, l.Bucket.Name),
+			extern: extern,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			q := map[string]interface{}{
+				"type":  "flux",
+				"query": tt.query,
+			}
+			if tt.extern != nil {
+				q["extern"] = tt.extern
+			}
+			body, err := json.Marshal(q)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := l.MustNewHTTPRequest("POST", fmt.Sprintf("/api/v2/query/estimate?orgID=%s", l.Org.ID), string(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := nethttp.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != nethttp.StatusOK {
+				b, _ := ioutil.ReadAll(resp.Body)
+				t.Fatalf("unexpected status code: %d, body: %s", resp.StatusCode, b)
+			}
+
+			var est query.CostEstimate
+			if err := json.NewDecoder(resp.Body).Decode(&est); err != nil {
+				t.Fatal(err)
+			}
+			if want, got := int64(10*5*2), est.SeriesN; want != got {
+				t.Fatalf("unexpected series count: want %d, got %d", want, got)
+			}
+			if est.PointsN < 0 || est.Bytes < 0 {
+				t.Fatalf("unexpected estimate: %+v", est)
+			}
+		})
+	}
+}
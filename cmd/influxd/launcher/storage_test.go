@@ -247,3 +247,64 @@ func TestStorage_CacheSnapshot_Age(t *testing.T) {
 		t.Fatalf("got %d series in TSM files, expected %d", got, exp)
 	}
 }
+
+func TestStorage_CacheSnapshot_Flags(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "memory size",
+			args: []string{
+				"--storage-cache-snapshot-memory-size", "10",
+			},
+		},
+		{
+			name: "write cold duration",
+			args: []string{
+				"--storage-cache-snapshot-write-cold-duration", "1s",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			l := launcher.RunTestLauncherOrFail(t, ctx, nil, tt.args...)
+			defer l.ShutdownOrFail(t, ctx)
+
+			l.SetupOrFail(t)
+
+			org1 := l.OnBoardOrFail(t, &influxdb.OnboardingRequest{
+				User:     "USER-1",
+				Password: "PASSWORD-1",
+				Org:      "ORG-01",
+				Bucket:   "BUCKET",
+			})
+
+			// Execute single write against the server.
+			l.WriteOrFail(t, org1, `m,k=v1 f=100i 946684800000000000`)
+			l.WriteOrFail(t, org1, `m,k=v2 f=101i 946684800000000000`)
+			l.WriteOrFail(t, org1, `m,k=v3 f=102i 946684800000000000`)
+			l.WriteOrFail(t, org1, `m,k=v4 f=103i 946684800000000000`)
+			l.WriteOrFail(t, org1, `m,k=v5 f=104i 946684800000000000`)
+
+			// Wait for cache to snapshot. The default thresholds would
+			// not trigger a snapshot within this time.
+			time.Sleep(time.Second * 5)
+
+			// Check there is TSM data.
+			report := tsm1.Report{
+				Dir:   filepath.Join(l.Path, "/engine/data"),
+				Exact: true,
+			}
+
+			summary, err := report.Run(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Five series should be in the snapshot
+			if got, exp := summary.Total, uint64(5); got != exp {
+				t.Fatalf("got %d series in TSM files, expected %d", got, exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package dbrp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"go.uber.org/zap"
+)
+
+// DefaultRetentionPolicy is the retention policy of a
+// default mapping that does not specify one.
+const DefaultRetentionPolicy = "autogen"
+
+// ParseDatabaseRetentionPolicy parses a database and retention policy
+// of the form db/rp. The retention policy is optional and defaults to
+// DefaultRetentionPolicy.
+func ParseDatabaseRetentionPolicy(s string) (db, rp string, err error) {
+	parts := strings.SplitN(s, "/", 2)
+	db, rp = parts[0], DefaultRetentionPolicy
+	if len(parts) == 2 && parts[1] != "" {
+		rp = parts[1]
+	}
+	if db == "" {
+		return "", "", &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("invalid database and retention policy %q", s),
+		}
+	}
+	return db, rp, nil
+}
+
+// EnsureMapping creates a mapping of db and rp to a bucket of an
+// organization unless the organization already maps them.
+func EnsureMapping(ctx context.Context, s influxdb.DBRPMappingServiceV2, orgID, bucketID influxdb.ID, db, rp string) error {
+	_, n, err := s.FindMany(ctx, influxdb.DBRPMappingFilterV2{
+		OrgID:           &orgID,
+		Database:        &db,
+		RetentionPolicy: &rp,
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	return s.Create(ctx, &influxdb.DBRPMappingV2{
+		Database:        db,
+		RetentionPolicy: rp,
+		OrganizationID:  orgID,
+		BucketID:        bucketID,
+	})
+}
+
+// OnboardingService maps a database and retention policy to the bucket
+// created by the initial onboarding so that InfluxQL queries against the
+// database can be resolved as soon as the instance is set up.
+type OnboardingService struct {
+	influxdb.OnboardingService
+	Logger             *zap.Logger
+	DBRPMappingService influxdb.DBRPMappingServiceV2
+
+	Database        string
+	RetentionPolicy string
+}
+
+func NewOnboardingService(logger *zap.Logger, onboardingService influxdb.OnboardingService, dbrpService influxdb.DBRPMappingServiceV2, db, rp string) *OnboardingService {
+	return &OnboardingService{
+		OnboardingService:  onboardingService,
+		Logger:             logger,
+		DBRPMappingService: dbrpService,
+		Database:           db,
+		RetentionPolicy:    rp,
+	}
+}
+
+func (s *OnboardingService) OnboardInitialUser(ctx context.Context, req *influxdb.OnboardingRequest) (*influxdb.OnboardingResults, error) {
+	res, err := s.OnboardingService.OnboardInitialUser(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// The instance is already set up so a failure to
+	// create the mapping does not fail the onboarding.
+	if err := EnsureMapping(ctx, s.DBRPMappingService, res.Org.ID, res.Bucket.ID, s.Database, s.RetentionPolicy); err != nil {
+		s.Logger.Error("Failed to create default DBRP mapping.",
+			zap.String("database", s.Database),
+			zap.String("retention_policy", s.RetentionPolicy),
+			zap.Error(err),
+		)
+	}
+	return res, nil
+}
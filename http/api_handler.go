@@ -47,6 +47,14 @@ type APIBackend struct {
 	// write request. A value of zero specifies there is no limit.
 	WriteParserMaxValues int
 
+	// QueryMaxResponseBytes is the maximum number of bytes written in the response
+	// of a single query. A value of zero specifies there is no limit.
+	QueryMaxResponseBytes int64
+
+	// QueryFloatNaNPolicy is how NaN and infinite float values are encoded
+	// in the response of a query. It defaults to passthrough.
+	QueryFloatNaNPolicy FloatNaNPolicy
+
 	NewBucketService func(*influxdb.Source) (influxdb.BucketService, error)
 	NewQueryService  func(*influxdb.Source) (query.ProxyQueryService, error)
 
@@ -57,6 +65,7 @@ type APIBackend struct {
 
 	PointsWriter                    storage.PointsWriter
 	DeleteService                   influxdb.DeleteService
+	BucketSizeService               influxdb.BucketSizeService
 	BackupService                   influxdb.BackupService
 	KVBackupService                 influxdb.KVBackupService
 	AuthorizationService            influxdb.AuthorizationService
@@ -77,6 +86,8 @@ type APIBackend struct {
 	PasswordsService                influxdb.PasswordsService
 	InfluxQLService                 query.ProxyQueryService
 	FluxService                     query.ProxyQueryService
+	QueryCostEstimator              query.CostEstimator
+	ActiveQueryService              query.ActiveQueryService
 	FluxLanguageService             influxdb.FluxLanguageService
 	TaskService                     influxdb.TaskService
 	CheckService                    influxdb.CheckService
@@ -144,6 +155,9 @@ func NewAPIHandler(b *APIBackend, opts ...APIHandlerOptFn) *APIHandler {
 	deleteBackend := NewDeleteBackend(b.Logger.With(zap.String("handler", "delete")), b)
 	h.Mount(prefixDelete, NewDeleteHandler(b.Logger, deleteBackend))
 
+	bucketSizeBackend := NewBucketSizeBackend(b.Logger.With(zap.String("handler", "bucket_size")), b)
+	h.Mount(prefixBucketSize, NewBucketSizeHandler(b.Logger, bucketSizeBackend))
+
 	documentBackend := NewDocumentBackend(b.Logger.With(zap.String("handler", "document")), b)
 	documentBackend.DocumentService = authorizer.NewDocumentService(b.DocumentService)
 	h.Mount(prefixDocuments, NewDocumentHandler(documentBackend))
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -130,13 +131,16 @@ func NewBucketHandler(log *zap.Logger, b *BucketBackend) *BucketHandler {
 
 // bucket is used for serialization/deserialization with duration string syntax.
 type bucket struct {
-	ID                  influxdb.ID     `json:"id,omitempty"`
-	OrgID               influxdb.ID     `json:"orgID,omitempty"`
-	Type                string          `json:"type"`
-	Description         string          `json:"description,omitempty"`
-	Name                string          `json:"name"`
-	RetentionPolicyName string          `json:"rp,omitempty"` // This to support v1 sources
-	RetentionRules      []retentionRule `json:"retentionRules"`
+	ID                        influxdb.ID                `json:"id,omitempty"`
+	OrgID                     influxdb.ID                `json:"orgID,omitempty"`
+	Type                      string                     `json:"type"`
+	Description               string                     `json:"description,omitempty"`
+	Name                      string                     `json:"name"`
+	RetentionPolicyName       string                     `json:"rp,omitempty"` // This to support v1 sources
+	RetentionRules            []retentionRule            `json:"retentionRules"`
+	SchemaType                string                     `json:"schemaType,omitempty"`
+	Fields                    []string                   `json:"fields,omitempty"`
+	MeasurementRetentionRules []measurementRetentionRule `json:"measurementRetentionRules,omitempty"`
 	influxdb.CRUDLog
 }
 
@@ -158,6 +162,53 @@ func (rr *retentionRule) RetentionPeriod() (time.Duration, error) {
 	return t, nil
 }
 
+// measurementRetentionRule overrides the retention period of a measurement of a bucket.
+type measurementRetentionRule struct {
+	Measurement  string `json:"measurement"`
+	EverySeconds int64  `json:"everySeconds"`
+}
+
+func measurementRetention(rules []measurementRetentionRule) (map[string]time.Duration, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	m := make(map[string]time.Duration, len(rules))
+	for _, r := range rules {
+		if r.Measurement == "" {
+			return nil, &influxdb.Error{
+				Code: influxdb.EUnprocessableEntity,
+				Msg:  "measurement retention rules must have a measurement",
+			}
+		}
+		rr := retentionRule{EverySeconds: r.EverySeconds}
+		d, err := rr.RetentionPeriod()
+		if err != nil {
+			return nil, err
+		}
+		m[r.Measurement] = d
+	}
+	return m, nil
+}
+
+func newMeasurementRetentionRules(m map[string]time.Duration) []measurementRetentionRule {
+	if len(m) == 0 {
+		return nil
+	}
+
+	rules := make([]measurementRetentionRule, 0, len(m))
+	for name, d := range m {
+		rules = append(rules, measurementRetentionRule{
+			Measurement:  name,
+			EverySeconds: int64(d.Round(time.Second) / time.Second),
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Measurement < rules[j].Measurement
+	})
+	return rules
+}
+
 func (b *bucket) toInfluxDB() (*influxdb.Bucket, error) {
 	if b == nil {
 		return nil, nil
@@ -176,15 +227,23 @@ func (b *bucket) toInfluxDB() (*influxdb.Bucket, error) {
 		}
 	}
 
+	mr, err := measurementRetention(b.MeasurementRetentionRules)
+	if err != nil {
+		return nil, err
+	}
+
 	return &influxdb.Bucket{
-		ID:                  b.ID,
-		OrgID:               b.OrgID,
-		Type:                influxdb.ParseBucketType(b.Type),
-		Description:         b.Description,
-		Name:                b.Name,
-		RetentionPolicyName: b.RetentionPolicyName,
-		RetentionPeriod:     d,
-		CRUDLog:             b.CRUDLog,
+		ID:                   b.ID,
+		OrgID:                b.OrgID,
+		Type:                 influxdb.ParseBucketType(b.Type),
+		Description:          b.Description,
+		Name:                 b.Name,
+		RetentionPolicyName:  b.RetentionPolicyName,
+		RetentionPeriod:      d,
+		SchemaType:           influxdb.SchemaType(b.SchemaType),
+		Fields:               b.Fields,
+		MeasurementRetention: mr,
+		CRUDLog:              b.CRUDLog,
 	}, nil
 }
 
@@ -203,22 +262,28 @@ func newBucket(pb *influxdb.Bucket) *bucket {
 	}
 
 	return &bucket{
-		ID:                  pb.ID,
-		OrgID:               pb.OrgID,
-		Type:                pb.Type.String(),
-		Name:                pb.Name,
-		Description:         pb.Description,
-		RetentionPolicyName: pb.RetentionPolicyName,
-		RetentionRules:      rules,
-		CRUDLog:             pb.CRUDLog,
+		ID:                        pb.ID,
+		OrgID:                     pb.OrgID,
+		Type:                      pb.Type.String(),
+		Name:                      pb.Name,
+		Description:               pb.Description,
+		RetentionPolicyName:       pb.RetentionPolicyName,
+		RetentionRules:            rules,
+		SchemaType:                string(pb.SchemaType),
+		Fields:                    pb.Fields,
+		MeasurementRetentionRules: newMeasurementRetentionRules(pb.MeasurementRetention),
+		CRUDLog:                   pb.CRUDLog,
 	}
 }
 
 // bucketUpdate is used for serialization/deserialization with retention rules.
 type bucketUpdate struct {
-	Name           *string         `json:"name,omitempty"`
-	Description    *string         `json:"description,omitempty"`
-	RetentionRules []retentionRule `json:"retentionRules,omitempty"`
+	Name                      *string                     `json:"name,omitempty"`
+	Description               *string                     `json:"description,omitempty"`
+	RetentionRules            []retentionRule             `json:"retentionRules,omitempty"`
+	SchemaType                *string                     `json:"schemaType,omitempty"`
+	Fields                    *[]string                   `json:"fields,omitempty"`
+	MeasurementRetentionRules *[]measurementRetentionRule `json:"measurementRetentionRules,omitempty"`
 }
 
 func (b *bucketUpdate) OK() error {
@@ -228,6 +293,16 @@ func (b *bucketUpdate) OK() error {
 			return err
 		}
 	}
+	if b.SchemaType != nil {
+		if _, err := influxdb.ParseSchemaType(*b.SchemaType); err != nil {
+			return err
+		}
+	}
+	if b.MeasurementRetentionRules != nil {
+		if _, err := measurementRetention(*b.MeasurementRetentionRules); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -242,11 +317,21 @@ func (b *bucketUpdate) toInfluxDB() *influxdb.BucketUpdate {
 		d, _ = b.RetentionRules[0].RetentionPeriod()
 	}
 
-	return &influxdb.BucketUpdate{
+	upd := &influxdb.BucketUpdate{
 		Name:            b.Name,
 		Description:     b.Description,
 		RetentionPeriod: &d,
+		Fields:          b.Fields,
+	}
+	if b.SchemaType != nil {
+		st := influxdb.SchemaType(*b.SchemaType)
+		upd.SchemaType = &st
+	}
+	if b.MeasurementRetentionRules != nil {
+		mr, _ := measurementRetention(*b.MeasurementRetentionRules)
+		upd.MeasurementRetention = &mr
 	}
+	return upd
 }
 
 func newBucketUpdate(pb *influxdb.BucketUpdate) *bucketUpdate {
@@ -258,6 +343,20 @@ func newBucketUpdate(pb *influxdb.BucketUpdate) *bucketUpdate {
 		Name:           pb.Name,
 		Description:    pb.Description,
 		RetentionRules: []retentionRule{},
+		Fields:         pb.Fields,
+	}
+
+	if pb.SchemaType != nil {
+		st := string(*pb.SchemaType)
+		up.SchemaType = &st
+	}
+
+	if pb.MeasurementRetention != nil {
+		rules := newMeasurementRetentionRules(*pb.MeasurementRetention)
+		if rules == nil {
+			rules = []measurementRetentionRule{}
+		}
+		up.MeasurementRetentionRules = &rules
 	}
 
 	if pb.RetentionPeriod != nil {
@@ -334,11 +433,14 @@ func (h *BucketHandler) handlePostBucket(w http.ResponseWriter, r *http.Request)
 }
 
 type postBucketRequest struct {
-	OrgID               influxdb.ID     `json:"orgID,omitempty"`
-	Name                string          `json:"name"`
-	Description         string          `json:"description"`
-	RetentionPolicyName string          `json:"rp,omitempty"` // This to support v1 sources
-	RetentionRules      []retentionRule `json:"retentionRules"`
+	OrgID                     influxdb.ID                `json:"orgID,omitempty"`
+	Name                      string                     `json:"name"`
+	Description               string                     `json:"description"`
+	RetentionPolicyName       string                     `json:"rp,omitempty"` // This to support v1 sources
+	RetentionRules            []retentionRule            `json:"retentionRules"`
+	SchemaType                string                     `json:"schemaType,omitempty"`
+	Fields                    []string                   `json:"fields,omitempty"`
+	MeasurementRetentionRules []measurementRetentionRule `json:"measurementRetentionRules,omitempty"`
 }
 
 func (b *postBucketRequest) OK() error {
@@ -359,6 +461,16 @@ func (b *postBucketRequest) OK() error {
 		}
 	}
 
+	if _, err := measurementRetention(b.MeasurementRetentionRules); err != nil {
+		return err
+	}
+
+	if b.SchemaType != "" {
+		if _, err := influxdb.ParseSchemaType(b.SchemaType); err != nil {
+			return err
+		}
+	}
+
 	// names starting with an underscore are reserved for system buckets
 	if err := validBucketName(b.toInfluxDB()); err != nil {
 		return &influxdb.Error{
@@ -376,14 +488,18 @@ func (b postBucketRequest) toInfluxDB() *influxdb.Bucket {
 	if len(b.RetentionRules) > 0 {
 		dur, _ = b.RetentionRules[0].RetentionPeriod()
 	}
+	mr, _ := measurementRetention(b.MeasurementRetentionRules)
 
 	return &influxdb.Bucket{
-		OrgID:               b.OrgID,
-		Description:         b.Description,
-		Name:                b.Name,
-		Type:                influxdb.BucketTypeUser,
-		RetentionPolicyName: b.RetentionPolicyName,
-		RetentionPeriod:     dur,
+		OrgID:                b.OrgID,
+		Description:          b.Description,
+		Name:                 b.Name,
+		Type:                 influxdb.BucketTypeUser,
+		RetentionPolicyName:  b.RetentionPolicyName,
+		RetentionPeriod:      dur,
+		SchemaType:           influxdb.SchemaType(b.SchemaType),
+		Fields:               b.Fields,
+		MeasurementRetention: mr,
 	}
 }
 
@@ -635,9 +635,12 @@ func TestService_handlePatchBucket(t *testing.T) {
 		BucketService influxdb.BucketService
 	}
 	type args struct {
-		id        string
-		name      string
-		retention time.Duration
+		id                   string
+		name                 string
+		retention            time.Duration
+		schemaType           influxdb.SchemaType
+		fields               []string
+		measurementRetention map[string]time.Duration
 	}
 	type wants struct {
 		statusCode  int
@@ -713,6 +716,118 @@ func TestService_handlePatchBucket(t *testing.T) {
   "retentionRules": [{"type": "expire", "everySeconds": 2}],
   "labels": []
 }
+`,
+			},
+		},
+		{
+			name: "update a bucket schema",
+			fields: fields{
+				&mock.BucketService{
+					UpdateBucketFn: func(ctx context.Context, id influxdb.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+						d := &influxdb.Bucket{
+							ID:    platformtesting.MustIDBase16("020f755c3c082000"),
+							Name:  "hello",
+							OrgID: platformtesting.MustIDBase16("020f755c3c082000"),
+						}
+
+						if upd.SchemaType != nil {
+							d.SchemaType = *upd.SchemaType
+						}
+
+						if upd.Fields != nil {
+							d.Fields = *upd.Fields
+						}
+
+						return d, nil
+					},
+				},
+			},
+			args: args{
+				id:         "020f755c3c082000",
+				schemaType: influxdb.SchemaTypeExplicit,
+				fields:     []string{"f0", "f1"},
+			},
+			wants: wants{
+				statusCode:  http.StatusOK,
+				contentType: "application/json; charset=utf-8",
+				body: `
+{
+  "links": {
+    "org": "/api/v2/orgs/020f755c3c082000",
+    "self": "/api/v2/buckets/020f755c3c082000",
+    "logs": "/api/v2/buckets/020f755c3c082000/logs",
+    "labels": "/api/v2/buckets/020f755c3c082000/labels",
+    "members": "/api/v2/buckets/020f755c3c082000/members",
+    "owners": "/api/v2/buckets/020f755c3c082000/owners",
+    "write": "/api/v2/write?org=020f755c3c082000&bucket=020f755c3c082000"
+  },
+  "createdAt": "0001-01-01T00:00:00Z",
+  "updatedAt": "0001-01-01T00:00:00Z",
+  "id": "020f755c3c082000",
+  "orgID": "020f755c3c082000",
+  "type": "user",
+  "name": "hello",
+  "retentionRules": [],
+  "schemaType": "explicit",
+  "fields": ["f0", "f1"],
+  "labels": []
+}
+`,
+			},
+		},
+		{
+			name: "update a bucket measurement retention",
+			fields: fields{
+				&mock.BucketService{
+					UpdateBucketFn: func(ctx context.Context, id influxdb.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+						d := &influxdb.Bucket{
+							ID:    platformtesting.MustIDBase16("020f755c3c082000"),
+							Name:  "hello",
+							OrgID: platformtesting.MustIDBase16("020f755c3c082000"),
+						}
+
+						if upd.MeasurementRetention != nil {
+							d.MeasurementRetention = *upd.MeasurementRetention
+						}
+
+						return d, nil
+					},
+				},
+			},
+			args: args{
+				id: "020f755c3c082000",
+				measurementRetention: map[string]time.Duration{
+					"m0": time.Hour,
+					"m1": 2 * time.Hour,
+				},
+			},
+			wants: wants{
+				statusCode:  http.StatusOK,
+				contentType: "application/json; charset=utf-8",
+				body: `
+{
+  "links": {
+    "org": "/api/v2/orgs/020f755c3c082000",
+    "self": "/api/v2/buckets/020f755c3c082000",
+    "logs": "/api/v2/buckets/020f755c3c082000/logs",
+    "labels": "/api/v2/buckets/020f755c3c082000/labels",
+    "members": "/api/v2/buckets/020f755c3c082000/members",
+    "owners": "/api/v2/buckets/020f755c3c082000/owners",
+    "write": "/api/v2/write?org=020f755c3c082000&bucket=020f755c3c082000"
+  },
+  "createdAt": "0001-01-01T00:00:00Z",
+  "updatedAt": "0001-01-01T00:00:00Z",
+  "id": "020f755c3c082000",
+  "orgID": "020f755c3c082000",
+  "type": "user",
+  "name": "hello",
+  "retentionRules": [],
+  "measurementRetentionRules": [
+    {"measurement": "m0", "everySeconds": 3600},
+    {"measurement": "m1", "everySeconds": 7200}
+  ],
+  "labels": []
+}
 `,
 			},
 		},
@@ -969,6 +1084,18 @@ func TestService_handlePatchBucket(t *testing.T) {
 				upd.RetentionPeriod = &tt.args.retention
 			}
 
+			if tt.args.schemaType != "" {
+				upd.SchemaType = &tt.args.schemaType
+			}
+
+			if tt.args.fields != nil {
+				upd.Fields = &tt.args.fields
+			}
+
+			if tt.args.measurementRetention != nil {
+				upd.MeasurementRetention = &tt.args.measurementRetention
+			}
+
 			b, err := json.Marshal(newBucketUpdate(&upd))
 			if err != nil {
 				t.Fatalf("failed to unmarshal bucket update: %v", err)
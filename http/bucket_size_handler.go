@@ -0,0 +1,133 @@
+package http
+
+import (
+	"fmt"
+	http "net/http"
+
+	"github.com/influxdata/httprouter"
+	"github.com/influxdata/influxdb/v2"
+	pcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"go.uber.org/zap"
+)
+
+// BucketSizeBackend is all services and associated parameters required to construct
+// the BucketSizeHandler.
+type BucketSizeBackend struct {
+	log *zap.Logger
+	influxdb.HTTPErrorHandler
+
+	BucketSizeService   influxdb.BucketSizeService
+	BucketService       influxdb.BucketService
+	OrganizationService influxdb.OrganizationService
+}
+
+// NewBucketSizeBackend returns a new instance of BucketSizeBackend
+func NewBucketSizeBackend(log *zap.Logger, b *APIBackend) *BucketSizeBackend {
+	return &BucketSizeBackend{
+		log: log,
+
+		HTTPErrorHandler:    b.HTTPErrorHandler,
+		BucketSizeService:   b.BucketSizeService,
+		BucketService:       b.BucketService,
+		OrganizationService: b.OrganizationService,
+	}
+}
+
+// BucketSizeHandler reports the estimated size of a bucket in storage.
+type BucketSizeHandler struct {
+	influxdb.HTTPErrorHandler
+	*httprouter.Router
+
+	log *zap.Logger
+
+	BucketSizeService   influxdb.BucketSizeService
+	BucketService       influxdb.BucketService
+	OrganizationService influxdb.OrganizationService
+}
+
+const (
+	prefixBucketSize = "/api/v2/bucketsize"
+)
+
+// NewBucketSizeHandler creates a new handler at /api/v2/bucketsize to report bucket sizes.
+func NewBucketSizeHandler(log *zap.Logger, b *BucketSizeBackend) *BucketSizeHandler {
+	h := &BucketSizeHandler{
+		HTTPErrorHandler: b.HTTPErrorHandler,
+		Router:           NewRouter(b.HTTPErrorHandler),
+		log:              log,
+
+		BucketSizeService:   b.BucketSizeService,
+		BucketService:       b.BucketService,
+		OrganizationService: b.OrganizationService,
+	}
+
+	h.HandlerFunc("GET", prefixBucketSize, h.handleGetBucketSize)
+	return h
+}
+
+type bucketSizeResponse struct {
+	OrgID    influxdb.ID `json:"orgID"`
+	BucketID influxdb.ID `json:"bucketID"`
+	Bytes    int64       `json:"bytes"`
+}
+
+func (h *BucketSizeHandler) handleGetBucketSize(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "BucketSizeHandler")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	a, err := pcontext.GetAuthorizer(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	org, err := queryOrganization(ctx, r, h.OrganizationService)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	bucket, err := queryBucket(ctx, org.ID, r, h.BucketService)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	p, err := influxdb.NewPermissionAtID(bucket.ID, influxdb.ReadAction, influxdb.BucketsResourceType, org.ID)
+	if err != nil {
+		h.HandleHTTPError(ctx, &influxdb.Error{
+			Code: influxdb.EInternal,
+			Op:   "http/handleGetBucketSize",
+			Msg:  fmt.Sprintf("unable to create permission for bucket: %v", err),
+			Err:  err,
+		}, w)
+		return
+	}
+
+	if pset, err := a.PermissionSet(); err != nil || !pset.Allowed(*p) {
+		h.HandleHTTPError(ctx, &influxdb.Error{
+			Code: influxdb.EForbidden,
+			Op:   "http/handleGetBucketSize",
+			Msg:  "insufficient permissions to read bucket size",
+		}, w)
+		return
+	}
+
+	n, err := h.BucketSizeService.BucketSize(ctx, org.ID, bucket.ID)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, bucketSizeResponse{
+		OrgID:    org.ID,
+		BucketID: bucket.ID,
+		Bytes:    n,
+	}); err != nil {
+		logEncodingError(h.log, r, err)
+		return
+	}
+}
@@ -2,9 +2,16 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"runtime"
+
+	"github.com/influxdata/influxdb/v2/query"
 )
 
+// DebugVarsPath exposes runtime stats of the process as JSON.
+const DebugVarsPath = "/debug/vars"
+
 // Flusher flushes data from a store to reset; used for testing.
 type Flusher interface {
 	Flush(ctx context.Context)
@@ -22,3 +29,53 @@ func DebugFlush(ctx context.Context, next http.Handler, f Flusher) http.HandlerF
 		next.ServeHTTP(w, r)
 	})
 }
+
+// debugVars are the runtime stats served by DebugVars.
+type debugVars struct {
+	Goroutines int                   `json:"goroutines"`
+	Memory     debugMemoryVars       `json:"memory"`
+	Query      query.ControllerStats `json:"query"`
+}
+
+type debugMemoryVars struct {
+	Alloc        uint64 `json:"alloc"`
+	Sys          uint64 `json:"sys"`
+	HeapAlloc    uint64 `json:"heapAlloc"`
+	HeapInuse    uint64 `json:"heapInuse"`
+	HeapObjects  uint64 `json:"heapObjects"`
+	NumGC        uint32 `json:"numGC"`
+	PauseTotalNs uint64 `json:"pauseTotalNs"`
+	LastGC       uint64 `json:"lastGC"`
+}
+
+// DebugVars serves the runtime stats of the process and the counters
+// of the query controller as JSON at DebugVarsPath. It is a lightweight
+// alternative to the prometheus metrics.
+func DebugVars(next http.Handler, s query.ControllerStatsService) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != DebugVarsPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		vars := debugVars{
+			Goroutines: runtime.NumGoroutine(),
+			Memory: debugMemoryVars{
+				Alloc:        ms.Alloc,
+				Sys:          ms.Sys,
+				HeapAlloc:    ms.HeapAlloc,
+				HeapInuse:    ms.HeapInuse,
+				HeapObjects:  ms.HeapObjects,
+				NumGC:        ms.NumGC,
+				PauseTotalNs: ms.PauseTotalNs,
+				LastGC:       ms.LastGC,
+			},
+			Query: s.Stats(),
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(vars)
+	})
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+type controllerStatsService struct {
+	stats query.ControllerStats
+}
+
+func (s *controllerStatsService) Stats() query.ControllerStats { return s.stats }
+
+func TestDebugVars(t *testing.T) {
+	var nextCalled bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nextCalled = true
+	})
+	h := DebugVars(next, &controllerStatsService{
+		stats: query.ControllerStats{Queries: 3, Succeeded: 2, Active: 1},
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, DebugVarsPath, nil))
+	if nextCalled {
+		t.Fatal("unexpected call to the next handler")
+	}
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Fatalf("got status code %d, expected %d", got, want)
+	}
+	if got, want := w.Header().Get("Content-Type"), "application/json; charset=utf-8"; got != want {
+		t.Errorf("got content type %q, expected %q", got, want)
+	}
+
+	var vars struct {
+		Goroutines int `json:"goroutines"`
+		Query      struct {
+			Queries   *int64 `json:"queries"`
+			Succeeded int64  `json:"succeeded"`
+		} `json:"query"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&vars); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if vars.Goroutines <= 0 {
+		t.Errorf("got %d goroutines, expected at least one", vars.Goroutines)
+	}
+	if vars.Query.Queries == nil {
+		t.Fatal("missing query count")
+	} else if got, want := *vars.Query.Queries, int64(3); got != want {
+		t.Errorf("got query count %d, expected %d", got, want)
+	}
+	if got, want := vars.Query.Succeeded, int64(2); got != want {
+		t.Errorf("got succeeded count %d, expected %d", got, want)
+	}
+
+	// Other requests are passed to the next handler.
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v2", nil))
+	if !nextCalled {
+		t.Error("expected a call to the next handler")
+	}
+}
@@ -36,6 +36,10 @@ type QueryRequest struct {
 	Dialect QueryDialect    `json:"dialect"`
 	Now     time.Time       `json:"now"`
 
+	// Priority orders the query in the queue of the query controller.
+	// It must be between query.MinPriority and query.MaxPriority.
+	Priority int `json:"priority,omitempty"`
+
 	// InfluxQL fields
 	Bucket string `json:"bucket,omitempty"`
 
@@ -100,6 +104,10 @@ func (r QueryRequest) Validate() error {
 		return fmt.Errorf("bucket parameter is required for influxql queries")
 	}
 
+	if r.Priority < query.MinPriority || r.Priority > query.MaxPriority {
+		return fmt.Errorf("priority must be between %d and %d", query.MinPriority, query.MaxPriority)
+	}
+
 	if len(r.Dialect.CommentPrefix) > 1 {
 		return fmt.Errorf("invalid dialect comment prefix: must be length 0 or 1")
 	}
@@ -316,6 +324,7 @@ func (r QueryRequest) proxyRequest(now func() time.Time) (*query.ProxyRequest, e
 		Request: query.Request{
 			OrganizationID: r.Org.ID,
 			Compiler:       compiler,
+			Priority:       r.Priority,
 		},
 		Dialect: dialect,
 	}, nil
@@ -325,6 +334,7 @@ func (r QueryRequest) proxyRequest(now func() time.Time) (*query.ProxyRequest, e
 // The ProxyRequest must contain supported compilers and dialects otherwise an error occurs.
 func QueryRequestFromProxyRequest(req *query.ProxyRequest) (*QueryRequest, error) {
 	qr := new(QueryRequest)
+	qr.Priority = req.Request.Priority
 	switch c := req.Request.Compiler.(type) {
 	case lang.FluxCompiler:
 		qr.Type = "flux"
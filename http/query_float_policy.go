@@ -0,0 +1,191 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2"
+)
+
+// FloatNaNPolicy is how NaN and infinite float values
+// are encoded in the response of a query.
+type FloatNaNPolicy string
+
+const (
+	// FloatNaNPassthrough encodes NaN and infinite values as they are.
+	FloatNaNPassthrough FloatNaNPolicy = "passthrough"
+
+	// FloatNaNNull encodes NaN and infinite values as null.
+	FloatNaNNull FloatNaNPolicy = "null"
+
+	// FloatNaNError fails the query when a NaN or infinite value is encoded.
+	FloatNaNError FloatNaNPolicy = "error"
+)
+
+// ParseFloatNaNPolicy returns the FloatNaNPolicy named by s.
+// An empty string is FloatNaNPassthrough.
+func ParseFloatNaNPolicy(s string) (FloatNaNPolicy, error) {
+	switch p := FloatNaNPolicy(s); p {
+	case "":
+		return FloatNaNPassthrough, nil
+	case FloatNaNPassthrough, FloatNaNNull, FloatNaNError:
+		return p, nil
+	default:
+		return "", &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("unknown float NaN policy %q: valid options are passthrough, null and error", s),
+		}
+	}
+}
+
+// floatPolicyDialect applies a FloatNaNPolicy other than
+// FloatNaNPassthrough to the results encoded for a query.
+type floatPolicyDialect struct {
+	flux.Dialect
+	policy FloatNaNPolicy
+}
+
+func (d *floatPolicyDialect) Encoder() flux.MultiResultEncoder {
+	return &floatPolicyEncoder{
+		MultiResultEncoder: d.Dialect.Encoder(),
+		policy:             d.policy,
+	}
+}
+
+type floatPolicyEncoder struct {
+	flux.MultiResultEncoder
+	policy FloatNaNPolicy
+}
+
+func (e *floatPolicyEncoder) Encode(w io.Writer, results flux.ResultIterator) (int64, error) {
+	return e.MultiResultEncoder.Encode(w, &floatPolicyResultIterator{
+		ResultIterator: results,
+		policy:         e.policy,
+	})
+}
+
+type floatPolicyResultIterator struct {
+	flux.ResultIterator
+	policy FloatNaNPolicy
+}
+
+func (it *floatPolicyResultIterator) Next() flux.Result {
+	return &floatPolicyResult{
+		Result: it.ResultIterator.Next(),
+		policy: it.policy,
+	}
+}
+
+type floatPolicyResult struct {
+	flux.Result
+	policy FloatNaNPolicy
+}
+
+func (r *floatPolicyResult) Tables() flux.TableIterator {
+	return &floatPolicyTableIterator{
+		TableIterator: r.Result.Tables(),
+		policy:        r.policy,
+	}
+}
+
+type floatPolicyTableIterator struct {
+	flux.TableIterator
+	policy FloatNaNPolicy
+}
+
+func (it *floatPolicyTableIterator) Do(f func(flux.Table) error) error {
+	return it.TableIterator.Do(func(tbl flux.Table) error {
+		for _, c := range tbl.Cols() {
+			if c.Type == flux.TFloat {
+				return f(&floatPolicyTable{Table: tbl, policy: it.policy})
+			}
+		}
+		return f(tbl)
+	})
+}
+
+// floatPolicyTable applies the policy to the NaN and
+// infinite values of the float columns of a table.
+type floatPolicyTable struct {
+	flux.Table
+	policy FloatNaNPolicy
+}
+
+func (t *floatPolicyTable) Do(f func(flux.ColReader) error) error {
+	return t.Table.Do(func(cr flux.ColReader) error {
+		buffer := arrow.TableBuffer{
+			GroupKey: cr.Key(),
+			Columns:  cr.Cols(),
+			Values:   make([]array.Interface, len(cr.Cols())),
+		}
+		for j, c := range cr.Cols() {
+			if c.Type != flux.TFloat {
+				arr := colValues(cr, j)
+				arr.Retain()
+				buffer.Values[j] = arr
+				continue
+			}
+			arr, err := t.floats(c, cr.Floats(j))
+			if err != nil {
+				for _, arr := range buffer.Values[:j] {
+					arr.Release()
+				}
+				return err
+			}
+			buffer.Values[j] = arr
+		}
+		defer buffer.Release()
+		return f(&buffer)
+	})
+}
+
+// floats returns vs with the policy applied to its NaN and infinite values.
+func (t *floatPolicyTable) floats(c flux.ColMeta, vs *array.Float64) (*array.Float64, error) {
+	b := arrow.NewFloatBuilder(&memory.Allocator{})
+	b.Resize(vs.Len())
+	for i := 0; i < vs.Len(); i++ {
+		if vs.IsNull(i) {
+			b.AppendNull()
+			continue
+		}
+		v := vs.Value(i)
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			b.Append(v)
+			continue
+		}
+		if t.policy == FloatNaNError {
+			b.Release()
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  fmt.Sprintf("cannot encode float value %v of column %q", v, c.Label),
+			}
+		}
+		b.AppendNull()
+	}
+	return b.NewFloat64Array(), nil
+}
+
+// colValues returns the values of column j of cr.
+func colValues(cr flux.ColReader, j int) array.Interface {
+	switch typ := cr.Cols()[j].Type; typ {
+	case flux.TInt:
+		return cr.Ints(j)
+	case flux.TUInt:
+		return cr.UInts(j)
+	case flux.TFloat:
+		return cr.Floats(j)
+	case flux.TString:
+		return cr.Strings(j)
+	case flux.TBool:
+		return cr.Bools(j)
+	case flux.TTime:
+		return cr.Times(j)
+	default:
+		panic(fmt.Errorf("unimplemented column type: %s", typ))
+	}
+}
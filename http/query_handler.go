@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/NYTimes/gziphandler"
@@ -36,6 +38,18 @@ import (
 const (
 	prefixQuery   = "/api/v2/query"
 	traceIDHeader = "Trace-Id"
+
+	// querySourceHeader labels a query with its source, such as the
+	// ID of a dashboard, so that its cost can be attributed in traces.
+	// The query metrics are only labeled with the sources allowed by
+	// the configuration of the query controller.
+	querySourceHeader = "X-Influx-Query-Source"
+
+	// queryIDHeader reports the ID the query controller assigned to
+	// the query so that it can be canceled while it is running.
+	queryIDHeader = "Query-Id"
+
+	prefixActiveQueries = "/api/v2/query/active"
 )
 
 // FluxBackend is all services and associated parameters required to construct
@@ -49,7 +63,17 @@ type FluxBackend struct {
 	OrganizationService influxdb.OrganizationService
 	ProxyQueryService   query.ProxyQueryService
 	FluxLanguageService influxdb.FluxLanguageService
+	QueryCostEstimator  query.CostEstimator
+	ActiveQueryService  query.ActiveQueryService
 	Flagger             feature.Flagger
+
+	// MaxResponseBytes is the maximum number of bytes written in
+	// the response of a single query. Zero means there is no limit.
+	MaxResponseBytes int64
+
+	// FloatNaNPolicy is how NaN and infinite float values are
+	// encoded in the response of a query. It defaults to passthrough.
+	FloatNaNPolicy FloatNaNPolicy
 }
 
 // NewFluxBackend returns a new instance of FluxBackend.
@@ -65,7 +89,11 @@ func NewFluxBackend(log *zap.Logger, b *APIBackend) *FluxBackend {
 		},
 		OrganizationService: b.OrganizationService,
 		FluxLanguageService: b.FluxLanguageService,
+		QueryCostEstimator:  b.QueryCostEstimator,
+		ActiveQueryService:  b.ActiveQueryService,
 		Flagger:             b.Flagger,
+		MaxResponseBytes:    b.QueryMaxResponseBytes,
+		FloatNaNPolicy:      b.QueryFloatNaNPolicy,
 	}
 }
 
@@ -84,10 +112,16 @@ type FluxHandler struct {
 	OrganizationService influxdb.OrganizationService
 	ProxyQueryService   query.ProxyQueryService
 	FluxLanguageService influxdb.FluxLanguageService
+	QueryCostEstimator  query.CostEstimator
+	ActiveQueryService  query.ActiveQueryService
 
 	EventRecorder metric.EventRecorder
 
 	Flagger feature.Flagger
+
+	MaxResponseBytes int64
+
+	FloatNaNPolicy FloatNaNPolicy
 }
 
 // Prefix provides the route prefix.
@@ -107,7 +141,11 @@ func NewFluxHandler(log *zap.Logger, b *FluxBackend) *FluxHandler {
 		OrganizationService: b.OrganizationService,
 		EventRecorder:       b.QueryEventRecorder,
 		FluxLanguageService: b.FluxLanguageService,
+		QueryCostEstimator:  b.QueryCostEstimator,
+		ActiveQueryService:  b.ActiveQueryService,
 		Flagger:             b.Flagger,
+		MaxResponseBytes:    b.MaxResponseBytes,
+		FloatNaNPolicy:      b.FloatNaNPolicy,
 	}
 
 	// query reponses can optionally be gzip encoded
@@ -115,6 +153,9 @@ func NewFluxHandler(log *zap.Logger, b *FluxBackend) *FluxHandler {
 	h.Handler("POST", prefixQuery, withFeatureProxy(b.AlgoWProxy, qh))
 	h.Handler("POST", "/api/v2/query/ast", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.postFluxAST)))
 	h.Handler("POST", "/api/v2/query/analyze", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.postQueryAnalyze)))
+	h.Handler("POST", "/api/v2/query/estimate", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.postQueryEstimate)))
+	h.Handler("GET", prefixActiveQueries, withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.getActiveQueries)))
+	h.Handler("DELETE", prefixActiveQueries+"/:id", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.deleteActiveQuery)))
 	h.Handler("GET", "/api/v2/query/suggestions", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.getFluxSuggestions)))
 	h.Handler("GET", "/api/v2/query/suggestions/:name", withFeatureProxy(b.AlgoWProxy, http.HandlerFunc(h.getFluxSuggestion)))
 	return h
@@ -130,6 +171,13 @@ func (h *FluxHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 	if id, _, found := tracing.InfoFromContext(ctx); found {
 		w.Header().Set(traceIDHeader, id)
 	}
+	if source := r.Header.Get(querySourceHeader); source != "" {
+		span.SetTag("query_source", source)
+		ctx = query.ContextWithSourceLabel(ctx, source)
+	}
+	ctx = query.ContextWithQueryIDFunc(ctx, func(id uint64) {
+		w.Header().Set(queryIDHeader, strconv.FormatUint(id, 10))
+	})
 
 	// TODO(desa): I really don't like how we're recording the usage metrics here
 	// Ideally this will be moved when we solve https://github.com/influxdata/influxdb/issues/13403
@@ -190,8 +238,29 @@ func (h *FluxHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
+
+	page, err := decodeRowPage(r, req)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
 	hd.SetHeaders(w)
 
+	if strings.EqualFold(r.Header.Get(resultStatsHeader), "true") {
+		req.Dialect = &resultStatsDialect{Dialect: req.Dialect}
+	}
+	if h.MaxResponseBytes > 0 {
+		req.Dialect = &responseLimitDialect{Dialect: req.Dialect, limit: h.MaxResponseBytes}
+	}
+	if h.FloatNaNPolicy != "" && h.FloatNaNPolicy != FloatNaNPassthrough {
+		req.Dialect = &floatPolicyDialect{Dialect: req.Dialect, policy: h.FloatNaNPolicy}
+	}
+
+	if page != nil {
+		h.handlePagedQuery(ctx, w, req, page)
+		return
+	}
+
 	cw := iocounter.Writer{Writer: w}
 	if _, err := h.ProxyQueryService.Query(ctx, &cw, req); err != nil {
 		if cw.Count() == 0 {
@@ -207,6 +276,39 @@ func (h *FluxHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handlePagedQuery runs a query and writes the rows of the response
+// within page. The page is buffered so that the headers describing
+// it can be set once the number of rows in it is known.
+func (h *FluxHandler) handlePagedQuery(ctx context.Context, w http.ResponseWriter, req *query.ProxyRequest, page *rowPage) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	req.Dialect = &rowPageDialect{Dialect: req.Dialect, page: page}
+
+	var buf bytes.Buffer
+	if _, err := h.ProxyQueryService.Query(ctx, &buf, req); err != nil {
+		if buf.Len() == 0 {
+			h.HandleHTTPError(ctx, err, w)
+			return
+		}
+		// The error is encoded in the response so
+		// there is no page following this one.
+		page.more = false
+		_ = tracing.LogError(span, err)
+	}
+
+	if err := page.SetHeaders(w); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	if _, err := buf.WriteTo(w); err != nil {
+		h.log.Info("Error writing response to client",
+			zap.String("handler", "flux"),
+			zap.Error(err),
+		)
+	}
+}
+
 type langRequest struct {
 	Query string `json:"query"`
 }
@@ -281,6 +383,184 @@ func (h *FluxHandler) postQueryAnalyze(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// postQueryEstimate estimates the cost of the storage reads of a query
+// without executing it.
+func (h *FluxHandler) postQueryEstimate(w http.ResponseWriter, r *http.Request) {
+	const op = "http/postQueryEstimate"
+	span, r := tracing.ExtractFromHTTPRequest(r, "FluxHandler")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	if h.QueryCostEstimator == nil {
+		h.HandleHTTPError(ctx, &influxdb.Error{
+			Code: influxdb.EMethodNotAllowed,
+			Msg:  "query cost estimates are not supported",
+			Op:   op,
+		}, w)
+		return
+	}
+
+	a, err := pcontext.GetAuthorizer(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, &influxdb.Error{
+			Code: influxdb.EUnauthorized,
+			Msg:  "authorization is invalid or missing in the query request",
+			Op:   op,
+			Err:  err,
+		}, w)
+		return
+	}
+
+	req, _, err := decodeProxyQueryRequest(ctx, r, a, h.OrganizationService)
+	if err != nil && err != influxdb.ErrAuthorizerNotSupported {
+		h.HandleHTTPError(ctx, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "failed to decode request body",
+			Op:   op,
+			Err:  err,
+		}, w)
+		return
+	}
+
+	// Transform the context into one with the request's authorization.
+	ctx = pcontext.SetAuthorizer(ctx, req.Request.Authorization)
+
+	est, err := h.QueryCostEstimator.EstimateCost(ctx, &req.Request)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	if err := encodeResponse(ctx, w, http.StatusOK, est); err != nil {
+		logEncodingError(h.log, r, err)
+		return
+	}
+}
+
+type activeQueriesResponse struct {
+	Queries []query.ActiveQuery `json:"queries"`
+}
+
+// getActiveQueries lists the active queries of an organization.
+func (h *FluxHandler) getActiveQueries(w http.ResponseWriter, r *http.Request) {
+	const op = "http/getActiveQueries"
+	span, r := tracing.ExtractFromHTTPRequest(r, "FluxHandler")
+	defer span.Finish()
+
+	ctx := r.Context()
+	orgID, err := h.authorizeActiveQueries(ctx, r, op)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	res := activeQueriesResponse{Queries: []query.ActiveQuery{}}
+	for _, q := range h.ActiveQueryService.ActiveQueries(ctx) {
+		if q.OrganizationID == orgID {
+			res.Queries = append(res.Queries, q)
+		}
+	}
+	if err := encodeResponse(ctx, w, http.StatusOK, res); err != nil {
+		logEncodingError(h.log, r, err)
+		return
+	}
+}
+
+// deleteActiveQuery cancels an active query of an organization by its ID.
+func (h *FluxHandler) deleteActiveQuery(w http.ResponseWriter, r *http.Request) {
+	const op = "http/deleteActiveQuery"
+	span, r := tracing.ExtractFromHTTPRequest(r, "FluxHandler")
+	defer span.Finish()
+
+	ctx := r.Context()
+	orgID, err := h.authorizeActiveQueries(ctx, r, op)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	id, err := strconv.ParseUint(httprouter.ParamsFromContext(ctx).ByName("id"), 10, 64)
+	if err != nil {
+		h.HandleHTTPError(ctx, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "invalid query id",
+			Op:   op,
+			Err:  err,
+		}, w)
+		return
+	}
+
+	// A query of another organization is reported as not
+	// found so that its existence is not revealed.
+	var found bool
+	for _, q := range h.ActiveQueryService.ActiveQueries(ctx) {
+		if q.ID == id && q.OrganizationID == orgID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		h.HandleHTTPError(ctx, &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Msg:  fmt.Sprintf("query %d is not active", id),
+			Op:   op,
+		}, w)
+		return
+	}
+
+	if err := h.ActiveQueryService.CancelQuery(ctx, id); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeActiveQueries returns the organization of the request
+// if the active queries are supported and the request is permitted
+// to read the organization.
+func (h *FluxHandler) authorizeActiveQueries(ctx context.Context, r *http.Request, op string) (influxdb.ID, error) {
+	if h.ActiveQueryService == nil {
+		return 0, &influxdb.Error{
+			Code: influxdb.EMethodNotAllowed,
+			Msg:  "listing active queries is not supported",
+			Op:   op,
+		}
+	}
+
+	a, err := pcontext.GetAuthorizer(ctx)
+	if err != nil {
+		return 0, &influxdb.Error{
+			Code: influxdb.EUnauthorized,
+			Msg:  "authorization is invalid or missing in the request",
+			Op:   op,
+			Err:  err,
+		}
+	}
+
+	org, err := queryOrganization(ctx, r, h.OrganizationService)
+	if err != nil {
+		return 0, err
+	}
+
+	p, err := influxdb.NewResourcePermission(influxdb.ReadAction, influxdb.OrgsResourceType, org.ID)
+	if err != nil {
+		return 0, &influxdb.Error{
+			Code: influxdb.EInternal,
+			Msg:  fmt.Sprintf("unable to create permission for organization: %v", err),
+			Op:   op,
+			Err:  err,
+		}
+	}
+	if pset, err := a.PermissionSet(); err != nil || !pset.Allowed(*p) {
+		return 0, &influxdb.Error{
+			Code: influxdb.EForbidden,
+			Msg:  "insufficient permissions to read the queries of the organization",
+			Op:   op,
+		}
+	}
+	return org.ID, nil
+}
+
 // fluxParams contain flux funciton parameters as defined by the semantic graph
 type fluxParams map[string]string
 
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -18,18 +20,27 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/memory"
+	fluxmock "github.com/influxdata/flux/mock"
 	"github.com/influxdata/influxdb/v2"
 	icontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/http/metric"
 	"github.com/influxdata/influxdb/v2/kit/check"
 	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
 	tracetesting "github.com/influxdata/influxdb/v2/kit/tracing/testing"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	influxmock "github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/query/control"
 	"github.com/influxdata/influxdb/v2/query/fluxlang"
 	"github.com/influxdata/influxdb/v2/query/mock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -453,6 +464,688 @@ func TestFluxHandler_PostQuery_Errors(t *testing.T) {
 	})
 }
 
+func TestFluxHandler_PostQuery_Pagination(t *testing.T) {
+	var nows []time.Time
+	b := &FluxBackend{
+		HTTPErrorHandler:   kithttp.ErrorHandler(0),
+		log:                zaptest.NewLogger(t),
+		QueryEventRecorder: noopEventRecorder{},
+		OrganizationService: &influxmock.OrganizationService{
+			FindOrganizationByIDF: func(ctx context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: id, Name: id.String()}, nil
+			},
+		},
+		ProxyQueryService: query.ProxyQueryServiceAsyncBridge{
+			AsyncQueryService: &mock.AsyncQueryService{
+				QueryF: func(ctx context.Context, req *query.Request) (flux.Query, error) {
+					nows = append(nows, req.Compiler.(lang.FluxCompiler).Now)
+					r := executetest.NewResult([]*executetest.Table{{
+						ColMeta: []flux.ColMeta{
+							{Label: "_time", Type: flux.TTime},
+							{Label: "_value", Type: flux.TFloat},
+						},
+						Data: [][]interface{}{
+							{execute.Time(0), 1.0},
+							{execute.Time(10), 2.0},
+							{execute.Time(20), 3.0},
+							{execute.Time(30), 4.0},
+							{execute.Time(40), 5.0},
+						},
+					}})
+					return mock.NewQuery().SetResults(r), nil
+				},
+			},
+		},
+		FluxLanguageService: fluxlang.DefaultService,
+		Flagger:             feature.DefaultFlagger(),
+	}
+	h := NewFluxHandler(zaptest.NewLogger(t), b)
+
+	doQuery := func(header map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, err := http.NewRequest("POST", "/api/v2/query?orgID=0000000000000001", strings.NewReader(`from(bucket: "b")`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = req.WithContext(icontext.SetAuthorizer(req.Context(), &influxdb.Authorization{}))
+		req.Header.Set("Content-Type", "application/vnd.flux")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+
+		w := httptest.NewRecorder()
+		h.handleQuery(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code %d: %s", w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	// values returns the _value column of each row in a CSV response.
+	values := func(body string) []string {
+		var (
+			vs  []string
+			idx = -1
+		)
+		for _, line := range strings.Split(body, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Split(line, ",")
+			if i := indexOf(fields, "_value"); i >= 0 {
+				idx = i
+				continue
+			}
+			vs = append(vs, fields[idx])
+		}
+		return vs
+	}
+
+	w := doQuery(map[string]string{
+		"Range-Unit": "rows",
+		"Range":      "0-2",
+	})
+	if got, want := w.Header().Get("Content-Range"), "0-2/*"; got != want {
+		t.Errorf("unexpected content range -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+	if got, want := values(w.Body.String()), []string{"1", "2", "3"}; !cmp.Equal(want, got) {
+		t.Errorf("unexpected values -want/+got:\n%s", cmp.Diff(want, got))
+	}
+	token := w.Header().Get("Query-Continuation")
+	if token == "" {
+		t.Fatal("expected continuation token")
+	}
+
+	w = doQuery(map[string]string{
+		"Query-Continuation": token,
+	})
+	if got, want := w.Header().Get("Content-Range"), "3-4/*"; got != want {
+		t.Errorf("unexpected content range -want/+got:\n\t- %q\n\t+ %q", want, got)
+	}
+	if got, want := values(w.Body.String()), []string{"4", "5"}; !cmp.Equal(want, got) {
+		t.Errorf("unexpected values -want/+got:\n%s", cmp.Diff(want, got))
+	}
+	if token := w.Header().Get("Query-Continuation"); token != "" {
+		t.Errorf("unexpected continuation token on last page: %q", token)
+	}
+
+	// Both pages must be read at the same time.
+	if len(nows) != 2 || !nows[0].Equal(nows[1]) {
+		t.Errorf("expected both pages to run at the same time, got %v", nows)
+	}
+}
+
+func TestFluxHandler_PostQuery_MaxResponseBytes(t *testing.T) {
+	const limit = 128
+	b := &FluxBackend{
+		HTTPErrorHandler:   kithttp.ErrorHandler(0),
+		log:                zaptest.NewLogger(t),
+		QueryEventRecorder: noopEventRecorder{},
+		OrganizationService: &influxmock.OrganizationService{
+			FindOrganizationByIDF: func(ctx context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: id, Name: id.String()}, nil
+			},
+		},
+		ProxyQueryService: query.ProxyQueryServiceAsyncBridge{
+			AsyncQueryService: &mock.AsyncQueryService{
+				QueryF: func(ctx context.Context, req *query.Request) (flux.Query, error) {
+					tbl := &executetest.Table{
+						ColMeta: []flux.ColMeta{
+							{Label: "_time", Type: flux.TTime},
+							{Label: "_value", Type: flux.TFloat},
+						},
+					}
+					for i := 0; i < 100; i++ {
+						tbl.Data = append(tbl.Data, []interface{}{execute.Time(i), float64(i)})
+					}
+					r := executetest.NewResult([]*executetest.Table{tbl})
+					return mock.NewQuery().SetResults(r), nil
+				},
+			},
+		},
+		FluxLanguageService: fluxlang.DefaultService,
+		Flagger:             feature.DefaultFlagger(),
+		MaxResponseBytes:    limit,
+	}
+	h := NewFluxHandler(zaptest.NewLogger(t), b)
+
+	req, err := http.NewRequest("POST", "/api/v2/query?orgID=0000000000000001", strings.NewReader(`from(bucket: "b")`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(icontext.SetAuthorizer(req.Context(), &influxdb.Authorization{}))
+	req.Header.Set("Content-Type", "application/vnd.flux")
+
+	w := httptest.NewRecorder()
+	h.handleQuery(w, req)
+
+	// The response has already started when the limit is exceeded
+	// so the error is encoded at the end of the truncated response.
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if want := "query response exceeded the maximum of 128 bytes"; !strings.Contains(body, want) {
+		t.Errorf("expected response to contain error %q, got:\n%s", want, body)
+	}
+	if strings.Contains(body, ",99\r\n") {
+		t.Errorf("expected response to be truncated, got:\n%s", body)
+	}
+}
+
+func TestFluxHandler_PostQuery_FloatNaNPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy FloatNaNPolicy
+		want   []string
+	}{
+		{
+			policy: FloatNaNPassthrough,
+			want:   []string{",1970-01-01T00:00:00Z,NaN\r\n", ",1970-01-01T00:00:00.00000001Z,+Inf\r\n"},
+		},
+		{
+			policy: FloatNaNNull,
+			want:   []string{",1970-01-01T00:00:00Z,\r\n", ",1970-01-01T00:00:00.00000001Z,\r\n"},
+		},
+		{
+			policy: FloatNaNError,
+			want:   []string{"cannot encode float value NaN of column"},
+		},
+	} {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			b := &FluxBackend{
+				HTTPErrorHandler:   kithttp.ErrorHandler(0),
+				log:                zaptest.NewLogger(t),
+				QueryEventRecorder: noopEventRecorder{},
+				OrganizationService: &influxmock.OrganizationService{
+					FindOrganizationByIDF: func(ctx context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+						return &influxdb.Organization{ID: id, Name: id.String()}, nil
+					},
+				},
+				ProxyQueryService: query.ProxyQueryServiceAsyncBridge{
+					AsyncQueryService: &mock.AsyncQueryService{
+						QueryF: func(ctx context.Context, req *query.Request) (flux.Query, error) {
+							r := executetest.NewResult([]*executetest.Table{{
+								ColMeta: []flux.ColMeta{
+									{Label: "_time", Type: flux.TTime},
+									{Label: "_value", Type: flux.TFloat},
+								},
+								Data: [][]interface{}{
+									{execute.Time(0), math.NaN()},
+									{execute.Time(10), math.Inf(1)},
+									{execute.Time(20), 1.5},
+								},
+							}})
+							return mock.NewQuery().SetResults(r), nil
+						},
+					},
+				},
+				FluxLanguageService: fluxlang.DefaultService,
+				Flagger:             feature.DefaultFlagger(),
+				FloatNaNPolicy:      tt.policy,
+			}
+			h := NewFluxHandler(zaptest.NewLogger(t), b)
+
+			req, err := http.NewRequest("POST", "/api/v2/query?orgID=0000000000000001", strings.NewReader(`from(bucket: "b")`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req = req.WithContext(icontext.SetAuthorizer(req.Context(), &influxdb.Authorization{}))
+			req.Header.Set("Content-Type", "application/vnd.flux")
+
+			w := httptest.NewRecorder()
+			h.handleQuery(w, req)
+
+			body := w.Body.String()
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("expected response to contain %q, got:\n%s", want, body)
+				}
+			}
+			if tt.policy != FloatNaNError && !strings.Contains(body, ",1.5\r\n") {
+				t.Errorf("expected response to contain the finite value, got:\n%s", body)
+			}
+		})
+	}
+}
+
+func TestFluxHandler_PostQuery_ResultStats(t *testing.T) {
+	b := &FluxBackend{
+		HTTPErrorHandler:   kithttp.ErrorHandler(0),
+		log:                zaptest.NewLogger(t),
+		QueryEventRecorder: noopEventRecorder{},
+		OrganizationService: &influxmock.OrganizationService{
+			FindOrganizationByIDF: func(ctx context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: id, Name: id.String()}, nil
+			},
+		},
+		ProxyQueryService: query.ProxyQueryServiceAsyncBridge{
+			AsyncQueryService: &mock.AsyncQueryService{
+				QueryF: func(ctx context.Context, req *query.Request) (flux.Query, error) {
+					r := executetest.NewResult([]*executetest.Table{
+						{
+							KeyCols: []string{"t0"},
+							ColMeta: []flux.ColMeta{
+								{Label: "_time", Type: flux.TTime},
+								{Label: "_value", Type: flux.TFloat},
+								{Label: "t0", Type: flux.TString},
+							},
+							Data: [][]interface{}{
+								{execute.Time(0), 1.0, "a"},
+								{execute.Time(10), 2.0, "a"},
+								{execute.Time(20), 3.0, "a"},
+							},
+						},
+						{
+							KeyCols: []string{"t0"},
+							ColMeta: []flux.ColMeta{
+								{Label: "_time", Type: flux.TTime},
+								{Label: "_value", Type: flux.TFloat},
+								{Label: "t0", Type: flux.TString},
+							},
+							Data: [][]interface{}{
+								{execute.Time(0), 4.0, "b"},
+								{execute.Time(10), 5.0, "b"},
+							},
+						},
+					})
+					return mock.NewQuery().SetResults(r), nil
+				},
+			},
+		},
+		FluxLanguageService: fluxlang.DefaultService,
+		Flagger:             feature.DefaultFlagger(),
+	}
+	h := NewFluxHandler(zaptest.NewLogger(t), b)
+
+	// The response is annotated so that it can be decoded.
+	body := `{"query": "from(bucket: \"b\")", "dialect": {"annotations": ["datatype", "group", "default"]}}`
+	req, err := http.NewRequest("POST", "/api/v2/query?orgID=0000000000000001", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(icontext.SetAuthorizer(req.Context(), &influxdb.Authorization{}))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Query-Result-Stats", "true")
+
+	w := httptest.NewRecorder()
+	h.handleQuery(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d: %s", w.Code, w.Body.String())
+	}
+
+	dec := csv.NewMultiResultDecoder(csv.ResultDecoderConfig{})
+	results, err := dec.Decode(ioutil.NopCloser(w.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer results.Release()
+
+	var (
+		tables, rows int64
+		stats        map[string]int64
+	)
+	for results.More() {
+		res := results.Next()
+		if res.Name() != "_result_stats" {
+			if err := res.Tables().Do(func(tbl flux.Table) error {
+				tables++
+				return tbl.Do(func(cr flux.ColReader) error {
+					rows += int64(cr.Len())
+					return nil
+				})
+			}); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		stats = make(map[string]int64)
+		if err := res.Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(cr flux.ColReader) error {
+				for j, col := range cr.Cols() {
+					stats[col.Label] = cr.Ints(j).Value(0)
+				}
+				return nil
+			})
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := results.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := tables, int64(2); got != want {
+		t.Errorf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if got, want := rows, int64(5); got != want {
+		t.Errorf("unexpected number of rows -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if want := map[string]int64{"tables": tables, "rows": rows}; !cmp.Equal(want, stats) {
+		t.Errorf("unexpected result stats -want/+got:\n%s", cmp.Diff(want, stats))
+	}
+}
+
+func TestFluxHandler_PostQuery_SourceLabel(t *testing.T) {
+	tracer := mocktracer.New()
+	oldTracer := opentracing.GlobalTracer()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(oldTracer)
+
+	ctrl, err := control.New(control.Config{
+		ConcurrencyQuota:         1,
+		MemoryBytesQuotaPerQuery: 1024 * 1024,
+		QueueSize:                1,
+		MetricQuerySources:       []string{"dashboard-1"},
+		ExecutorDependencies: []flux.Dependency{
+			executetest.NewTestExecuteDependencies(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ctrl.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(ctrl.PrometheusCollectors()...)
+
+	b := &FluxBackend{
+		HTTPErrorHandler:   kithttp.ErrorHandler(0),
+		log:                zaptest.NewLogger(t),
+		QueryEventRecorder: noopEventRecorder{},
+		OrganizationService: &influxmock.OrganizationService{
+			FindOrganizationByIDF: func(ctx context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: id, Name: id.String()}, nil
+			},
+		},
+		ProxyQueryService:   query.ProxyQueryServiceAsyncBridge{AsyncQueryService: ctrl},
+		FluxLanguageService: fluxlang.DefaultService,
+		Flagger:             feature.DefaultFlagger(),
+	}
+	h := NewFluxHandler(zaptest.NewLogger(t), b)
+
+	q := `import "csv"
+csv.from(csv: "#datatype,string,long,long\n#group,false,false,false\n#default,_result,,\n,result,table,_value\n,,0,1\n")`
+	for _, source := range []string{"dashboard-1", "dashboard-2"} {
+		req, err := http.NewRequest("POST", "/api/v2/query?orgID=0000000000000001", strings.NewReader(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = req.WithContext(icontext.SetAuthorizer(req.Context(), &influxdb.Authorization{}))
+		req.Header.Set("Content-Type", "application/vnd.flux")
+		req.Header.Set(querySourceHeader, source)
+
+		w := httptest.NewRecorder()
+		h.handleQuery(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	// The spans are tagged with every source.
+	for _, op := range []string{"request", "all"} {
+		sources := make(map[interface{}]bool)
+		for _, span := range tracer.FinishedSpans() {
+			if span.OperationName == op {
+				sources[span.Tag("query_source")] = true
+			}
+		}
+		if want := map[interface{}]bool{"dashboard-1": true, "dashboard-2": true}; !cmp.Equal(want, sources) {
+			t.Errorf("unexpected query_source tags of span %s -want/+got:\n%s", op, cmp.Diff(want, sources))
+		}
+	}
+
+	// The metrics are only labeled with the allowed sources.
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, source := range []string{"dashboard-1", "other"} {
+		m := promtest.MustFindMetric(t, mfs, "query_control_all_duration_seconds", map[string]string{
+			"org":    "0000000000000001",
+			"source": source,
+		})
+		if got := m.GetHistogram().GetSampleCount(); got != 1 {
+			t.Errorf("unexpected query count of source %s: got %d want 1", source, got)
+		}
+	}
+}
+
+func TestQueryRequest_proxyRequest_CompileCache(t *testing.T) {
+	ctrl, err := control.New(control.Config{
+		ConcurrencyQuota:         1,
+		MemoryBytesQuotaPerQuery: 1024 * 1024,
+		QueueSize:                1,
+		CompileCacheSize:         10,
+		CompileCacheTTL:          time.Minute,
+		ExecutorDependencies: []flux.Dependency{
+			executetest.NewTestExecuteDependencies(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ctrl.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(ctrl.PrometheusCollectors()...)
+
+	// The request does not set a now time so each query is
+	// stamped with the time that it is proxied.
+	r := QueryRequest{
+		Type: "flux",
+		Query: `import "csv"
+csv.from(csv: "#datatype,string,long,long\n#group,false,false,false\n#default,_result,,\n,result,table,_value\n,,0,1\n")`,
+		Org: &influxdb.Organization{ID: 1},
+	}.WithDefaults()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, now := range []time.Time{start.Add(100 * time.Millisecond), start.Add(10 * time.Second)} {
+		pr, err := r.proxyRequest(func() time.Time { return now })
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := ctrl.Query(context.Background(), &pr.Request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for res := range q.Results() {
+			if err := res.Tables().Do(func(tbl flux.Table) error {
+				tbl.Done()
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		q.Done()
+		if err := q.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The second query reuses the program of the first.
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := promtest.MustFindMetric(t, mfs, "query_control_compile_cache_hits_total", map[string]string{"org": "0000000000000001"})
+	if got := m.GetCounter().GetValue(); got != 1 {
+		t.Errorf("unexpected compile cache hits: got %v want 1", got)
+	}
+}
+
+func TestFluxHandler_ActiveQueries_Cancel(t *testing.T) {
+	ctrl, err := control.New(control.Config{
+		ConcurrencyQuota:         1,
+		MemoryBytesQuotaPerQuery: 1024 * 1024,
+		QueueSize:                1,
+		ExecutorDependencies: []flux.Dependency{
+			executetest.NewTestExecuteDependencies(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ctrl.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	executing := make(chan struct{})
+	canceled := make(chan error, 1)
+	compiler := &fluxmock.Compiler{
+		CompileFn: func(ctx context.Context) (flux.Program, error) {
+			return &fluxmock.Program{
+				ExecuteFn: func(ctx context.Context, q *fluxmock.Query, alloc *memory.Allocator) {
+					close(executing)
+
+					// Block as a long running query would
+					// until the query is canceled.
+					timer := time.NewTimer(10 * time.Second)
+					defer timer.Stop()
+
+					select {
+					case <-ctx.Done():
+						canceled <- ctx.Err()
+					case <-timer.C:
+						canceled <- nil
+					}
+				},
+			}, nil
+		},
+	}
+
+	orgID := influxdb.ID(1)
+	bridge := query.ProxyQueryServiceAsyncBridge{AsyncQueryService: ctrl}
+	b := &FluxBackend{
+		HTTPErrorHandler:   kithttp.ErrorHandler(0),
+		log:                zaptest.NewLogger(t),
+		QueryEventRecorder: noopEventRecorder{},
+		OrganizationService: &influxmock.OrganizationService{
+			FindOrganizationByIDF: func(ctx context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: id, Name: id.String()}, nil
+			},
+			FindOrganizationF: func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return &influxdb.Organization{ID: *filter.ID, Name: filter.ID.String()}, nil
+			},
+		},
+		ProxyQueryService: &mock.ProxyQueryService{
+			QueryF: func(ctx context.Context, w io.Writer, req *query.ProxyRequest) (flux.Statistics, error) {
+				// Execute the long running query in place of the query of the request.
+				req.Request.Compiler = compiler
+				return bridge.Query(ctx, w, req)
+			},
+		},
+		ActiveQueryService:  ctrl,
+		FluxLanguageService: fluxlang.DefaultService,
+		Flagger:             feature.DefaultFlagger(),
+	}
+	h := NewFluxHandler(zaptest.NewLogger(t), b)
+
+	auth := &influxdb.Authorization{
+		OrgID:  orgID,
+		Status: influxdb.Active,
+		Permissions: []influxdb.Permission{{
+			Action:   influxdb.ReadAction,
+			Resource: influxdb.Resource{Type: influxdb.OrgsResourceType, ID: &orgID},
+		}},
+	}
+	newRequest := func(method, path string, body io.Reader) *http.Request {
+		t.Helper()
+		req, err := http.NewRequest(method, path, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req.WithContext(icontext.SetAuthorizer(req.Context(), auth))
+	}
+
+	queryReq := newRequest("POST", "/api/v2/query?orgID="+orgID.String(), strings.NewReader(`from(bucket: "b")`))
+	queryReq.Header.Set("Content-Type", "application/vnd.flux")
+	queryW := httptest.NewRecorder()
+	queryDone := make(chan struct{})
+	go func() {
+		defer close(queryDone)
+		h.ServeHTTP(queryW, queryReq)
+	}()
+
+	select {
+	case <-executing:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the query to execute")
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest("GET", "/api/v2/query/active?orgID="+orgID.String(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d: %s", w.Code, w.Body.String())
+	}
+	var res activeQueriesResponse
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Queries) != 1 {
+		t.Fatalf("unexpected number of active queries: got %d want 1", len(res.Queries))
+	}
+	if got, want := res.Queries[0].State, "executing"; got != want {
+		t.Errorf("unexpected query state: got %s want %s", got, want)
+	}
+	id := res.Queries[0].ID
+
+	// The query cannot be canceled by another organization.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest("DELETE", fmt.Sprintf("/api/v2/query/active/%d?orgID=0000000000000002", id), nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code canceling the query of another organization %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest("DELETE", fmt.Sprintf("/api/v2/query/active/%d?orgID=%s", id, orgID), nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status code %d: %s", w.Code, w.Body.String())
+	}
+
+	select {
+	case err := <-canceled:
+		if got, want := err, context.Canceled; got != want {
+			t.Errorf("unexpected error -want/+got\n\t- %v\n\t+ %v", want, got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected query to be canceled")
+	}
+	select {
+	case <-queryDone:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the query request to finish")
+	}
+	if got, want := queryW.Header().Get(queryIDHeader), strconv.FormatUint(id, 10); got != want {
+		t.Errorf("unexpected query id header: got %q want %q", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, newRequest("DELETE", fmt.Sprintf("/api/v2/query/active/%d?orgID=%s", id, orgID), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unexpected status code canceling a finished query %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func indexOf(ss []string, s string) int {
+	for i := range ss {
+		if ss[i] == s {
+			return i
+		}
+	}
+	return -1
+}
+
 func TestFluxService_Query_gzip(t *testing.T) {
 	// orgService is just to mock out orgs by returning
 	// the same org every time.
@@ -0,0 +1,414 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+const (
+	rangeUnitHeader         = "Range-Unit"
+	rangeHeader             = "Range"
+	contentRangeHeader      = "Content-Range"
+	queryContinuationHeader = "Query-Continuation"
+
+	rangeUnitRows = "rows"
+)
+
+// rowPage is a bounded range of the rows of a query response.
+// Rows are counted across all of the tables of all of the results
+// in the order they are encoded.
+type rowPage struct {
+	offset, limit int64
+
+	// now is the time the query is run at so that every
+	// page of a query sees the same data.
+	now time.Time
+
+	// hash identifies the query the page belongs to.
+	hash uint64
+
+	// seen is the number of rows read so far,
+	// rows the number of them within the page.
+	seen, rows int64
+
+	// more is set if there are rows after the page.
+	more bool
+}
+
+// rowPageToken is the decoded form of a continuation token.
+type rowPageToken struct {
+	Offset int64     `json:"offset"`
+	Limit  int64     `json:"limit"`
+	Now    time.Time `json:"now"`
+	Hash   uint64    `json:"hash"`
+}
+
+// decodeRowPage returns the page of rows requested with either the
+// Range-Unit and Range headers or a continuation token. The compiler
+// of req is updated to run at the time of the first page. It returns
+// nil if the request is not paginated.
+func decodeRowPage(r *http.Request, req *query.ProxyRequest) (*rowPage, error) {
+	token := r.Header.Get(queryContinuationHeader)
+	if token == "" && !strings.EqualFold(r.Header.Get(rangeUnitHeader), rangeUnitRows) {
+		return nil, nil
+	}
+
+	if _, ok := req.Dialect.(*csv.Dialect); !ok {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("pagination is not supported for dialect %T", req.Dialect),
+		}
+	}
+
+	hash, err := queryHash(req.Request.Compiler)
+	if err != nil {
+		return nil, err
+	}
+
+	var page *rowPage
+	if token != "" {
+		if page, err = decodeRowPageToken(token); err != nil {
+			return nil, err
+		}
+		if page.hash != hash {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "continuation token does not match query",
+			}
+		}
+	} else {
+		offset, limit, err := parseRowRange(r.Header.Get(rangeHeader))
+		if err != nil {
+			return nil, err
+		}
+		page = &rowPage{
+			offset: offset,
+			limit:  limit,
+			now:    compilerNow(req.Request.Compiler),
+			hash:   hash,
+		}
+	}
+
+	req.Request.Compiler = withCompilerNow(req.Request.Compiler, page.now)
+	return page, nil
+}
+
+// parseRowRange parses an inclusive range of rows such as 0-99.
+func parseRowRange(s string) (offset, limit int64, err error) {
+	invalid := func() error {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("invalid range of rows %q", s),
+		}
+	}
+
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, invalid()
+	}
+	first, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
+	if err != nil || first < 0 {
+		return 0, 0, invalid()
+	}
+	last, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+	if err != nil || last < first {
+		return 0, 0, invalid()
+	}
+	return first, last - first + 1, nil
+}
+
+func decodeRowPageToken(token string) (*rowPage, error) {
+	var t rowPageToken
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(b, &t)
+	}
+	if err != nil || t.Offset < 0 || t.Limit <= 0 {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "invalid continuation token",
+			Err:  err,
+		}
+	}
+	return &rowPage{
+		offset: t.Offset,
+		limit:  t.Limit,
+		now:    t.Now,
+		hash:   t.Hash,
+	}, nil
+}
+
+// nextToken returns the continuation token for the page
+// following p or an empty string if p is the last page.
+func (p *rowPage) nextToken() (string, error) {
+	if !p.more {
+		return "", nil
+	}
+	b, err := json.Marshal(rowPageToken{
+		Offset: p.offset + p.limit,
+		Limit:  p.limit,
+		Now:    p.now,
+		Hash:   p.hash,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// SetHeaders sets the headers describing the rows in the page.
+func (p *rowPage) SetHeaders(w http.ResponseWriter) error {
+	token, err := p.nextToken()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set(rangeUnitHeader, rangeUnitRows)
+	if p.rows == 0 {
+		w.Header().Set(contentRangeHeader, "*/*")
+	} else {
+		w.Header().Set(contentRangeHeader, fmt.Sprintf("%d-%d/*", p.offset, p.offset+p.rows-1))
+	}
+	if token != "" {
+		w.Header().Set(queryContinuationHeader, token)
+	}
+	return nil
+}
+
+// span records that the next n rows have been read and returns
+// the range [i, j) of those rows that are within the page.
+func (p *rowPage) span(n int) (i, j int) {
+	start, end := p.seen, p.seen+int64(n)
+	p.seen = end
+
+	lo, hi := p.offset, p.offset+p.limit
+	if end > hi {
+		p.more = true
+	}
+	if lo < start {
+		lo = start
+	}
+	if hi > end {
+		hi = end
+	}
+	if lo >= hi {
+		return 0, 0
+	}
+	p.rows += hi - lo
+	return int(lo - start), int(hi - start)
+}
+
+// queryHash identifies the query run by a compiler
+// regardless of the time it is run at.
+func queryHash(c flux.Compiler) (uint64, error) {
+	c = withCompilerNow(c, time.Time{})
+	switch c.(type) {
+	case lang.FluxCompiler, lang.ASTCompiler:
+	default:
+		return 0, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("pagination is not supported for compiler %T", c),
+		}
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(b)
+	return h.Sum64(), nil
+}
+
+func compilerNow(c flux.Compiler) time.Time {
+	switch c := c.(type) {
+	case lang.FluxCompiler:
+		return c.Now
+	case lang.ASTCompiler:
+		return c.Now
+	}
+	return time.Time{}
+}
+
+func withCompilerNow(c flux.Compiler, now time.Time) flux.Compiler {
+	switch c := c.(type) {
+	case lang.FluxCompiler:
+		c.Now = now
+		return c
+	case lang.ASTCompiler:
+		c.Now = now
+		return c
+	}
+	return c
+}
+
+// rowPageDialect encodes only the rows of a query within a page.
+type rowPageDialect struct {
+	flux.Dialect
+	page *rowPage
+}
+
+func (d *rowPageDialect) Encoder() flux.MultiResultEncoder {
+	return &rowPageEncoder{
+		MultiResultEncoder: d.Dialect.Encoder(),
+		page:               d.page,
+	}
+}
+
+type rowPageEncoder struct {
+	flux.MultiResultEncoder
+	page *rowPage
+}
+
+func (e *rowPageEncoder) Encode(w io.Writer, results flux.ResultIterator) (int64, error) {
+	return e.MultiResultEncoder.Encode(w, &rowPageResultIterator{
+		ResultIterator: results,
+		page:           e.page,
+	})
+}
+
+type rowPageResultIterator struct {
+	flux.ResultIterator
+	page *rowPage
+}
+
+func (it *rowPageResultIterator) Next() flux.Result {
+	return &rowPageResult{
+		Result: it.ResultIterator.Next(),
+		page:   it.page,
+	}
+}
+
+type rowPageResult struct {
+	flux.Result
+	page *rowPage
+}
+
+func (r *rowPageResult) Tables() flux.TableIterator {
+	return &rowPageTableIterator{
+		TableIterator: r.Result.Tables(),
+		page:          r.page,
+	}
+}
+
+// rowPageTableIterator produces the rows of each table that are within
+// the page. Every table is read so that the query runs to completion,
+// but tables without any rows in the page are not produced.
+type rowPageTableIterator struct {
+	flux.TableIterator
+	page *rowPage
+}
+
+func (it *rowPageTableIterator) Do(f func(flux.Table) error) error {
+	return it.TableIterator.Do(func(tbl flux.Table) error {
+		var buffers []arrow.TableBuffer
+		if err := tbl.Do(func(cr flux.ColReader) error {
+			i, j := it.page.span(cr.Len())
+			if i == j {
+				return nil
+			}
+
+			buffer := arrow.TableBuffer{
+				GroupKey: cr.Key(),
+				Columns:  cr.Cols(),
+				Values:   make([]array.Interface, len(cr.Cols())),
+			}
+			for k := range cr.Cols() {
+				buffer.Values[k] = arrow.Slice(columnValues(cr, k), int64(i), int64(j))
+			}
+			buffers = append(buffers, buffer)
+			return nil
+		}); err != nil {
+			for i := range buffers {
+				buffers[i].Release()
+			}
+			return err
+		}
+
+		if len(buffers) == 0 {
+			return nil
+		}
+		return f(&rowPageTable{
+			key:     tbl.Key(),
+			cols:    tbl.Cols(),
+			buffers: buffers,
+		})
+	})
+}
+
+// rowPageTable is a table of the buffered rows of a page.
+type rowPageTable struct {
+	used    int32
+	key     flux.GroupKey
+	cols    []flux.ColMeta
+	buffers []arrow.TableBuffer
+}
+
+func (t *rowPageTable) Key() flux.GroupKey   { return t.key }
+func (t *rowPageTable) Cols() []flux.ColMeta { return t.cols }
+func (t *rowPageTable) Empty() bool          { return false }
+
+func (t *rowPageTable) Do(f func(flux.ColReader) error) error {
+	if !atomic.CompareAndSwapInt32(&t.used, 0, 1) {
+		return &influxdb.Error{
+			Code: influxdb.EInternal,
+			Msg:  "table already read",
+		}
+	}
+	defer t.release()
+
+	for i := range t.buffers {
+		if err := f(&t.buffers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *rowPageTable) Done() {
+	if atomic.CompareAndSwapInt32(&t.used, 0, 1) {
+		t.release()
+	}
+}
+
+func (t *rowPageTable) release() {
+	for i := range t.buffers {
+		t.buffers[i].Release()
+	}
+	t.buffers = nil
+}
+
+func columnValues(cr flux.ColReader, j int) array.Interface {
+	switch typ := cr.Cols()[j].Type; typ {
+	case flux.TInt:
+		return cr.Ints(j)
+	case flux.TUInt:
+		return cr.UInts(j)
+	case flux.TFloat:
+		return cr.Floats(j)
+	case flux.TString:
+		return cr.Strings(j)
+	case flux.TBool:
+		return cr.Bools(j)
+	case flux.TTime:
+		return cr.Times(j)
+	default:
+		panic(fmt.Errorf("unimplemented column type: %s", typ))
+	}
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/influxdb/v2"
+)
+
+// responseLimitDialect limits the number of bytes
+// encoded for the response of a query.
+type responseLimitDialect struct {
+	flux.Dialect
+	limit int64
+}
+
+func (d *responseLimitDialect) Encoder() flux.MultiResultEncoder {
+	e := &responseLimitEncoder{
+		MultiResultEncoder: d.Dialect.Encoder(),
+		limit:              d.limit,
+	}
+	if csvDialect, ok := d.Dialect.(*csv.Dialect); ok {
+		e.errorEncoder = csv.NewResultEncoder(csvDialect.ResultEncoderConfig)
+	}
+	return e
+}
+
+// responseLimitEncoder fails the encoding of a query once more than
+// limit bytes have been written. If part of the response has already
+// been written, the error is encoded after it when the dialect
+// supports it so that the client can tell the response is truncated.
+type responseLimitEncoder struct {
+	flux.MultiResultEncoder
+	limit        int64
+	errorEncoder *csv.ResultEncoder
+}
+
+func (e *responseLimitEncoder) Encode(w io.Writer, results flux.ResultIterator) (int64, error) {
+	lw := &responseLimitWriter{Writer: w, limit: e.limit}
+	n, err := e.MultiResultEncoder.Encode(lw, results)
+	if !lw.exceeded {
+		return n, err
+	}
+
+	// Stop the query rather than wait for results that won't be written.
+	results.Release()
+	err = &influxdb.Error{
+		Code: influxdb.ETooLarge,
+		Msg:  fmt.Sprintf("query response exceeded the maximum of %d bytes", e.limit),
+	}
+	if lw.n == 0 || e.errorEncoder == nil {
+		return lw.n, err
+	}
+
+	// The response may have been truncated in the middle of a
+	// row so end it before the error is encoded.
+	sep := "\r\n"
+	if lw.last != '\n' {
+		sep = "\r\n\r\n"
+	}
+	if _, werr := io.WriteString(w, sep); werr != nil {
+		return lw.n, err
+	}
+	if werr := e.errorEncoder.EncodeError(w, err); werr != nil {
+		return lw.n, werr
+	}
+	return lw.n, err
+}
+
+// responseLimitWriter writes at most limit bytes to the
+// underlying writer and fails any write after that.
+type responseLimitWriter struct {
+	io.Writer
+	n, limit int64
+	last     byte
+	exceeded bool
+}
+
+func (w *responseLimitWriter) Write(p []byte) (int, error) {
+	if w.exceeded {
+		return 0, io.ErrShortWrite
+	}
+	if remaining := w.limit - w.n; int64(len(p)) > remaining {
+		w.exceeded = true
+		n, err := w.write(p[:remaining])
+		if err == nil {
+			err = io.ErrShortWrite
+		}
+		return n, err
+	}
+	return w.write(p)
+}
+
+func (w *responseLimitWriter) write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.n += int64(n)
+	if n > 0 {
+		w.last = p[n-1]
+	}
+	return n, err
+}
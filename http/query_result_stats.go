@@ -0,0 +1,155 @@
+package http
+
+import (
+	"io"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+)
+
+const (
+	// resultStatsHeader requests that a table with the number of
+	// tables and rows in the response is appended to it.
+	resultStatsHeader = "Query-Result-Stats"
+
+	// resultStatsName is the name of the result with the stats table.
+	resultStatsName = "_result_stats"
+)
+
+// resultStats counts the tables and rows encoded for a query.
+type resultStats struct {
+	tables, rows int64
+}
+
+// resultStatsDialect appends a result with the number of tables and
+// rows encoded to the response of a query.
+type resultStatsDialect struct {
+	flux.Dialect
+}
+
+func (d *resultStatsDialect) Encoder() flux.MultiResultEncoder {
+	return &resultStatsEncoder{
+		MultiResultEncoder: d.Dialect.Encoder(),
+	}
+}
+
+type resultStatsEncoder struct {
+	flux.MultiResultEncoder
+}
+
+func (e *resultStatsEncoder) Encode(w io.Writer, results flux.ResultIterator) (int64, error) {
+	return e.MultiResultEncoder.Encode(w, &resultStatsResultIterator{
+		ResultIterator: results,
+		stats:          &resultStats{},
+	})
+}
+
+// resultStatsResultIterator produces the results of a query followed
+// by the stats result once all of them have been read. The stats
+// result is not produced if the query fails.
+type resultStatsResultIterator struct {
+	flux.ResultIterator
+	stats *resultStats
+
+	// last is set once the stats result is next, done once it is read.
+	last, done bool
+}
+
+func (it *resultStatsResultIterator) More() bool {
+	if it.ResultIterator.More() {
+		return true
+	}
+	if it.done || it.ResultIterator.Err() != nil {
+		return false
+	}
+	it.last = true
+	return true
+}
+
+func (it *resultStatsResultIterator) Next() flux.Result {
+	if it.last {
+		it.done = true
+		return &resultStatsResult{stats: it.stats}
+	}
+	return &resultStatsCountResult{
+		Result: it.ResultIterator.Next(),
+		stats:  it.stats,
+	}
+}
+
+type resultStatsCountResult struct {
+	flux.Result
+	stats *resultStats
+}
+
+func (r *resultStatsCountResult) Tables() flux.TableIterator {
+	return &resultStatsTableIterator{
+		TableIterator: r.Result.Tables(),
+		stats:         r.stats,
+	}
+}
+
+type resultStatsTableIterator struct {
+	flux.TableIterator
+	stats *resultStats
+}
+
+func (it *resultStatsTableIterator) Do(f func(flux.Table) error) error {
+	return it.TableIterator.Do(func(tbl flux.Table) error {
+		it.stats.tables++
+		return f(&resultStatsTable{Table: tbl, stats: it.stats})
+	})
+}
+
+// resultStatsTable counts the rows of a table as they are read.
+type resultStatsTable struct {
+	flux.Table
+	stats *resultStats
+}
+
+func (t *resultStatsTable) Do(f func(flux.ColReader) error) error {
+	return t.Table.Do(func(cr flux.ColReader) error {
+		t.stats.rows += int64(cr.Len())
+		return f(cr)
+	})
+}
+
+// resultStatsResult is the result with the stats table.
+type resultStatsResult struct {
+	stats *resultStats
+}
+
+func (r *resultStatsResult) Name() string { return resultStatsName }
+
+func (r *resultStatsResult) Tables() flux.TableIterator {
+	return r
+}
+
+func (r *resultStatsResult) Do(f func(flux.Table) error) error {
+	key := execute.NewGroupKey(nil, nil)
+	builder := execute.NewColListTableBuilder(key, &memory.Allocator{})
+	defer builder.ClearData()
+
+	for _, c := range []struct {
+		label string
+		value int64
+	}{
+		{label: "tables", value: r.stats.tables},
+		{label: "rows", value: r.stats.rows},
+	} {
+		j, err := builder.AddCol(flux.ColMeta{Label: c.label, Type: flux.TInt})
+		if err != nil {
+			return err
+		}
+		if err := builder.AppendInt(j, c.value); err != nil {
+			return err
+		}
+	}
+
+	tbl, err := builder.Table()
+	if err != nil {
+		return err
+	}
+	return f(tbl)
+}
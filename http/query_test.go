@@ -75,12 +75,13 @@ func TestQueryRequest_WithDefaults(t *testing.T) {
 
 func TestQueryRequest_Validate(t *testing.T) {
 	type fields struct {
-		Extern  json.RawMessage
-		AST     json.RawMessage
-		Query   string
-		Type    string
-		Dialect QueryDialect
-		org     *platform.Organization
+		Extern   json.RawMessage
+		AST      json.RawMessage
+		Query    string
+		Type     string
+		Dialect  QueryDialect
+		Priority int
+		org      *platform.Organization
 	}
 	tests := []struct {
 		name    string
@@ -159,6 +160,19 @@ func TestQueryRequest_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "priority out of range",
+			fields: fields{
+				Query: "from()",
+				Type:  "flux",
+				Dialect: QueryDialect{
+					Delimiter:      ",",
+					DateTimeFormat: "RFC3339",
+				},
+				Priority: query.MaxPriority + 1,
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid query",
 			fields: fields{
@@ -174,12 +188,13 @@ func TestQueryRequest_Validate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := QueryRequest{
-				Extern:  tt.fields.Extern,
-				AST:     tt.fields.AST,
-				Query:   tt.fields.Query,
-				Type:    tt.fields.Type,
-				Dialect: tt.fields.Dialect,
-				Org:     tt.fields.org,
+				Extern:   tt.fields.Extern,
+				AST:      tt.fields.AST,
+				Query:    tt.fields.Query,
+				Type:     tt.fields.Type,
+				Dialect:  tt.fields.Dialect,
+				Priority: tt.fields.Priority,
+				Org:      tt.fields.org,
 			}
 			if err := r.Validate(); (err != nil) != tt.wantErr {
 				t.Errorf("QueryRequest.Validate() error = %v, wantErr %v", err, tt.wantErr)
@@ -10,7 +10,9 @@ import (
 )
 
 // ReadyHandler is a default readiness handler. The default behaviour is always ready.
-func ReadyHandler() http.Handler {
+// If any of the degraded checks returns an error the service is still ready, but the
+// status is reported as degraded along with the errors.
+func ReadyHandler(degraded ...func() error) http.Handler {
 	up := time.Now()
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -19,12 +21,19 @@ func ReadyHandler() http.Handler {
 			Status string    `json:"status"`
 			Start  time.Time `json:"started"`
 			// TODO(jsteenb2): learn why and leave comment for this being a toml.Duration
-			Up toml.Duration `json:"up"`
+			Up       toml.Duration `json:"up"`
+			Degraded []string      `json:"degraded,omitempty"`
 		}{
 			Status: "ready",
 			Start:  up,
 			Up:     toml.Duration(time.Since(up)),
 		}
+		for _, check := range degraded {
+			if err := check(); err != nil {
+				status.Status = "degraded"
+				status.Degraded = append(status.Degraded, err.Error())
+			}
+		}
 
 		enc := json.NewEncoder(w)
 		enc.SetIndent("", "    ")
@@ -202,6 +202,26 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 	requestBytes = parsed.RawSize
 
 	if err := h.PointsWriter.WritePoints(ctx, parsed.Points); err != nil {
+		if influxdb.ErrorCode(err) == influxdb.EInvalid {
+			// The points were rejected by the schema or series limit of the bucket.
+			h.HandleHTTPError(ctx, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Op:   opWriteHandler,
+				Msg:  "failed to write points",
+				Err:  err,
+			}, sw)
+			return
+		}
+		if influxdb.ErrorCode(err) == influxdb.ETooManyRequests {
+			// The bucket has exceeded its write rate limit.
+			h.HandleHTTPError(ctx, &influxdb.Error{
+				Code: influxdb.ETooManyRequests,
+				Op:   opWriteHandler,
+				Msg:  "failed to write points",
+				Err:  err,
+			}, sw)
+			return
+		}
 		h.HandleHTTPError(ctx, &influxdb.Error{
 			Code: influxdb.EInternal,
 			Op:   opWriteHandler,
@@ -398,6 +418,16 @@ func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes
 	}
 
 	encoding := r.Header.Get("Content-Encoding")
+	if maxBatchSizeBytes > 0 && encoding == "" && r.ContentLength > maxBatchSizeBytes {
+		// Reject a body that is known to be too large without reading it.
+		// The size of a compressed body is only known once it is read.
+		return nil, &influxdb.Error{
+			Code: influxdb.ETooLarge,
+			Op:   "http/newWriteRequest",
+			Msg:  msgUnableToReadData,
+			Err:  ErrMaxBatchSizeExceeded,
+		}
+	}
 	body, err := PointBatchReadCloser(r.Body, encoding, maxBatchSizeBytes)
 	if err != nil {
 		return nil, err
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"fmt"
@@ -394,6 +395,112 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 	}
 }
 
+func TestWriteHandler_handleWrite_MaxBatchSizeBytes(t *testing.T) {
+	const (
+		org    = "043e0780ee2b1000"
+		bucket = "04504b356e23b000"
+		limit  = 64
+	)
+	const line = "m1,t1=v1 f1=1\n"
+	body := strings.Repeat(line, 10)
+
+	gzipped := func(s string) *bytes.Buffer {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		if err := gw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return &buf
+	}
+
+	for _, tt := range []struct {
+		name          string
+		body          io.Reader
+		contentLength int64
+		encoding      string
+		code          int
+	}{
+		{
+			name:          "content length over limit",
+			body:          strings.NewReader(body),
+			contentLength: int64(len(body)),
+			code:          http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:          "unknown content length",
+			body:          strings.NewReader(body),
+			contentLength: -1,
+			code:          http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:          "compressed body over limit",
+			body:          gzipped(body),
+			contentLength: -1,
+			encoding:      "gzip",
+			code:          http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:          "body within limit",
+			body:          strings.NewReader(strings.Repeat(line, 4)),
+			contentLength: int64(4 * len(line)),
+			code:          http.StatusNoContent,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(org), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(org, bucket), nil
+			}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				Logger:              zaptest.NewLogger(t),
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        &mock.PointsWriter{},
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), WithMaxBatchSizeBytes(limit))
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(org, bucket))
+
+			cr := &countingReader{Reader: tt.body}
+			r := httptest.NewRequest("POST", "http://localhost:9999/api/v2/write?org="+org+"&bucket="+bucket, cr)
+			r.ContentLength = tt.contentLength
+			if tt.encoding != "" {
+				r.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if got, want := w.Code, tt.code; got != want {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, want, w.Body.String())
+			}
+			if tt.code == http.StatusRequestEntityTooLarge && tt.contentLength > limit && cr.n > 0 {
+				t.Errorf("expected body with a known size over the limit not to be read, read %d bytes", cr.n)
+			}
+		})
+	}
+}
+
+// countingReader counts the bytes read from a reader.
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += n
+	return n, err
+}
+
 var DefaultErrorHandler = kithttp.ErrorHandler(0)
 
 func bucketWritePermission(org, bucket string) *influxdb.Authorization {
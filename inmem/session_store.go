@@ -2,6 +2,7 @@ package inmem
 
 import (
 	"errors"
+	"strings"
 	"sync"
 	"time"
 )
@@ -44,6 +45,27 @@ func (s *SessionStore) Get(key string) (string, error) {
 	return s.data[key], nil
 }
 
+// Scan calls fn for each key with the prefix. The keys are copied
+// before fn is called so that fn may modify the store.
+func (s *SessionStore) Scan(prefix string, fn func(key, val string) error) error {
+	s.mu.RLock()
+	var keys, vals []string
+	for k, v := range s.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+			vals = append(vals, v)
+		}
+	}
+	s.mu.RUnlock()
+
+	for i := range keys {
+		if err := fn(keys[i], vals[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *SessionStore) Delete(key string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
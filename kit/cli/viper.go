@@ -173,6 +173,18 @@ func BindOptions(cmd *cobra.Command, opts []Opt) {
 			}
 			mustBindPFlag(o.Flag, flagset)
 			*destP = viper.GetDuration(envVar)
+		case *float64:
+			var d float64
+			if o.Default != nil {
+				d = o.Default.(float64)
+			}
+			if hasShort {
+				flagset.Float64VarP(destP, o.Flag, string(o.Short), d, o.Desc)
+			} else {
+				flagset.Float64Var(destP, o.Flag, d, o.Desc)
+			}
+			mustBindPFlag(o.Flag, flagset)
+			*destP = viper.GetFloat64(envVar)
 		case *[]string:
 			var d []string
 			if o.Default != nil {
@@ -41,6 +41,7 @@ func ExampleNewCommand() {
 	var number int
 	var sleep bool
 	var duration time.Duration
+	var ratio float64
 	var stringSlice []string
 	var fancyBool customFlag
 	cmd := NewCommand(&Program{
@@ -51,6 +52,7 @@ func ExampleNewCommand() {
 			}
 			fmt.Println(sleep)
 			fmt.Println(duration)
+			fmt.Println(ratio)
 			fmt.Println(stringSlice)
 			fmt.Println(fancyBool)
 			return nil
@@ -81,6 +83,12 @@ func ExampleNewCommand() {
 				Default: time.Minute,
 				Desc:    "how long to sleep",
 			},
+			{
+				DestP:   &ratio,
+				Flag:    "ratio",
+				Default: 0.5,
+				Desc:    "a fraction",
+			},
 			{
 				DestP:   &stringSlice,
 				Flag:    "string-slice",
@@ -105,6 +113,7 @@ func ExampleNewCommand() {
 	// 1
 	// true
 	// 1m0s
+	// 0.5
 	// [foo bar]
 	// on
 }
@@ -3,6 +3,7 @@ package feature
 import (
 	"context"
 	"strings"
+	"sync/atomic"
 
 	"github.com/opentracing/opentracing-go"
 )
@@ -128,6 +129,33 @@ func (*defaultFlagger) Flags(_ context.Context, flags ...Flag) (map[string]inter
 	return m, nil
 }
 
+// AtomicFlagger is a Flagger whose underlying Flagger can be replaced
+// while it is in use, such as when flag overrides are reloaded.
+type AtomicFlagger struct {
+	v atomic.Value
+}
+
+type flaggerValue struct {
+	Flagger
+}
+
+// NewAtomicFlagger returns an AtomicFlagger that uses f until it is replaced.
+func NewAtomicFlagger(f Flagger) *AtomicFlagger {
+	a := &AtomicFlagger{}
+	a.Store(f)
+	return a
+}
+
+// Store replaces the underlying Flagger.
+func (a *AtomicFlagger) Store(f Flagger) {
+	a.v.Store(flaggerValue{Flagger: f})
+}
+
+// Flags returns the flags of the current underlying Flagger.
+func (a *AtomicFlagger) Flags(ctx context.Context, flags ...Flag) (map[string]interface{}, error) {
+	return a.v.Load().(flaggerValue).Flags(ctx, flags...)
+}
+
 // Flags returns all feature flags.
 func Flags() []Flag {
 	return all
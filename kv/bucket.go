@@ -681,6 +681,18 @@ func (s *Service) updateBucket(ctx context.Context, tx Tx, id influxdb.ID, upd i
 		b.Description = *upd.Description
 	}
 
+	if upd.SchemaType != nil {
+		b.SchemaType = *upd.SchemaType
+	}
+
+	if upd.Fields != nil {
+		b.Fields = *upd.Fields
+	}
+
+	if upd.MeasurementRetention != nil {
+		b.MeasurementRetention = *upd.MeasurementRetention
+	}
+
 	if upd.Name != nil {
 		b0, err := s.findBucketByName(ctx, tx, b.OrgID, *upd.Name)
 		if err == nil && b0.ID != id {
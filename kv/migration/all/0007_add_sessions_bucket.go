@@ -0,0 +1,10 @@
+package all
+
+import "github.com/influxdata/influxdb/v2/kv/migration"
+
+// Migration0007_AddSessionsBucket creates the bucket of the sessions
+// that are kept in the kv store when the session store is bolt.
+var Migration0007_AddSessionsBucket = migration.CreateBuckets(
+	"create sessions bucket",
+	[]byte("sessionsv2"),
+)
@@ -19,5 +19,7 @@ var Migrations = [...]migration.Spec{
 	Migration0005_AddPkgerBuckets,
 	// delete bucket sessionsv1
 	Migration0006_DeleteBucketSessionsv1,
+	// add sessions bucket
+	Migration0007_AddSessionsBucket,
 	// {{ do_not_edit . }}
 }
@@ -22,14 +22,10 @@ func New(w io.Writer) *zap.Logger {
 func (c *Config) New(defaultOutput io.Writer) (*zap.Logger, error) {
 	w := defaultOutput
 	format := c.Format
-	if format == "console" {
-		// Disallow the console logger if the output is not a terminal.
-		return nil, fmt.Errorf("unknown logging format: %s", format)
-	}
-
 	// If the format is empty or auto, then set the format depending
-	// on whether or not a terminal is present.
-	if format == "" || format == "auto" {
+	// on whether or not a terminal is present. The console format is
+	// only used with a terminal and falls back to logfmt otherwise.
+	if format == "" || format == "auto" || format == "console" {
 		if IsTerminal(w) {
 			format = "console"
 		} else {
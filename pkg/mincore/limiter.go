@@ -74,6 +74,13 @@ func (l *Limiter) WaitPointer(ctx context.Context, ptr unsafe.Pointer) error {
 // WaitRange checks all pages in b for page faults and, if so, rate limits their access.
 // Once a page access is limited, it's updated to be considered memory resident.
 func (l *Limiter) WaitRange(ctx context.Context, b []byte) error {
+	return l.WaitRangeWith(ctx, l.underlying, b)
+}
+
+// WaitRangeWith is like WaitRange but rate limits the page faults with
+// limiter rather than the underlying limiter. This allows some accesses
+// to the data to be limited separately while sharing the in-core vector.
+func (l *Limiter) WaitRangeWith(ctx context.Context, limiter *rate.Limiter, b []byte) error {
 	// Empty byte slices will never access memory so skip them.
 	if len(b) == 0 {
 		return nil
@@ -110,7 +117,7 @@ func (l *Limiter) WaitRange(ctx context.Context, b []byte) error {
 	}
 
 	for i := 0; i < n; i++ {
-		if err := l.underlying.Wait(ctx); err != nil {
+		if err := limiter.Wait(ctx); err != nil {
 			return err
 		}
 	}
@@ -52,6 +52,24 @@ func TestLimiter(t *testing.T) {
 		}
 	})
 
+	// Ensure a separate limiter is used in place of the underlying limiter.
+	t.Run("WaitRangeWith", func(t *testing.T) {
+		t.Parallel()
+
+		data := make([]byte, 4*pageSize)
+		l := mincore.NewLimiter(rate.NewLimiter(1, 1), data) // 1 fault per sec
+		l.Mincore = func(data []byte) ([]byte, error) { return make([]byte, 4), nil }
+
+		start := time.Now()
+		if err := l.WaitRangeWith(context.Background(), rate.NewLimiter(rate.Inf, 1), data); err != nil {
+			t.Fatal(err)
+		}
+
+		if d := time.Since(start); d >= time.Second {
+			t.Fatalf("too much time elapsed: %s", d)
+		}
+	})
+
 	// Ensure pages are marked as in-core after calling Wait() on them.
 	t.Run("MoveToInMemoryAfterUse", func(t *testing.T) {
 		t.Parallel()
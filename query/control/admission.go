@@ -0,0 +1,124 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/query/stdlib/influxdata/influxdb"
+	"go.uber.org/zap"
+)
+
+// memoryAdmission reserves the estimated memory of the queries
+// executed by the controller out of MaxMemoryBytes.
+type memoryAdmission struct {
+	limit int64
+
+	mu       sync.Mutex
+	reserved int64
+	// released is closed and replaced whenever memory is released
+	// to wake the queries waiting for it.
+	released chan struct{}
+}
+
+func newMemoryAdmission(limit int64) *memoryAdmission {
+	return &memoryAdmission{
+		limit:    limit,
+		released: make(chan struct{}),
+	}
+}
+
+// reserve waits until bytes fit in the memory that is not reserved and
+// reserves them. A reservation larger than the limit is made once no
+// memory is reserved. It returns false if ctx is done first.
+func (a *memoryAdmission) reserve(ctx context.Context, bytes int64) bool {
+	for ctx.Err() == nil {
+		a.mu.Lock()
+		if a.reserved == 0 || a.reserved+bytes <= a.limit {
+			a.reserved += bytes
+			a.mu.Unlock()
+			return true
+		}
+		released := a.released
+		a.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+		}
+	}
+	return false
+}
+
+// release releases the memory of a reservation.
+func (a *memoryAdmission) release(bytes int64) {
+	a.mu.Lock()
+	a.reserved -= bytes
+	close(a.released)
+	a.released = make(chan struct{})
+	a.mu.Unlock()
+}
+
+// estimateMemory estimates the memory used by the program of q compiled
+// by compiler for the admission of q. A query that cannot be estimated
+// is counted and estimated at FallbackEstimatedMemoryBytes.
+func (c *Controller) estimateMemory(ctx context.Context, q *Query, compiler flux.Compiler) int64 {
+	var (
+		bytes int64
+		err   error
+	)
+	if estimate := c.config.EstimateMemoryBytes; estimate != nil {
+		bytes, err = estimate(ctx, query.RequestFromContext(ctx))
+	} else {
+		bytes, err = estimateReadBytes(ctx, compiler, q.program)
+	}
+	if err == nil && bytes < 0 {
+		err = fmt.Errorf("negative estimate: %d", bytes)
+	}
+	if err != nil {
+		c.log.Debug("Unable to estimate the memory of a query", zap.Error(err))
+		c.metrics.estimateErrors.WithLabelValues(q.labelValues...).Inc()
+		return c.config.FallbackEstimatedMemoryBytes
+	}
+	return bytes
+}
+
+// estimateReadBytes estimates the memory of a compiled
+// program as the bytes of its storage reads.
+func estimateReadBytes(ctx context.Context, compiler flux.Compiler, prog flux.Program) (int64, error) {
+	var extern json.RawMessage
+	if c, ok := compiler.(lang.FluxCompiler); ok {
+		extern = c.Extern
+	}
+	ps, err := planProgram(ctx, prog, extern)
+	if err != nil {
+		return 0, err
+	}
+	est, err := influxdb.EstimateCost(ctx, ps)
+	if err != nil {
+		return 0, err
+	}
+	return est.Bytes, nil
+}
+
+// admitQuery waits until the estimated memory of a queued query is
+// reserved and then pushes it to the queue of the workers, so that
+// the query does not hold a worker while it waits. A query that is
+// canceled while it waits is failed without being executed.
+func (c *Controller) admitQuery(q *Query) {
+	defer c.wg.Done()
+
+	if !c.admission.reserve(q.parentCtx, q.estimatedBytes) {
+		c.queueMu.Lock()
+		c.queued--
+		c.queueMu.Unlock()
+		q.setErr(q.parentCtx.Err())
+		return
+	}
+	q.admitted = true
+	c.pushQuery(q)
+}
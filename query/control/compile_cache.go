@@ -0,0 +1,116 @@
+package control
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/lang"
+)
+
+// compileCache is an LRU cache of compiled programs. A program is
+// checked out of the cache by the query that uses it and is only
+// returned once that query is done so that no two queries ever share
+// a program. Programs expire ttl after they were compiled.
+type compileCache struct {
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	evictor  *list.List
+	capacity int
+	ttl      time.Duration
+
+	now func() time.Time
+}
+
+// compileCacheEntry is a compiled program and the key it was cached under.
+type compileCacheEntry struct {
+	key     string
+	program flux.Program
+	expires time.Time
+}
+
+func newCompileCache(capacity int, ttl time.Duration) *compileCache {
+	return &compileCache{
+		entries:  make(map[string]*list.Element),
+		evictor:  list.New(),
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// compileCacheKey returns the key of the program compiled by compiler.
+// Only Flux queries with a now time are cached, as a query without one
+// is evaluated relative to the time it is compiled. The key contains the
+// query with its surrounding whitespace removed along with the externs
+// and the now time truncated to nowPrecision, so that a query is reused
+// for the queries whose now times fall within the same interval. Those
+// queries are evaluated with the now time of the query that compiled
+// the program.
+func compileCacheKey(compiler flux.Compiler, nowPrecision time.Duration) (string, bool) {
+	c, ok := compiler.(lang.FluxCompiler)
+	if !ok || c.Now.IsZero() {
+		return "", false
+	}
+	now := c.Now.Truncate(nowPrecision)
+	return fmt.Sprintf("%d\x00%s\x00%s", now.UnixNano(), c.Extern, strings.TrimSpace(c.Query)), true
+}
+
+// checkout removes the program cached under key from the cache
+// and returns it. It returns nil if there is no such program or it
+// has expired.
+func (c *compileCache) checkout(key string) *compileCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ele, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.remove(ele)
+
+	entry := ele.Value.(*compileCacheEntry)
+	if !c.now().Before(entry.expires) {
+		return nil
+	}
+	return entry
+}
+
+// newEntry returns an entry for a newly compiled program. The program
+// is cached once the query that compiled it releases it.
+func (c *compileCache) newEntry(key string, program flux.Program) *compileCacheEntry {
+	return &compileCacheEntry{
+		key:     key,
+		program: program,
+		expires: c.now().Add(c.ttl),
+	}
+}
+
+// release returns a program checked out or compiled by a query once
+// that query is done with it. It evicts the least recently used
+// programs if the cache is full.
+func (c *compileCache) release(entry *compileCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.now().Before(entry.expires) {
+		return
+	}
+	if ele, ok := c.entries[entry.key]; ok {
+		// Another query compiled the same program in the meantime.
+		c.remove(ele)
+	}
+	c.entries[entry.key] = c.evictor.PushFront(entry)
+
+	for c.evictor.Len() > c.capacity {
+		c.remove(c.evictor.Back())
+	}
+}
+
+func (c *compileCache) remove(ele *list.Element) {
+	c.evictor.Remove(ele)
+	delete(c.entries, ele.Value.(*compileCacheEntry).key)
+}
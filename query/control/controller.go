@@ -18,11 +18,14 @@
 package control
 
 import (
+	"container/heap"
 	"context"
 	"fmt"
 	"runtime/debug"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/codes"
@@ -43,6 +46,14 @@ import (
 // orgLabel is the metric label to use in the controller
 const orgLabel = "org"
 
+// sourceLabel is the metric label of the duration histograms
+// that holds the source label of the query.
+const sourceLabel = "source"
+
+// otherSource is the value of the sourceLabel of the queries whose
+// source label is not one of the MetricQuerySources.
+const otherSource = "other"
+
 // Controller provides a central location to manage all incoming queries.
 // The controller is responsible for compiling, queueing, and executing queries.
 type Controller struct {
@@ -50,17 +61,29 @@ type Controller struct {
 	lastID     uint64
 	queriesMu  sync.RWMutex
 	queries    map[QueryID]*Query
-	queryQueue chan *Query
+	queryQueue chan struct{} // holds a token for each query in queue
+	queueMu    sync.Mutex
+	queue      priorityQueue // a token admits the highest priority query
+	queued     int           // queries in queue or waiting for admission
 	wg         sync.WaitGroup
 	shutdown   bool
 	done       chan struct{}
 	abortOnce  sync.Once
 	abort      chan struct{}
 	memory     *memoryManager
+	cache      *compileCache
+	admission  *memoryAdmission
 
 	metrics   *controllerMetrics
 	labelKeys []string
 
+	// metricSources is the set of the MetricQuerySources.
+	metricSources map[string]bool
+
+	// requests counts the requests of each result, in the order of
+	// labelSuccess, labelCompileError, labelQueueError and labelRuntimeError.
+	requests [4]int64
+
 	log *zap.Logger
 
 	dependencies []flux.Dependency
@@ -98,7 +121,56 @@ type Config struct {
 	// The context value must be a string or an implementation of the Stringer interface.
 	MetricLabelKeys []string
 
+	// MetricQuerySources is the list of the source labels of queries that
+	// the duration histograms are labeled with. The metrics of any other
+	// source are labeled as "other", so that clients cannot grow the
+	// number of series of the histograms. The full source label is only
+	// recorded on the spans of the query.
+	MetricQuerySources []string
+
 	ExecutorDependencies []flux.Dependency
+
+	// CompileCacheSize is the number of compiled Flux programs that are
+	// kept so that repeating a query with the same text and a now time
+	// in the same CompileCacheNowPrecision interval does not compile it
+	// again. If this is unset, then programs are
+	// not cached.
+	CompileCacheSize int
+
+	// CompileCacheTTL is how long a compiled program is kept
+	// after it was compiled.
+	CompileCacheTTL time.Duration
+
+	// CompileCacheNowPrecision is the interval that the now times of
+	// queries are truncated to when they are cached, so that repeating
+	// a query with a now time in the same interval reuses its program
+	// and is evaluated with the now time of the query that compiled it.
+	// If this is unset, then CompileCacheTTL is used.
+	CompileCacheNowPrecision time.Duration
+
+	// AdmitByEstimatedMemory, when set, queues each query until its
+	// estimated memory fits in the MaxMemoryBytes not reserved by the
+	// estimates of the queries executing, rather than failing it once
+	// it runs out of memory. A query whose estimate is larger than
+	// MaxMemoryBytes is executed once no other query is reserving memory.
+	// MaxMemoryBytes must be set.
+	AdmitByEstimatedMemory bool
+
+	// EstimateMemoryBytes estimates the memory of a query for its
+	// admission once it is compiled. It defaults to the bytes of the
+	// storage reads in the plan of the compiled program.
+	EstimateMemoryBytes func(ctx context.Context, req *query.Request) (int64, error)
+
+	// FallbackEstimatedMemoryBytes is the memory estimated for the
+	// admission of a query that cannot be estimated. If this is unset,
+	// then MemoryBytesQuotaPerQuery is used.
+	FallbackEstimatedMemoryBytes int64
+
+	// MaxCPUTime is the CPU time a query may spend before it is canceled.
+	// The CPU time is approximated by the time spent scanning storage,
+	// as reported through query.CPUTimeFuncFromContext. If this is
+	// unset, then there is no limit.
+	MaxCPUTime time.Duration
 }
 
 // complete will fill in the defaults, validate the configuration, and
@@ -108,6 +180,12 @@ func (c *Config) complete() (Config, error) {
 	if config.InitialMemoryBytesQuotaPerQuery == 0 {
 		config.InitialMemoryBytesQuotaPerQuery = config.MemoryBytesQuotaPerQuery
 	}
+	if config.CompileCacheNowPrecision == 0 {
+		config.CompileCacheNowPrecision = config.CompileCacheTTL
+	}
+	if config.FallbackEstimatedMemoryBytes == 0 {
+		config.FallbackEstimatedMemoryBytes = config.MemoryBytesQuotaPerQuery
+	}
 
 	if err := config.validate(true); err != nil {
 		return Config{}, err
@@ -136,6 +214,24 @@ func (c *Config) validate(isComplete bool) error {
 	if c.QueueSize <= 0 {
 		return errors.New("QueueSize must be positive")
 	}
+	if c.AdmitByEstimatedMemory && c.MaxMemoryBytes == 0 {
+		return errors.New("MaxMemoryBytes must be set to admit queries by estimated memory")
+	}
+	if c.FallbackEstimatedMemoryBytes < 0 {
+		return errors.New("FallbackEstimatedMemoryBytes must be positive")
+	}
+	if c.CompileCacheSize < 0 {
+		return errors.New("CompileCacheSize must be positive")
+	}
+	if c.CompileCacheSize > 0 && c.CompileCacheTTL <= 0 {
+		return errors.New("CompileCacheTTL must be positive")
+	}
+	if c.CompileCacheNowPrecision < 0 {
+		return errors.New("CompileCacheNowPrecision must be positive")
+	}
+	if c.MaxCPUTime < 0 {
+		return errors.New("MaxCPUTime must be positive")
+	}
 	return nil
 }
 
@@ -161,7 +257,10 @@ func New(config Config) (*Controller, error) {
 		zap.Int64("initial_memory_bytes_quota_per_query", c.InitialMemoryBytesQuotaPerQuery),
 		zap.Int64("memory_bytes_quota_per_query", c.MemoryBytesQuotaPerQuery),
 		zap.Int64("max_memory_bytes", c.MaxMemoryBytes),
-		zap.Int("queue_size", c.QueueSize))
+		zap.Int("queue_size", c.QueueSize),
+		zap.Int("compile_cache_size", c.CompileCacheSize),
+		zap.Bool("admit_by_estimated_memory", c.AdmitByEstimatedMemory),
+		zap.Duration("max_cpu_time", c.MaxCPUTime))
 
 	mm := &memoryManager{
 		initialBytesQuotaPerQuery: c.InitialMemoryBytesQuotaPerQuery,
@@ -175,7 +274,7 @@ func New(config Config) (*Controller, error) {
 	ctrl := &Controller{
 		config:       c,
 		queries:      make(map[QueryID]*Query),
-		queryQueue:   make(chan *Query, c.QueueSize),
+		queryQueue:   make(chan struct{}, c.QueueSize),
 		done:         make(chan struct{}),
 		abort:        make(chan struct{}),
 		memory:       mm,
@@ -184,6 +283,16 @@ func New(config Config) (*Controller, error) {
 		labelKeys:    c.MetricLabelKeys,
 		dependencies: c.ExecutorDependencies,
 	}
+	ctrl.metricSources = make(map[string]bool, len(c.MetricQuerySources))
+	for _, source := range c.MetricQuerySources {
+		ctrl.metricSources[source] = true
+	}
+	if c.CompileCacheSize > 0 {
+		ctrl.cache = newCompileCache(c.CompileCacheSize, c.CompileCacheTTL)
+	}
+	if c.AdmitByEstimatedMemory {
+		ctrl.admission = newMemoryAdmission(c.MaxMemoryBytes)
+	}
 	ctrl.wg.Add(c.ConcurrencyQuota)
 	for i := 0; i < c.ConcurrencyQuota; i++ {
 		go func() {
@@ -207,7 +316,7 @@ func (c *Controller) Query(ctx context.Context, req *query.Request) (flux.Query,
 	for _, dep := range c.dependencies {
 		ctx = dep.Inject(ctx)
 	}
-	q, err := c.query(ctx, req.Compiler)
+	q, err := c.query(ctx, req.Compiler, query.ClampPriority(req.Priority))
 	if err != nil {
 		return q, err
 	}
@@ -217,8 +326,8 @@ func (c *Controller) Query(ctx context.Context, req *query.Request) (flux.Query,
 
 // query submits a query for execution returning immediately.
 // Done must be called on any returned Query objects.
-func (c *Controller) query(ctx context.Context, compiler flux.Compiler) (flux.Query, error) {
-	q, err := c.createQuery(ctx, compiler.CompilerType())
+func (c *Controller) query(ctx context.Context, compiler flux.Compiler, priority int) (flux.Query, error) {
+	q, err := c.createQuery(ctx, compiler.CompilerType(), priority)
 	if err != nil {
 		return nil, handleFluxError(err)
 	}
@@ -238,7 +347,7 @@ func (c *Controller) query(ctx context.Context, compiler flux.Compiler) (flux.Qu
 	return q, nil
 }
 
-func (c *Controller) createQuery(ctx context.Context, ct flux.CompilerType) (*Query, error) {
+func (c *Controller) createQuery(ctx context.Context, ct flux.CompilerType, priority int) (*Query, error) {
 	c.queriesMu.RLock()
 	if c.shutdown {
 		c.queriesMu.RUnlock()
@@ -263,17 +372,35 @@ func (c *Controller) createQuery(ctx context.Context, ct flux.CompilerType) (*Qu
 	}
 	compileLabelValues[len(compileLabelValues)-1] = string(ct)
 
+	source := query.SourceLabelFromContext(ctx)
+	var orgID influxdb.ID
+	if req := query.RequestFromContext(ctx); req != nil {
+		orgID = req.OrganizationID
+	}
+
+	// The query is accounted the CPU time reported by its execution.
+	var q *Query
 	cctx, cancel := context.WithCancel(ctx)
+	cctx = query.ContextWithCPUTimeFunc(cctx, func(d time.Duration) error {
+		return q.addCPUTime(d)
+	})
 	parentSpan, parentCtx := tracing.StartSpanFromContextWithPromMetrics(
 		cctx,
 		"all",
-		c.metrics.allDur.WithLabelValues(labelValues...),
+		c.metrics.allDur.WithLabelValues(durationLabelValues(labelValues, c.metricSource(source))...),
 		c.metrics.all.WithLabelValues(labelValues...),
 	)
-	q := &Query{
+	if source != "" {
+		parentSpan.SetTag("query_source", source)
+	}
+	q = &Query{
 		id:                 id,
+		priority:           priority,
 		labelValues:        labelValues,
 		compileLabelValues: compileLabelValues,
+		source:             source,
+		metricSource:       c.metricSource(source),
+		orgID:              orgID,
 		state:              Created,
 		c:                  c,
 		results:            make(chan flux.Result),
@@ -298,6 +425,10 @@ func (c *Controller) createQuery(ctx context.Context, ct flux.CompilerType) (*Qu
 		return nil, err
 	}
 	c.queries[id] = q
+
+	if fn := query.QueryIDFuncFromContext(ctx); fn != nil {
+		fn(uint64(id))
+	}
 	return q, nil
 }
 
@@ -312,6 +443,37 @@ func (c *Controller) countQueryRequest(q *Query, result requestsLabel) {
 	copy(lvs, q.labelValues)
 	lvs[l] = string(result)
 	c.metrics.requests.WithLabelValues(lvs...).Inc()
+
+	switch result {
+	case labelSuccess:
+		atomic.AddInt64(&c.requests[0], 1)
+	case labelCompileError:
+		atomic.AddInt64(&c.requests[1], 1)
+	case labelQueueError:
+		atomic.AddInt64(&c.requests[2], 1)
+	case labelRuntimeError:
+		atomic.AddInt64(&c.requests[3], 1)
+	}
+}
+
+// metricSource returns the value of the sourceLabel of the metrics of
+// a query with the source label. It is source if that is empty or one
+// of the MetricQuerySources and otherSource otherwise.
+func (c *Controller) metricSource(source string) string {
+	if source == "" || c.metricSources[source] {
+		return source
+	}
+	return otherSource
+}
+
+// durationLabelValues returns the label values of a duration histogram,
+// which are labelValues followed by the source label of the query.
+func durationLabelValues(labelValues []string, source string) []string {
+	l := len(labelValues)
+	lvs := make([]string, l+1)
+	copy(lvs, labelValues)
+	lvs[l] = source
+	return lvs
 }
 
 func (c *Controller) compileQuery(q *Query, compiler flux.Compiler) (err error) {
@@ -339,6 +501,18 @@ func (c *Controller) compileQuery(q *Query, compiler flux.Compiler) (err error)
 		}
 	}
 
+	key, cacheable := compileCacheKey(compiler, c.config.CompileCacheNowPrecision)
+	if cacheable && c.cache != nil {
+		if entry := c.cache.checkout(key); entry != nil {
+			c.metrics.compileCacheHits.WithLabelValues(q.labelValues...).Inc()
+			q.cacheEntry = entry
+			q.setProgram(entry.program, log)
+			c.estimateQuery(ctx, q, compiler)
+			return nil
+		}
+		c.metrics.compileCacheMisses.WithLabelValues(q.labelValues...).Inc()
+	}
+
 	prog, err := compiler.Compile(ctx, runtime.Default)
 	if err != nil {
 		return &flux.Error{
@@ -347,14 +521,22 @@ func (c *Controller) compileQuery(q *Query, compiler flux.Compiler) (err error)
 		}
 	}
 
-	if p, ok := prog.(lang.LoggingProgram); ok {
-		p.SetLogger(log)
+	if cacheable && c.cache != nil {
+		q.cacheEntry = c.cache.newEntry(key, prog)
 	}
-
-	q.program = prog
+	q.setProgram(prog, log)
+	c.estimateQuery(ctx, q, compiler)
 	return nil
 }
 
+// estimateQuery estimates the memory of the program of q compiled
+// by compiler when queries are admitted by it.
+func (c *Controller) estimateQuery(ctx context.Context, q *Query, compiler flux.Compiler) {
+	if c.admission != nil {
+		q.estimatedBytes = c.estimateMemory(ctx, q, compiler)
+	}
+}
+
 func (c *Controller) enqueueQuery(q *Query) error {
 	if _, ok := q.tryQueue(); !ok {
 		return &flux.Error{
@@ -363,29 +545,89 @@ func (c *Controller) enqueueQuery(q *Query) error {
 		}
 	}
 
-	select {
-	case c.queryQueue <- q:
-	default:
+	// A query waiting for its estimated memory counts against
+	// the queue size although it is not pushed to the queue yet.
+	c.queueMu.Lock()
+	if c.queued >= c.config.QueueSize {
+		c.queueMu.Unlock()
 		return &flux.Error{
 			Code: codes.ResourceExhausted,
 			Msg:  "queue length exceeded",
 		}
 	}
+	c.queued++
+	c.queueMu.Unlock()
 
+	if c.admission != nil {
+		c.wg.Add(1)
+		go c.admitQuery(q)
+		return nil
+	}
+	c.pushQuery(q)
 	return nil
 }
 
+// pushQuery pushes a queued query to the queue of the workers. The query
+// is pushed and its token is sent while the queue is locked so that no
+// worker may pop it before it is in the queue. Sending the token never
+// blocks as there are at most QueueSize queued queries.
+func (c *Controller) pushQuery(q *Query) {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	heap.Push(&c.queue, q)
+	c.queryQueue <- struct{}{}
+}
+
 func (c *Controller) processQueryQueue() {
 	for {
 		select {
 		case <-c.done:
 			return
-		case q := <-c.queryQueue:
+		case <-c.queryQueue:
+			c.queueMu.Lock()
+			q := heap.Pop(&c.queue).(*Query)
+			c.queued--
+			c.queueMu.Unlock()
 			c.executeQuery(q)
 		}
 	}
 }
 
+// priorityQueue is a heap of queries ordered by priority. Queries
+// with the same priority are ordered by when they were created.
+type priorityQueue []*Query
+
+func (qq priorityQueue) Len() int { return len(qq) }
+
+func (qq priorityQueue) Less(i, j int) bool {
+	if qq[i].priority != qq[j].priority {
+		return qq[i].priority > qq[j].priority
+	}
+	return qq[i].id < qq[j].id
+}
+
+func (qq priorityQueue) Swap(i, j int) {
+	qq[i], qq[j] = qq[j], qq[i]
+	qq[i].queueIndex = i
+	qq[j].queueIndex = j
+}
+
+func (qq *priorityQueue) Push(x interface{}) {
+	q := x.(*Query)
+	q.queueIndex = len(*qq)
+	*qq = append(*qq, q)
+}
+
+func (qq *priorityQueue) Pop() interface{} {
+	old := *qq
+	n := len(old)
+	q := old[n-1]
+	old[n-1] = nil
+	q.queueIndex = -1
+	*qq = old[:n-1]
+	return q
+}
+
 // executeQuery will execute a compiled program and wait for its completion.
 func (c *Controller) executeQuery(q *Query) {
 
@@ -460,6 +702,55 @@ func (c *Controller) Queries() []*Query {
 	return queries
 }
 
+// ActiveQueries reports the queries that are compiling, queued or executing.
+func (c *Controller) ActiveQueries(ctx context.Context) []query.ActiveQuery {
+	queries := c.Queries()
+	active := make([]query.ActiveQuery, 0, len(queries))
+	for _, q := range queries {
+		active = append(active, query.ActiveQuery{
+			ID:             uint64(q.id),
+			OrganizationID: q.orgID,
+			State:          q.State().String(),
+			Source:         q.source,
+		})
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].ID < active[j].ID
+	})
+	return active
+}
+
+// CancelQuery cancels the active query with the ID. The query must
+// still be released with Done by its caller, which sees it fail with
+// a cancellation error. This does not wait for the query to finish.
+func (c *Controller) CancelQuery(ctx context.Context, id uint64) error {
+	c.queriesMu.RLock()
+	q, ok := c.queries[QueryID(id)]
+	c.queriesMu.RUnlock()
+	if !ok {
+		return &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Msg:  fmt.Sprintf("query %d is not active", id),
+		}
+	}
+	q.Cancel()
+	return nil
+}
+
+// CancelAll cancels all of the active queries. Each query must still
+// be released with Done. This does not wait for the queries to finish.
+func (c *Controller) CancelAll(ctx context.Context) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	c.queriesMu.RLock()
+	defer c.queriesMu.RUnlock()
+	span.LogKV("queries", len(c.queries))
+	for _, q := range c.queries {
+		q.Cancel()
+	}
+}
+
 // Shutdown will signal to the Controller that it should not accept any
 // new queries and that it should finish executing any existing queries.
 // This will return once the Controller's run loop has been exited and all
@@ -476,11 +767,7 @@ func (c *Controller) Shutdown(ctx context.Context) error {
 	c.queriesMu.Unlock()
 
 	// Cancel all of the currently active queries.
-	c.queriesMu.RLock()
-	for _, q := range c.queries {
-		q.Cancel()
-	}
-	c.queriesMu.RUnlock()
+	c.CancelAll(ctx)
 
 	// Wait for query processing goroutines to finish.
 	defer c.wg.Wait()
@@ -509,6 +796,23 @@ func (c *Controller) PrometheusCollectors() []prometheus.Collector {
 	return collectors
 }
 
+// Stats reports the counters of the queries of the controller.
+func (c *Controller) Stats() query.ControllerStats {
+	c.queriesMu.RLock()
+	active := len(c.queries)
+	c.queriesMu.RUnlock()
+
+	return query.ControllerStats{
+		Queries:           int64(atomic.LoadUint64(&c.lastID)),
+		Active:            int64(active),
+		Succeeded:         atomic.LoadInt64(&c.requests[0]),
+		CompileErrors:     atomic.LoadInt64(&c.requests[1]),
+		QueueErrors:       atomic.LoadInt64(&c.requests[2]),
+		RuntimeErrors:     atomic.LoadInt64(&c.requests[3]),
+		MemoryUnusedBytes: c.GetUnusedMemoryBytes(),
+	}
+}
+
 func (c *Controller) GetUnusedMemoryBytes() int64 {
 	return c.memory.getUnusedMemoryBytes()
 }
@@ -521,9 +825,22 @@ func (c *Controller) GetUsedMemoryBytes() int64 {
 type Query struct {
 	id QueryID
 
+	// priority orders the query in the queue. Queries with a higher
+	// priority are executed before those with a lower one.
+	priority   int
+	queueIndex int
+
 	labelValues        []string
 	compileLabelValues []string
 
+	// source is the source label of the query. The duration histograms
+	// are labeled with its metricSource in addition to labelValues.
+	source       string
+	metricSource string
+
+	// orgID is the organization of the request of the query.
+	orgID influxdb.ID
+
 	c *Controller
 
 	// query state. The stateMu protects access for the group below.
@@ -544,8 +861,31 @@ type Query struct {
 	exec    flux.Query
 	results chan flux.Result
 
+	// cacheEntry is the entry of the compile cache that holds
+	// the program. It is returned to the cache when the query
+	// is done if the query succeeded.
+	cacheEntry *compileCacheEntry
+
 	memoryManager *queryMemoryManager
 	alloc         *memory.Allocator
+
+	// estimatedBytes is the estimated memory of the query, which is
+	// reserved by the admission of the controller once admitted is set.
+	estimatedBytes int64
+	admitted       bool
+
+	// cpuTime is the CPU time in nanoseconds accounted to the query.
+	// cpuErr is set once it exceeds the MaxCPUTime of the controller.
+	cpuTime int64
+	cpuOnce sync.Once
+	cpuErr  error
+}
+
+func (q *Query) setProgram(prog flux.Program, log *zap.Logger) {
+	if p, ok := prog.(lang.LoggingProgram); ok {
+		p.SetLogger(log)
+	}
+	q.program = prog
 }
 
 // ID reports an ephemeral unique ID for the query.
@@ -553,6 +893,35 @@ func (q *Query) ID() QueryID {
 	return q.id
 }
 
+// CPUTime reports the CPU time accounted to the query so far.
+func (q *Query) CPUTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&q.cpuTime))
+}
+
+// addCPUTime accounts d of CPU time to the query. Once the query has
+// exceeded the MaxCPUTime of the controller, it is canceled and an
+// error is returned.
+func (q *Query) addCPUTime(d time.Duration) error {
+	n := time.Duration(atomic.AddInt64(&q.cpuTime, int64(d)))
+	max := q.c.config.MaxCPUTime
+	if max <= 0 || n <= max {
+		return nil
+	}
+
+	q.cpuOnce.Do(func() {
+		err := &flux.Error{
+			Code: codes.ResourceExhausted,
+			Msg:  fmt.Sprintf("query exceeded the maximum CPU time of %s", max),
+		}
+		q.stateMu.Lock()
+		q.cpuErr = err
+		q.stateMu.Unlock()
+		q.addRuntimeError(err)
+		q.cancel()
+	})
+	return q.cpuErr
+}
+
 // Cancel will stop the query execution.
 func (q *Query) Cancel() {
 	// Call the cancel function to signal that execution should
@@ -633,12 +1002,23 @@ func (q *Query) Done() {
 			// Record unused memory after finish.
 			q.recordUnusedMemory()
 		}
+		if q.admitted {
+			q.c.admission.release(q.estimatedBytes)
+		}
+
+		q.c.metrics.cpuTime.WithLabelValues(q.labelValues...).Add(q.CPUTime().Seconds())
 
 		// Count query request.
 		if q.err != nil || len(q.runtimeErrs) > 0 {
 			q.c.countQueryRequest(q, labelRuntimeError)
 		} else {
 			q.c.countQueryRequest(q, labelSuccess)
+
+			// The program is no longer used so it can be
+			// reused by the next identical query.
+			if q.cacheEntry != nil {
+				q.c.cache.release(q.cacheEntry)
+			}
 		}
 
 	})
@@ -764,7 +1144,7 @@ TRANSITION:
 	q.currentSpan, currentCtx = tracing.StartSpanFromContextWithPromMetrics(
 		q.parentCtx,
 		newState.String(),
-		dur.WithLabelValues(labelValues...),
+		dur.WithLabelValues(durationLabelValues(labelValues, q.metricSource)...),
 		gauge.WithLabelValues(labelValues...),
 	)
 	return currentCtx, true
@@ -774,6 +1154,10 @@ TRANSITION:
 func (q *Query) Err() error {
 	q.stateMu.Lock()
 	err := q.err
+	if q.cpuErr != nil {
+		// The query was canceled for its CPU time.
+		err = q.cpuErr
+	}
 	q.stateMu.Unlock()
 	return handleFluxError(err)
 }
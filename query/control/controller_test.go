@@ -157,6 +157,101 @@ func TestController_QuerySuccess(t *testing.T) {
 	validateRequestTotals(t, reg, 1, 0, 0, 0)
 }
 
+func TestController_CompileCache(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		now          time.Time
+		hits, misses float64
+	}{
+		{
+			name:   "now",
+			now:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			hits:   1,
+			misses: 1,
+		},
+		{
+			// Queries without a now time are evaluated relative to
+			// the time they are compiled and so are not cached.
+			name: "no now",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config
+			cfg.CompileCacheSize = 10
+			cfg.CompileCacheTTL = time.Minute
+			ctrl, err := control.New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer shutdown(t, ctrl)
+
+			reg := setupPromRegistry(ctrl)
+
+			compiler := lang.FluxCompiler{
+				Now: tt.now,
+				Query: `
+import "csv"
+
+data = "
+#datatype,string,long,dateTime:RFC3339,long
+#group,false,false,false,false
+#default,_result,,,
+,result,table,_time,_value
+,,0,2020-01-01T00:00:00Z,1
+"
+
+csv.from(csv: data)
+`,
+			}
+
+			// A cached program produces the same results each time it is started.
+			for i := 0; i < 2; i++ {
+				q, err := ctrl.Query(context.Background(), makeRequest(compiler))
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				var rows int
+				for res := range q.Results() {
+					if err := res.Tables().Do(func(tbl flux.Table) error {
+						return tbl.Do(func(cr flux.ColReader) error {
+							rows += cr.Len()
+							return nil
+						})
+					}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				q.Done()
+
+				if err := q.Err(); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if rows != 1 {
+					t.Errorf("unexpected number of rows in run %d: got %d want: 1", i, rows)
+				}
+			}
+
+			metrics, err := reg.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range map[string]float64{
+				"query_control_compile_cache_hits_total":   tt.hits,
+				"query_control_compile_cache_misses_total": tt.misses,
+			} {
+				var got float64
+				if m := FindMetric(metrics, name, map[string]string{"org": ""}); m != nil {
+					got = m.Counter.GetValue()
+				}
+				if got != want {
+					t.Errorf("unexpected %s: got %v want: %v", name, got, want)
+				}
+			}
+			validateRequestTotals(t, reg, 2, 0, 0, 0)
+		})
+	}
+}
+
 func TestController_QueryCompileError(t *testing.T) {
 	ctrl, err := control.New(config)
 	if err != nil {
@@ -592,6 +687,62 @@ func TestController_ShutdownWithRunningQuery(t *testing.T) {
 	wg.Wait()
 }
 
+func TestController_CancelAll(t *testing.T) {
+	ctrl, err := control.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	executing := make(chan struct{})
+	canceled := make(chan error, 1)
+	compiler := &mock.Compiler{
+		CompileFn: func(ctx context.Context) (flux.Program, error) {
+			return &mock.Program{
+				ExecuteFn: func(ctx context.Context, q *mock.Query, alloc *memory.Allocator) {
+					close(executing)
+
+					// Block as a long running query would
+					// until the query is canceled.
+					timer := time.NewTimer(10 * time.Second)
+					defer timer.Stop()
+
+					select {
+					case <-ctx.Done():
+						canceled <- ctx.Err()
+					case <-timer.C:
+						canceled <- nil
+					}
+				},
+			}, nil
+		},
+	}
+
+	q, err := ctrl.Query(context.Background(), makeRequest(compiler))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer q.Done()
+
+	// Wait until execution has started.
+	<-executing
+
+	ctrl.CancelAll(context.Background())
+
+	select {
+	case err := <-canceled:
+		if got, want := err, context.Canceled; got != want {
+			t.Errorf("unexpected error -want/+got\n\t- %v\n\t+ %v", want, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected query to be canceled")
+	}
+
+	for range q.Results() {
+		// discard the results
+	}
+}
+
 func TestController_ShutdownWithTimeout(t *testing.T) {
 	ctrl, err := control.New(config)
 	if err != nil {
@@ -684,6 +835,57 @@ func TestController_PerQueryMemoryLimit(t *testing.T) {
 	}
 }
 
+func TestController_MaxCPUTime(t *testing.T) {
+	config := config
+	config.MaxCPUTime = 10 * time.Millisecond
+	ctrl, err := control.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	compiler := &mock.Compiler{
+		CompileFn: func(ctx context.Context) (flux.Program, error) {
+			return &mock.Program{
+				ExecuteFn: func(ctx context.Context, q *mock.Query, alloc *memory.Allocator) {
+					// This is emulating a storage read that spends
+					// CPU time scanning until it is stopped.
+					addCPUTime := query.CPUTimeFuncFromContext(ctx)
+					if addCPUTime == nil {
+						q.SetErr(errors.New("expected a CPU time function on the context"))
+						return
+					}
+					for {
+						if err := addCPUTime(time.Millisecond); err != nil {
+							q.SetErr(err)
+							return
+						}
+					}
+				},
+			}, nil
+		},
+	}
+
+	q, err := ctrl.Query(context.Background(), makeRequest(compiler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range q.Results() {
+		// discard the results
+	}
+	q.Done()
+
+	if err := q.Err(); err == nil {
+		t.Fatal("expected error about CPU time exceeded")
+	} else if !strings.Contains(err.Error(), "maximum CPU time") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := q.(*control.Query).CPUTime(), config.MaxCPUTime; got <= want {
+		t.Fatalf("unexpected CPU time: got %s, want more than %s", got, want)
+	}
+}
+
 func TestController_ConcurrencyQuota(t *testing.T) {
 	const (
 		numQueries       = 3
@@ -820,6 +1022,311 @@ func TestController_QueueSize(t *testing.T) {
 	}
 }
 
+func TestController_QueuePriority(t *testing.T) {
+	config := config
+	config.ConcurrencyQuota = 1
+	config.QueueSize = 2
+	ctrl, err := control.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	executed := make(chan string, 3)
+	newCompiler := func(name string, block <-chan struct{}) flux.Compiler {
+		return &mock.Compiler{
+			CompileFn: func(ctx context.Context) (flux.Program, error) {
+				return &mock.Program{
+					ExecuteFn: func(ctx context.Context, q *mock.Query, alloc *memory.Allocator) {
+						executed <- name
+						<-block
+					},
+				}, nil
+			},
+		}
+	}
+	query := func(name string, priority int, block <-chan struct{}) {
+		t.Helper()
+		req := makeRequest(newCompiler(name, block))
+		req.Priority = priority
+		q, err := ctrl.Query(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			for range q.Results() {
+				// discard the results
+			}
+			q.Done()
+		}()
+	}
+
+	// Occupy the only slot so the other queries are queued.
+	block := make(chan struct{})
+	query("blocking", 0, block)
+	if got := <-executed; got != "blocking" {
+		t.Fatalf("unexpected query executed: %s", got)
+	}
+
+	unblocked := make(chan struct{})
+	close(unblocked)
+	query("low", 0, unblocked)
+	query("high", 10, unblocked)
+	close(block)
+
+	for _, want := range []string{"high", "low"} {
+		select {
+		case got := <-executed:
+			if got != want {
+				t.Fatalf("unexpected query executed -want/+got:\n\t- %s\n\t+ %s", want, got)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for query %s to execute", want)
+		}
+	}
+}
+
+func TestController_AdmitByEstimatedMemory(t *testing.T) {
+	config := config
+	config.ConcurrencyQuota = 2
+	config.QueueSize = 2
+	config.MaxMemoryBytes = config.MemoryBytesQuotaPerQuery * 2
+	config.AdmitByEstimatedMemory = true
+	// Each query is estimated to use more than half of the memory
+	// so only one of them may execute at a time.
+	estimate := config.MaxMemoryBytes * 3 / 4
+	config.EstimateMemoryBytes = func(ctx context.Context, req *query.Request) (int64, error) {
+		return estimate, nil
+	}
+	ctrl, err := control.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	executed := make(chan string, 2)
+	newCompiler := func(name string, block <-chan struct{}) flux.Compiler {
+		return &mock.Compiler{
+			CompileFn: func(ctx context.Context) (flux.Program, error) {
+				return &mock.Program{
+					ExecuteFn: func(ctx context.Context, q *mock.Query, alloc *memory.Allocator) {
+						executed <- name
+						<-block
+					},
+				}, nil
+			},
+		}
+	}
+	query := func(name string, block <-chan struct{}) {
+		t.Helper()
+		q, err := ctrl.Query(context.Background(), makeRequest(newCompiler(name, block)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			for range q.Results() {
+				// discard the results
+			}
+			q.Done()
+		}()
+	}
+
+	block := make(chan struct{})
+	query("first", block)
+	if got := <-executed; got != "first" {
+		t.Fatalf("unexpected query executed: %s", got)
+	}
+
+	// There is a free slot but not enough memory for the second query.
+	unblocked := make(chan struct{})
+	close(unblocked)
+	query("second", unblocked)
+	select {
+	case got := <-executed:
+		t.Fatalf("query %s executed before the memory of the first query was freed", got)
+	case <-time.After(250 * time.Millisecond):
+	}
+
+	close(block)
+	select {
+	case got := <-executed:
+		if got != "second" {
+			t.Fatalf("unexpected query executed: %s", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the second query to execute")
+	}
+}
+
+func TestController_AdmitByEstimatedMemory_NoWorker(t *testing.T) {
+	config := config
+	config.ConcurrencyQuota = 2
+	config.QueueSize = 3
+	config.MaxMemoryBytes = config.MemoryBytesQuotaPerQuery * 2
+	config.AdmitByEstimatedMemory = true
+	// The large queries are estimated to use more than half of
+	// the memory so only one of them may execute at a time.
+	estimates := make(map[flux.Compiler]int64)
+	config.EstimateMemoryBytes = func(ctx context.Context, req *query.Request) (int64, error) {
+		return estimates[req.Compiler], nil
+	}
+	ctrl, err := control.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+
+	executed := make(chan string, 3)
+	query := func(name string, estimate int64, block <-chan struct{}) {
+		t.Helper()
+		compiler := &mock.Compiler{
+			CompileFn: func(ctx context.Context) (flux.Program, error) {
+				return &mock.Program{
+					ExecuteFn: func(ctx context.Context, q *mock.Query, alloc *memory.Allocator) {
+						executed <- name
+						<-block
+					},
+				}, nil
+			},
+		}
+		estimates[compiler] = estimate
+		q, err := ctrl.Query(context.Background(), makeRequest(compiler))
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			for range q.Results() {
+				// discard the results
+			}
+			q.Done()
+		}()
+	}
+
+	large := config.MaxMemoryBytes * 3 / 4
+	block := make(chan struct{})
+	query("first", large, block)
+	if got := <-executed; got != "first" {
+		t.Fatalf("unexpected query executed: %s", got)
+	}
+
+	// The second query waits for the memory of the first query
+	// without holding the free worker that the third query uses.
+	unblocked := make(chan struct{})
+	close(unblocked)
+	query("second", large, unblocked)
+	query("third", 0, unblocked)
+	select {
+	case got := <-executed:
+		if got != "third" {
+			t.Fatalf("unexpected query executed: %s", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the third query to execute")
+	}
+
+	close(block)
+	select {
+	case got := <-executed:
+		if got != "second" {
+			t.Fatalf("unexpected query executed: %s", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the second query to execute")
+	}
+}
+
+func TestController_AdmitByEstimatedMemory_EstimateError(t *testing.T) {
+	config := config
+	config.ConcurrencyQuota = 2
+	config.QueueSize = 2
+	config.MaxMemoryBytes = config.MemoryBytesQuotaPerQuery * 2
+	config.AdmitByEstimatedMemory = true
+	// The first query is estimated to use more than half of the memory
+	// and the second query, which cannot be estimated, falls back to
+	// an estimate that does not fit alongside it.
+	config.FallbackEstimatedMemoryBytes = config.MaxMemoryBytes * 3 / 4
+	estimates := make(map[flux.Compiler]int64)
+	config.EstimateMemoryBytes = func(ctx context.Context, req *query.Request) (int64, error) {
+		estimate, ok := estimates[req.Compiler]
+		if !ok {
+			return 0, errors.New("expected error")
+		}
+		return estimate, nil
+	}
+	ctrl, err := control.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(t, ctrl)
+	reg := setupPromRegistry(ctrl)
+
+	executed := make(chan string, 2)
+	query := func(name string, block <-chan struct{}) {
+		t.Helper()
+		compiler := &mock.Compiler{
+			CompileFn: func(ctx context.Context) (flux.Program, error) {
+				return &mock.Program{
+					ExecuteFn: func(ctx context.Context, q *mock.Query, alloc *memory.Allocator) {
+						executed <- name
+						<-block
+					},
+				}, nil
+			},
+		}
+		if name == "first" {
+			estimates[compiler] = config.MaxMemoryBytes * 3 / 4
+		}
+		q, err := ctrl.Query(context.Background(), makeRequest(compiler))
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			for range q.Results() {
+				// discard the results
+			}
+			q.Done()
+		}()
+	}
+
+	block := make(chan struct{})
+	query("first", block)
+	if got := <-executed; got != "first" {
+		t.Fatalf("unexpected query executed: %s", got)
+	}
+
+	// The second query is not admitted at zero bytes.
+	unblocked := make(chan struct{})
+	close(unblocked)
+	query("second", unblocked)
+	select {
+	case got := <-executed:
+		t.Fatalf("query %s executed before the memory of the first query was freed", got)
+	case <-time.After(250 * time.Millisecond):
+	}
+
+	close(block)
+	select {
+	case got := <-executed:
+		if got != "second" {
+			t.Fatalf("unexpected query executed: %s", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the second query to execute")
+	}
+
+	metrics, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got float64
+	if m := FindMetric(metrics, "query_control_estimate_errors_total", map[string]string{"org": ""}); m != nil {
+		got = m.Counter.GetValue()
+	}
+	if want := float64(1); got != want {
+		t.Errorf("unexpected query_control_estimate_errors_total: got %v want: %v", got, want)
+	}
+}
+
 // Test that rapidly starting and canceling the query and then calling done will correctly
 // cancel the query and not result in a race condition.
 func TestController_CancelDone(t *testing.T) {
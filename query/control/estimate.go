@@ -0,0 +1,114 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/runtime"
+	"github.com/influxdata/flux/spec"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/query"
+	"github.com/influxdata/influxdb/v2/query/stdlib/influxdata/influxdb"
+)
+
+// EstimateCost estimates the cost of the storage reads of a Flux query.
+// The query is compiled and planned with the dependencies of the
+// controller but it is not executed and does not use a query slot.
+func (c *Controller) EstimateCost(ctx context.Context, req *query.Request) (query.CostEstimate, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	compiler, ok := req.Compiler.(lang.FluxCompiler)
+	if !ok {
+		return query.CostEstimate{}, &flux.Error{
+			Code: codes.Invalid,
+			Msg:  "cost estimates are only supported for flux queries",
+		}
+	}
+
+	ctx = query.ContextWithRequest(ctx, req)
+	for _, dep := range c.dependencies {
+		ctx = dep.Inject(ctx)
+	}
+
+	prog, err := compiler.Compile(ctx, runtime.Default)
+	if err != nil {
+		return query.CostEstimate{}, &flux.Error{
+			Msg: "compilation failed",
+			Err: err,
+		}
+	}
+
+	ps, err := planProgram(ctx, prog, compiler.Extern)
+	if err != nil {
+		return query.CostEstimate{}, err
+	}
+	return influxdb.EstimateCost(ctx, ps)
+}
+
+// planProgram returns the physical plan of a compiled program. The
+// plan of a program compiled from a Flux script is only created once
+// it is started, so its script is evaluated and planned here. As when
+// the program is started, the script is evaluated with the extern of
+// its compiler, which is the JSON of a File, merged into it.
+func planProgram(ctx context.Context, prog flux.Program, extern json.RawMessage) (*plan.Spec, error) {
+	switch p := prog.(type) {
+	case *lang.Program:
+		return p.PlanSpec, nil
+	case *lang.AstProgram:
+		now := p.Now
+		if now.IsZero() {
+			now = time.Now()
+		}
+		pkg := p.Ast
+		if len(extern) > 0 {
+			hdl, err := p.Runtime.JSONToHandle(wrapFileJSONInPkg(extern))
+			if err != nil {
+				return nil, &flux.Error{
+					Code: codes.Invalid,
+					Msg:  "invalid extern",
+					Err:  err,
+				}
+			}
+			if err := p.Runtime.MergePackages(hdl, p.Ast); err != nil {
+				return nil, err
+			}
+			pkg = hdl
+		}
+		sideEffects, _, err := p.Runtime.Eval(ctx, pkg, flux.SetNowOption(now))
+		if err != nil {
+			return nil, &flux.Error{
+				Msg: "compilation failed",
+				Err: err,
+			}
+		}
+		fluxSpec, err := spec.FromEvaluation(ctx, sideEffects, now)
+		if err != nil {
+			return nil, err
+		}
+
+		ps, err := plan.NewLogicalPlanner().Plan(ctx, fluxSpec)
+		if err != nil {
+			return nil, err
+		}
+		return plan.NewPhysicalPlanner().Plan(ctx, ps)
+	default:
+		return nil, &flux.Error{
+			Code: codes.Unimplemented,
+			Msg:  fmt.Sprintf("cannot plan a program of type %T", prog),
+		}
+	}
+}
+
+// wrapFileJSONInPkg wraps the JSON of the File of an extern in a
+// Package so that it can be converted to a handle, as Flux does when
+// it compiles a query.
+func wrapFileJSONInPkg(bs []byte) []byte {
+	return []byte(fmt.Sprintf(`{"type":"Package","package":"main","files":[%s]}`, string(bs)))
+}
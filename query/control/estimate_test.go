@@ -0,0 +1,46 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/flux/runtime"
+	_ "github.com/influxdata/influxdb/v2/query/builtin"
+)
+
+func TestPlanProgram_Extern(t *testing.T) {
+	// The extern is the JSON of a File, as in the body of a query request.
+	extern := json.RawMessage(`{
+		"type": "File",
+		"body": [
+			{
+				"type": "VariableAssignment",
+				"id": {"type": "Identifier", "name": "v"},
+				"init": {"type": "IntegerLiteral", "value": "1"}
+			}
+		]
+	}`)
+	compiler := lang.FluxCompiler{
+		Query: `import "array"
+array.from(rows: [{v: v}])`,
+	}
+	prog, err := compiler.Compile(context.Background(), runtime.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps, err := planProgram(context.Background(), prog, extern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(ps.Roots), 1; got != want {
+		t.Fatalf("unexpected number of plan roots: got %d, want %d", got, want)
+	}
+
+	// The query cannot be evaluated without the extern.
+	if _, err := planProgram(context.Background(), prog, nil); err == nil {
+		t.Fatal("expected an error evaluating the query without its extern")
+	}
+}
@@ -7,12 +7,19 @@ type controllerMetrics struct {
 	requests  *prometheus.CounterVec
 	functions *prometheus.CounterVec
 
+	compileCacheHits   *prometheus.CounterVec
+	compileCacheMisses *prometheus.CounterVec
+
+	estimateErrors *prometheus.CounterVec
+
 	all          *prometheus.GaugeVec
 	compiling    *prometheus.GaugeVec
 	queueing     *prometheus.GaugeVec
 	executing    *prometheus.GaugeVec
 	memoryUnused *prometheus.GaugeVec
 
+	cpuTime *prometheus.CounterVec
+
 	allDur       *prometheus.HistogramVec
 	compilingDur *prometheus.HistogramVec
 	queueingDur  *prometheus.HistogramVec
@@ -49,6 +56,27 @@ func newControllerMetrics(labels []string) *controllerMetrics {
 			Help:      "Count of functions in queries",
 		}, append(labels, "function")),
 
+		compileCacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "compile_cache_hits_total",
+			Help:      "Count of queries that reused a cached compiled program",
+		}, labels),
+
+		compileCacheMisses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "compile_cache_misses_total",
+			Help:      "Count of cacheable queries that had to be compiled",
+		}, labels),
+
+		estimateErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "estimate_errors_total",
+			Help:      "Count of queries whose memory could not be estimated for their admission",
+		}, labels),
+
 		all: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
@@ -84,13 +112,20 @@ func newControllerMetrics(labels []string) *controllerMetrics {
 			Help:      "The free memory as seen by the internal memory manager",
 		}, labels),
 
+		cpuTime: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "cpu_seconds_total",
+			Help:      "Total CPU time spent scanning storage for queries",
+		}, labels),
+
 		allDur: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
 			Name:      "all_duration_seconds",
 			Help:      "Histogram of total times spent in all query states",
 			Buckets:   prometheus.ExponentialBuckets(1e-3, 5, 7),
-		}, labels),
+		}, append(labels, sourceLabel)),
 
 		compilingDur: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: namespace,
@@ -98,7 +133,7 @@ func newControllerMetrics(labels []string) *controllerMetrics {
 			Name:      "compiling_duration_seconds",
 			Help:      "Histogram of times spent compiling queries",
 			Buckets:   prometheus.ExponentialBuckets(1e-3, 5, 7),
-		}, append(labels, "compiler_type")),
+		}, append(labels, "compiler_type", sourceLabel)),
 
 		queueingDur: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: namespace,
@@ -106,7 +141,7 @@ func newControllerMetrics(labels []string) *controllerMetrics {
 			Name:      "queueing_duration_seconds",
 			Help:      "Histogram of times spent queueing queries",
 			Buckets:   prometheus.ExponentialBuckets(1e-3, 5, 7),
-		}, labels),
+		}, append(labels, sourceLabel)),
 
 		executingDur: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: namespace,
@@ -114,7 +149,7 @@ func newControllerMetrics(labels []string) *controllerMetrics {
 			Name:      "executing_duration_seconds",
 			Help:      "Histogram of times spent executing queries",
 			Buckets:   prometheus.ExponentialBuckets(1e-3, 5, 7),
-		}, labels),
+		}, append(labels, sourceLabel)),
 	}
 }
 
@@ -124,12 +159,19 @@ func (cm *controllerMetrics) PrometheusCollectors() []prometheus.Collector {
 		cm.requests,
 		cm.functions,
 
+		cm.compileCacheHits,
+		cm.compileCacheMisses,
+
+		cm.estimateErrors,
+
 		cm.all,
 		cm.compiling,
 		cm.queueing,
 		cm.executing,
 		cm.memoryUnused,
 
+		cm.cpuTime,
+
 		cm.allDur,
 		cm.compilingDur,
 		cm.queueingDur,
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/influxdata/flux"
 	platform "github.com/influxdata/influxdb/v2"
@@ -16,6 +17,24 @@ const (
 	PreferNoContentWErrHeaderValue = "return-no-content-with-error"
 )
 
+// MinPriority and MaxPriority bound the Priority of a Request so that
+// a client cannot starve every other query by choosing a large value.
+const (
+	MinPriority = -10
+	MaxPriority = 10
+)
+
+// ClampPriority returns p bounded by MinPriority and MaxPriority.
+func ClampPriority(p int) int {
+	if p < MinPriority {
+		return MinPriority
+	}
+	if p > MaxPriority {
+		return MaxPriority
+	}
+	return p
+}
+
 // Request represents the query to run.
 // Options to mutate the header associated to this Request can be specified
 // via `WithOption` or associated methods.
@@ -33,6 +52,12 @@ type Request struct {
 	// Source represents the ultimate source of the request.
 	Source string `json:"source"`
 
+	// Priority orders the request in the query queue. Queued requests
+	// with a higher priority are executed before those with a lower
+	// priority, such as interactive queries before background ones.
+	// It is clamped to MinPriority and MaxPriority.
+	Priority int `json:"priority,omitempty"`
+
 	// compilerMappings maps compiler types to creation methods
 	compilerMappings flux.CompilerMappings
 
@@ -139,6 +164,56 @@ func RequestFromContext(ctx context.Context) *Request {
 	return v.(*Request)
 }
 
+type sourceLabelContextKey struct{}
+
+// ContextWithSourceLabel returns a new context with the label of the
+// client that issued the query, such as the ID of a dashboard.
+// The label attributes the cost of the query in traces and metrics.
+func ContextWithSourceLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, sourceLabelContextKey{}, label)
+}
+
+// SourceLabelFromContext retrieves the source label from a context.
+// If no label exists on the context an empty string is returned.
+func SourceLabelFromContext(ctx context.Context) string {
+	label, _ := ctx.Value(sourceLabelContextKey{}).(string)
+	return label
+}
+
+type queryIDFuncContextKey struct{}
+
+// ContextWithQueryIDFunc returns a new context with a function that is
+// called with the ID a query controller assigns to the query once it
+// is admitted, such as to report the ID in a response header.
+func ContextWithQueryIDFunc(ctx context.Context, fn func(id uint64)) context.Context {
+	return context.WithValue(ctx, queryIDFuncContextKey{}, fn)
+}
+
+// QueryIDFuncFromContext retrieves the function of ContextWithQueryIDFunc
+// from a context. If no function exists on the context nil is returned.
+func QueryIDFuncFromContext(ctx context.Context) func(id uint64) {
+	fn, _ := ctx.Value(queryIDFuncContextKey{}).(func(id uint64))
+	return fn
+}
+
+type cpuTimeFuncContextKey struct{}
+
+// ContextWithCPUTimeFunc returns a new context with a function that is
+// called with the CPU time spent on the query, such as by a storage read
+// as it scans, so that a query controller can account for it. The
+// function returns an error once the query has exceeded its CPU time,
+// which the caller should return to stop its work.
+func ContextWithCPUTimeFunc(ctx context.Context, fn func(d time.Duration) error) context.Context {
+	return context.WithValue(ctx, cpuTimeFuncContextKey{}, fn)
+}
+
+// CPUTimeFuncFromContext retrieves the function of ContextWithCPUTimeFunc
+// from a context. If no function exists on the context nil is returned.
+func CPUTimeFuncFromContext(ctx context.Context) func(d time.Duration) error {
+	fn, _ := ctx.Value(cpuTimeFuncContextKey{}).(func(d time.Duration) error)
+	return fn
+}
+
 // ProxyRequest specifies a query request and the dialect for the results.
 type ProxyRequest struct {
 	// Request is the basic query request
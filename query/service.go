@@ -38,6 +38,51 @@ type ProxyQueryService interface {
 	Query(ctx context.Context, w io.Writer, req *ProxyRequest) (flux.Statistics, error)
 }
 
+// CostEstimator estimates the cost of a query without executing it.
+type CostEstimator interface {
+	// EstimateCost estimates the cost of the storage reads of the query.
+	EstimateCost(ctx context.Context, req *Request) (CostEstimate, error)
+}
+
+// ControllerStats are the counters of the queries of a query controller.
+type ControllerStats struct {
+	// Queries is the number of queries requested.
+	Queries int64 `json:"queries"`
+	// Active is the number of queries that are compiling, queued or executing.
+	Active int64 `json:"active"`
+
+	Succeeded     int64 `json:"succeeded"`
+	CompileErrors int64 `json:"compileErrors"`
+	QueueErrors   int64 `json:"queueErrors"`
+	RuntimeErrors int64 `json:"runtimeErrors"`
+
+	// MemoryUnusedBytes is the memory that is available to queries.
+	MemoryUnusedBytes int64 `json:"memoryUnusedBytes"`
+}
+
+// ControllerStatsService reports the counters of a query controller.
+type ControllerStatsService interface {
+	Stats() ControllerStats
+}
+
+// ActiveQuery describes a query that is compiling, queued or executing.
+type ActiveQuery struct {
+	ID             uint64      `json:"id"`
+	OrganizationID influxdb.ID `json:"orgID"`
+	State          string      `json:"state"`
+	Source         string      `json:"source,omitempty"`
+}
+
+// ActiveQueryService lists and cancels the active queries of a query controller.
+type ActiveQueryService interface {
+	// ActiveQueries reports the active queries ordered by their ID.
+	ActiveQueries(ctx context.Context) []ActiveQuery
+
+	// CancelQuery cancels the active query with the ID.
+	// It returns a not found error if there is no such query.
+	CancelQuery(ctx context.Context, id uint64) error
+}
+
 // Parse will take flux source code and produce a package.
 // If there are errors when parsing, the first error is returned.
 // An ast.Package may be returned when a parsing error occurs,
@@ -0,0 +1,80 @@
+package influxdb
+
+import (
+	"context"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/codes"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/values"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+// EstimateCost estimates the cost of the storage reads of a physical plan
+// without executing it. The cost of each read is estimated as if its
+// filter were the only one pushed down, so the aggregates and groupings
+// pushed into a read do not reduce its cost.
+func EstimateCost(ctx context.Context, ps *plan.Spec) (query.CostEstimate, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var est query.CostEstimate
+
+	deps := GetStorageDependencies(ctx).FromDeps
+	reader, ok := deps.Reader.(query.CostEstimateReader)
+	if !ok {
+		return est, &flux.Error{
+			Code: codes.Unimplemented,
+			Msg:  "storage does not support cost estimates",
+		}
+	}
+
+	req := query.RequestFromContext(ctx)
+	if req == nil {
+		return est, &flux.Error{
+			Code: codes.Internal,
+			Msg:  "missing request on context",
+		}
+	}
+
+	err := ps.BottomUpWalk(func(node plan.Node) error {
+		var spec *ReadRangePhysSpec
+		switch s := node.ProcedureSpec().(type) {
+		case *ReadRangePhysSpec:
+			spec = s
+		case *ReadGroupPhysSpec:
+			spec = &s.ReadRangePhysSpec
+		case *ReadWindowAggregatePhysSpec:
+			spec = &s.ReadRangePhysSpec
+		case *ReadTagKeysPhysSpec:
+			spec = &s.ReadRangePhysSpec
+		case *ReadTagValuesPhysSpec:
+			spec = &s.ReadRangePhysSpec
+		default:
+			return nil
+		}
+
+		bucketID, err := spec.LookupBucketID(ctx, req.OrganizationID, deps.BucketLookup)
+		if err != nil {
+			return err
+		}
+
+		readEst, err := reader.EstimateReadFilter(ctx, query.ReadFilterSpec{
+			OrganizationID: req.OrganizationID,
+			BucketID:       bucketID,
+			Bounds: execute.Bounds{
+				Start: values.ConvertTime(spec.Bounds.Start.Time(spec.Bounds.Now)),
+				Stop:  values.ConvertTime(spec.Bounds.Stop.Time(spec.Bounds.Now)),
+			},
+			Predicate: spec.Filter,
+		})
+		if err != nil {
+			return err
+		}
+		est.Add(readEst)
+		return nil
+	})
+	return est, err
+}
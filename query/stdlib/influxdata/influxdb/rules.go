@@ -3,6 +3,7 @@ package influxdb
 import (
 	"context"
 	"math"
+	"time"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/ast"
@@ -21,6 +22,7 @@ func init() {
 	plan.RegisterPhysicalRules(
 		FromStorageRule{},
 		PushDownRangeRule{},
+		DefaultRangeRule{},
 		PushDownFilterRule{},
 		PushDownGroupRule{},
 		PushDownReadTagKeysRule{},
@@ -127,6 +129,63 @@ func (rule PushDownRangeRule) Rewrite(ctx context.Context, node plan.Node) (plan
 	}), true, nil
 }
 
+// DefaultRangeRule bounds a read from storage that is not followed
+// by a range with the default range of the storage dependencies.
+type DefaultRangeRule struct{}
+
+func (rule DefaultRangeRule) Name() string {
+	return "DefaultRangeRule"
+}
+
+// Pattern matches 'from'
+func (rule DefaultRangeRule) Pattern() plan.Pattern {
+	return plan.Pat(FromKind)
+}
+
+// Rewrite converts 'from' into 'ReadRange' over the default range when
+// there is no range after it. A read with a range anywhere after it is
+// left for PushDownRangeRule, or rejected as unbounded, so that the
+// default range never narrows the range of a query.
+func (rule DefaultRangeRule) Rewrite(ctx context.Context, node plan.Node) (plan.Node, bool, error) {
+	d := GetStorageDependencies(ctx).FromDeps.DefaultRange
+	if d <= 0 || hasRangeSuccessor(node) {
+		return node, false, nil
+	}
+
+	fromSpec := node.ProcedureSpec().(*FromStorageProcedureSpec)
+	return plan.CreatePhysicalNode("ReadRange", &ReadRangePhysSpec{
+		Bucket:   fromSpec.Bucket.Name,
+		BucketID: fromSpec.Bucket.ID,
+		Bounds: flux.Bounds{
+			Start: flux.Time{IsRelative: true, Relative: -d},
+			Stop:  flux.Now,
+			Now:   planNow(ctx),
+		},
+	}), true, nil
+}
+
+// planNow returns the now time of the query being planned, which the
+// program sets on its execution dependencies before planning, so that
+// a relative range is evaluated like one written in the query.
+func planNow(ctx context.Context) time.Time {
+	if execute.HaveExecutionDependencies(ctx) {
+		if now := execute.GetExecutionDependencies(ctx).Now; now != nil && !now.IsZero() {
+			return *now
+		}
+	}
+	return time.Now()
+}
+
+// hasRangeSuccessor returns true if there is a range after node.
+func hasRangeSuccessor(node plan.Node) bool {
+	for _, succ := range node.Successors() {
+		if succ.Kind() == universe.RangeKind || hasRangeSuccessor(succ) {
+			return true
+		}
+	}
+	return false
+}
+
 // PushDownFilterRule is a rule that pushes filters into from procedures to be evaluated in the storage layer.
 // This rule is likely to be replaced by a more generic rule when we have a better
 // framework for pushing filters, etc into sources.
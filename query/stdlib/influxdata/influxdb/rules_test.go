@@ -188,6 +188,34 @@ func TestPushDownRangeRule(t *testing.T) {
 	}
 }
 
+func TestDefaultRangeRule_Now(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := influxdb.StorageDependencies{
+		FromDeps: influxdb.FromDependencies{DefaultRange: time.Hour},
+	}.Inject(context.Background())
+	ctx = execute.NewExecutionDependencies(&memory.Allocator{}, &now, nil).Inject(ctx)
+
+	node := plan.CreateLogicalNode("from", &influxdb.FromStorageProcedureSpec{
+		Bucket: influxdb.NameOrID{Name: "my-bucket"},
+	})
+	got, changed, err := influxdb.DefaultRangeRule{}.Rewrite(ctx, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("expected the read to be bounded by the default range")
+	}
+
+	// The range is relative to the now time of the query.
+	bounds := got.ProcedureSpec().(*influxdb.ReadRangePhysSpec).Bounds
+	if start, want := bounds.Start.Time(bounds.Now), now.Add(-time.Hour); !start.Equal(want) {
+		t.Errorf("unexpected start -want/+got:\n\t- %s\n\t+ %s", want, start)
+	}
+	if stop := bounds.Stop.Time(bounds.Now); !stop.Equal(now) {
+		t.Errorf("unexpected stop -want/+got:\n\t- %s\n\t+ %s", now, stop)
+	}
+}
+
 func TestPushDownFilterRule(t *testing.T) {
 	var (
 		bounds = flux.Bounds{
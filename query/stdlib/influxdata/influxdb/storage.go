@@ -2,6 +2,7 @@ package influxdb
 
 import (
 	"context"
+	"time"
 
 	platform "github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/prom"
@@ -28,6 +29,10 @@ type FromDependencies struct {
 	BucketLookup       BucketLookup
 	OrganizationLookup OrganizationLookup
 	Metrics            *metrics
+
+	// DefaultRange, when set, bounds the reads of from() that are not
+	// followed by a range() to the last DefaultRange before now.
+	DefaultRange time.Duration
 }
 
 func (d FromDependencies) Validate() error {
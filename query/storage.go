@@ -3,11 +3,13 @@ package query
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/plan"
+	"github.com/influxdata/flux/values"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
 	"github.com/influxdata/influxdb/v2/tsdb/cursors"
@@ -59,12 +61,131 @@ type WindowAggregateReader interface {
 	ReadWindowAggregate(ctx context.Context, spec ReadWindowAggregateSpec, alloc *memory.Allocator) (TableIterator, error)
 }
 
+// CostEstimate is the estimated cost of reading data from storage.
+type CostEstimate struct {
+	// SeriesN is the number of series that are read. A series
+	// is identified by its measurement, tags and field.
+	SeriesN int64 `json:"seriesCount"`
+
+	// PointsN is the estimated number of points that are read.
+	PointsN int64 `json:"estimatedPoints"`
+
+	// Bytes is the estimated number of bytes that are read.
+	Bytes int64 `json:"estimatedBytes"`
+}
+
+// Add adds the cost of other to e.
+func (e *CostEstimate) Add(other CostEstimate) {
+	e.SeriesN += other.SeriesN
+	e.PointsN += other.PointsN
+	e.Bytes += other.Bytes
+}
+
+// CostEstimateReader estimates the cost of a read without reading its values.
+type CostEstimateReader interface {
+	// EstimateReadFilter estimates the cost of a ReadFilter of spec.
+	EstimateReadFilter(ctx context.Context, spec ReadFilterSpec) (CostEstimate, error)
+}
+
 type ReadFilterSpec struct {
 	OrganizationID influxdb.ID
 	BucketID       influxdb.ID
 
 	Bounds    execute.Bounds
 	Predicate *datatypes.Predicate
+
+	// SeriesKeys, when set, restricts ReadFilter to the series identified
+	// by these keys, bypassing the index. Each key is of the form
+	// "m0,t0=v0,_field=f0". Keys that do not exist produce no tables.
+	SeriesKeys [][]byte
+
+	// CoerceToFloat, when set, causes ReadFilter to produce integer and
+	// unsigned fields as floats. Values with a magnitude greater than
+	// 2^53 cannot be represented exactly and lose precision.
+	CoerceToFloat bool
+
+	// ProgressFn, when set, is called after each table is read with the
+	// number of points read from storage since the previous call.
+	ProgressFn func(pointsRead int64)
+
+	// Unsorted, when set, allows a parallel ReadFilter to produce each
+	// table as soon as it is ready rather than in the order of the series
+	// keys. The order in which Do produces tables is then not
+	// deterministic. A serial read is not affected.
+	Unsorted bool
+
+	// ChunkDuration, when set, splits the bounds into consecutive
+	// sub-ranges of this duration that are read one after another so
+	// that the memory used for dense series is bounded. Each sub-range
+	// produces its own tables with the group key of the full bounds, so
+	// a series may produce more than one table.
+	ChunkDuration time.Duration
+
+	// SeriesKeyColumn, when set, adds a _series column to each table
+	// produced by ReadFilter with the key of its series, such as
+	// "m0,_field=f0,t0=v0". The key is in the same escaped form as
+	// SeriesKeys and can be parsed with models.ParseKey.
+	SeriesKeyColumn bool
+
+	// IncludeMissingFields, when set, causes ReadFilter to produce a
+	// table for each series that lacks a field read for another series
+	// of its measurement, or that has no points for a field within the
+	// bounds. The table has a single row whose _time and _value are null.
+	// A series without any of the fields read is not produced.
+	IncludeMissingFields bool
+
+	// MaxRows, when set, causes ReadFilter to fail once the tables it
+	// produces would contain more than this number of rows in total.
+	// The rows are counted as the tables are read.
+	MaxRows int
+
+	// SortTags, when set, causes ReadFilter to produce its tables ordered
+	// by the values of these tags of their group keys, compared in turn.
+	// Tables without a tag sort before those with it, and tables with the
+	// same values keep the order of their series. The tables are buffered
+	// in memory until they have all been read.
+	SortTags []string
+
+	// SortTagsDescending reverses the order of the SortTags.
+	SortTagsDescending bool
+
+	// ValueScale and ValueOffset, when either is set, convert each
+	// numeric _value to _value*ValueScale+ValueOffset, such as to
+	// convert bytes to megabytes. A zero ValueScale is read as one.
+	// Integer and unsigned fields are produced as floats unless the
+	// conversion keeps their values integral. A ReadWindowAggregate
+	// converts its aggregated values, other than those of count.
+	ValueScale  float64
+	ValueOffset float64
+
+	// ChangedSince, when set, restricts ReadFilter to the series that
+	// have at least one point strictly after this time within the
+	// bounds, such as to read the series updated since the last read
+	// of an incremental sync. The tables of those series have all of
+	// their points within the bounds.
+	ChangedSince values.Time
+
+	// DownsamplePoints, when set, reduces the points of each numeric
+	// table produced by ReadFilter to at most this number with the
+	// Largest-Triangle-Three-Buckets algorithm, which keeps the first
+	// and last points and the points that preserve the shape of the
+	// series when plotted. The points of a table are read into memory
+	// to be downsampled. String and boolean fields are not downsampled.
+	DownsamplePoints int
+
+	// Watermark, when set, causes ReadFilter to drop the points with a
+	// _time before it, such as points that arrive late for a part of the
+	// bounds that has already been read, as a streaming read would. The
+	// tables keep the group key of the full bounds.
+	Watermark values.Time
+
+	// FieldsAsTag produces a table for each series without its _field
+	// with the points of all of its fields ordered by time, and then by
+	// field. The name of the field of each point is in a field column
+	// that is not part of the group key. The fields of a series must
+	// have the same type. The tables are buffered in memory until they
+	// have all been read.
+	FieldsAsTag bool
 }
 
 type ReadGroupSpec struct {
@@ -74,6 +195,27 @@ type ReadGroupSpec struct {
 	GroupKeys []string
 
 	AggregateMethod string
+
+	// IncludeTimeSpan adds _start_time and _stop_time columns with the
+	// earliest and latest point time of each group. The groups are
+	// buffered in memory. It cannot be used with an AggregateMethod.
+	IncludeTimeSpan bool
+
+	// KeepColumns lists the tag columns whose values are taken from
+	// the row chosen by a first or last AggregateMethod. Without it,
+	// the value of a tag column that is not part of the group key may
+	// come from any series in the group.
+	KeepColumns []string
+
+	// ApproximateCountDistinct estimates the count_distinct aggregate
+	// with a HyperLogLog sketch rather than keeping every distinct value
+	// of a group in memory. The estimate has a small relative error.
+	ApproximateCountDistinct bool
+
+	// CreateEmpty produces a table for every group of the series that
+	// match the predicate, even when none of them have points within the
+	// bounds. The tables of such groups are empty.
+	CreateEmpty bool
 }
 
 func (spec *ReadGroupSpec) Name() string {
@@ -93,11 +235,130 @@ type ReadWindowAggregateSpec struct {
 	ReadFilterSpec
 	WindowEvery int64
 	Offset      int64
+
+	// OffsetRelativeTo is the origin from which the windows are offset
+	// by Offset. The windows are aligned to the Unix epoch by default.
+	OffsetRelativeTo OffsetRelativeTo
+
 	Aggregates  []plan.ProcedureKind
 	CreateEmpty bool
 	TimeColumn  string
+
+	// BoundsAsColumns removes _start and _stop from the group key
+	// so that the bounds of each window are kept as regular columns
+	// rather than producing a table for each window. It cannot be
+	// used with a TimeColumn.
+	BoundsAsColumns bool
+
+	// ValueColumn is the label of the output value column.
+	// It defaults to _value when empty.
+	ValueColumn string
+
+	// Rate divides the difference aggregate by the elapsed seconds
+	// between the first and last points of each window.
+	Rate bool
+
+	// NonNegative treats a decrease in the difference and rate
+	// aggregates as a counter reset rather than a negative difference.
+	NonNegative bool
+
+	// AllowIntegerOverflow permits the sum of an integer field to
+	// wrap around instead of returning an error when it overflows.
+	AllowIntegerOverflow bool
+
+	// WithCount adds an integer _count column with the number of
+	// points in each window. It may only be used with the mean aggregate.
+	WithCount bool
+
+	// IntegralUnit is the time unit of the integral aggregate in
+	// nanoseconds. It defaults to one second when zero.
+	IntegralUnit int64
+
+	// ValuePredicate filters the aggregated value of each window.
+	// Windows whose value does not match it are dropped, including
+	// those created by CreateEmpty. It may only reference the value.
+	ValuePredicate *datatypes.Predicate
+
+	// WindowBounds aggregates into exactly these windows, which may be
+	// of any length and need not be contiguous, rather than windows of
+	// WindowEvery. Points outside of every window are dropped. It cannot
+	// be used with WindowEvery or Offset.
+	WindowBounds []execute.Bounds
+
+	// SelectorTimeColumn is the label of a time column with the time
+	// of the point selected by the min or max aggregate in each window,
+	// such as _min_time. The column is kept separate from _time so that
+	// it is not replaced by the TimeColumn. It may only be used with
+	// the min and max aggregates.
+	SelectorTimeColumn string
+
+	// MovingAverage averages the mean of each window with the means of
+	// the MovingAverage-1 windows before it. Windows without points are
+	// not counted and no value is produced for the first MovingAverage-1
+	// windows. It may only be used with the mean aggregate.
+	MovingAverage int
+
+	// FillPrevious fills the windows without points that are created
+	// by CreateEmpty with the value of the last window before them
+	// that had a point. It may only be used with CreateEmpty and the
+	// first, last, min and max aggregates without a TimeColumn or
+	// WindowBounds.
+	FillPrevious bool
+
+	// MaterializeTo is the bucket of the organization to which the
+	// aggregated points are written as they are read. Each point keeps
+	// the measurement, field and tags of its series and the time of its
+	// window. The caller must be permitted to write to the bucket.
+	MaterializeTo influxdb.ID
+
+	// Pivot produces a table for each series without its _field with
+	// a row for each _time and a column for each field, named by the
+	// field, that holds its aggregated value. Columns other than the
+	// group key, _time and the value column are dropped. It requires
+	// a _time column, from a TimeColumn or a selector aggregate.
+	Pivot bool
+
+	// CountTimeColumn is the time of the _time column produced with
+	// the count aggregate: "start" or "stop" for a bound of the window,
+	// like TimeColumn, or "first" or "last" for the time of the first or
+	// last point counted in the window. Windows without points created
+	// by CreateEmpty have the stop of the window. It replaces the
+	// TimeColumn and may only be used with the count aggregate.
+	CountTimeColumn string
+
+	// ActualStart adds an _actual_start column with the time of the
+	// earliest point of each window, which is later than its _start
+	// when the window has no point at its start, such as a partial
+	// window truncated by the bounds. It is null for windows without
+	// points. The points are read twice. It cannot be used with
+	// WindowBounds or Pivot.
+	ActualStart bool
+
+	// WeightField weights each value of the mean aggregate by the value
+	// of this field of the same series at the same time. Points without
+	// a weight are skipped and the series of the field itself are not
+	// aggregated. It may only be used with the mean aggregate without
+	// WithCount or MovingAverage.
+	WeightField string
 }
 
+// OffsetRelativeTo is the origin of the Offset of the windows of
+// a ReadWindowAggregateSpec.
+type OffsetRelativeTo int
+
+const (
+	// OffsetRelativeToEpoch starts a window at every multiple of the
+	// window period after the Unix epoch, shifted by the offset, so the
+	// windows of reads with different bounds are aligned with each other.
+	OffsetRelativeToEpoch OffsetRelativeTo = iota
+
+	// OffsetRelativeToBoundsStart starts a window at every multiple of
+	// the window period after the start of the bounds, shifted by the
+	// offset, so that the first window starts at the start of the
+	// bounds when the offset is zero.
+	OffsetRelativeToBoundsStart
+)
+
 func (spec *ReadWindowAggregateSpec) Name() string {
 	var agg string
 	if len(spec.Aggregates) > 0 {
@@ -106,6 +367,17 @@ func (spec *ReadWindowAggregateSpec) Name() string {
 	return fmt.Sprintf("readWindow(%s)", agg)
 }
 
+// ReadHistogramSpec describes the windows of a histogram read.
+type ReadHistogramSpec struct {
+	ReadFilterSpec
+
+	// WindowEvery and Offset divide the bounds into windows like
+	// ReadWindowAggregateSpec. The bounds are a single window
+	// when WindowEvery is zero.
+	WindowEvery int64
+	Offset      int64
+}
+
 // TableIterator is a table iterator that also keeps track of cursor statistics from the storage engine.
 type TableIterator interface {
 	flux.TableIterator
@@ -0,0 +1,168 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kv"
+)
+
+var kvStoreBucket = []byte("sessionsv2")
+
+// KVStore is a ScanStore that keeps its keys in a kv.Store, such as
+// bolt, so that sessions persist across restarts. An expired key is
+// not returned by Get and is removed by the next Scan.
+type KVStore struct {
+	kvStore kv.Store
+}
+
+// NewKVStore creates a store over the sessions bucket of kvStore,
+// which is created by the kv migrations.
+func NewKVStore(kvStore kv.Store) *KVStore {
+	return &KVStore{kvStore: kvStore}
+}
+
+// kvEntry is a value of the store with its expiration.
+type kvEntry struct {
+	Value    string    `json:"value"`
+	ExpireAt time.Time `json:"expireAt,omitempty"`
+}
+
+func (e *kvEntry) expired(now time.Time) bool {
+	return !e.ExpireAt.IsZero() && !e.ExpireAt.After(now)
+}
+
+func (s *KVStore) Set(key, val string, expireAt time.Time) error {
+	if !expireAt.IsZero() && expireAt.Before(time.Now()) {
+		// key is already expired. no problem
+		return nil
+	}
+
+	return s.kvStore.Update(context.Background(), func(tx kv.Tx) error {
+		return putKVEntry(tx, key, &kvEntry{Value: val, ExpireAt: expireAt})
+	})
+}
+
+func (s *KVStore) Get(key string) (string, error) {
+	var val string
+	err := s.kvStore.View(context.Background(), func(tx kv.Tx) error {
+		e, err := getKVEntry(tx, key)
+		if err != nil || e == nil || e.expired(time.Now()) {
+			return err
+		}
+		val = e.Value
+		return nil
+	})
+	return val, err
+}
+
+func (s *KVStore) Delete(key string) error {
+	return s.kvStore.Update(context.Background(), func(tx kv.Tx) error {
+		b, err := tx.Bucket(kvStoreBucket)
+		if err != nil {
+			return err
+		}
+		return b.Delete([]byte(key))
+	})
+}
+
+func (s *KVStore) ExpireAt(key string, expireAt time.Time) error {
+	return s.kvStore.Update(context.Background(), func(tx kv.Tx) error {
+		e, err := getKVEntry(tx, key)
+		if err != nil || e == nil {
+			return err
+		}
+		e.ExpireAt = expireAt
+		return putKVEntry(tx, key, e)
+	})
+}
+
+// Scan calls fn for each key with the prefix that has not expired and
+// removes the keys that have. The keys are copied before fn is called
+// so that fn may modify the store.
+func (s *KVStore) Scan(prefix string, fn func(key, val string) error) error {
+	var keys, vals []string
+	if err := s.kvStore.Update(context.Background(), func(tx kv.Tx) error {
+		b, err := tx.Bucket(kvStoreBucket)
+		if err != nil {
+			return err
+		}
+		cur, err := b.ForwardCursor([]byte(prefix), kv.WithCursorPrefix([]byte(prefix)))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var expired [][]byte
+		for k, v := cur.Next(); k != nil; k, v = cur.Next() {
+			var e kvEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				cur.Close()
+				return err
+			}
+			if e.expired(now) {
+				expired = append(expired, append([]byte(nil), k...))
+				continue
+			}
+			keys = append(keys, string(k))
+			vals = append(vals, e.Value)
+		}
+		if err := cur.Err(); err != nil {
+			cur.Close()
+			return err
+		}
+		if err := cur.Close(); err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for i := range keys {
+		if err := fn(keys[i], vals[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getKVEntry returns the entry of key, or nil if there is none.
+func getKVEntry(tx kv.Tx, key string) (*kvEntry, error) {
+	b, err := tx.Bucket(kvStoreBucket)
+	if err != nil {
+		return nil, err
+	}
+	v, err := b.Get([]byte(key))
+	if kv.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e := &kvEntry{}
+	if err := json.Unmarshal(v, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func putKVEntry(tx kv.Tx, key string, e *kvEntry) error {
+	b, err := tx.Bucket(kvStoreBucket)
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), v)
+}
@@ -0,0 +1,69 @@
+package session_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"github.com/influxdata/influxdb/v2/session"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestKVStore_Restart(t *testing.T) {
+	f, err := ioutil.TempFile("", "influxdata-bolt-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	path := f.Name()
+	defer os.Remove(path)
+
+	ctx := context.Background()
+	open := func() *bolt.KVStore {
+		s := bolt.NewKVStore(zaptest.NewLogger(t), path, bolt.WithNoSync)
+		if err := s.Open(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := all.Up(ctx, zaptest.NewLogger(t), s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	expected := &influxdb.Session{
+		ID:        1,
+		Key:       "2",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	kvStore := open()
+	if err := session.NewStorage(session.NewKVStore(kvStore)).CreateSession(ctx, expected); err != nil {
+		t.Fatal(err)
+	}
+	if err := kvStore.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The session is still valid after the store is reopened.
+	kvStore = open()
+	defer kvStore.Close()
+
+	storage := session.NewStorage(session.NewKVStore(kvStore))
+	got, err := storage.FindSessionByKey(ctx, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got, expected) {
+		t.Fatalf("expected identical sessions: \n%+v\n%+v", got, expected)
+	}
+	if err := got.Expired(); err != nil {
+		t.Fatalf("expected a valid session: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/rand"
 	"github.com/influxdata/influxdb/v2/snowflake"
+	"go.uber.org/zap"
 )
 
 // Service implements the influxdb.SessionService interface and
@@ -99,6 +100,35 @@ func (s *Service) FindSession(ctx context.Context, key string) (*influxdb.Sessio
 	return session, nil
 }
 
+// ReapExpiredSessions removes the sessions that have expired
+// and returns the number of sessions that were removed.
+func (s *Service) ReapExpiredSessions(ctx context.Context) (int, error) {
+	return s.store.DeleteExpiredSessions(ctx, time.Now())
+}
+
+// RunReaper removes expired sessions every interval until the
+// context is canceled.
+func (s *Service) RunReaper(ctx context.Context, log *zap.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.ReapExpiredSessions(ctx)
+			if err != nil {
+				log.Error("Failed to reap expired sessions", zap.Error(err))
+				continue
+			}
+			if n > 0 {
+				log.Debug("Reaped expired sessions", zap.Int("count", n))
+			}
+		}
+	}
+}
+
 // ExpireSession removes a session from the system
 func (s *Service) ExpireSession(ctx context.Context, key string) error {
 	session, err := s.store.FindSessionByKey(ctx, key)
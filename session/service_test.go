@@ -2,6 +2,7 @@ package session
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -56,3 +57,64 @@ func initSessionService(f influxdbtesting.SessionFields, t *testing.T) (influxdb
 	}
 	return svc, "session", func() {}
 }
+
+func TestSessionService_RunReaper(t *testing.T) {
+	store := inmem.NewSessionStore()
+	ss := NewStorage(store)
+	svc := NewService(ss, nil, nil, nil)
+
+	ctx := context.Background()
+	live := &influxdb.Session{
+		ID:        1,
+		Key:       "live",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := ss.CreateSession(ctx, live); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the expired session without an expiration so that it is
+	// retained by the store, as if its expiration had been missed.
+	expired := &influxdb.Session{
+		ID:        2,
+		Key:       "expired",
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+	b, err := json.Marshal(expired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(sessionID(expired.ID), string(b), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(sessionIndexKey(expired.Key), expired.ID.String(), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	reapCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svc.RunReaper(reapCtx, zaptest.NewLogger(t), 10*time.Millisecond)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := ss.FindSessionByID(ctx, expired.ID)
+		if influxdb.ErrorCode(err) == influxdb.ENotFound {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("expired session was not reaped: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if _, err := ss.FindSessionByKey(ctx, expired.Key); influxdb.ErrorCode(err) != influxdb.ENotFound {
+		t.Errorf("expected session index to be removed, got %v", err)
+	}
+	if _, err := ss.FindSessionByID(ctx, live.ID); err != nil {
+		t.Errorf("expected live session to remain: %v", err)
+	}
+}
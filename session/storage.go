@@ -15,6 +15,15 @@ type Store interface {
 	ExpireAt(key string, expireAt time.Time) error
 }
 
+// ScanStore is a Store that can iterate over its keys. It is
+// required to delete expired sessions in the background.
+type ScanStore interface {
+	Store
+
+	// Scan calls fn for each key with the prefix and its value.
+	Scan(prefix string, fn func(key, val string) error) error
+}
+
 var storePrefix = "sessionsv2/"
 var storeIndex = "sessionsindexv2/"
 
@@ -127,6 +136,37 @@ func (s *Storage) DeleteSession(ctx context.Context, id influxdb.ID) error {
 	return nil
 }
 
+// DeleteExpiredSessions removes the sessions that expired before now and
+// returns the number of sessions that were removed. If the underlying
+// store cannot be scanned, no sessions are removed.
+func (s *Storage) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	ss, ok := s.store.(ScanStore)
+	if !ok {
+		return 0, nil
+	}
+
+	var expired []influxdb.ID
+	if err := ss.Scan(storePrefix, func(key, val string) error {
+		session := &influxdb.Session{}
+		if err := json.Unmarshal([]byte(val), session); err != nil {
+			return err
+		}
+		if session.ExpiresAt.Before(now) {
+			expired = append(expired, session.ID)
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	for _, id := range expired {
+		if err := s.DeleteSession(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
+
 func sessionID(id influxdb.ID) string {
 	return storePrefix + id.String()
 }
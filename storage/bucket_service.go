@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
@@ -21,15 +22,41 @@ type BucketDeleter interface {
 type BucketService struct {
 	inner  influxdb.BucketService
 	engine BucketDeleter
+
+	defaultRetention  time.Duration
+	defaultSchemaType influxdb.SchemaType
+}
+
+// BucketServiceOption configures a BucketService.
+type BucketServiceOption func(*BucketService)
+
+// WithDefaultRetention sets the retention period of buckets
+// that are created without one. A value of 0 keeps data forever.
+func WithDefaultRetention(d time.Duration) BucketServiceOption {
+	return func(s *BucketService) {
+		s.defaultRetention = d
+	}
+}
+
+// WithDefaultSchemaType sets the schema type of user buckets that are
+// created without one. System buckets are never given a schema type.
+func WithDefaultSchemaType(t influxdb.SchemaType) BucketServiceOption {
+	return func(s *BucketService) {
+		s.defaultSchemaType = t
+	}
 }
 
 // NewBucketService returns a new BucketService for the provided BucketDeleter,
 // which typically will be an Engine.
-func NewBucketService(s influxdb.BucketService, engine BucketDeleter) *BucketService {
-	return &BucketService{
+func NewBucketService(s influxdb.BucketService, engine BucketDeleter, opts ...BucketServiceOption) *BucketService {
+	bs := &BucketService{
 		inner:  s,
 		engine: engine,
 	}
+	for _, opt := range opts {
+		opt(bs)
+	}
+	return bs
 }
 
 // FindBucketByID returns a single bucket by ID.
@@ -78,6 +105,8 @@ func (s *BucketService) FindBuckets(ctx context.Context, filter influxdb.BucketF
 }
 
 // CreateBucket creates a new bucket and sets b.ID with the new identifier.
+// Buckets without a retention period are given the default retention and
+// user buckets without a schema type are given the default schema type.
 func (s *BucketService) CreateBucket(ctx context.Context, b *influxdb.Bucket) error {
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
@@ -85,6 +114,12 @@ func (s *BucketService) CreateBucket(ctx context.Context, b *influxdb.Bucket) er
 	if s.inner == nil || s.engine == nil {
 		return errors.New("nil inner BucketService or Engine")
 	}
+	if b.RetentionPeriod == 0 {
+		b.RetentionPeriod = s.defaultRetention
+	}
+	if b.SchemaType == "" && b.Type != influxdb.BucketTypeSystem {
+		b.SchemaType = s.defaultSchemaType
+	}
 	return s.inner.CreateBucket(ctx, b)
 }
 
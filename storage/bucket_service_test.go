@@ -3,6 +3,7 @@ package storage_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/inmem"
@@ -56,6 +57,76 @@ func TestBucketService(t *testing.T) {
 	}
 }
 
+func TestBucketService_CreateBucket_DefaultRetention(t *testing.T) {
+	inmemService := newInMemKVSVC(t)
+	service := storage.NewBucketService(inmemService, &MockDeleter{}, storage.WithDefaultRetention(72*time.Hour))
+
+	org := &influxdb.Organization{Name: "org1"}
+	if err := inmemService.CreateOrganization(context.TODO(), org); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		retention time.Duration
+		exp       time.Duration
+	}{
+		{name: "default", retention: 0, exp: 72 * time.Hour},
+		{name: "explicit", retention: 2 * time.Hour, exp: 2 * time.Hour},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := &influxdb.Bucket{OrgID: org.ID, Name: tt.name, RetentionPeriod: tt.retention}
+			if err := service.CreateBucket(context.TODO(), bucket); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := inmemService.FindBucketByID(context.TODO(), bucket.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.RetentionPeriod != tt.exp {
+				t.Errorf("got retention period %s, expected %s", got.RetentionPeriod, tt.exp)
+			}
+		})
+	}
+}
+
+func TestBucketService_CreateBucket_DefaultSchemaType(t *testing.T) {
+	inmemService := newInMemKVSVC(t)
+	service := storage.NewBucketService(inmemService, &MockDeleter{}, storage.WithDefaultSchemaType(influxdb.SchemaTypeExplicit))
+
+	org := &influxdb.Organization{Name: "org1"}
+	if err := inmemService.CreateOrganization(context.TODO(), org); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		typ        influxdb.BucketType
+		schemaType influxdb.SchemaType
+		exp        influxdb.SchemaType
+	}{
+		{name: "default", exp: influxdb.SchemaTypeExplicit},
+		{name: "implicit", schemaType: influxdb.SchemaTypeImplicit, exp: influxdb.SchemaTypeImplicit},
+		{name: "_system", typ: influxdb.BucketTypeSystem, exp: ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := &influxdb.Bucket{OrgID: org.ID, Name: tt.name, Type: tt.typ, SchemaType: tt.schemaType}
+			if err := service.CreateBucket(context.TODO(), bucket); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := inmemService.FindBucketByID(context.TODO(), bucket.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.SchemaType != tt.exp {
+				t.Errorf("got schema type %q, expected %q", got.SchemaType, tt.exp)
+			}
+		})
+	}
+}
+
 type MockDeleter struct {
 	orgID, bucketID influxdb.ID
 }
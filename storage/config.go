@@ -41,6 +41,29 @@ type Config struct {
 	// Index config.
 	Index     tsi1.Config `toml:"index"`
 	IndexPath string      `toml:"index-path"` // Overrides the default path.
+
+	// AllowPartialOpen lets the engine open when write entries of the WAL
+	// cannot be replayed. The entries are skipped and the engine is
+	// degraded. The points of the skipped entries are lost for good once
+	// the cache is next snapshotted and the WAL segments are removed, so
+	// the WAL should be backed up first. Delete entries that cannot be
+	// replayed still fail the open, as skipping them would bring the
+	// deleted data back.
+	AllowPartialOpen bool `toml:"allow-partial-open"`
+
+	// SnapshotOnShutdown snapshots the cache to TSM files when the engine
+	// is closed so that the WAL does not need to be replayed on open.
+	SnapshotOnShutdown bool `toml:"snapshot-on-shutdown"`
+
+	// MaxSeriesPerBucket is the number of series a bucket may have before
+	// writes that create new series in it are rejected. Points of existing
+	// series are still written. A value of 0 disables the limit.
+	MaxSeriesPerBucket int `toml:"max-series-per-bucket"`
+
+	// WriteRateLimitPerBucket is the number of points per second that may
+	// be written to each bucket. Writes over the limit are rejected with
+	// ETooManyRequests. A value of 0 disables the limit.
+	WriteRateLimitPerBucket int `toml:"write-rate-limit-per-bucket"`
 }
 
 // NewConfig initialises a new config for an Engine.
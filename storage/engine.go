@@ -67,6 +67,14 @@ type Engine struct {
 
 	writePointsValidationEnabled bool
 
+	// writeLimiter limits the write rate of each bucket. It is nil
+	// when Config.WriteRateLimitPerBucket is not set.
+	writeLimiter *writeLimiter
+
+	// degraded is the first error skipped while opening
+	// the engine when Config.AllowPartialOpen is set.
+	degraded error
+
 	// Tracks all goroutines started by the Engine.
 	wg sync.WaitGroup
 
@@ -176,6 +184,16 @@ func WithPageFaultLimiter(limiter *rate.Limiter) Option {
 	}
 }
 
+// WithBucketPageFaultLimiter allows the caller to limit the page faults of
+// reading the TSM data of each bucket separately. The limiter set with
+// WithPageFaultLimiter still applies to buckets that are not limited
+// separately, and to the index and series file.
+func WithBucketPageFaultLimiter(limiter *tsm1.BucketPageFaultLimiter) Option {
+	return func(e *Engine) {
+		e.engine.WithBucketPageFaultLimiter(limiter)
+	}
+}
+
 // NewEngine initialises a new storage engine, including a series file, index and
 // TSM engine.
 func NewEngine(path string, c Config, options ...Option) *Engine {
@@ -188,6 +206,10 @@ func NewEngine(path string, c Config, options ...Option) *Engine {
 		writePointsValidationEnabled: true,
 	}
 
+	if c.WriteRateLimitPerBucket > 0 {
+		e.writeLimiter = newWriteLimiter(c.WriteRateLimitPerBucket)
+	}
+
 	// Initialize series file.
 	e.sfile = seriesfile.NewSeriesFile(c.GetSeriesFilePath(path))
 	e.sfile.LargeWriteThreshold = c.SeriesFile.LargeSeriesWriteThreshold
@@ -278,9 +300,13 @@ func (e *Engine) Open(ctx context.Context) (err error) {
 		return err
 	}
 
+	e.degraded = nil
 	if err := e.replayWAL(); err != nil {
 		return err
 	}
+	if e.degraded != nil {
+		e.logger.Warn("Engine opened in degraded mode", zap.Error(e.degraded))
+	}
 
 	e.closing = make(chan struct{})
 
@@ -318,27 +344,19 @@ func (e *Engine) replayWAL() error {
 	reader := wal.NewWALReader(walPaths)
 	reader.WithLogger(e.logger)
 	err = reader.Read(func(entry wal.WALEntry) error {
-		switch en := entry.(type) {
-		case *wal.WriteWALEntry:
-			points := tsm1.ValuesToPoints(en.Values)
-			err := e.writePointsLocked(context.Background(), tsdb.NewSeriesCollection(points), en.Values)
-			if _, ok := err.(tsdb.PartialWriteError); ok {
-				err = nil
-			}
+		err := e.replayWALEntry(entry)
+		if err == nil || !e.config.AllowPartialOpen {
 			return err
-
-		case *wal.DeleteBucketRangeWALEntry:
-			var pred tsm1.Predicate
-			if len(en.Predicate) > 0 {
-				pred, err = tsm1.UnmarshalPredicate(en.Predicate)
-				if err != nil {
-					return err
-				}
-			}
-
-			return e.deleteBucketRangeLocked(context.Background(), en.OrgID, en.BucketID, en.Min, en.Max, pred)
 		}
-
+		// Skipping a delete would bring the deleted data back,
+		// so the engine is never opened without it.
+		if _, ok := entry.(*wal.DeleteBucketRangeWALEntry); ok {
+			return err
+		}
+		e.logger.Error("Skipping WAL write entry that cannot be replayed; its points are lost once the WAL is snapshotted", zap.Error(err))
+		if e.degraded == nil {
+			e.degraded = fmt.Errorf("skipped WAL write entries whose points are lost once the WAL is snapshotted: %v", err)
+		}
 		return nil
 	})
 
@@ -350,6 +368,41 @@ func (e *Engine) replayWAL() error {
 	return err
 }
 
+// replayWALEntry applies an entry of the WAL to the engine.
+func (e *Engine) replayWALEntry(entry wal.WALEntry) error {
+	switch en := entry.(type) {
+	case *wal.WriteWALEntry:
+		points := tsm1.ValuesToPoints(en.Values)
+		err := e.writePointsLocked(context.Background(), tsdb.NewSeriesCollection(points), en.Values)
+		if _, ok := err.(tsdb.PartialWriteError); ok {
+			err = nil
+		}
+		return err
+
+	case *wal.DeleteBucketRangeWALEntry:
+		var pred tsm1.Predicate
+		if len(en.Predicate) > 0 {
+			var err error
+			pred, err = tsm1.UnmarshalPredicate(en.Predicate)
+			if err != nil {
+				return err
+			}
+		}
+
+		return e.deleteBucketRangeLocked(context.Background(), en.OrgID, en.BucketID, en.Min, en.Max, pred)
+	}
+
+	return nil
+}
+
+// Degraded returns the first error that was skipped while opening the
+// engine with Config.AllowPartialOpen set, or nil if it opened fully.
+func (e *Engine) Degraded() error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.degraded
+}
+
 // EnableCompactions allows the series file, index, & underlying engine to compact.
 func (e *Engine) EnableCompactions() {
 	e.sfile.EnableCompactions()
@@ -433,6 +486,14 @@ func (e *Engine) runRetentionEnforcer() {
 // Close closes the store and all underlying resources. It returns an error if
 // any of the underlying systems fail to close.
 func (e *Engine) Close() error {
+	return e.CloseContext(context.Background())
+}
+
+// CloseContext closes the engine. When Config.SnapshotOnShutdown is set
+// the cache is snapshotted to TSM files before the engine is closed. If
+// ctx is done before the snapshot completes the snapshot is interrupted
+// and its data is replayed from the WAL when the engine is next opened.
+func (e *Engine) CloseContext(ctx context.Context) error {
 	e.mu.RLock()
 	if e.closing == nil {
 		e.mu.RUnlock()
@@ -447,6 +508,10 @@ func (e *Engine) Close() error {
 	// Wait for any other goroutines to finish.
 	e.wg.Wait()
 
+	if e.config.SnapshotOnShutdown {
+		e.snapshotOnShutdown(ctx)
+	}
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	e.closing = nil
@@ -459,6 +524,31 @@ func (e *Engine) Close() error {
 	return ch.Done()
 }
 
+// snapshotOnShutdown snapshots the cache, interrupting
+// the snapshot if ctx is done before it completes.
+func (e *Engine) snapshotOnShutdown(ctx context.Context) {
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		select {
+		case <-ctx.Done():
+			// Aborts the snapshot in progress.
+			e.engine.SetCompactionsEnabled(false)
+		case <-done:
+		}
+	}()
+
+	err := e.engine.WriteSnapshot(ctx, tsm1.CacheStatusShutdown)
+	close(done)
+	wg.Wait()
+
+	if err != nil {
+		e.logger.Warn("Failed to snapshot cache on shutdown", zap.Error(err))
+	}
+}
+
 // CreateSeriesCursor creates a SeriesCursor for usage with the read service.
 func (e *Engine) CreateSeriesCursor(ctx context.Context, orgID, bucketID influxdb.ID, cond influxql.Expr) (SeriesCursor, error) {
 	e.mu.RLock()
@@ -560,6 +650,20 @@ func (e *Engine) WritePoints(ctx context.Context, points []models.Point) error {
 		return ErrEngineClosed
 	}
 
+	// Reject the whole batch if any of its buckets is writing too fast.
+	if e.writeLimiter != nil {
+		if err := e.writeLimiter.allow(collection); err != nil {
+			return err
+		}
+	}
+
+	// Drop the points of new series beyond the limit of their bucket
+	// and report them once the remaining points are written.
+	limitErr := e.limitSeries(collection)
+	if limitErr != nil && collection.Length() == 0 {
+		return limitErr
+	}
+
 	// Convert the collection to values for adding to the WAL/Cache.
 	values, err := tsm1.CollectionToValues(collection)
 	if err != nil {
@@ -571,7 +675,10 @@ func (e *Engine) WritePoints(ctx context.Context, points []models.Point) error {
 		return err
 	}
 
-	return e.writePointsLocked(ctx, collection, values)
+	if err := e.writePointsLocked(ctx, collection, values); err != nil {
+		return err
+	}
+	return limitErr
 }
 
 // writePointsLocked does the work of writing points and must be called under some sort of lock.
@@ -644,7 +751,13 @@ func (e *Engine) CommitSegments(ctx context.Context, segs []string, fn func() er
 func (e *Engine) DeleteBucket(ctx context.Context, orgID, bucketID influxdb.ID) error {
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
-	return e.DeleteBucketRange(ctx, orgID, bucketID, math.MinInt64, math.MaxInt64)
+	if err := e.DeleteBucketRange(ctx, orgID, bucketID, math.MinInt64, math.MaxInt64); err != nil {
+		return err
+	}
+	if e.writeLimiter != nil {
+		e.writeLimiter.remove(orgID, bucketID)
+	}
+	return nil
 }
 
 // DeleteBucketRange deletes an entire bucket from the storage engine.
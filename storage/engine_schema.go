@@ -36,3 +36,53 @@ func (e *Engine) TagValues(ctx context.Context, orgID, bucketID influxdb.ID, tag
 
 	return e.engine.TagValues(ctx, orgID, bucketID, tagKey, start, end, predicate)
 }
+
+// MayHaveDataInRange reports whether the bucket may contain data within
+// the time range [start, end]. It never returns false when data exists.
+func (e *Engine) MayHaveDataInRange(orgID, bucketID influxdb.ID, start, end int64) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return false
+	}
+
+	return e.engine.MayHaveDataInRange(orgID, bucketID, start, end)
+}
+
+// ReadBlockStats returns the TSM blocks of the bucket that overlap the
+// time range [start, end]. It is intended for debugging.
+func (e *Engine) ReadBlockStats(ctx context.Context, orgID, bucketID influxdb.ID, start, end int64) ([]cursors.BlockStat, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return nil, nil
+	}
+
+	return e.engine.ReadBlockStats(ctx, orgID, bucketID, start, end)
+}
+
+// PrefetchBlocks pages the TSM blocks of the bucket that overlap the
+// time range [start, end] into the OS page cache without decoding them.
+// If include is not nil, only the blocks of the series keys it returns
+// true for are paged in. It returns the number of bytes paged in.
+func (e *Engine) PrefetchBlocks(ctx context.Context, orgID, bucketID influxdb.ID, start, end int64, include func(seriesKey []byte) bool) (int64, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return 0, ErrEngineClosed
+	}
+
+	return e.engine.PrefetchBlocks(ctx, orgID, bucketID, start, end, include)
+}
+
+// BucketSize returns an estimate of the number of bytes used by the bucket,
+// computed from the sizes of the TSM files and cache.
+func (e *Engine) BucketSize(ctx context.Context, orgID, bucketID influxdb.ID) (int64, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return 0, ErrEngineClosed
+	}
+
+	return e.engine.BucketSize(orgID, bucketID), nil
+}
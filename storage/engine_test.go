@@ -7,16 +7,23 @@ import (
 	"math"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
 	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/data/gen"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/storage/wal"
+	"github.com/influxdata/influxdb/v2/toml"
 	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"github.com/influxdata/influxdb/v2/tsdb/seriesfile"
 	"github.com/influxdata/influxdb/v2/tsdb/tsm1"
+	"github.com/influxdata/influxdb/v2/tsdb/value"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -203,6 +210,52 @@ func TestEngine_WriteAddNewField(t *testing.T) {
 	}
 }
 
+func TestEngine_WALFsyncDelay(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	c := storage.NewConfig()
+	c.WAL.FsyncDelay = toml.Duration(delay)
+	engine := NewEngine(c, rand.Int(), rand.Int())
+	defer engine.Close()
+	engine.MustOpen()
+
+	start := time.Now()
+	err := engine.Engine.WritePoints(context.TODO(), []models.Point{models.MustNewPoint(
+		tsdb.EncodeNameString(engine.org, engine.bucket),
+		models.NewTags(map[string]string{models.FieldKeyTagKey: "value", models.MeasurementTagKey: "cpu", "host": "server"}),
+		map[string]interface{}{"value": 1.0},
+		time.Unix(1, 2),
+	)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The write waits for the delayed fsync before returning.
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Fatalf("write returned after %v, exp at least %v", elapsed, delay)
+	}
+
+	// The write is durable in the WAL once it has returned.
+	paths, err := wal.SegmentFileNames(c.GetWALPath(engine.path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := wal.NewWALReader(paths).Read(func(entry wal.WALEntry) error {
+		if w, ok := entry.(*wal.WriteWALEntry); ok {
+			for _, values := range w.Values {
+				n += len(values)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := n, 1; got != exp {
+		t.Fatalf("got %d values in WAL, exp %d", got, exp)
+	}
+}
+
 func TestEngine_DeleteBucket(t *testing.T) {
 	engine := NewDefaultEngine()
 	defer engine.Close()
@@ -255,6 +308,170 @@ func TestEngine_DeleteBucket(t *testing.T) {
 	}
 }
 
+func TestEngine_BucketSize(t *testing.T) {
+	engine := NewDefaultEngine()
+	defer engine.Close()
+	engine.MustOpen()
+
+	write := func(n int) {
+		t.Helper()
+		points := make([]models.Point, 0, n)
+		for i := 0; i < n; i++ {
+			points = append(points, models.MustNewPoint(
+				tsdb.EncodeNameString(engine.org, engine.bucket),
+				models.NewTags(map[string]string{models.FieldKeyTagKey: "value", models.MeasurementTagKey: "cpu", "host": "server"}),
+				map[string]interface{}{"value": float64(i)},
+				time.Unix(int64(i), 0),
+			))
+		}
+		if err := engine.Engine.WritePoints(context.TODO(), points); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	size := func(orgID, bucketID influxdb.ID) int64 {
+		t.Helper()
+		n, err := engine.BucketSize(context.Background(), orgID, bucketID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if got := size(engine.org, engine.bucket); got != 0 {
+		t.Fatalf("got size %d for empty bucket, exp 0", got)
+	}
+
+	write(100)
+	before := size(engine.org, engine.bucket)
+	if before <= 0 {
+		t.Fatalf("got size %d, exp size > 0", before)
+	}
+
+	write(1000)
+	after := size(engine.org, engine.bucket)
+	if after <= before {
+		t.Fatalf("got size %d after writing more data, exp size > %d", after, before)
+	}
+
+	// Other buckets are not affected.
+	bucketID, _ := influxdb.IDFromString("8888888888888888")
+	if got := size(engine.org, *bucketID); got != 0 {
+		t.Fatalf("got size %d for other bucket, exp 0", got)
+	}
+}
+
+func TestEngine_MaxSeriesPerBucket(t *testing.T) {
+	config := storage.NewConfig()
+	config.MaxSeriesPerBucket = 10
+	engine := NewEngine(config, rand.Int(), rand.Int())
+	defer engine.Close()
+	engine.MustOpen()
+
+	// points returns a point for each of n series of the bucket.
+	points := func(bucket influxdb.ID, n int, ts int64) []models.Point {
+		tags := gen.NewTagsValuesSequenceCounts("cpu", "value", "tag", []int{n})
+		var points []models.Point
+		for tags.Next() {
+			points = append(points, models.MustNewPoint(
+				tsdb.EncodeNameString(engine.org, bucket),
+				tags.Value().Clone(),
+				map[string]interface{}{"value": 1.0},
+				time.Unix(ts, 0),
+			))
+		}
+		return points
+	}
+
+	if err := engine.Engine.WritePoints(context.TODO(), points(engine.bucket, 10, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// New series are rejected once the bucket has reached its limit.
+	err := engine.Engine.WritePoints(context.TODO(), points(engine.bucket, 15, 2))
+	if got, exp := influxdb.ErrorCode(err), influxdb.EInvalid; got != exp {
+		t.Fatalf("got error code %q, exp %q: %v", got, exp, err)
+	}
+	if got, exp := engine.SeriesCardinality(), int64(10); got != exp {
+		t.Fatalf("got %d series, exp %d series in index", got, exp)
+	}
+
+	// Existing series still accept points.
+	if err := engine.Engine.WritePoints(context.TODO(), points(engine.bucket, 10, 3)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Other buckets are not affected.
+	if err := engine.Engine.WritePoints(context.TODO(), points(engine.bucket+1, 10, 1)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEngine_WriteRateLimitPerBucket(t *testing.T) {
+	config := storage.NewConfig()
+	config.WriteRateLimitPerBucket = 10
+	engine := NewEngine(config, rand.Int(), rand.Int())
+	defer engine.Close()
+	engine.MustOpen()
+
+	tags := models.NewTags(map[string]string{models.FieldKeyTagKey: "value", models.MeasurementTagKey: "cpu"})
+
+	// points returns n points of a single series of the bucket.
+	points := func(bucket influxdb.ID, n int, ts int64) []models.Point {
+		var points []models.Point
+		for i := 0; i < n; i++ {
+			points = append(points, models.MustNewPoint(
+				tsdb.EncodeNameString(engine.org, bucket),
+				tags,
+				map[string]interface{}{"value": 1.0},
+				time.Unix(ts+int64(i), 0),
+			))
+		}
+		return points
+	}
+
+	if err := engine.Engine.WritePoints(context.TODO(), points(engine.bucket, 10, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes are rejected once the bucket has used up its limit.
+	err := engine.Engine.WritePoints(context.TODO(), points(engine.bucket, 10, 10))
+	if got, exp := influxdb.ErrorCode(err), influxdb.ETooManyRequests; got != exp {
+		t.Fatalf("got error code %q, exp %q: %v", got, exp, err)
+	}
+
+	// Other buckets are not affected.
+	if err := engine.Engine.WritePoints(context.TODO(), points(engine.bucket+1, 10, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The points accepted before the limit was reached are kept.
+	itr, err := engine.CreateCursorIterator(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur, err := itr.Next(context.Background(), &cursors.CursorRequest{
+		Name:      []byte(tsdb.EncodeNameString(engine.org, engine.bucket)),
+		Tags:      tags,
+		Field:     "value",
+		Ascending: true,
+		StartTime: math.MinInt64,
+		EndTime:   math.MaxInt64,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cur.Close()
+
+	var n int
+	for a := cur.(cursors.FloatArrayCursor).Next(); a.Len() > 0; a = cur.(cursors.FloatArrayCursor).Next() {
+		n += a.Len()
+	}
+	if got, exp := n, 10; got != exp {
+		t.Fatalf("got %d points, exp %d", got, exp)
+	}
+}
+
 func TestEngine_DeleteBucket_Predicate(t *testing.T) {
 	engine := NewDefaultEngine()
 	defer engine.Close()
@@ -369,6 +586,151 @@ func TestEngine_OpenClose(t *testing.T) {
 	}
 }
 
+func TestEngine_AllowPartialOpen(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		// corrupt appends an entry that cannot be replayed to the WAL.
+		corrupt func(t *testing.T, w *wal.WAL, org, bucket influxdb.ID, pt models.Point)
+		// opens is whether the engine opens in degraded mode.
+		opens bool
+	}{
+		{
+			// A value of another type than the value of the same field in the cache.
+			name: "write",
+			corrupt: func(t *testing.T, w *wal.WAL, org, bucket influxdb.ID, pt models.Point) {
+				key := tsm1.SeriesFieldKey(string(pt.Key()), "value")
+				if _, err := w.WriteMulti(context.Background(), map[string][]value.Value{
+					key: {value.NewIntegerValue(pt.UnixNano()+1, 1)},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			opens: true,
+		},
+		{
+			// A delete whose predicate cannot be decoded. Skipping
+			// it would bring the deleted data back.
+			name: "delete",
+			corrupt: func(t *testing.T, w *wal.WAL, org, bucket influxdb.ID, pt models.Point) {
+				if _, err := w.DeleteBucketRange(org, bucket, 0, math.MaxInt64, []byte{0xff}); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := storage.NewConfig()
+			engine := NewEngine(c, rand.Int(), rand.Int())
+			defer engine.Close()
+			engine.MustOpen()
+
+			pt := models.MustNewPoint(
+				tsdb.EncodeNameString(engine.org, engine.bucket),
+				models.NewTags(map[string]string{models.FieldKeyTagKey: "value", models.MeasurementTagKey: "cpu", "host": "server"}),
+				map[string]interface{}{"value": 1.0},
+				time.Unix(1, 2),
+			)
+			if err := engine.Engine.WritePoints(context.TODO(), []models.Point{pt}); err != nil {
+				t.Fatal(err)
+			}
+			if err := engine.Engine.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := engine.Degraded(); err != nil {
+				t.Fatalf("unexpected degraded engine: %v", err)
+			}
+
+			w := wal.NewWAL(c.GetWALPath(engine.path))
+			if err := w.Open(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			tt.corrupt(t, w, engine.org, engine.bucket, pt)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			c.AllowPartialOpen = true
+			degraded := storage.NewEngine(engine.path, c, storage.WithEngineID(engine.engineID), storage.WithNodeID(engine.nodeID))
+			err := degraded.Open(context.Background())
+			if !tt.opens {
+				if err == nil {
+					degraded.Close()
+					t.Fatal("expected engine to fail to open with an entry that cannot be skipped")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected engine to open in degraded mode: %v", err)
+			}
+			defer degraded.Close()
+
+			if degraded.Degraded() == nil {
+				t.Fatal("expected engine to be degraded")
+			}
+		})
+	}
+}
+
+func TestEngine_SnapshotOnShutdown(t *testing.T) {
+	for _, snapshot := range []bool{false, true} {
+		t.Run(fmt.Sprintf("snapshot=%v", snapshot), func(t *testing.T) {
+			c := storage.NewConfig()
+			c.SnapshotOnShutdown = snapshot
+			engine := NewEngine(c, rand.Int(), rand.Int())
+			defer engine.Close()
+			engine.MustOpen()
+
+			err := engine.Engine.WritePoints(context.TODO(), []models.Point{models.MustNewPoint(
+				tsdb.EncodeNameString(engine.org, engine.bucket),
+				models.NewTags(map[string]string{models.FieldKeyTagKey: "value", models.MeasurementTagKey: "cpu", "host": "server"}),
+				map[string]interface{}{"value": 1.0},
+				time.Unix(1, 2),
+			)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := engine.Engine.CloseContext(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			tsmFiles, err := filepath.Glob(filepath.Join(c.GetEnginePath(engine.path), "*."+tsm1.TSMFileExtension))
+			if err != nil {
+				t.Fatal(err)
+			}
+			walFiles, err := wal.SegmentFileNames(c.GetWALPath(engine.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var walEntries int
+			if err := wal.NewWALReader(walFiles).Read(func(wal.WALEntry) error {
+				walEntries++
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if snapshot {
+				if len(tsmFiles) == 0 {
+					t.Error("expected the cache to be written to a TSM file")
+				}
+				if walEntries != 0 {
+					t.Errorf("got %d WAL entries, expected none", walEntries)
+				}
+			} else {
+				if len(tsmFiles) != 0 {
+					t.Errorf("unexpected TSM files: %v", tsmFiles)
+				}
+				if walEntries == 0 {
+					t.Error("expected the write to remain in the WAL")
+				}
+			}
+		})
+	}
+}
+
 func TestEngine_InitializeMetrics(t *testing.T) {
 	engine := NewDefaultEngine()
 
@@ -404,6 +766,56 @@ func TestEngine_InitializeMetrics(t *testing.T) {
 	}
 }
 
+func TestEngine_SeriesFileSegmentSizeMetric(t *testing.T) {
+	engine := NewDefaultEngine()
+	defer engine.Close()
+	engine.MustOpen()
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(engine.PrometheusCollectors()...)
+
+	// segmentSize returns the size of the series file
+	// summed over all of its partitions.
+	segmentSize := func() float64 {
+		t.Helper()
+		mfs := promtest.MustGather(t, reg)
+
+		var total float64
+		for i := 0; i < seriesfile.SeriesFilePartitionN; i++ {
+			m := promtest.FindMetric(mfs, "storage_series_file_segment_bytes", prometheus.Labels{
+				"node_id":               fmt.Sprint(engine.nodeID),
+				"engine_id":             fmt.Sprint(engine.engineID),
+				"series_file_partition": fmt.Sprint(i),
+			})
+			if m != nil {
+				total += m.GetGauge().GetValue()
+			}
+		}
+		return total
+	}
+
+	before := segmentSize()
+
+	// Write a point for each of 1000 series.
+	tags := gen.NewTagsValuesSequenceCounts("cpu", "value", "tag", []int{10, 100})
+	var points []models.Point
+	for tags.Next() {
+		points = append(points, models.MustNewPoint(
+			tsdb.EncodeNameString(engine.org, engine.bucket),
+			tags.Value().Clone(),
+			map[string]interface{}{"value": 1.0},
+			time.Unix(1, 0),
+		))
+	}
+	if err := engine.Engine.WritePoints(context.TODO(), points); err != nil {
+		t.Fatal(err)
+	}
+
+	if after := segmentSize(); after <= before {
+		t.Fatalf("got segment size %v after writing series, exp size > %v", after, before)
+	}
+}
+
 // Ensures that when a shard is closed, it removes any series meta-data
 // from the index.
 func TestEngineClose_RemoveIndex(t *testing.T) {
@@ -0,0 +1,166 @@
+package storageflux
+
+import (
+	"fmt"
+
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// actualStartColLabel is the label of the column
+// added by ReadWindowAggregateSpec.ActualStart.
+const actualStartColLabel = "_actual_start"
+
+// readActualStarts returns the time of the earliest point of each
+// window by the stop of the window, truncated by the bounds, for each
+// series, identified by the hash key of its tags. The raw points are
+// read as the aggregated windows do not have the times of their points.
+func (wai *windowAggregateIterator) readActualStarts() (map[string]map[int64]int64, error) {
+	rs, err := wai.readFilter()
+	if err != nil || rs == nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	every, offset := wai.windowEveryAndOffset()
+	boundsStop := int64(wai.spec.Bounds.Stop)
+	starts := make(map[string]map[int64]int64)
+	for rs.Next() {
+		cur := rs.Cursor()
+		if cur == nil {
+			continue
+		}
+
+		var next func() []int64
+		switch typedCur := cur.(type) {
+		case cursors.IntegerArrayCursor:
+			next = func() []int64 { return typedCur.Next().Timestamps }
+		case cursors.FloatArrayCursor:
+			next = func() []int64 { return typedCur.Next().Timestamps }
+		case cursors.UnsignedArrayCursor:
+			next = func() []int64 { return typedCur.Next().Timestamps }
+		case cursors.StringArrayCursor:
+			next = func() []int64 { return typedCur.Next().Timestamps }
+		case cursors.BooleanArrayCursor:
+			next = func() []int64 { return typedCur.Next().Timestamps }
+		default:
+			panic(fmt.Sprintf("unreachable: %T", typedCur))
+		}
+
+		ws := make(map[int64]int64)
+		for timestamps := next(); len(timestamps) > 0; timestamps = next() {
+			for _, ts := range timestamps {
+				stop := windowStop(ts, every, offset)
+				if stop > boundsStop {
+					stop = boundsStop
+				}
+				if _, ok := ws[stop]; !ok {
+					ws[stop] = ts
+				}
+			}
+		}
+		starts[string(rs.Tags().HashKey())] = ws
+
+		stats := cur.Stats()
+		wai.stats.ScannedValues += stats.ScannedValues
+		wai.stats.ScannedBytes += stats.ScannedBytes
+		cur.Close()
+	}
+	return starts, rs.Err()
+}
+
+// actualStartTable adds the _actual_start column to a table of
+// aggregated windows with the time of the earliest point of the
+// window of each row. The window of a row is identified by its stop,
+// which is the _stop column or the _time column of a TimeColumn.
+type actualStartTable struct {
+	storageTable
+	starts        map[int64]int64
+	cols          []flux.ColMeta
+	timeColumn    string
+	every, offset int64
+	boundsStop    int64
+	alloc         *memory.Allocator
+}
+
+func (wai *windowAggregateIterator) newActualStartTable(table storageTable, starts map[int64]int64) *actualStartTable {
+	cols := make([]flux.ColMeta, 0, len(table.Cols())+1)
+	cols = append(cols, table.Cols()...)
+	cols = append(cols, flux.ColMeta{Label: actualStartColLabel, Type: flux.TTime})
+	every, offset := wai.windowEveryAndOffset()
+	return &actualStartTable{
+		storageTable: table,
+		starts:       starts,
+		cols:         cols,
+		timeColumn:   wai.spec.TimeColumn,
+		every:        every,
+		offset:       offset,
+		boundsStop:   int64(wai.spec.Bounds.Stop),
+		alloc:        wai.alloc,
+	}
+}
+
+func (t *actualStartTable) Cols() []flux.ColMeta { return t.cols }
+
+// windowStop returns the stop of the window of row i of cr.
+func (t *actualStartTable) windowStop(cr flux.ColReader, i int) (int64, bool) {
+	label := execute.DefaultStopColLabel
+	if t.timeColumn != "" {
+		label = execute.DefaultTimeColLabel
+	}
+	idx := execute.ColIdx(label, cr.Cols())
+	if idx < 0 || cr.Cols()[idx].Type != flux.TTime {
+		return 0, false
+	}
+	times := cr.Times(idx)
+	if times.IsNull(i) {
+		return 0, false
+	}
+
+	stop := times.Value(i)
+	if t.timeColumn == execute.DefaultStartColLabel {
+		stop = windowStop(stop, t.every, t.offset)
+	}
+	if stop > t.boundsStop {
+		stop = t.boundsStop
+	}
+	return stop, true
+}
+
+func (t *actualStartTable) Do(f func(flux.ColReader) error) error {
+	return t.storageTable.Do(func(cr flux.ColReader) error {
+		b := arrow.NewIntBuilder(t.alloc)
+		b.Resize(cr.Len())
+		for i, n := 0, cr.Len(); i < n; i++ {
+			stop, ok := t.windowStop(cr, i)
+			if !ok {
+				b.AppendNull()
+				continue
+			}
+			start, ok := t.starts[stop]
+			if !ok {
+				b.AppendNull()
+				continue
+			}
+			b.Append(start)
+		}
+
+		buffer := arrow.TableBuffer{
+			GroupKey: cr.Key(),
+			Columns:  t.cols,
+			Values:   make([]array.Interface, len(t.cols)),
+		}
+		for j := range cr.Cols() {
+			arr := getColumnValues(cr, j)
+			arr.Retain()
+			buffer.Values[j] = arr
+		}
+		buffer.Values[len(t.cols)-1] = b.NewInt64Array()
+		defer buffer.Release()
+		return f(&buffer)
+	})
+}
@@ -0,0 +1,110 @@
+package storageflux
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+// Metadata keys of the fields of the Arrow schema of a table
+// written by ReadFilterArrow.
+const (
+	// ArrowFluxTypeKey is the Flux type of a column, such as time or string.
+	ArrowFluxTypeKey = "flux_type"
+
+	// ArrowGroupKeyKey is "true" for the columns of the group key
+	// of a table and "false" otherwise.
+	ArrowGroupKeyKey = "group_key"
+)
+
+// ArrowReader writes the tables of a read in the Arrow IPC format.
+type ArrowReader interface {
+	// ReadFilterArrow writes an Arrow IPC stream to w for each table of
+	// the ReadFilter of spec, one after another. The schema of a stream
+	// has a field for each column of its table, annotated with the
+	// ArrowFluxTypeKey and ArrowGroupKeyKey metadata, and each of its
+	// record batches holds the arrays of a block of the table without
+	// copying them.
+	ReadFilterArrow(ctx context.Context, spec query.ReadFilterSpec, w io.Writer) error
+}
+
+func (r *storeReader) ReadFilterArrow(ctx context.Context, spec query.ReadFilterSpec, w io.Writer) error {
+	ti, err := r.ReadFilter(ctx, spec, &memory.Allocator{})
+	if err != nil {
+		return err
+	}
+	return ti.Do(func(tbl flux.Table) error {
+		return writeArrowTable(w, tbl)
+	})
+}
+
+// writeArrowTable writes tbl to w as an Arrow IPC stream.
+func writeArrowTable(w io.Writer, tbl flux.Table) error {
+	schema, err := arrowSchema(tbl)
+	if err != nil {
+		tbl.Done()
+		return err
+	}
+
+	iw := ipc.NewWriter(w, ipc.WithSchema(schema))
+	if err := tbl.Do(func(cr flux.ColReader) error {
+		cols := make([]array.Interface, len(cr.Cols()))
+		for j := range cr.Cols() {
+			cols[j] = getColumnValues(cr, j)
+		}
+		rec := array.NewRecord(schema, cols, int64(cr.Len()))
+		defer rec.Release()
+		return iw.Write(rec)
+	}); err != nil {
+		return err
+	}
+	return iw.Close()
+}
+
+// arrowSchema returns the Arrow schema of the columns of tbl.
+func arrowSchema(tbl flux.Table) (*arrow.Schema, error) {
+	key := tbl.Key()
+	fields := make([]arrow.Field, len(tbl.Cols()))
+	for j, c := range tbl.Cols() {
+		typ, err := arrowDataType(c.Type)
+		if err != nil {
+			return nil, err
+		}
+		fields[j] = arrow.Field{
+			Name:     c.Label,
+			Type:     typ,
+			Nullable: true,
+			Metadata: arrow.NewMetadata(
+				[]string{ArrowFluxTypeKey, ArrowGroupKeyKey},
+				[]string{c.Type.String(), strconv.FormatBool(key.HasCol(c.Label))},
+			),
+		}
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+// arrowDataType returns the type of the arrays of a Flux column type.
+func arrowDataType(typ flux.ColType) (arrow.DataType, error) {
+	switch typ {
+	case flux.TInt, flux.TTime:
+		return arrow.PrimitiveTypes.Int64, nil
+	case flux.TUInt:
+		return arrow.PrimitiveTypes.Uint64, nil
+	case flux.TFloat:
+		return arrow.PrimitiveTypes.Float64, nil
+	case flux.TString:
+		return arrow.BinaryTypes.String, nil
+	case flux.TBool:
+		return arrow.FixedWidthTypes.Boolean, nil
+	default:
+		return nil, fmt.Errorf("unsupported column type: %s", typ)
+	}
+}
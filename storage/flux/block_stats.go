@@ -0,0 +1,138 @@
+package storageflux
+
+import (
+	"context"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2/kit/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/query"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// BlockStatsReader describes the storage blocks that would be read
+// for a ReadFilter. It is intended for debugging.
+type BlockStatsReader interface {
+	// ReadBlockStats returns a single table with a row for each block
+	// within the bounds of spec. The series column contains the series
+	// key in the same form as ReadFilterSpec.SeriesKeys.
+	ReadBlockStats(ctx context.Context, spec query.ReadFilterSpec, alloc *memory.Allocator) (query.TableIterator, error)
+}
+
+var (
+	fieldKeyBytes       = []byte("_field")
+	measurementKeyBytes = []byte(datatypes.MeasurementKey)
+)
+
+func (r *storeReader) ReadBlockStats(ctx context.Context, spec query.ReadFilterSpec, alloc *memory.Allocator) (query.TableIterator, error) {
+	return &blockStatsIterator{
+		ctx:   ctx,
+		s:     r.s,
+		spec:  spec,
+		alloc: alloc,
+	}, nil
+}
+
+type blockStatsIterator struct {
+	ctx   context.Context
+	s     storage.Store
+	spec  query.ReadFilterSpec
+	alloc *memory.Allocator
+}
+
+func (bi *blockStatsIterator) Do(f func(flux.Table) error) error {
+	bs, ok := bi.s.(storage.BlockStatsStore)
+	if !ok {
+		return errors.New("storage does not support block stats")
+	}
+
+	src := bi.s.GetSource(
+		uint64(bi.spec.OrganizationID),
+		uint64(bi.spec.BucketID),
+	)
+
+	// Setup read request
+	any, err := types.MarshalAny(src)
+	if err != nil {
+		return err
+	}
+
+	var req datatypes.ReadFilterRequest
+	req.ReadSource = any
+	req.Range.Start = int64(bi.spec.Bounds.Start)
+	req.Range.End = int64(bi.spec.Bounds.Stop)
+
+	stats, err := bs.ReadBlockStats(bi.ctx, &req)
+	if err != nil {
+		return err
+	}
+	return bi.handleRead(f, stats)
+}
+
+func (bi *blockStatsIterator) handleRead(f func(flux.Table) error, stats []cursors.BlockStat) error {
+	key := execute.NewGroupKey(nil, nil)
+	builder := execute.NewColListTableBuilder(key, bi.alloc)
+	defer builder.ClearData()
+
+	var idx [5]int
+	for i, col := range []flux.ColMeta{
+		{Label: "series", Type: flux.TString},
+		{Label: "min_time", Type: flux.TTime},
+		{Label: "max_time", Type: flux.TTime},
+		{Label: "count", Type: flux.TInt},
+		{Label: "type", Type: flux.TString},
+	} {
+		j, err := builder.AddCol(col)
+		if err != nil {
+			return err
+		}
+		idx[i] = j
+	}
+
+	var tags models.Tags
+	for _, stat := range stats {
+		// Convert the series key as stored by the engine, which uses
+		// special tag keys for the measurement and field.
+		_, tags = models.ParseKeyBytesWithTags(stat.SeriesKey, tags[:0])
+		name := tags.Get(models.MeasurementTagKeyBytes)
+		tags.Delete(models.MeasurementTagKeyBytes)
+		tags.Delete(models.FieldKeyTagKeyBytes)
+		tags.Set(fieldKeyBytes, stat.Field)
+
+		if err := builder.AppendString(idx[0], string(models.MakeKey(name, tags))); err != nil {
+			return err
+		}
+		if err := builder.AppendTime(idx[1], execute.Time(stat.MinTime)); err != nil {
+			return err
+		}
+		if err := builder.AppendTime(idx[2], execute.Time(stat.MaxTime)); err != nil {
+			return err
+		}
+		if err := builder.AppendInt(idx[3], int64(stat.Count)); err != nil {
+			return err
+		}
+		if err := builder.AppendString(idx[4], cursors.FieldTypeToDataType(stat.Type).String()); err != nil {
+			return err
+		}
+	}
+
+	// Construct the table and add to the reference count
+	// so we can free the table later.
+	tbl, err := builder.Table()
+	if err != nil {
+		return err
+	}
+
+	// Release the references to the arrays held by the builder.
+	builder.ClearData()
+	return f(tbl)
+}
+
+func (bi *blockStatsIterator) Statistics() cursors.CursorStats {
+	return cursors.CursorStats{}
+}
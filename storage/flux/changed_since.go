@@ -0,0 +1,118 @@
+package storageflux
+
+import (
+	"sync"
+
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// changedSince wraps read so that it only produces the series that
+// have a point after the ChangedSince time of the spec within its
+// bounds. The series are found with a read of that part of the bounds
+// the first time read is called, which must not be concurrent with
+// the other reads of req.
+func (fi *filterIterator) changedSince(req *datatypes.ReadFilterRequest, read readFunc) readFunc {
+	var (
+		once    sync.Once
+		changed map[string]bool
+		err     error
+	)
+	return func(n int) ([]storage.ResultSet, error) {
+		once.Do(func() {
+			changed, err = fi.changedSeries(req, read)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(changed) == 0 {
+			return make([]storage.ResultSet, n), nil
+		}
+
+		rss, err := read(n)
+		if err != nil {
+			return nil, err
+		}
+		for i, rs := range rss {
+			if rs != nil {
+				rss[i] = &changedSeriesResultSet{ResultSet: rs, changed: changed}
+			}
+		}
+		return rss, nil
+	}
+}
+
+// changedSeries returns the keys of the series that
+// have a point after ChangedSince within the bounds.
+func (fi *filterIterator) changedSeries(req *datatypes.ReadFilterRequest, read readFunc) (map[string]bool, error) {
+	changed := make(map[string]bool)
+	start := int64(fi.spec.ChangedSince) + 1
+	if start < int64(fi.spec.Bounds.Start) {
+		start = int64(fi.spec.Bounds.Start)
+	}
+	if start >= int64(fi.spec.Bounds.Stop) {
+		return changed, nil
+	}
+
+	// Restore the range of the request, which may be
+	// that of a chunk, once the series have been read.
+	rangeStart, rangeEnd := req.Range.Start, req.Range.End
+	defer func() {
+		req.Range.Start, req.Range.End = rangeStart, rangeEnd
+	}()
+	req.Range.Start, req.Range.End = start, int64(fi.spec.Bounds.Stop)
+
+	rss, err := read(1)
+	if err != nil || rss[0] == nil {
+		return changed, err
+	}
+	rs := rss[0]
+	defer rs.Close()
+
+	for rs.Next() {
+		cur := rs.Cursor()
+		if cur == nil {
+			continue
+		}
+		if hasPoints(cur) {
+			changed[string(rs.Tags().HashKey())] = true
+		}
+		cur.Close()
+	}
+	return changed, rs.Err()
+}
+
+// hasPoints returns true if cur produces at least one point.
+func hasPoints(cur cursors.Cursor) bool {
+	switch cur := cur.(type) {
+	case cursors.FloatArrayCursor:
+		return cur.Next().Len() > 0
+	case cursors.IntegerArrayCursor:
+		return cur.Next().Len() > 0
+	case cursors.UnsignedArrayCursor:
+		return cur.Next().Len() > 0
+	case cursors.StringArrayCursor:
+		return cur.Next().Len() > 0
+	case cursors.BooleanArrayCursor:
+		return cur.Next().Len() > 0
+	default:
+		return false
+	}
+}
+
+// changedSeriesResultSet skips the series of a
+// ResultSet that are not in the changed set.
+type changedSeriesResultSet struct {
+	storage.ResultSet
+	changed map[string]bool
+}
+
+func (rs *changedSeriesResultSet) Next() bool {
+	for rs.ResultSet.Next() {
+		if rs.changed[string(rs.Tags().HashKey())] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package storageflux
+
+import (
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// integerToFloatArrayCursor converts the values of an integer cursor
+// to floats. Integers with a magnitude greater than 2^53 lose precision.
+type integerToFloatArrayCursor struct {
+	cursors.IntegerArrayCursor
+	res *cursors.FloatArray
+}
+
+func newIntegerToFloatArrayCursor(cur cursors.IntegerArrayCursor) *integerToFloatArrayCursor {
+	return &integerToFloatArrayCursor{
+		IntegerArrayCursor: cur,
+		res:                &cursors.FloatArray{},
+	}
+}
+
+func (c *integerToFloatArrayCursor) Next() *cursors.FloatArray {
+	a := c.IntegerArrayCursor.Next()
+	c.res.Timestamps = a.Timestamps
+	if cap(c.res.Values) < len(a.Values) {
+		c.res.Values = make([]float64, len(a.Values))
+	}
+	c.res.Values = c.res.Values[:len(a.Values)]
+	for i, v := range a.Values {
+		c.res.Values[i] = float64(v)
+	}
+	return c.res
+}
+
+// unsignedToFloatArrayCursor converts the values of an unsigned cursor
+// to floats. Values greater than 2^53 lose precision.
+type unsignedToFloatArrayCursor struct {
+	cursors.UnsignedArrayCursor
+	res *cursors.FloatArray
+}
+
+func newUnsignedToFloatArrayCursor(cur cursors.UnsignedArrayCursor) *unsignedToFloatArrayCursor {
+	return &unsignedToFloatArrayCursor{
+		UnsignedArrayCursor: cur,
+		res:                 &cursors.FloatArray{},
+	}
+}
+
+func (c *unsignedToFloatArrayCursor) Next() *cursors.FloatArray {
+	a := c.UnsignedArrayCursor.Next()
+	c.res.Timestamps = a.Timestamps
+	if cap(c.res.Values) < len(a.Values) {
+		c.res.Values = make([]float64, len(a.Values))
+	}
+	c.res.Values = c.res.Values[:len(a.Values)]
+	for i, v := range a.Values {
+		c.res.Values[i] = float64(v)
+	}
+	return c.res
+}
+
+// unsignedToIntegerArrayCursor converts the values of an unsigned cursor
+// to integers. Values greater than math.MaxInt64 wrap around.
+type unsignedToIntegerArrayCursor struct {
+	cursors.UnsignedArrayCursor
+	res *cursors.IntegerArray
+}
+
+func newUnsignedToIntegerArrayCursor(cur cursors.UnsignedArrayCursor) *unsignedToIntegerArrayCursor {
+	return &unsignedToIntegerArrayCursor{
+		UnsignedArrayCursor: cur,
+		res:                 &cursors.IntegerArray{},
+	}
+}
+
+func (c *unsignedToIntegerArrayCursor) Next() *cursors.IntegerArray {
+	a := c.UnsignedArrayCursor.Next()
+	c.res.Timestamps = a.Timestamps
+	if cap(c.res.Values) < len(a.Values) {
+		c.res.Values = make([]int64, len(a.Values))
+	}
+	c.res.Values = c.res.Values[:len(a.Values)]
+	for i, v := range a.Values {
+		c.res.Values[i] = int64(v)
+	}
+	return c.res
+}
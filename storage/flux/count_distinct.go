@@ -0,0 +1,146 @@
+package storageflux
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2/pkg/hll"
+)
+
+// CountDistinctKind is the number of distinct values of each group of
+// ReadGroup. It is computed by the reader rather than the storage engine.
+// See ReadGroupSpec.ApproximateCountDistinct.
+const CountDistinctKind = "count_distinct"
+
+// distinctCounter counts the distinct values added to it.
+// Each value is added in its binary form.
+type distinctCounter interface {
+	add(v []byte)
+	count() int64
+}
+
+// exactDistinctCounter keeps every distinct value in a set.
+type exactDistinctCounter map[string]struct{}
+
+func (c exactDistinctCounter) add(v []byte) { c[string(v)] = struct{}{} }
+func (c exactDistinctCounter) count() int64 { return int64(len(c)) }
+
+// approxDistinctCounter estimates the number of distinct values
+// with a HyperLogLog sketch, which uses a bounded amount of memory.
+type approxDistinctCounter struct {
+	sketch *hll.Plus
+}
+
+func (c approxDistinctCounter) add(v []byte) { c.sketch.Add(v) }
+func (c approxDistinctCounter) count() int64 { return int64(c.sketch.Count()) }
+
+// countDistinctTable reduces a group to a single row with the group key
+// and the number of distinct values of the group in an integer _value
+// column. Like the other group aggregates, the output has no _time column.
+type countDistinctTable struct {
+	storageTable
+	cols   []flux.ColMeta
+	approx bool
+	alloc  *memory.Allocator
+}
+
+func newCountDistinctTable(table storageTable, approx bool, alloc *memory.Allocator) *countDistinctTable {
+	keyCols := table.Key().Cols()
+	cols := make([]flux.ColMeta, 0, len(keyCols)+1)
+	cols = append(cols, keyCols...)
+	cols = append(cols, flux.ColMeta{Label: execute.DefaultValueColLabel, Type: flux.TInt})
+	return &countDistinctTable{
+		storageTable: table,
+		cols:         cols,
+		approx:       approx,
+		alloc:        alloc,
+	}
+}
+
+func (t *countDistinctTable) Cols() []flux.ColMeta { return t.cols }
+
+func (t *countDistinctTable) Do(f func(flux.ColReader) error) error {
+	var counter distinctCounter = make(exactDistinctCounter)
+	if t.approx {
+		counter = approxDistinctCounter{sketch: hll.NewDefaultPlus()}
+	}
+
+	valueIdx := execute.ColIdx(execute.DefaultValueColLabel, t.storageTable.Cols())
+	buf := make([]byte, 8)
+	if err := t.storageTable.Do(func(cr flux.ColReader) error {
+		switch cr.Cols()[valueIdx].Type {
+		case flux.TInt:
+			vs := cr.Ints(valueIdx)
+			for i := 0; i < vs.Len(); i++ {
+				if vs.IsValid(i) {
+					binary.BigEndian.PutUint64(buf, uint64(vs.Value(i)))
+					counter.add(buf)
+				}
+			}
+		case flux.TUInt:
+			vs := cr.UInts(valueIdx)
+			for i := 0; i < vs.Len(); i++ {
+				if vs.IsValid(i) {
+					binary.BigEndian.PutUint64(buf, vs.Value(i))
+					counter.add(buf)
+				}
+			}
+		case flux.TFloat:
+			vs := cr.Floats(valueIdx)
+			for i := 0; i < vs.Len(); i++ {
+				if vs.IsValid(i) {
+					binary.BigEndian.PutUint64(buf, math.Float64bits(vs.Value(i)))
+					counter.add(buf)
+				}
+			}
+		case flux.TBool:
+			vs := cr.Bools(valueIdx)
+			for i := 0; i < vs.Len(); i++ {
+				if vs.IsValid(i) {
+					buf[0] = 0
+					if vs.Value(i) {
+						buf[0] = 1
+					}
+					counter.add(buf[:1])
+				}
+			}
+		case flux.TString:
+			vs := cr.Strings(valueIdx)
+			for i := 0; i < vs.Len(); i++ {
+				if vs.IsValid(i) {
+					counter.add(vs.Value(i))
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	key := t.Key()
+	builder := execute.NewColListTableBuilder(key, t.alloc)
+	defer builder.ClearData()
+	for j, col := range t.cols {
+		if _, err := builder.AddCol(col); err != nil {
+			return err
+		}
+		var err error
+		if j < len(key.Cols()) {
+			err = builder.AppendValue(j, key.Value(j))
+		} else {
+			err = builder.AppendInt(j, counter.count())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	out, err := builder.Table()
+	if err != nil {
+		return err
+	}
+	return out.Do(f)
+}
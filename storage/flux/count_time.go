@@ -0,0 +1,228 @@
+package storageflux
+
+import (
+	"fmt"
+
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// The modes of the CountTimeColumn of a ReadWindowAggregateSpec.
+const (
+	CountTimeStart = "start"
+	CountTimeStop  = "stop"
+	CountTimeFirst = "first"
+	CountTimeLast  = "last"
+)
+
+// setCountTimeColumn validates the CountTimeColumn of the spec and
+// replaces its TimeColumn with the bound of the window it produces.
+// The first and last modes replace the stop of each window with the
+// time of a point once the windows have been counted.
+func (wai *windowAggregateIterator) setCountTimeColumn() error {
+	if len(wai.spec.Aggregates) == 0 || !isAggregateCount(wai.spec.Aggregates[0]) {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "count time column is only supported with the count aggregate",
+		}
+	}
+
+	switch wai.spec.CountTimeColumn {
+	case CountTimeStart:
+		wai.spec.TimeColumn = execute.DefaultStartColLabel
+	case CountTimeStop, CountTimeFirst, CountTimeLast:
+		wai.spec.TimeColumn = execute.DefaultStopColLabel
+	default:
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("unknown count time column %q: must be one of start, stop, first or last", wai.spec.CountTimeColumn),
+		}
+	}
+	return nil
+}
+
+// readCountTime counts the points of each window along with the time
+// of their first or last point. The storage engine does not return
+// the times of the points it counts so the raw values are read and
+// each window is counted here.
+func (wai *windowAggregateIterator) readCountTime(f func(flux.Table) error) error {
+	rs, err := wai.readFilter()
+	if err != nil || rs == nil {
+		return err
+	}
+
+	every, offset := wai.windowEveryAndOffset()
+	return wai.handleRead(f, &countTimeResultSet{
+		ResultSet: rs,
+		every:     every,
+		offset:    offset,
+		last:      wai.spec.CountTimeColumn == CountTimeLast,
+	})
+}
+
+// countTimeResultSet wraps the cursors of a ResultSet so that
+// they produce the count of each window.
+type countTimeResultSet struct {
+	storage.ResultSet
+	every, offset int64
+	last          bool
+}
+
+func (r *countTimeResultSet) Cursor() cursors.Cursor {
+	cur := r.ResultSet.Cursor()
+	var next func() []int64
+	switch typedCur := cur.(type) {
+	case cursors.IntegerArrayCursor:
+		next = func() []int64 { return typedCur.Next().Timestamps }
+	case cursors.FloatArrayCursor:
+		next = func() []int64 { return typedCur.Next().Timestamps }
+	case cursors.UnsignedArrayCursor:
+		next = func() []int64 { return typedCur.Next().Timestamps }
+	case cursors.StringArrayCursor:
+		next = func() []int64 { return typedCur.Next().Timestamps }
+	case cursors.BooleanArrayCursor:
+		next = func() []int64 { return typedCur.Next().Timestamps }
+	case nil:
+		return nil
+	default:
+		panic(fmt.Sprintf("unreachable: %T", typedCur))
+	}
+	return newWindowCountTimeCursor(cur, next, r.every, r.offset, r.last)
+}
+
+// windowCountTimeCursor produces the number of points of each window.
+// The time of the first or last point of each window is queued in times,
+// in the same order as the counts produced by Next, until it is consumed
+// by a countTimeTable.
+type windowCountTimeCursor struct {
+	cursors.Cursor
+	next          func() []int64
+	every, offset int64
+	last          bool
+	res           *cursors.IntegerArray
+	times         []int64
+
+	// state of the current window
+	windowEnd     int64
+	time          int64
+	count         int64
+	windowHasData bool
+}
+
+func newWindowCountTimeCursor(cur cursors.Cursor, next func() []int64, every, offset int64, last bool) *windowCountTimeCursor {
+	return &windowCountTimeCursor{
+		Cursor: cur,
+		next:   next,
+		every:  every,
+		offset: offset,
+		last:   last,
+		res:    cursors.NewIntegerArrayLen(storage.MaxPointsPerBlock),
+	}
+}
+
+func (c *windowCountTimeCursor) Next() *cursors.IntegerArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	for c.res.Len() == 0 {
+		timestamps := c.next()
+		if len(timestamps) == 0 {
+			// Emit the final window.
+			c.emit()
+			c.windowHasData = false
+			break
+		}
+
+		for _, ts := range timestamps {
+			if c.windowHasData && ts >= c.windowEnd {
+				c.emit()
+				c.windowHasData = false
+			}
+
+			if !c.windowHasData {
+				c.windowEnd = windowStop(ts, c.every, c.offset)
+				c.time, c.count = ts, 0
+				c.windowHasData = true
+			}
+			if c.last {
+				c.time = ts
+			}
+			c.count++
+		}
+	}
+	return c.res
+}
+
+func (c *windowCountTimeCursor) emit() {
+	if !c.windowHasData {
+		return
+	}
+	c.res.Timestamps = append(c.res.Timestamps, c.windowEnd)
+	c.res.Values = append(c.res.Values, c.count)
+	c.times = append(c.times, c.time)
+}
+
+// countTimeTable replaces the _time column of a table of counts with
+// the times queued by a windowCountTimeCursor. Windows without points
+// have a count of zero and keep the stop of the window.
+type countTimeTable struct {
+	storageTable
+	cur      *windowCountTimeCursor
+	timeIdx  int
+	valueIdx int
+	alloc    *memory.Allocator
+}
+
+func newCountTimeTable(table storageTable, cur *windowCountTimeCursor, valueIdx int, alloc *memory.Allocator) *countTimeTable {
+	return &countTimeTable{
+		storageTable: table,
+		cur:          cur,
+		timeIdx:      execute.ColIdx(execute.DefaultTimeColLabel, table.Cols()),
+		valueIdx:     valueIdx,
+		alloc:        alloc,
+	}
+}
+
+func (t *countTimeTable) Do(f func(flux.ColReader) error) error {
+	return t.storageTable.Do(func(cr flux.ColReader) error {
+		if t.timeIdx < 0 {
+			return f(cr)
+		}
+
+		times := cr.Times(t.timeIdx)
+		vs := cr.Ints(t.valueIdx)
+		b := arrow.NewIntBuilder(t.alloc)
+		b.Resize(cr.Len())
+		for i, n := 0, cr.Len(); i < n; i++ {
+			if vs.IsNull(i) || vs.Value(i) == 0 {
+				b.Append(times.Value(i))
+				continue
+			}
+			b.Append(t.cur.times[0])
+			t.cur.times = t.cur.times[1:]
+		}
+
+		buffer := arrow.TableBuffer{
+			GroupKey: cr.Key(),
+			Columns:  cr.Cols(),
+			Values:   make([]array.Interface, len(cr.Cols())),
+		}
+		for j := range cr.Cols() {
+			if j == t.timeIdx {
+				continue
+			}
+			arr := getColumnValues(cr, j)
+			arr.Retain()
+			buffer.Values[j] = arr
+		}
+		buffer.Values[t.timeIdx] = b.NewInt64Array()
+		defer buffer.Release()
+		return f(&buffer)
+	})
+}
@@ -0,0 +1,42 @@
+package storageflux
+
+import (
+	"time"
+
+	"github.com/influxdata/flux"
+)
+
+// timeTables wraps f so that the time spent reading the buffers of
+// the tables passed to it is reported to add. The time spent in the
+// callers of the tables is not included, so this approximates the
+// CPU time spent scanning storage. The read fails with the error
+// returned by add.
+func timeTables(f func(flux.Table) error, add func(d time.Duration) error) func(flux.Table) error {
+	return func(tbl flux.Table) error {
+		return f(&cpuTimeTable{Table: tbl, add: add})
+	}
+}
+
+// cpuTimeTable is a table that reports the time spent
+// reading each of its buffers.
+type cpuTimeTable struct {
+	flux.Table
+	add func(d time.Duration) error
+}
+
+func (t *cpuTimeTable) Do(f func(flux.ColReader) error) error {
+	start := time.Now()
+	if err := t.Table.Do(func(cr flux.ColReader) error {
+		if err := t.add(time.Since(start)); err != nil {
+			return err
+		}
+		if err := f(cr); err != nil {
+			return err
+		}
+		start = time.Now()
+		return nil
+	}); err != nil {
+		return err
+	}
+	return t.add(time.Since(start))
+}
@@ -0,0 +1,441 @@
+// Generated by tmpl
+// https://github.com/benbjohnson/tmpl
+//
+// DO NOT EDIT!
+// Source: decode.gen.go.tmpl
+
+package storageflux
+
+import (
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// floatDecodeAheadCursor decodes the next array of a cursor in the
+// decode pool while the previous array is read. The cursor reuses the
+// array it returns, so the arrays are copied into two buffers in turn.
+type floatDecodeAheadCursor struct {
+	cursors.FloatArrayCursor
+	pool *decodePool
+	bufs [2]*cursors.FloatArray
+	i    int // index of the buffer returned by the last call to Next
+
+	// pending is true while the next array is decoded in the pool.
+	pending bool
+	next    chan *cursors.FloatArray
+	ready   *cursors.FloatArray
+}
+
+func newFloatDecodeAheadCursor(pool *decodePool, cur cursors.FloatArrayCursor) *floatDecodeAheadCursor {
+	c := &floatDecodeAheadCursor{
+		FloatArrayCursor: cur,
+		pool:             pool,
+		bufs:             [2]*cursors.FloatArray{cursors.NewFloatArrayLen(0), cursors.NewFloatArrayLen(0)},
+		next:             make(chan *cursors.FloatArray, 1),
+	}
+	c.decodeAhead()
+	return c
+}
+
+// decodeAhead decodes the next array into the free buffer in the pool.
+// If the pool is busy, the array is decoded by Next instead.
+func (c *floatDecodeAheadCursor) decodeAhead() {
+	a := c.bufs[c.i^1]
+	if !c.pool.acquire() {
+		return
+	}
+	c.pending = true
+	go func() {
+		defer c.pool.release()
+		c.decode(a)
+		c.next <- a
+	}()
+}
+
+func (c *floatDecodeAheadCursor) decode(a *cursors.FloatArray) {
+	src := c.FloatArrayCursor.Next()
+	a.Timestamps = append(a.Timestamps[:0], src.Timestamps...)
+	a.Values = append(a.Values[:0], src.Values...)
+}
+
+// wait waits for the array decoded in the pool, if any.
+func (c *floatDecodeAheadCursor) wait() {
+	if c.pending {
+		c.ready = <-c.next
+		c.pending = false
+	}
+}
+
+func (c *floatDecodeAheadCursor) Next() *cursors.FloatArray {
+	c.wait()
+	a := c.ready
+	if a == nil {
+		a = c.bufs[c.i^1]
+		c.decode(a)
+	}
+	c.ready = nil
+	c.i ^= 1
+
+	if a.Len() > 0 {
+		c.decodeAhead()
+	}
+	return a
+}
+
+func (c *floatDecodeAheadCursor) Close() {
+	c.wait()
+	c.FloatArrayCursor.Close()
+}
+
+func (c *floatDecodeAheadCursor) Err() error {
+	c.wait()
+	return c.FloatArrayCursor.Err()
+}
+
+func (c *floatDecodeAheadCursor) Stats() cursors.CursorStats {
+	c.wait()
+	return c.FloatArrayCursor.Stats()
+}
+
+// integerDecodeAheadCursor decodes the next array of a cursor in the
+// decode pool while the previous array is read. The cursor reuses the
+// array it returns, so the arrays are copied into two buffers in turn.
+type integerDecodeAheadCursor struct {
+	cursors.IntegerArrayCursor
+	pool *decodePool
+	bufs [2]*cursors.IntegerArray
+	i    int // index of the buffer returned by the last call to Next
+
+	// pending is true while the next array is decoded in the pool.
+	pending bool
+	next    chan *cursors.IntegerArray
+	ready   *cursors.IntegerArray
+}
+
+func newIntegerDecodeAheadCursor(pool *decodePool, cur cursors.IntegerArrayCursor) *integerDecodeAheadCursor {
+	c := &integerDecodeAheadCursor{
+		IntegerArrayCursor: cur,
+		pool:               pool,
+		bufs:               [2]*cursors.IntegerArray{cursors.NewIntegerArrayLen(0), cursors.NewIntegerArrayLen(0)},
+		next:               make(chan *cursors.IntegerArray, 1),
+	}
+	c.decodeAhead()
+	return c
+}
+
+// decodeAhead decodes the next array into the free buffer in the pool.
+// If the pool is busy, the array is decoded by Next instead.
+func (c *integerDecodeAheadCursor) decodeAhead() {
+	a := c.bufs[c.i^1]
+	if !c.pool.acquire() {
+		return
+	}
+	c.pending = true
+	go func() {
+		defer c.pool.release()
+		c.decode(a)
+		c.next <- a
+	}()
+}
+
+func (c *integerDecodeAheadCursor) decode(a *cursors.IntegerArray) {
+	src := c.IntegerArrayCursor.Next()
+	a.Timestamps = append(a.Timestamps[:0], src.Timestamps...)
+	a.Values = append(a.Values[:0], src.Values...)
+}
+
+// wait waits for the array decoded in the pool, if any.
+func (c *integerDecodeAheadCursor) wait() {
+	if c.pending {
+		c.ready = <-c.next
+		c.pending = false
+	}
+}
+
+func (c *integerDecodeAheadCursor) Next() *cursors.IntegerArray {
+	c.wait()
+	a := c.ready
+	if a == nil {
+		a = c.bufs[c.i^1]
+		c.decode(a)
+	}
+	c.ready = nil
+	c.i ^= 1
+
+	if a.Len() > 0 {
+		c.decodeAhead()
+	}
+	return a
+}
+
+func (c *integerDecodeAheadCursor) Close() {
+	c.wait()
+	c.IntegerArrayCursor.Close()
+}
+
+func (c *integerDecodeAheadCursor) Err() error {
+	c.wait()
+	return c.IntegerArrayCursor.Err()
+}
+
+func (c *integerDecodeAheadCursor) Stats() cursors.CursorStats {
+	c.wait()
+	return c.IntegerArrayCursor.Stats()
+}
+
+// unsignedDecodeAheadCursor decodes the next array of a cursor in the
+// decode pool while the previous array is read. The cursor reuses the
+// array it returns, so the arrays are copied into two buffers in turn.
+type unsignedDecodeAheadCursor struct {
+	cursors.UnsignedArrayCursor
+	pool *decodePool
+	bufs [2]*cursors.UnsignedArray
+	i    int // index of the buffer returned by the last call to Next
+
+	// pending is true while the next array is decoded in the pool.
+	pending bool
+	next    chan *cursors.UnsignedArray
+	ready   *cursors.UnsignedArray
+}
+
+func newUnsignedDecodeAheadCursor(pool *decodePool, cur cursors.UnsignedArrayCursor) *unsignedDecodeAheadCursor {
+	c := &unsignedDecodeAheadCursor{
+		UnsignedArrayCursor: cur,
+		pool:                pool,
+		bufs:                [2]*cursors.UnsignedArray{cursors.NewUnsignedArrayLen(0), cursors.NewUnsignedArrayLen(0)},
+		next:                make(chan *cursors.UnsignedArray, 1),
+	}
+	c.decodeAhead()
+	return c
+}
+
+// decodeAhead decodes the next array into the free buffer in the pool.
+// If the pool is busy, the array is decoded by Next instead.
+func (c *unsignedDecodeAheadCursor) decodeAhead() {
+	a := c.bufs[c.i^1]
+	if !c.pool.acquire() {
+		return
+	}
+	c.pending = true
+	go func() {
+		defer c.pool.release()
+		c.decode(a)
+		c.next <- a
+	}()
+}
+
+func (c *unsignedDecodeAheadCursor) decode(a *cursors.UnsignedArray) {
+	src := c.UnsignedArrayCursor.Next()
+	a.Timestamps = append(a.Timestamps[:0], src.Timestamps...)
+	a.Values = append(a.Values[:0], src.Values...)
+}
+
+// wait waits for the array decoded in the pool, if any.
+func (c *unsignedDecodeAheadCursor) wait() {
+	if c.pending {
+		c.ready = <-c.next
+		c.pending = false
+	}
+}
+
+func (c *unsignedDecodeAheadCursor) Next() *cursors.UnsignedArray {
+	c.wait()
+	a := c.ready
+	if a == nil {
+		a = c.bufs[c.i^1]
+		c.decode(a)
+	}
+	c.ready = nil
+	c.i ^= 1
+
+	if a.Len() > 0 {
+		c.decodeAhead()
+	}
+	return a
+}
+
+func (c *unsignedDecodeAheadCursor) Close() {
+	c.wait()
+	c.UnsignedArrayCursor.Close()
+}
+
+func (c *unsignedDecodeAheadCursor) Err() error {
+	c.wait()
+	return c.UnsignedArrayCursor.Err()
+}
+
+func (c *unsignedDecodeAheadCursor) Stats() cursors.CursorStats {
+	c.wait()
+	return c.UnsignedArrayCursor.Stats()
+}
+
+// stringDecodeAheadCursor decodes the next array of a cursor in the
+// decode pool while the previous array is read. The cursor reuses the
+// array it returns, so the arrays are copied into two buffers in turn.
+type stringDecodeAheadCursor struct {
+	cursors.StringArrayCursor
+	pool *decodePool
+	bufs [2]*cursors.StringArray
+	i    int // index of the buffer returned by the last call to Next
+
+	// pending is true while the next array is decoded in the pool.
+	pending bool
+	next    chan *cursors.StringArray
+	ready   *cursors.StringArray
+}
+
+func newStringDecodeAheadCursor(pool *decodePool, cur cursors.StringArrayCursor) *stringDecodeAheadCursor {
+	c := &stringDecodeAheadCursor{
+		StringArrayCursor: cur,
+		pool:              pool,
+		bufs:              [2]*cursors.StringArray{cursors.NewStringArrayLen(0), cursors.NewStringArrayLen(0)},
+		next:              make(chan *cursors.StringArray, 1),
+	}
+	c.decodeAhead()
+	return c
+}
+
+// decodeAhead decodes the next array into the free buffer in the pool.
+// If the pool is busy, the array is decoded by Next instead.
+func (c *stringDecodeAheadCursor) decodeAhead() {
+	a := c.bufs[c.i^1]
+	if !c.pool.acquire() {
+		return
+	}
+	c.pending = true
+	go func() {
+		defer c.pool.release()
+		c.decode(a)
+		c.next <- a
+	}()
+}
+
+func (c *stringDecodeAheadCursor) decode(a *cursors.StringArray) {
+	src := c.StringArrayCursor.Next()
+	a.Timestamps = append(a.Timestamps[:0], src.Timestamps...)
+	a.Values = append(a.Values[:0], src.Values...)
+}
+
+// wait waits for the array decoded in the pool, if any.
+func (c *stringDecodeAheadCursor) wait() {
+	if c.pending {
+		c.ready = <-c.next
+		c.pending = false
+	}
+}
+
+func (c *stringDecodeAheadCursor) Next() *cursors.StringArray {
+	c.wait()
+	a := c.ready
+	if a == nil {
+		a = c.bufs[c.i^1]
+		c.decode(a)
+	}
+	c.ready = nil
+	c.i ^= 1
+
+	if a.Len() > 0 {
+		c.decodeAhead()
+	}
+	return a
+}
+
+func (c *stringDecodeAheadCursor) Close() {
+	c.wait()
+	c.StringArrayCursor.Close()
+}
+
+func (c *stringDecodeAheadCursor) Err() error {
+	c.wait()
+	return c.StringArrayCursor.Err()
+}
+
+func (c *stringDecodeAheadCursor) Stats() cursors.CursorStats {
+	c.wait()
+	return c.StringArrayCursor.Stats()
+}
+
+// booleanDecodeAheadCursor decodes the next array of a cursor in the
+// decode pool while the previous array is read. The cursor reuses the
+// array it returns, so the arrays are copied into two buffers in turn.
+type booleanDecodeAheadCursor struct {
+	cursors.BooleanArrayCursor
+	pool *decodePool
+	bufs [2]*cursors.BooleanArray
+	i    int // index of the buffer returned by the last call to Next
+
+	// pending is true while the next array is decoded in the pool.
+	pending bool
+	next    chan *cursors.BooleanArray
+	ready   *cursors.BooleanArray
+}
+
+func newBooleanDecodeAheadCursor(pool *decodePool, cur cursors.BooleanArrayCursor) *booleanDecodeAheadCursor {
+	c := &booleanDecodeAheadCursor{
+		BooleanArrayCursor: cur,
+		pool:               pool,
+		bufs:               [2]*cursors.BooleanArray{cursors.NewBooleanArrayLen(0), cursors.NewBooleanArrayLen(0)},
+		next:               make(chan *cursors.BooleanArray, 1),
+	}
+	c.decodeAhead()
+	return c
+}
+
+// decodeAhead decodes the next array into the free buffer in the pool.
+// If the pool is busy, the array is decoded by Next instead.
+func (c *booleanDecodeAheadCursor) decodeAhead() {
+	a := c.bufs[c.i^1]
+	if !c.pool.acquire() {
+		return
+	}
+	c.pending = true
+	go func() {
+		defer c.pool.release()
+		c.decode(a)
+		c.next <- a
+	}()
+}
+
+func (c *booleanDecodeAheadCursor) decode(a *cursors.BooleanArray) {
+	src := c.BooleanArrayCursor.Next()
+	a.Timestamps = append(a.Timestamps[:0], src.Timestamps...)
+	a.Values = append(a.Values[:0], src.Values...)
+}
+
+// wait waits for the array decoded in the pool, if any.
+func (c *booleanDecodeAheadCursor) wait() {
+	if c.pending {
+		c.ready = <-c.next
+		c.pending = false
+	}
+}
+
+func (c *booleanDecodeAheadCursor) Next() *cursors.BooleanArray {
+	c.wait()
+	a := c.ready
+	if a == nil {
+		a = c.bufs[c.i^1]
+		c.decode(a)
+	}
+	c.ready = nil
+	c.i ^= 1
+
+	if a.Len() > 0 {
+		c.decodeAhead()
+	}
+	return a
+}
+
+func (c *booleanDecodeAheadCursor) Close() {
+	c.wait()
+	c.BooleanArrayCursor.Close()
+}
+
+func (c *booleanDecodeAheadCursor) Err() error {
+	c.wait()
+	return c.BooleanArrayCursor.Err()
+}
+
+func (c *booleanDecodeAheadCursor) Stats() cursors.CursorStats {
+	c.wait()
+	return c.BooleanArrayCursor.Stats()
+}
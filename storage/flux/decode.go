@@ -0,0 +1,59 @@
+package storageflux
+
+//go:generate env GO111MODULE=on go run github.com/benbjohnson/tmpl -data=@types.tmpldata decode.gen.go.tmpl
+
+import (
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// decodePool bounds the number of blocks that are decoded ahead
+// of the tables reading them across every read of a reader.
+type decodePool struct {
+	sem chan struct{}
+}
+
+// newDecodePool returns a pool that decodes up to n blocks concurrently,
+// or nil if n is less than or equal to one.
+func newDecodePool(n int) *decodePool {
+	if n <= 1 {
+		return nil
+	}
+	return &decodePool{sem: make(chan struct{}, n)}
+}
+
+// acquire reserves a worker of the pool without waiting.
+// It returns false if every worker is busy.
+func (p *decodePool) acquire() bool {
+	select {
+	case p.sem <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *decodePool) release() { <-p.sem }
+
+// decodeAhead wraps cur so that its next block is decoded by the pool
+// while the previous block is read. The order of the values is not
+// changed. The cursor is returned as is if the pool is nil.
+func (p *decodePool) decodeAhead(cur cursors.Cursor) cursors.Cursor {
+	if p == nil {
+		return cur
+	}
+
+	switch typedCur := cur.(type) {
+	case cursors.IntegerArrayCursor:
+		return newIntegerDecodeAheadCursor(p, typedCur)
+	case cursors.FloatArrayCursor:
+		return newFloatDecodeAheadCursor(p, typedCur)
+	case cursors.UnsignedArrayCursor:
+		return newUnsignedDecodeAheadCursor(p, typedCur)
+	case cursors.BooleanArrayCursor:
+		return newBooleanDecodeAheadCursor(p, typedCur)
+	case cursors.StringArrayCursor:
+		return newStringDecodeAheadCursor(p, typedCur)
+	default:
+		return cur
+	}
+}
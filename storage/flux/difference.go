@@ -0,0 +1,283 @@
+package storageflux
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// readDifference computes the difference between the last and first
+// values of each window. The storage engine does not support this
+// aggregate so the raw values are read and each window is computed here.
+func (wai *windowAggregateIterator) readDifference(f func(flux.Table) error) error {
+	rs, err := wai.readFilter()
+	if err != nil || rs == nil {
+		return err
+	}
+
+	every, offset := wai.windowEveryAndOffset()
+	return wai.handleRead(f, &differenceResultSet{
+		ResultSet:   rs,
+		every:       every,
+		offset:      offset,
+		rate:        wai.spec.Rate,
+		nonNegative: wai.spec.NonNegative,
+	})
+}
+
+// readFilter reads the raw values of the series of the spec for
+// aggregates that are computed by the reader rather than the storage engine.
+func (wai *windowAggregateIterator) readFilter() (storage.ResultSet, error) {
+	src := wai.s.GetSource(
+		uint64(wai.spec.OrganizationID),
+		uint64(wai.spec.BucketID),
+	)
+
+	// Setup read request
+	any, err := types.MarshalAny(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var req datatypes.ReadFilterRequest
+	req.ReadSource = any
+	req.Predicate = wai.spec.Predicate
+	req.Range.Start = int64(wai.spec.Bounds.Start)
+	req.Range.End = int64(wai.spec.Bounds.Stop)
+
+	return wai.s.ReadFilter(wai.ctx, &req)
+}
+
+// windowEveryAndOffset returns the window period and the
+// offset normalized to the same range as the storage engine.
+func (wai *windowAggregateIterator) windowEveryAndOffset() (every, offset int64) {
+	every, offset = wai.spec.WindowEvery, wai.spec.Offset
+	if every > 0 {
+		offset = storage.Modulo(offset, every)
+	}
+	return every, offset
+}
+
+// differenceResultSet wraps the cursors of a ResultSet so that
+// they produce the difference for each window.
+type differenceResultSet struct {
+	storage.ResultSet
+	every, offset     int64
+	rate, nonNegative bool
+	err               error
+}
+
+func (r *differenceResultSet) Cursor() cursors.Cursor {
+	cur := r.ResultSet.Cursor()
+	if cur == nil {
+		return nil
+	}
+
+	var floatCur cursors.FloatArrayCursor
+	switch typedCur := cur.(type) {
+	case cursors.FloatArrayCursor:
+		floatCur = typedCur
+	case cursors.IntegerArrayCursor:
+		if !r.rate {
+			return newIntegerWindowDifferenceCursor(typedCur, r.every, r.offset, r.nonNegative)
+		}
+		floatCur = newIntegerToFloatArrayCursor(typedCur)
+	case cursors.UnsignedArrayCursor:
+		if !r.rate {
+			return newIntegerWindowDifferenceCursor(newUnsignedToIntegerArrayCursor(typedCur), r.every, r.offset, r.nonNegative)
+		}
+		floatCur = newUnsignedToFloatArrayCursor(typedCur)
+	default:
+		cur.Close()
+		if r.err == nil {
+			r.err = &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  fmt.Sprintf("unsupported for aggregate difference: %T", cur),
+			}
+		}
+		return nil
+	}
+	return newFloatWindowDifferenceCursor(floatCur, r.every, r.offset, r.rate, r.nonNegative)
+}
+
+func (r *differenceResultSet) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.ResultSet.Err()
+}
+
+// windowStop returns the stop time of the window containing ts.
+// When every is zero, all points are in a single window.
+func windowStop(ts, every, offset int64) int64 {
+	if every == 0 {
+		return math.MaxInt64
+	}
+	return storage.WindowStop(ts, every, offset)
+}
+
+// floatWindowDifferenceCursor produces the difference between the last
+// and first value of each window, or the rate of change per second when
+// rate is set. The timestamp of each value is the window stop time.
+// When nonNegative is set, a decrease is treated as a counter reset and
+// the value after the reset is added to the difference.
+type floatWindowDifferenceCursor struct {
+	cursors.FloatArrayCursor
+	every, offset     int64
+	rate, nonNegative bool
+	res               *cursors.FloatArray
+
+	// state of the current window
+	windowEnd     int64
+	firstTS       int64
+	lastTS        int64
+	prev, acc     float64
+	windowHasData bool
+}
+
+func newFloatWindowDifferenceCursor(cur cursors.FloatArrayCursor, every, offset int64, rate, nonNegative bool) *floatWindowDifferenceCursor {
+	return &floatWindowDifferenceCursor{
+		FloatArrayCursor: cur,
+		every:            every,
+		offset:           offset,
+		rate:             rate,
+		nonNegative:      nonNegative,
+		res:              cursors.NewFloatArrayLen(storage.MaxPointsPerBlock),
+	}
+}
+
+func (c *floatWindowDifferenceCursor) Next() *cursors.FloatArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	for c.res.Len() == 0 {
+		a := c.FloatArrayCursor.Next()
+		if a.Len() == 0 {
+			// Emit the final window.
+			c.emit()
+			c.windowHasData = false
+			break
+		}
+
+		for i, ts := range a.Timestamps {
+			v := a.Values[i]
+			if c.windowHasData && ts >= c.windowEnd {
+				c.emit()
+				c.windowHasData = false
+			}
+
+			if !c.windowHasData {
+				c.windowEnd = windowStop(ts, c.every, c.offset)
+				c.firstTS, c.lastTS = ts, ts
+				c.prev, c.acc = v, 0
+				c.windowHasData = true
+				continue
+			}
+
+			if c.nonNegative && v < c.prev {
+				c.acc += v
+			} else {
+				c.acc += v - c.prev
+			}
+			c.prev, c.lastTS = v, ts
+		}
+	}
+	return c.res
+}
+
+func (c *floatWindowDifferenceCursor) emit() {
+	if !c.windowHasData {
+		return
+	}
+
+	v := c.acc
+	if c.rate {
+		elapsed := c.lastTS - c.firstTS
+		if elapsed == 0 {
+			// The rate of a window with a single point is undefined.
+			return
+		}
+		v /= float64(elapsed) / 1e9
+	}
+	c.res.Timestamps = append(c.res.Timestamps, c.windowEnd)
+	c.res.Values = append(c.res.Values, v)
+}
+
+// integerWindowDifferenceCursor produces the difference between the
+// last and first value of each window. The timestamp of each value
+// is the window stop time. When nonNegative is set, a decrease is
+// treated as a counter reset and the value after the reset is added
+// to the difference.
+type integerWindowDifferenceCursor struct {
+	cursors.IntegerArrayCursor
+	every, offset int64
+	nonNegative   bool
+	res           *cursors.IntegerArray
+
+	// state of the current window
+	windowEnd     int64
+	prev, acc     int64
+	windowHasData bool
+}
+
+func newIntegerWindowDifferenceCursor(cur cursors.IntegerArrayCursor, every, offset int64, nonNegative bool) *integerWindowDifferenceCursor {
+	return &integerWindowDifferenceCursor{
+		IntegerArrayCursor: cur,
+		every:              every,
+		offset:             offset,
+		nonNegative:        nonNegative,
+		res:                cursors.NewIntegerArrayLen(storage.MaxPointsPerBlock),
+	}
+}
+
+func (c *integerWindowDifferenceCursor) Next() *cursors.IntegerArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	for c.res.Len() == 0 {
+		a := c.IntegerArrayCursor.Next()
+		if a.Len() == 0 {
+			// Emit the final window.
+			c.emit()
+			c.windowHasData = false
+			break
+		}
+
+		for i, ts := range a.Timestamps {
+			v := a.Values[i]
+			if c.windowHasData && ts >= c.windowEnd {
+				c.emit()
+				c.windowHasData = false
+			}
+
+			if !c.windowHasData {
+				c.windowEnd = windowStop(ts, c.every, c.offset)
+				c.prev, c.acc = v, 0
+				c.windowHasData = true
+				continue
+			}
+
+			if c.nonNegative && v < c.prev {
+				c.acc += v
+			} else {
+				c.acc += v - c.prev
+			}
+			c.prev = v
+		}
+	}
+	return c.res
+}
+
+func (c *integerWindowDifferenceCursor) emit() {
+	if !c.windowHasData {
+		return
+	}
+	c.res.Timestamps = append(c.res.Timestamps, c.windowEnd)
+	c.res.Values = append(c.res.Values, c.acc)
+}
@@ -0,0 +1,173 @@
+package storageflux
+
+import (
+	"math"
+
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// newDownsampleCursor wraps cur so that it produces at most n of its
+// points chosen by lttb. Cursors of other than numeric values are
+// returned as they are.
+func newDownsampleCursor(cur cursors.Cursor, n int) cursors.Cursor {
+	switch typedCur := cur.(type) {
+	case cursors.FloatArrayCursor:
+		return &floatDownsampleCursor{FloatArrayCursor: typedCur, n: n}
+	case cursors.IntegerArrayCursor:
+		return &integerDownsampleCursor{IntegerArrayCursor: typedCur, n: n}
+	case cursors.UnsignedArrayCursor:
+		return &unsignedDownsampleCursor{UnsignedArrayCursor: typedCur, n: n}
+	default:
+		return cur
+	}
+}
+
+// lttb returns the indexes of at most threshold of the points with
+// the times ts and values y, in ascending order, chosen with the
+// Largest-Triangle-Three-Buckets algorithm. The first and last points
+// are always chosen. The points between them are split into buckets
+// and the point of each bucket that forms the largest triangle with
+// the point chosen from the bucket before it and the average of the
+// bucket after it is chosen.
+func lttb(ts []int64, y func(i int) float64, threshold int) []int {
+	n := len(ts)
+	if threshold >= n || threshold <= 0 {
+		idxs := make([]int, n)
+		for i := range idxs {
+			idxs[i] = i
+		}
+		return idxs
+	}
+	if threshold == 1 {
+		return []int{0}
+	}
+	if threshold == 2 {
+		return []int{0, n - 1}
+	}
+
+	// The times are relative to the first point so that
+	// they are not too large to be exact as floats.
+	x := func(i int) float64 { return float64(ts[i] - ts[0]) }
+
+	idxs := make([]int, 0, threshold)
+	idxs = append(idxs, 0)
+	every := float64(n-2) / float64(threshold-2)
+	a := 0
+	for i := 0; i < threshold-2; i++ {
+		// The average of the next bucket.
+		avgStart := int(float64(i+1)*every) + 1
+		avgEnd := int(float64(i+2)*every) + 1
+		if avgEnd > n {
+			avgEnd = n
+		}
+		var avgX, avgY float64
+		for j := avgStart; j < avgEnd; j++ {
+			avgX += x(j)
+			avgY += y(j)
+		}
+		avgX /= float64(avgEnd - avgStart)
+		avgY /= float64(avgEnd - avgStart)
+
+		// The point of this bucket with the largest triangle.
+		rangeStart := int(float64(i)*every) + 1
+		rangeEnd := int(float64(i+1)*every) + 1
+		ax, ay := x(a), y(a)
+		maxArea, maxIdx := -1.0, rangeStart
+		for j := rangeStart; j < rangeEnd; j++ {
+			area := math.Abs((ax-avgX)*(y(j)-ay) - (ax-x(j))*(avgY-ay))
+			if area > maxArea {
+				maxArea, maxIdx = area, j
+			}
+		}
+		idxs = append(idxs, maxIdx)
+		a = maxIdx
+	}
+	return append(idxs, n-1)
+}
+
+// floatDownsampleCursor reads all of the points of a cursor
+// and produces the points chosen by lttb in a single array.
+type floatDownsampleCursor struct {
+	cursors.FloatArrayCursor
+	n    int
+	done bool
+}
+
+func (c *floatDownsampleCursor) Next() *cursors.FloatArray {
+	if c.done {
+		return cursors.NewFloatArrayLen(0)
+	}
+	c.done = true
+
+	all := cursors.NewFloatArrayLen(0)
+	for a := c.FloatArrayCursor.Next(); a.Len() > 0; a = c.FloatArrayCursor.Next() {
+		all.Timestamps = append(all.Timestamps, a.Timestamps...)
+		all.Values = append(all.Values, a.Values...)
+	}
+
+	idxs := lttb(all.Timestamps, func(i int) float64 { return all.Values[i] }, c.n)
+	res := cursors.NewFloatArrayLen(len(idxs))
+	for i, idx := range idxs {
+		res.Timestamps[i] = all.Timestamps[idx]
+		res.Values[i] = all.Values[idx]
+	}
+	return res
+}
+
+// integerDownsampleCursor reads all of the points of a cursor
+// and produces the points chosen by lttb in a single array.
+type integerDownsampleCursor struct {
+	cursors.IntegerArrayCursor
+	n    int
+	done bool
+}
+
+func (c *integerDownsampleCursor) Next() *cursors.IntegerArray {
+	if c.done {
+		return cursors.NewIntegerArrayLen(0)
+	}
+	c.done = true
+
+	all := cursors.NewIntegerArrayLen(0)
+	for a := c.IntegerArrayCursor.Next(); a.Len() > 0; a = c.IntegerArrayCursor.Next() {
+		all.Timestamps = append(all.Timestamps, a.Timestamps...)
+		all.Values = append(all.Values, a.Values...)
+	}
+
+	idxs := lttb(all.Timestamps, func(i int) float64 { return float64(all.Values[i]) }, c.n)
+	res := cursors.NewIntegerArrayLen(len(idxs))
+	for i, idx := range idxs {
+		res.Timestamps[i] = all.Timestamps[idx]
+		res.Values[i] = all.Values[idx]
+	}
+	return res
+}
+
+// unsignedDownsampleCursor reads all of the points of a cursor
+// and produces the points chosen by lttb in a single array.
+type unsignedDownsampleCursor struct {
+	cursors.UnsignedArrayCursor
+	n    int
+	done bool
+}
+
+func (c *unsignedDownsampleCursor) Next() *cursors.UnsignedArray {
+	if c.done {
+		return cursors.NewUnsignedArrayLen(0)
+	}
+	c.done = true
+
+	all := cursors.NewUnsignedArrayLen(0)
+	for a := c.UnsignedArrayCursor.Next(); a.Len() > 0; a = c.UnsignedArrayCursor.Next() {
+		all.Timestamps = append(all.Timestamps, a.Timestamps...)
+		all.Values = append(all.Values, a.Values...)
+	}
+
+	idxs := lttb(all.Timestamps, func(i int) float64 { return float64(all.Values[i]) }, c.n)
+	res := cursors.NewUnsignedArrayLen(len(idxs))
+	for i, idx := range idxs {
+		res.Timestamps[i] = all.Timestamps[idx]
+		res.Values[i] = all.Values[idx]
+	}
+	return res
+}
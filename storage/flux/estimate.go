@@ -0,0 +1,102 @@
+package storageflux
+
+import (
+	"context"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/influxdata/influxdb/v2/kit/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/query"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+)
+
+// EstimateReadFilter estimates the cost of a ReadFilter. The series are
+// counted with the index and the points and bytes are estimated from the
+// blocks of those series. Only the timestamps of each block are decoded
+// and a block that is partly outside of the bounds is counted in
+// proportion to its overlap with the bounds. Data which has not been
+// snapshotted from the cache is not included in the points and bytes.
+func (r *storeReader) EstimateReadFilter(ctx context.Context, spec query.ReadFilterSpec) (query.CostEstimate, error) {
+	bs, ok := r.s.(storage.BlockStatsStore)
+	if !ok {
+		return query.CostEstimate{}, errors.New("storage does not support cost estimates")
+	}
+
+	src := r.s.GetSource(
+		uint64(spec.OrganizationID),
+		uint64(spec.BucketID),
+	)
+
+	// Setup read request
+	any, err := types.MarshalAny(src)
+	if err != nil {
+		return query.CostEstimate{}, err
+	}
+
+	var req datatypes.ReadFilterRequest
+	req.ReadSource = any
+	req.Predicate = spec.Predicate
+	req.Range.Start = int64(spec.Bounds.Start)
+	req.Range.End = int64(spec.Bounds.Stop)
+
+	var est query.CostEstimate
+	series, err := r.readSeries(ctx, &req)
+	if err != nil {
+		return est, err
+	}
+	est.SeriesN = int64(len(series))
+
+	stats, err := bs.ReadBlockStats(ctx, &req)
+	if err != nil {
+		return est, err
+	}
+
+	var tags models.Tags
+	for _, stat := range stats {
+		// Convert the series key as stored by the engine to the
+		// form of the tags produced by the index.
+		_, tags = models.ParseKeyBytesWithTags(stat.SeriesKey, tags[:0])
+		name := tags.Get(models.MeasurementTagKeyBytes)
+		tags.Delete(models.MeasurementTagKeyBytes)
+		tags.Delete(models.FieldKeyTagKeyBytes)
+		tags.Set(measurementKeyBytes, name)
+		tags.Set(fieldKeyBytes, stat.Field)
+		if !series[string(tags.HashKey())] {
+			continue
+		}
+
+		min, max := stat.MinTime, stat.MaxTime
+		if min < req.Range.Start {
+			min = req.Range.Start
+		}
+		if max > req.Range.End {
+			max = req.Range.End
+		}
+		if span := stat.MaxTime - stat.MinTime; span > 0 && (min != stat.MinTime || max != stat.MaxTime) {
+			ratio := float64(max-min) / float64(span)
+			est.PointsN += int64(float64(stat.Count) * ratio)
+			est.Bytes += int64(float64(stat.Size) * ratio)
+			continue
+		}
+		est.PointsN += int64(stat.Count)
+		est.Bytes += int64(stat.Size)
+	}
+	return est, nil
+}
+
+// readSeries returns the hash keys of the tags of every
+// series that matches req. No cursors are created.
+func (r *storeReader) readSeries(ctx context.Context, req *datatypes.ReadFilterRequest) (map[string]bool, error) {
+	rs, err := r.s.ReadFilter(ctx, req)
+	if err != nil || rs == nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	series := make(map[string]bool)
+	for rs.Next() {
+		series[string(rs.Tags().HashKey())] = true
+	}
+	return series, rs.Err()
+}
@@ -0,0 +1,110 @@
+package storageflux
+
+import (
+	"context"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2/kit/errors"
+	"github.com/influxdata/influxdb/v2/query"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// FieldKeysReader lists the field keys of a bucket
+// without reading any of their values.
+type FieldKeysReader interface {
+	// ReadFieldKeys returns a single table with a row for each distinct
+	// field key and type within the bounds of spec. The _fieldKey column
+	// contains the key and the _fieldType column the InfluxQL name of
+	// its type, such as float or integer.
+	ReadFieldKeys(ctx context.Context, spec query.ReadFilterSpec, alloc *memory.Allocator) (query.TableIterator, error)
+}
+
+func (r *storeReader) ReadFieldKeys(ctx context.Context, spec query.ReadFilterSpec, alloc *memory.Allocator) (query.TableIterator, error) {
+	return &fieldKeysIterator{
+		ctx:   ctx,
+		s:     r.s,
+		spec:  spec,
+		alloc: alloc,
+	}, nil
+}
+
+type fieldKeysIterator struct {
+	ctx   context.Context
+	s     storage.Store
+	spec  query.ReadFilterSpec
+	alloc *memory.Allocator
+}
+
+func (fi *fieldKeysIterator) Do(f func(flux.Table) error) error {
+	fs, ok := fi.s.(storage.FieldKeysStore)
+	if !ok {
+		return errors.New("storage does not support field keys")
+	}
+
+	src := fi.s.GetSource(
+		uint64(fi.spec.OrganizationID),
+		uint64(fi.spec.BucketID),
+	)
+
+	// Setup read request
+	any, err := types.MarshalAny(src)
+	if err != nil {
+		return err
+	}
+
+	var req datatypes.ReadFilterRequest
+	req.ReadSource = any
+	req.Predicate = fi.spec.Predicate
+	req.Range.Start = int64(fi.spec.Bounds.Start)
+	req.Range.End = int64(fi.spec.Bounds.Stop)
+
+	fields, err := fs.FieldKeys(fi.ctx, &req)
+	if err != nil {
+		return err
+	}
+	return fi.handleRead(f, fields)
+}
+
+func (fi *fieldKeysIterator) handleRead(f func(flux.Table) error, fields []cursors.MeasurementField) error {
+	key := execute.NewGroupKey(nil, nil)
+	builder := execute.NewColListTableBuilder(key, fi.alloc)
+	defer builder.ClearData()
+
+	keyIdx, err := builder.AddCol(flux.ColMeta{Label: "_fieldKey", Type: flux.TString})
+	if err != nil {
+		return err
+	}
+	typeIdx, err := builder.AddCol(flux.ColMeta{Label: "_fieldType", Type: flux.TString})
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if err := builder.AppendString(keyIdx, field.Key); err != nil {
+			return err
+		}
+		if err := builder.AppendString(typeIdx, cursors.FieldTypeToDataType(field.Type).String()); err != nil {
+			return err
+		}
+	}
+
+	// Construct the table and add to the reference count
+	// so we can free the table later.
+	tbl, err := builder.Table()
+	if err != nil {
+		return err
+	}
+
+	// Release the references to the arrays held by the builder.
+	builder.ClearData()
+	return f(tbl)
+}
+
+func (fi *fieldKeysIterator) Statistics() cursors.CursorStats {
+	return cursors.CursorStats{}
+}
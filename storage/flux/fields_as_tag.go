@@ -0,0 +1,199 @@
+package storageflux
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/values"
+	"github.com/influxdata/influxdb/v2"
+)
+
+// fieldColLabel is the label of the column with the
+// field of each point added by ReadFilterSpec.FieldsAsTag.
+const fieldColLabel = "field"
+
+// readFieldsAsTag reads the tables of the spec and passes a table
+// for each series without its _field to f once they have all been
+// read. The table has the points of every field of the series with
+// the name of their field in the field column.
+func (fi *filterIterator) readFieldsAsTag(f func(flux.Table) error) error {
+	if len(fi.spec.SortTags) > 0 || fi.spec.SeriesKeyColumn {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "fields as tag cannot be combined with sort tags or the series key column",
+		}
+	}
+
+	m := &fieldMerger{
+		lookup: execute.NewRandomAccessGroupLookup(),
+		alloc:  fi.alloc,
+	}
+	if err := fi.read(m.add); err != nil {
+		return err
+	}
+	return m.emit(f)
+}
+
+// fieldMerger collects the points of the tables of a read
+// by their group key without the _field column.
+type fieldMerger struct {
+	groups []*fieldGroup
+	lookup *execute.RandomAccessGroupLookup
+	alloc  *memory.Allocator
+}
+
+// fieldGroup is the points of every field of a series.
+type fieldGroup struct {
+	key    flux.GroupKey
+	typ    flux.ColType
+	points []fieldPoint
+}
+
+// fieldPoint is a point of a field of a series.
+type fieldPoint struct {
+	time  int64
+	field string
+	value values.Value
+}
+
+// add collects the points of tbl.
+func (m *fieldMerger) add(tbl flux.Table) error {
+	cols := tbl.Cols()
+	timeIdx := execute.ColIdx(execute.DefaultTimeColLabel, cols)
+	valueIdx := execute.ColIdx(execute.DefaultValueColLabel, cols)
+	fieldIdx := execute.ColIdx("_field", cols)
+	if timeIdx < 0 || valueIdx < 0 || fieldIdx < 0 {
+		tbl.Done()
+		return nil
+	}
+
+	g, err := m.group(tbl.Key(), cols[valueIdx].Type)
+	if err != nil {
+		tbl.Done()
+		return err
+	}
+	return tbl.Do(func(cr flux.ColReader) error {
+		times := cr.Times(timeIdx)
+		fields := cr.Strings(fieldIdx)
+		for i := 0; i < cr.Len(); i++ {
+			if !times.IsValid(i) || !fields.IsValid(i) {
+				continue
+			}
+			g.points = append(g.points, fieldPoint{
+				time:  times.Value(i),
+				field: fields.ValueString(i),
+				value: execute.ValueForRow(cr, i, valueIdx),
+			})
+		}
+		return nil
+	})
+}
+
+// group returns the group of the tables with key, ignoring its _field.
+// The values of the tables of a group must have the same type.
+func (m *fieldMerger) group(key flux.GroupKey, typ flux.ColType) (*fieldGroup, error) {
+	cols := make([]flux.ColMeta, 0, len(key.Cols()))
+	vs := make([]values.Value, 0, len(key.Cols()))
+	for j, c := range key.Cols() {
+		if c.Label == "_field" {
+			continue
+		}
+		if c.Label == fieldColLabel {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  fmt.Sprintf("cannot add the %s column to a series with a tag of the same name", fieldColLabel),
+			}
+		}
+		cols = append(cols, c)
+		vs = append(vs, key.Value(j))
+	}
+	key = execute.NewGroupKey(cols, vs)
+
+	if g, ok := m.lookup.Lookup(key); ok {
+		g := g.(*fieldGroup)
+		if g.typ != typ {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  fmt.Sprintf("cannot merge the fields of series %s with values of type %s and %s", key, g.typ, typ),
+			}
+		}
+		return g, nil
+	}
+	g := &fieldGroup{key: key, typ: typ}
+	m.lookup.Set(key, g)
+	m.groups = append(m.groups, g)
+	return g, nil
+}
+
+// emit passes a table for each group to f in the order they were read.
+func (m *fieldMerger) emit(f func(flux.Table) error) error {
+	for _, g := range m.groups {
+		tbl, err := g.table(m.alloc)
+		if err != nil {
+			return err
+		}
+		if err := f(tbl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// table returns the points of g ordered by time and then by field.
+func (g *fieldGroup) table(alloc *memory.Allocator) (flux.Table, error) {
+	builder := execute.NewColListTableBuilder(g.key, alloc)
+	defer builder.ClearData()
+	if err := execute.AddTableKeyCols(g.key, builder); err != nil {
+		return nil, err
+	}
+	timeIdx, err := builder.AddCol(flux.ColMeta{Label: execute.DefaultTimeColLabel, Type: flux.TTime})
+	if err != nil {
+		return nil, err
+	}
+	valueIdx, err := builder.AddCol(flux.ColMeta{Label: execute.DefaultValueColLabel, Type: g.typ})
+	if err != nil {
+		return nil, err
+	}
+	fieldIdx, err := builder.AddCol(flux.ColMeta{Label: fieldColLabel, Type: flux.TString})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(g.points, func(i, j int) bool {
+		if g.points[i].time != g.points[j].time {
+			return g.points[i].time < g.points[j].time
+		}
+		return g.points[i].field < g.points[j].field
+	})
+	for _, p := range g.points {
+		for j := range g.key.Cols() {
+			if err := builder.AppendValue(j, g.key.Value(j)); err != nil {
+				return nil, err
+			}
+		}
+		if err := builder.AppendTime(timeIdx, execute.Time(p.time)); err != nil {
+			return nil, err
+		}
+		if p.value.IsNull() {
+			err = builder.AppendNil(valueIdx)
+		} else {
+			err = builder.AppendValue(valueIdx, p.value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := builder.AppendString(fieldIdx, p.field); err != nil {
+			return nil, err
+		}
+	}
+
+	tbl, err := builder.Table()
+	if err != nil {
+		return nil, err
+	}
+	builder.ClearData()
+	return tbl, nil
+}
@@ -0,0 +1,131 @@
+package storageflux
+
+import (
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/memory"
+)
+
+// fillPreviousTable replaces the null values of the empty windows of a
+// selector table with the value of the last window that had a point.
+// Empty windows before the first point are left null. Only the value
+// column is filled so the time of a filled window remains null.
+type fillPreviousTable struct {
+	storageTable
+	valueIdx int
+	alloc    *memory.Allocator
+
+	// prev is the last value read and valid is set once there is one.
+	prev  interface{}
+	valid bool
+}
+
+func newFillPreviousTable(table storageTable, valueIdx int, alloc *memory.Allocator) *fillPreviousTable {
+	return &fillPreviousTable{
+		storageTable: table,
+		valueIdx:     valueIdx,
+		alloc:        alloc,
+	}
+}
+
+func (t *fillPreviousTable) Do(f func(flux.ColReader) error) error {
+	return t.storageTable.Do(func(cr flux.ColReader) error {
+		buffer := arrow.TableBuffer{
+			GroupKey: cr.Key(),
+			Columns:  cr.Cols(),
+			Values:   make([]array.Interface, len(cr.Cols())),
+		}
+		for j := range cr.Cols() {
+			if j == t.valueIdx {
+				buffer.Values[j] = t.fill(getColumnValues(cr, j))
+				continue
+			}
+			arr := getColumnValues(cr, j)
+			arr.Retain()
+			buffer.Values[j] = arr
+		}
+		defer buffer.Release()
+		return f(&buffer)
+	})
+}
+
+// fill returns the values of arr with each null value
+// replaced by the last valid value before it.
+func (t *fillPreviousTable) fill(arr array.Interface) array.Interface {
+	switch arr := arr.(type) {
+	case *array.Int64:
+		b := arrow.NewIntBuilder(t.alloc)
+		b.Resize(arr.Len())
+		for i := 0; i < arr.Len(); i++ {
+			if arr.IsValid(i) {
+				t.prev, t.valid = arr.Value(i), true
+			}
+			if !t.valid {
+				b.AppendNull()
+				continue
+			}
+			b.Append(t.prev.(int64))
+		}
+		return b.NewArray()
+	case *array.Float64:
+		b := arrow.NewFloatBuilder(t.alloc)
+		b.Resize(arr.Len())
+		for i := 0; i < arr.Len(); i++ {
+			if arr.IsValid(i) {
+				t.prev, t.valid = arr.Value(i), true
+			}
+			if !t.valid {
+				b.AppendNull()
+				continue
+			}
+			b.Append(t.prev.(float64))
+		}
+		return b.NewArray()
+	case *array.Uint64:
+		b := arrow.NewUintBuilder(t.alloc)
+		b.Resize(arr.Len())
+		for i := 0; i < arr.Len(); i++ {
+			if arr.IsValid(i) {
+				t.prev, t.valid = arr.Value(i), true
+			}
+			if !t.valid {
+				b.AppendNull()
+				continue
+			}
+			b.Append(t.prev.(uint64))
+		}
+		return b.NewArray()
+	case *array.Boolean:
+		b := arrow.NewBoolBuilder(t.alloc)
+		b.Resize(arr.Len())
+		for i := 0; i < arr.Len(); i++ {
+			if arr.IsValid(i) {
+				t.prev, t.valid = arr.Value(i), true
+			}
+			if !t.valid {
+				b.AppendNull()
+				continue
+			}
+			b.Append(t.prev.(bool))
+		}
+		return b.NewArray()
+	case *array.Binary:
+		b := arrow.NewStringBuilder(t.alloc)
+		b.Resize(arr.Len())
+		for i := 0; i < arr.Len(); i++ {
+			if arr.IsValid(i) {
+				t.prev, t.valid = arr.ValueString(i), true
+			}
+			if !t.valid {
+				b.AppendNull()
+				continue
+			}
+			b.AppendString(t.prev.(string))
+		}
+		return b.NewArray()
+	default:
+		arr.Retain()
+		return arr
+	}
+}
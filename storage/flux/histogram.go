@@ -0,0 +1,221 @@
+package storageflux
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/values"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+// HistogramReader counts the values of each window of a series in buckets.
+type HistogramReader interface {
+	// ReadHistogram returns a table for each window of each series read
+	// by spec with a row for each upper bound of buckets, which must be
+	// sorted. Like the histogram function, the le column contains the
+	// upper bound and the _value column the number of values that are
+	// less than or equal to it. Windows without values produce no table.
+	ReadHistogram(ctx context.Context, spec query.ReadHistogramSpec, buckets []float64, alloc *memory.Allocator) (query.TableIterator, error)
+}
+
+func (r *storeReader) ReadHistogram(ctx context.Context, spec query.ReadHistogramSpec, buckets []float64, alloc *memory.Allocator) (query.TableIterator, error) {
+	if len(buckets) == 0 || !sort.Float64sAreSorted(buckets) {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "histogram buckets must be sorted and not empty",
+		}
+	}
+	if spec.WindowEvery < 0 {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "histogram window must not be negative",
+		}
+	}
+
+	ti, err := r.ReadFilter(ctx, spec.ReadFilterSpec, alloc)
+	if err != nil {
+		return nil, err
+	}
+	return &histogramIterator{
+		TableIterator: ti,
+		spec:          spec,
+		buckets:       buckets,
+		alloc:         alloc,
+	}, nil
+}
+
+// histogramIterator counts the values of the tables of a ReadFilter.
+type histogramIterator struct {
+	query.TableIterator
+	spec    query.ReadHistogramSpec
+	buckets []float64
+	alloc   *memory.Allocator
+}
+
+func (hi *histogramIterator) Do(f func(flux.Table) error) error {
+	return hi.TableIterator.Do(func(tbl flux.Table) error {
+		return hi.histogram(tbl, f)
+	})
+}
+
+// histogram produces a table with the bucket counts
+// of each window of tbl that has values.
+func (hi *histogramIterator) histogram(tbl flux.Table, f func(flux.Table) error) error {
+	cols := tbl.Cols()
+	timeIdx := execute.ColIdx(execute.DefaultTimeColLabel, cols)
+	valueIdx := execute.ColIdx(execute.DefaultValueColLabel, cols)
+	if timeIdx < 0 || valueIdx < 0 {
+		tbl.Done()
+		return nil
+	}
+	switch typ := cols[valueIdx].Type; typ {
+	case flux.TFloat, flux.TInt, flux.TUInt:
+	default:
+		tbl.Done()
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("cannot compute a histogram of %s values", typ),
+		}
+	}
+
+	var (
+		counts      = make([]int64, len(hi.buckets))
+		start, stop int64
+		window      bool
+	)
+	if err := tbl.Do(func(cr flux.ColReader) error {
+		times := cr.Times(timeIdx)
+		for i := 0; i < cr.Len(); i++ {
+			if !times.IsValid(i) {
+				continue
+			}
+			v, ok := histogramValue(cr, i, valueIdx)
+			if !ok {
+				continue
+			}
+
+			if t := times.Value(i); !window || t >= stop {
+				if window {
+					if err := hi.emit(tbl.Key(), start, stop, counts, f); err != nil {
+						return err
+					}
+				}
+				start, stop = hi.window(t)
+				window = true
+			}
+			if j := sort.SearchFloat64s(hi.buckets, v); j < len(counts) {
+				counts[j]++
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if !window {
+		return nil
+	}
+	return hi.emit(tbl.Key(), start, stop, counts, f)
+}
+
+// window returns the bounds of the window that contains t.
+func (hi *histogramIterator) window(t int64) (start, stop int64) {
+	bounds := hi.spec.Bounds
+	every := hi.spec.WindowEvery
+	if every == 0 {
+		return int64(bounds.Start), int64(bounds.Stop)
+	}
+
+	m := (t - hi.spec.Offset) % every
+	if m < 0 {
+		m += every
+	}
+	start, stop = t-m, t-m+every
+	if start < int64(bounds.Start) {
+		start = int64(bounds.Start)
+	}
+	if stop > int64(bounds.Stop) {
+		stop = int64(bounds.Stop)
+	}
+	return start, stop
+}
+
+// emit passes a table with the cumulative counts of a window
+// to f and resets the counts for the next window.
+func (hi *histogramIterator) emit(key flux.GroupKey, start, stop int64, counts []int64, f func(flux.Table) error) error {
+	cols := key.Cols()
+	vs := make([]values.Value, len(cols))
+	for j, c := range cols {
+		switch c.Label {
+		case execute.DefaultStartColLabel:
+			vs[j] = values.NewTime(values.Time(start))
+		case execute.DefaultStopColLabel:
+			vs[j] = values.NewTime(values.Time(stop))
+		default:
+			vs[j] = key.Value(j)
+		}
+	}
+	key = execute.NewGroupKey(cols, vs)
+
+	builder := execute.NewColListTableBuilder(key, hi.alloc)
+	defer builder.ClearData()
+	if err := execute.AddTableKeyCols(key, builder); err != nil {
+		return err
+	}
+	leIdx, err := builder.AddCol(flux.ColMeta{Label: "le", Type: flux.TFloat})
+	if err != nil {
+		return err
+	}
+	valueIdx, err := builder.AddCol(flux.ColMeta{Label: execute.DefaultValueColLabel, Type: flux.TFloat})
+	if err != nil {
+		return err
+	}
+
+	var n int64
+	for i, le := range hi.buckets {
+		n += counts[i]
+		counts[i] = 0
+		for j := range cols {
+			if err := builder.AppendValue(j, key.Value(j)); err != nil {
+				return err
+			}
+		}
+		if err := builder.AppendFloat(leIdx, le); err != nil {
+			return err
+		}
+		if err := builder.AppendFloat(valueIdx, float64(n)); err != nil {
+			return err
+		}
+	}
+
+	tbl, err := builder.Table()
+	if err != nil {
+		return err
+	}
+	builder.ClearData()
+	return f(tbl)
+}
+
+// histogramValue returns the numeric value of row i as a float.
+func histogramValue(cr flux.ColReader, i, j int) (float64, bool) {
+	switch cr.Cols()[j].Type {
+	case flux.TFloat:
+		if vs := cr.Floats(j); vs.IsValid(i) {
+			return vs.Value(i), true
+		}
+	case flux.TInt:
+		if vs := cr.Ints(j); vs.IsValid(i) {
+			return float64(vs.Value(i)), true
+		}
+	case flux.TUInt:
+		if vs := cr.UInts(j); vs.IsValid(i) {
+			return float64(vs.Value(i)), true
+		}
+	}
+	return 0, false
+}
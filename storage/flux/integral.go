@@ -0,0 +1,163 @@
+package storageflux
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// readIntegral computes the area under the values of each window.
+// The storage engine does not support this aggregate so the raw
+// values are read and each window is computed here.
+func (wai *windowAggregateIterator) readIntegral(f func(flux.Table) error) error {
+	unit := wai.spec.IntegralUnit
+	if unit < 0 {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "integral unit must be positive",
+		}
+	} else if unit == 0 {
+		unit = int64(time.Second)
+	}
+
+	rs, err := wai.readFilter()
+	if err != nil || rs == nil {
+		return err
+	}
+
+	every, offset := wai.windowEveryAndOffset()
+	return wai.handleRead(f, &integralResultSet{
+		ResultSet: rs,
+		every:     every,
+		offset:    offset,
+		unit:      unit,
+	})
+}
+
+// integralResultSet wraps the cursors of a ResultSet so that
+// they produce the integral for each window.
+type integralResultSet struct {
+	storage.ResultSet
+	every, offset int64
+	unit          int64
+	err           error
+}
+
+func (r *integralResultSet) Cursor() cursors.Cursor {
+	cur := r.ResultSet.Cursor()
+	if cur == nil {
+		return nil
+	}
+
+	var floatCur cursors.FloatArrayCursor
+	switch typedCur := cur.(type) {
+	case cursors.FloatArrayCursor:
+		floatCur = typedCur
+	case cursors.IntegerArrayCursor:
+		floatCur = newIntegerToFloatArrayCursor(typedCur)
+	case cursors.UnsignedArrayCursor:
+		floatCur = newUnsignedToFloatArrayCursor(typedCur)
+	default:
+		cur.Close()
+		if r.err == nil {
+			r.err = &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  fmt.Sprintf("unsupported for aggregate integral: %T", cur),
+			}
+		}
+		return nil
+	}
+	return newWindowIntegralCursor(floatCur, r.every, r.offset, r.unit)
+}
+
+func (r *integralResultSet) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.ResultSet.Err()
+}
+
+// windowIntegralCursor produces the trapezoidal area under the values
+// of each window in multiples of unit. The values are interpolated
+// linearly at the window boundaries so that the area between two points
+// in different windows is divided between those windows. The timestamp
+// of each value is the window stop time.
+type windowIntegralCursor struct {
+	cursors.FloatArrayCursor
+	every, offset int64
+	unit          float64
+	res           *cursors.FloatArray
+
+	// the previous point
+	prevTS  int64
+	prev    float64
+	hasPrev bool
+
+	// state of the current window
+	windowEnd int64
+	acc       float64
+}
+
+func newWindowIntegralCursor(cur cursors.FloatArrayCursor, every, offset, unit int64) *windowIntegralCursor {
+	return &windowIntegralCursor{
+		FloatArrayCursor: cur,
+		every:            every,
+		offset:           offset,
+		unit:             float64(unit),
+		res:              cursors.NewFloatArrayLen(storage.MaxPointsPerBlock),
+	}
+}
+
+func (c *windowIntegralCursor) Next() *cursors.FloatArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	for c.res.Len() == 0 {
+		a := c.FloatArrayCursor.Next()
+		if a.Len() == 0 {
+			// Emit the final window.
+			if c.hasPrev {
+				c.emit()
+				c.hasPrev = false
+			}
+			break
+		}
+
+		for i, ts := range a.Timestamps {
+			v := a.Values[i]
+			if !c.hasPrev {
+				c.windowEnd = windowStop(ts, c.every, c.offset)
+				c.prevTS, c.prev, c.hasPrev = ts, v, true
+				c.acc = 0
+				continue
+			}
+			c.addSegment(ts, v)
+			c.prevTS, c.prev = ts, v
+		}
+	}
+	return c.res
+}
+
+// addSegment adds the area between the previous point and the point
+// at ts with value v, emitting each window the segment passes the end of.
+func (c *windowIntegralCursor) addSegment(ts int64, v float64) {
+	startTS, start := c.prevTS, c.prev
+	for ts >= c.windowEnd {
+		end := c.prev + (v-c.prev)*float64(c.windowEnd-c.prevTS)/float64(ts-c.prevTS)
+		c.acc += (start + end) / 2 * float64(c.windowEnd-startTS) / c.unit
+		c.emit()
+		startTS, start = c.windowEnd, end
+		c.windowEnd += c.every
+		c.acc = 0
+	}
+	c.acc += (start + v) / 2 * float64(ts-startTS) / c.unit
+}
+
+func (c *windowIntegralCursor) emit() {
+	c.res.Timestamps = append(c.res.Timestamps, c.windowEnd)
+	c.res.Values = append(c.res.Values, c.acc)
+}
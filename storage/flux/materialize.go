@@ -0,0 +1,173 @@
+package storageflux
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/values"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// pointsWriter writes the windows materialized by ReadWindowAggregate.
+type pointsWriter = storage.PointsWriter
+
+// WithPointsWriter sets the writer of the points materialized by
+// ReadWindowAggregate with the MaterializeTo option and the service
+// used to find the bucket they are written to. The option is rejected
+// by readers without them.
+func WithPointsWriter(w storage.PointsWriter, buckets influxdb.BucketService) Option {
+	return func(r *storeReader) {
+		r.pw = w
+		r.buckets = buckets
+	}
+}
+
+// materialize returns a function that writes the rows of each table
+// to the MaterializeTo bucket before passing the table to f. Rows with
+// a null value are not written. The time of each point is the _time
+// column, or the _stop column when it is missing or null.
+func (wai *windowAggregateIterator) materialize(f func(flux.Table) error) (func(flux.Table) error, error) {
+	if wai.pw == nil || wai.buckets == nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "materializing windows is not supported by this reader",
+		}
+	}
+	if len(wai.spec.Aggregates) == 0 {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "materializing windows requires an aggregate",
+		}
+	}
+
+	if err := wai.authorizeMaterialize(); err != nil {
+		return nil, err
+	}
+
+	name := tsdb.EncodeNameString(wai.spec.OrganizationID, wai.spec.MaterializeTo)
+	label := wai.valueColumn()
+	return func(tbl flux.Table) error {
+		cols := tbl.Cols()
+		valueIdx := execute.ColIdx(label, cols)
+		fieldIdx := execute.ColIdx("_field", cols)
+		measurementIdx := execute.ColIdx("_measurement", cols)
+		if valueIdx < 0 || fieldIdx < 0 || measurementIdx < 0 {
+			return f(tbl)
+		}
+		timeIdx := execute.ColIdx(execute.DefaultTimeColLabel, cols)
+		stopIdx := execute.ColIdx(execute.DefaultStopColLabel, cols)
+
+		// The remaining string columns are the tags of the series.
+		var tagIdxs []int
+		for j, c := range cols {
+			switch {
+			case c.Type != flux.TString, j == valueIdx, j == fieldIdx, j == measurementIdx:
+				continue
+			}
+			tagIdxs = append(tagIdxs, j)
+		}
+
+		builder := execute.NewColListTableBuilder(tbl.Key(), wai.alloc)
+		defer builder.ClearData()
+		if err := execute.AddTableCols(tbl, builder); err != nil {
+			return err
+		}
+
+		if err := tbl.Do(func(cr flux.ColReader) error {
+			points := make([]models.Point, 0, cr.Len())
+			for i := 0; i < cr.Len(); i++ {
+				for j := range cr.Cols() {
+					if err := builder.AppendValue(j, execute.ValueForRow(cr, i, j)); err != nil {
+						return err
+					}
+				}
+
+				v := execute.ValueForRow(cr, i, valueIdx)
+				if v.IsNull() {
+					continue
+				}
+				var ts values.Time
+				if j := timeIdx; j >= 0 && cr.Times(j).IsValid(i) {
+					ts = values.Time(cr.Times(j).Value(i))
+				} else if j := stopIdx; j >= 0 && cr.Times(j).IsValid(i) {
+					ts = values.Time(cr.Times(j).Value(i))
+				} else {
+					continue
+				}
+
+				tags := make(models.Tags, 0, len(tagIdxs)+2)
+				tags = append(tags,
+					models.NewTag(models.MeasurementTagKeyBytes, []byte(cr.Strings(measurementIdx).ValueString(i))),
+					models.NewTag(models.FieldKeyTagKeyBytes, []byte(cr.Strings(fieldIdx).ValueString(i))),
+				)
+				for _, j := range tagIdxs {
+					if arr := cr.Strings(j); arr.IsValid(i) {
+						tags = append(tags, models.NewTag([]byte(cr.Cols()[j].Label), []byte(arr.ValueString(i))))
+					}
+				}
+				sort.Sort(tags)
+
+				field := cr.Strings(fieldIdx).ValueString(i)
+				pt, err := models.NewPoint(name, tags, models.Fields{field: materializedValue(cols[valueIdx].Type, v)}, time.Unix(0, int64(ts)))
+				if err != nil {
+					return err
+				}
+				points = append(points, pt)
+			}
+			if len(points) == 0 {
+				return nil
+			}
+			return wai.pw.WritePoints(wai.ctx, points)
+		}); err != nil {
+			return err
+		}
+
+		out, err := builder.Table()
+		if err != nil {
+			return err
+		}
+		builder.ClearData()
+		return f(out)
+	}, nil
+}
+
+// authorizeMaterialize checks that the MaterializeTo bucket belongs to
+// the organization of the read and that the authorizer of the context
+// is permitted to write to it.
+func (wai *windowAggregateIterator) authorizeMaterialize() error {
+	b, err := wai.buckets.FindBucketByID(wai.ctx, wai.spec.MaterializeTo)
+	if err != nil {
+		return err
+	}
+	if b.OrgID != wai.spec.OrganizationID {
+		return &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Msg:  fmt.Sprintf("bucket %s not found in organization %s", wai.spec.MaterializeTo, wai.spec.OrganizationID),
+		}
+	}
+	_, _, err = authorizer.AuthorizeWrite(wai.ctx, influxdb.BucketsResourceType, b.ID, b.OrgID)
+	return err
+}
+
+// materializedValue returns the field value of an aggregated value.
+func materializedValue(typ flux.ColType, v values.Value) interface{} {
+	switch typ {
+	case flux.TInt:
+		return v.Int()
+	case flux.TUInt:
+		return v.UInt()
+	case flux.TFloat:
+		return v.Float()
+	case flux.TBool:
+		return v.Bool()
+	default:
+		return v.Str()
+	}
+}
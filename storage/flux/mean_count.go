@@ -0,0 +1,197 @@
+package storageflux
+
+import (
+	"fmt"
+
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// countColLabel is the label of the column containing
+// the number of points in each window of a mean.
+const countColLabel = "_count"
+
+// readMeanCount computes the mean and the number of points of each
+// window. The storage engine cannot return both from a single read so
+// the raw values are read and each window is computed here.
+func (wai *windowAggregateIterator) readMeanCount(f func(flux.Table) error) error {
+	rs, err := wai.readFilter()
+	if err != nil || rs == nil {
+		return err
+	}
+
+	every, offset := wai.windowEveryAndOffset()
+	return wai.handleRead(f, &meanCountResultSet{
+		ResultSet: rs,
+		every:     every,
+		offset:    offset,
+	})
+}
+
+// meanCountResultSet wraps the cursors of a ResultSet so that
+// they produce the mean and count for each window.
+type meanCountResultSet struct {
+	storage.ResultSet
+	every, offset int64
+	err           error
+}
+
+func (r *meanCountResultSet) Cursor() cursors.Cursor {
+	cur := r.ResultSet.Cursor()
+	if cur == nil {
+		return nil
+	}
+
+	var floatCur cursors.FloatArrayCursor
+	switch typedCur := cur.(type) {
+	case cursors.FloatArrayCursor:
+		floatCur = typedCur
+	case cursors.IntegerArrayCursor:
+		floatCur = newIntegerToFloatArrayCursor(typedCur)
+	case cursors.UnsignedArrayCursor:
+		floatCur = newUnsignedToFloatArrayCursor(typedCur)
+	default:
+		cur.Close()
+		if r.err == nil {
+			r.err = &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  fmt.Sprintf("unsupported for aggregate mean: %T", cur),
+			}
+		}
+		return nil
+	}
+	return newWindowMeanCountCursor(floatCur, r.every, r.offset)
+}
+
+func (r *meanCountResultSet) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.ResultSet.Err()
+}
+
+// windowMeanCountCursor produces the mean of each window. The number
+// of points of each window is queued in counts, in the same order as
+// the values produced by Next, until it is consumed by a meanCountTable.
+type windowMeanCountCursor struct {
+	cursors.FloatArrayCursor
+	every, offset int64
+	res           *cursors.FloatArray
+	counts        []int64
+
+	// state of the current window
+	windowEnd     int64
+	sum           float64
+	count         int64
+	windowHasData bool
+}
+
+func newWindowMeanCountCursor(cur cursors.FloatArrayCursor, every, offset int64) *windowMeanCountCursor {
+	return &windowMeanCountCursor{
+		FloatArrayCursor: cur,
+		every:            every,
+		offset:           offset,
+		res:              cursors.NewFloatArrayLen(storage.MaxPointsPerBlock),
+	}
+}
+
+func (c *windowMeanCountCursor) Next() *cursors.FloatArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	for c.res.Len() == 0 {
+		a := c.FloatArrayCursor.Next()
+		if a.Len() == 0 {
+			// Emit the final window.
+			c.emit()
+			c.windowHasData = false
+			break
+		}
+
+		for i, ts := range a.Timestamps {
+			if c.windowHasData && ts >= c.windowEnd {
+				c.emit()
+				c.windowHasData = false
+			}
+
+			if !c.windowHasData {
+				c.windowEnd = windowStop(ts, c.every, c.offset)
+				c.sum, c.count = 0, 0
+				c.windowHasData = true
+			}
+			c.sum += a.Values[i]
+			c.count++
+		}
+	}
+	return c.res
+}
+
+func (c *windowMeanCountCursor) emit() {
+	if !c.windowHasData {
+		return
+	}
+	c.res.Timestamps = append(c.res.Timestamps, c.windowEnd)
+	c.res.Values = append(c.res.Values, c.sum/float64(c.count))
+	c.counts = append(c.counts, c.count)
+}
+
+// meanCountTable adds a _count column to a table of means
+// using the counts queued by a windowMeanCountCursor.
+// Windows without a mean have a count of zero.
+type meanCountTable struct {
+	storageTable
+	cur      *windowMeanCountCursor
+	cols     []flux.ColMeta
+	valueIdx int
+	alloc    *memory.Allocator
+}
+
+func newMeanCountTable(table storageTable, cur *windowMeanCountCursor, valueIdx int, alloc *memory.Allocator) *meanCountTable {
+	cols := make([]flux.ColMeta, 0, len(table.Cols())+1)
+	cols = append(cols, table.Cols()...)
+	cols = append(cols, flux.ColMeta{Label: countColLabel, Type: flux.TInt})
+	return &meanCountTable{
+		storageTable: table,
+		cur:          cur,
+		cols:         cols,
+		valueIdx:     valueIdx,
+		alloc:        alloc,
+	}
+}
+
+func (t *meanCountTable) Cols() []flux.ColMeta { return t.cols }
+
+func (t *meanCountTable) Do(f func(flux.ColReader) error) error {
+	return t.storageTable.Do(func(cr flux.ColReader) error {
+		vs := cr.Floats(t.valueIdx)
+		b := arrow.NewIntBuilder(t.alloc)
+		b.Resize(cr.Len())
+		for i, n := 0, cr.Len(); i < n; i++ {
+			if vs.IsNull(i) {
+				b.Append(0)
+				continue
+			}
+			b.Append(t.cur.counts[0])
+			t.cur.counts = t.cur.counts[1:]
+		}
+
+		buffer := arrow.TableBuffer{
+			GroupKey: cr.Key(),
+			Columns:  t.cols,
+			Values:   make([]array.Interface, len(t.cols)),
+		}
+		for j := range cr.Cols() {
+			arr := getColumnValues(cr, j)
+			arr.Retain()
+			buffer.Values[j] = arr
+		}
+		buffer.Values[len(t.cols)-1] = b.NewInt64Array()
+		defer buffer.Release()
+		return f(&buffer)
+	})
+}
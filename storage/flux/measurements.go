@@ -0,0 +1,97 @@
+package storageflux
+
+import (
+	"context"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/query"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// MeasurementsReader lists the measurements of a bucket
+// without reading any of their values.
+type MeasurementsReader interface {
+	// ReadMeasurements returns a single table with a row for each
+	// measurement with series matching the predicate of spec within
+	// its bounds. The _measurement column contains the name of the
+	// measurement. The measurements are read from the index.
+	ReadMeasurements(ctx context.Context, spec query.ReadFilterSpec, alloc *memory.Allocator) (query.TableIterator, error)
+}
+
+func (r *storeReader) ReadMeasurements(ctx context.Context, spec query.ReadFilterSpec, alloc *memory.Allocator) (query.TableIterator, error) {
+	return &measurementsIterator{
+		ctx:   ctx,
+		s:     r.s,
+		spec:  spec,
+		alloc: alloc,
+	}, nil
+}
+
+type measurementsIterator struct {
+	ctx   context.Context
+	s     storage.Store
+	spec  query.ReadFilterSpec
+	alloc *memory.Allocator
+}
+
+func (mi *measurementsIterator) Do(f func(flux.Table) error) error {
+	src := mi.s.GetSource(
+		uint64(mi.spec.OrganizationID),
+		uint64(mi.spec.BucketID),
+	)
+
+	var req datatypes.TagValuesRequest
+	any, err := types.MarshalAny(src)
+	if err != nil {
+		return err
+	}
+	req.TagsSource = any
+	req.TagKey = models.MeasurementTagKey
+	req.Predicate = mi.spec.Predicate
+	req.Range.Start = int64(mi.spec.Bounds.Start)
+	req.Range.End = int64(mi.spec.Bounds.Stop)
+
+	rs, err := mi.s.TagValues(mi.ctx, &req)
+	if err != nil {
+		return err
+	}
+	return mi.handleRead(f, rs)
+}
+
+func (mi *measurementsIterator) handleRead(f func(flux.Table) error, rs cursors.StringIterator) error {
+	key := execute.NewGroupKey(nil, nil)
+	builder := execute.NewColListTableBuilder(key, mi.alloc)
+	defer builder.ClearData()
+
+	nameIdx, err := builder.AddCol(flux.ColMeta{Label: "_measurement", Type: flux.TString})
+	if err != nil {
+		return err
+	}
+
+	for rs.Next() {
+		if err := builder.AppendString(nameIdx, rs.Value()); err != nil {
+			return err
+		}
+	}
+
+	// Construct the table and add to the reference count
+	// so we can free the table later.
+	tbl, err := builder.Table()
+	if err != nil {
+		return err
+	}
+
+	// Release the references to the arrays held by the builder.
+	builder.ClearData()
+	return f(tbl)
+}
+
+func (mi *measurementsIterator) Statistics() cursors.CursorStats {
+	return cursors.CursorStats{}
+}
@@ -0,0 +1,190 @@
+package storageflux
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/influxdb/v2/models"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// seriesFields records the fields of each series produced by a read
+// so that the fields a series lacks can be produced as null tables.
+// A series is identified by its tags without the _field tag.
+type seriesFields struct {
+	coerceToFloat bool
+
+	mu sync.Mutex
+	// types holds the type of each field read for a measurement.
+	types map[string]map[string]flux.ColType
+	// series holds the series in the order they were first read.
+	series []*fieldSet
+	index  map[string]*fieldSet
+}
+
+// fieldSet is a series and the fields read for it.
+type fieldSet struct {
+	tags   models.Tags
+	fields map[string]bool
+}
+
+func newSeriesFields(coerceToFloat bool) *seriesFields {
+	return &seriesFields{
+		coerceToFloat: coerceToFloat,
+		types:         make(map[string]map[string]flux.ColType),
+		index:         make(map[string]*fieldSet),
+	}
+}
+
+// track wraps read so that the fields of each of its
+// series are recorded as their cursors are created.
+func (sf *seriesFields) track(read readFunc) readFunc {
+	return func(n int) ([]storage.ResultSet, error) {
+		rss, err := read(n)
+		if err != nil {
+			return nil, err
+		}
+		for i, rs := range rss {
+			if rs != nil {
+				rss[i] = &seriesFieldsResultSet{ResultSet: rs, fields: sf}
+			}
+		}
+		return rss, nil
+	}
+}
+
+// add records the series identified by tags. The field of the series
+// is only recorded if it has data, that is if cur is not nil.
+func (sf *seriesFields) add(tags models.Tags, cur cursors.Cursor) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	field := string(tags.Get(fieldKeyBytes))
+	seriesTags := tags.Clone()
+	seriesTags.Delete(fieldKeyBytes)
+
+	key := string(seriesTags.HashKey())
+	fs, ok := sf.index[key]
+	if !ok {
+		fs = &fieldSet{tags: seriesTags, fields: make(map[string]bool)}
+		sf.index[key] = fs
+		sf.series = append(sf.series, fs)
+	}
+	if cur == nil {
+		return
+	}
+	fs.fields[field] = true
+
+	measurement := seriesTags.GetString(datatypes.MeasurementKey)
+	types, ok := sf.types[measurement]
+	if !ok {
+		types = make(map[string]flux.ColType)
+		sf.types[measurement] = types
+	}
+	if _, ok := types[field]; !ok {
+		types[field] = sf.colType(cur)
+	}
+}
+
+// colType returns the type of the _value column
+// of the table that is produced for cur.
+func (sf *seriesFields) colType(cur cursors.Cursor) flux.ColType {
+	switch cur.(type) {
+	case cursors.IntegerArrayCursor:
+		if sf.coerceToFloat {
+			return flux.TFloat
+		}
+		return flux.TInt
+	case cursors.FloatArrayCursor:
+		return flux.TFloat
+	case cursors.UnsignedArrayCursor:
+		if sf.coerceToFloat {
+			return flux.TFloat
+		}
+		return flux.TUInt
+	case cursors.BooleanArrayCursor:
+		return flux.TBool
+	case cursors.StringArrayCursor:
+		return flux.TString
+	default:
+		return flux.TInvalid
+	}
+}
+
+// seriesFieldsResultSet records the fields of
+// each series of a ResultSet in a seriesFields.
+type seriesFieldsResultSet struct {
+	storage.ResultSet
+	fields *seriesFields
+}
+
+func (rs *seriesFieldsResultSet) Cursor() cursors.Cursor {
+	cur := rs.ResultSet.Cursor()
+	rs.fields.add(rs.Tags(), cur)
+	return cur
+}
+
+// missingFieldTables produces a table with a single null row for each
+// field that was read for a measurement but not for one of its series.
+func (fi *filterIterator) missingFieldTables(f func(flux.Table) error, sf *seriesFields) error {
+	for _, fs := range sf.series {
+		types := sf.types[fs.tags.GetString(datatypes.MeasurementKey)]
+		fields := make([]string, 0, len(types))
+		for field := range types {
+			if !fs.fields[field] {
+				fields = append(fields, field)
+			}
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			tags := fs.tags.Clone()
+			tags.Set(fieldKeyBytes, []byte(field))
+			tbl, err := fi.nullTable(tags, types[field])
+			if err != nil {
+				return err
+			}
+			if err := f(tbl); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nullTable returns a table for the series identified by tags
+// with a single row whose _time and _value are null.
+func (fi *filterIterator) nullTable(tags models.Tags, typ flux.ColType) (flux.Table, error) {
+	bnds := fi.spec.Bounds
+	cols, defs := determineTableColsForSeries(tags, typ)
+	cols, defs = fi.withSeriesKeyColumn(cols, defs, tags)
+
+	builder := execute.NewColListTableBuilder(defaultGroupKeyForSeries(tags, bnds), fi.alloc)
+	for j, col := range cols {
+		if _, err := builder.AddCol(col); err != nil {
+			return nil, err
+		}
+
+		var err error
+		switch {
+		case j == startColIdx:
+			err = builder.AppendTime(j, bnds.Start)
+		case j == stopColIdx:
+			err = builder.AppendTime(j, bnds.Stop)
+		case j == timeColIdx, j == valueColIdx:
+			err = builder.AppendNil(j)
+		case j-4 < len(tags):
+			err = builder.AppendString(j, string(tags[j-4].Value))
+		default:
+			err = builder.AppendString(j, string(defs[j]))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return builder.Table()
+}
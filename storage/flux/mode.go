@@ -0,0 +1,209 @@
+package storageflux
+
+import (
+	"fmt"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// readMode computes the most frequent value of each window. The storage
+// engine does not support this aggregate so the raw values are read
+// and each window is computed here.
+func (wai *windowAggregateIterator) readMode(f func(flux.Table) error) error {
+	rs, err := wai.readFilter()
+	if err != nil || rs == nil {
+		return err
+	}
+
+	every, offset := wai.windowEveryAndOffset()
+	return wai.handleRead(f, &modeResultSet{
+		ResultSet: rs,
+		every:     every,
+		offset:    offset,
+	})
+}
+
+// modeResultSet wraps the cursors of a ResultSet so that
+// they produce the mode for each window.
+type modeResultSet struct {
+	storage.ResultSet
+	every, offset int64
+	err           error
+}
+
+func (r *modeResultSet) Cursor() cursors.Cursor {
+	cur := r.ResultSet.Cursor()
+	if cur == nil {
+		return nil
+	}
+
+	switch typedCur := cur.(type) {
+	case cursors.IntegerArrayCursor:
+		return newIntegerWindowModeCursor(typedCur, r.every, r.offset)
+	case cursors.StringArrayCursor:
+		return newStringWindowModeCursor(typedCur, r.every, r.offset)
+	default:
+		cur.Close()
+		if r.err == nil {
+			r.err = &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  fmt.Sprintf("unsupported for aggregate mode: %T", cur),
+			}
+		}
+		return nil
+	}
+}
+
+func (r *modeResultSet) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.ResultSet.Err()
+}
+
+// integerWindowModeCursor produces the most frequent value of each
+// window. When several values are equally frequent, the smallest of
+// them is produced. The timestamp of each value is the window stop time.
+type integerWindowModeCursor struct {
+	cursors.IntegerArrayCursor
+	every, offset int64
+	res           *cursors.IntegerArray
+
+	// state of the current window
+	windowEnd     int64
+	counts        map[int64]int
+	windowHasData bool
+}
+
+func newIntegerWindowModeCursor(cur cursors.IntegerArrayCursor, every, offset int64) *integerWindowModeCursor {
+	return &integerWindowModeCursor{
+		IntegerArrayCursor: cur,
+		every:              every,
+		offset:             offset,
+		res:                cursors.NewIntegerArrayLen(storage.MaxPointsPerBlock),
+		counts:             make(map[int64]int),
+	}
+}
+
+func (c *integerWindowModeCursor) Next() *cursors.IntegerArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	for c.res.Len() == 0 {
+		a := c.IntegerArrayCursor.Next()
+		if a.Len() == 0 {
+			// Emit the final window.
+			c.emit()
+			c.windowHasData = false
+			break
+		}
+
+		for i, ts := range a.Timestamps {
+			if c.windowHasData && ts >= c.windowEnd {
+				c.emit()
+				c.windowHasData = false
+			}
+
+			if !c.windowHasData {
+				c.windowEnd = windowStop(ts, c.every, c.offset)
+				for v := range c.counts {
+					delete(c.counts, v)
+				}
+				c.windowHasData = true
+			}
+			c.counts[a.Values[i]]++
+		}
+	}
+	return c.res
+}
+
+func (c *integerWindowModeCursor) emit() {
+	if !c.windowHasData {
+		return
+	}
+
+	var mode int64
+	n := 0
+	for v, count := range c.counts {
+		if count > n || (count == n && v < mode) {
+			mode, n = v, count
+		}
+	}
+	c.res.Timestamps = append(c.res.Timestamps, c.windowEnd)
+	c.res.Values = append(c.res.Values, mode)
+}
+
+// stringWindowModeCursor produces the most frequent value of each
+// window. When several values are equally frequent, the smallest of
+// them is produced. The timestamp of each value is the window stop time.
+type stringWindowModeCursor struct {
+	cursors.StringArrayCursor
+	every, offset int64
+	res           *cursors.StringArray
+
+	// state of the current window
+	windowEnd     int64
+	counts        map[string]int
+	windowHasData bool
+}
+
+func newStringWindowModeCursor(cur cursors.StringArrayCursor, every, offset int64) *stringWindowModeCursor {
+	return &stringWindowModeCursor{
+		StringArrayCursor: cur,
+		every:             every,
+		offset:            offset,
+		res:               cursors.NewStringArrayLen(storage.MaxPointsPerBlock),
+		counts:            make(map[string]int),
+	}
+}
+
+func (c *stringWindowModeCursor) Next() *cursors.StringArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	for c.res.Len() == 0 {
+		a := c.StringArrayCursor.Next()
+		if a.Len() == 0 {
+			// Emit the final window.
+			c.emit()
+			c.windowHasData = false
+			break
+		}
+
+		for i, ts := range a.Timestamps {
+			if c.windowHasData && ts >= c.windowEnd {
+				c.emit()
+				c.windowHasData = false
+			}
+
+			if !c.windowHasData {
+				c.windowEnd = windowStop(ts, c.every, c.offset)
+				for v := range c.counts {
+					delete(c.counts, v)
+				}
+				c.windowHasData = true
+			}
+			c.counts[a.Values[i]]++
+		}
+	}
+	return c.res
+}
+
+func (c *stringWindowModeCursor) emit() {
+	if !c.windowHasData {
+		return
+	}
+
+	var mode string
+	n := 0
+	for v, count := range c.counts {
+		if count > n || (count == n && v < mode) {
+			mode, n = v, count
+		}
+	}
+	c.res.Timestamps = append(c.res.Timestamps, c.windowEnd)
+	c.res.Values = append(c.res.Values, mode)
+}
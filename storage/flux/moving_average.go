@@ -0,0 +1,97 @@
+package storageflux
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// movingAverageResultSet wraps the cursors of a ResultSet of window
+// means so that they produce the moving average of the means.
+type movingAverageResultSet struct {
+	storage.ResultSet
+	n   int
+	err error
+}
+
+func (r *movingAverageResultSet) Cursor() cursors.Cursor {
+	cur := r.ResultSet.Cursor()
+	if cur == nil {
+		return nil
+	}
+
+	floatCur, ok := cur.(cursors.FloatArrayCursor)
+	if !ok {
+		cur.Close()
+		if r.err == nil {
+			r.err = &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  fmt.Sprintf("unsupported for moving average: %T", cur),
+			}
+		}
+		return nil
+	}
+	return newMovingAverageCursor(floatCur, r.n)
+}
+
+func (r *movingAverageResultSet) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.ResultSet.Err()
+}
+
+// movingAverageCursor produces the average of each window mean and
+// the n-1 window means before it. Nothing is produced for the first
+// n-1 windows as they do not have enough windows before them.
+type movingAverageCursor struct {
+	cursors.FloatArrayCursor
+	res *cursors.FloatArray
+
+	// means of the last n windows, with the
+	// mean of the oldest window at means[i]
+	means []float64
+	i     int
+}
+
+func newMovingAverageCursor(cur cursors.FloatArrayCursor, n int) *movingAverageCursor {
+	return &movingAverageCursor{
+		FloatArrayCursor: cur,
+		res:              cursors.NewFloatArrayLen(storage.MaxPointsPerBlock),
+		means:            make([]float64, 0, n),
+	}
+}
+
+func (c *movingAverageCursor) Next() *cursors.FloatArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	for c.res.Len() == 0 {
+		a := c.FloatArrayCursor.Next()
+		if a.Len() == 0 {
+			break
+		}
+
+		for i, ts := range a.Timestamps {
+			if len(c.means) < cap(c.means) {
+				c.means = append(c.means, a.Values[i])
+				if len(c.means) < cap(c.means) {
+					continue
+				}
+			} else {
+				c.means[c.i] = a.Values[i]
+				c.i = (c.i + 1) % len(c.means)
+			}
+
+			var sum float64
+			for j := range c.means {
+				sum += c.means[(c.i+j)%len(c.means)]
+			}
+			c.res.Timestamps = append(c.res.Timestamps, ts)
+			c.res.Values = append(c.res.Values, sum/float64(len(c.means)))
+		}
+	}
+	return c.res
+}
@@ -0,0 +1,211 @@
+package storageflux
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/values"
+	"github.com/influxdata/influxdb/v2"
+)
+
+// readPivot reads the aggregated windows of the spec without pivoting
+// them and passes a table with the fields of each series as columns to
+// f once they have all been read.
+func (wai *windowAggregateIterator) readPivot(f func(flux.Table) error) error {
+	spec := wai.spec
+	spec.Pivot = false
+
+	narrow := &windowAggregateIterator{
+		ctx:     wai.ctx,
+		s:       wai.s,
+		spec:    spec,
+		cache:   wai.cache,
+		alloc:   wai.alloc,
+		pw:      wai.pw,
+		buckets: wai.buckets,
+	}
+	p := &pivoter{
+		label:  wai.valueColumn(),
+		lookup: execute.NewRandomAccessGroupLookup(),
+		alloc:  wai.alloc,
+	}
+	err := narrow.Do(p.add)
+	wai.stats.ScannedValues += narrow.stats.ScannedValues
+	wai.stats.ScannedBytes += narrow.stats.ScannedBytes
+	if err != nil {
+		return err
+	}
+	return p.emit(f)
+}
+
+// pivoter collects the values of the tables of a read by their
+// group key without the _field column.
+type pivoter struct {
+	label  string
+	groups []*pivotGroup
+	lookup *execute.RandomAccessGroupLookup
+	alloc  *memory.Allocator
+}
+
+// pivotGroup is the pivoted values of a series.
+type pivotGroup struct {
+	key    flux.GroupKey
+	times  []int64
+	rows   map[int64]struct{}
+	types  map[string]flux.ColType
+	values map[string]map[int64]values.Value
+}
+
+// add collects the values of tbl.
+func (p *pivoter) add(tbl flux.Table) error {
+	cols := tbl.Cols()
+	timeIdx := execute.ColIdx(execute.DefaultTimeColLabel, cols)
+	valueIdx := execute.ColIdx(p.label, cols)
+	fieldIdx := execute.ColIdx("_field", cols)
+	if timeIdx < 0 {
+		tbl.Done()
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "pivot requires a _time column",
+		}
+	}
+	if valueIdx < 0 || fieldIdx < 0 {
+		tbl.Done()
+		return nil
+	}
+
+	g := p.group(tbl.Key())
+	return tbl.Do(func(cr flux.ColReader) error {
+		times := cr.Times(timeIdx)
+		fields := cr.Strings(fieldIdx)
+		for i := 0; i < cr.Len(); i++ {
+			if !times.IsValid(i) || !fields.IsValid(i) {
+				continue
+			}
+			field := fields.ValueString(i)
+			if field == execute.DefaultTimeColLabel || g.key.HasCol(field) {
+				return &influxdb.Error{
+					Code: influxdb.EInvalid,
+					Msg:  fmt.Sprintf("cannot pivot field %q into a column of the same name", field),
+				}
+			}
+			if typ, ok := g.types[field]; !ok {
+				g.types[field] = cols[valueIdx].Type
+				g.values[field] = make(map[int64]values.Value)
+			} else if typ != cols[valueIdx].Type {
+				return &influxdb.Error{
+					Code: influxdb.EInvalid,
+					Msg:  fmt.Sprintf("cannot pivot field %q with values of type %s and %s", field, typ, cols[valueIdx].Type),
+				}
+			}
+
+			t := times.Value(i)
+			if _, ok := g.rows[t]; !ok {
+				g.rows[t] = struct{}{}
+				g.times = append(g.times, t)
+			}
+			g.values[field][t] = execute.ValueForRow(cr, i, valueIdx)
+		}
+		return nil
+	})
+}
+
+// group returns the group of the tables with key, ignoring its _field.
+func (p *pivoter) group(key flux.GroupKey) *pivotGroup {
+	cols := make([]flux.ColMeta, 0, len(key.Cols()))
+	vs := make([]values.Value, 0, len(key.Cols()))
+	for j, c := range key.Cols() {
+		if c.Label == "_field" {
+			continue
+		}
+		cols = append(cols, c)
+		vs = append(vs, key.Value(j))
+	}
+	key = execute.NewGroupKey(cols, vs)
+
+	if g, ok := p.lookup.Lookup(key); ok {
+		return g.(*pivotGroup)
+	}
+	g := &pivotGroup{
+		key:    key,
+		rows:   make(map[int64]struct{}),
+		types:  make(map[string]flux.ColType),
+		values: make(map[string]map[int64]values.Value),
+	}
+	p.lookup.Set(key, g)
+	p.groups = append(p.groups, g)
+	return g
+}
+
+// emit passes a table for each group to f in the order they were read.
+func (p *pivoter) emit(f func(flux.Table) error) error {
+	for _, g := range p.groups {
+		tbl, err := g.table(p.alloc)
+		if err != nil {
+			return err
+		}
+		if err := f(tbl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// table returns the rows of g ordered by time with
+// a column for each field ordered by name.
+func (g *pivotGroup) table(alloc *memory.Allocator) (flux.Table, error) {
+	builder := execute.NewColListTableBuilder(g.key, alloc)
+	defer builder.ClearData()
+	if err := execute.AddTableKeyCols(g.key, builder); err != nil {
+		return nil, err
+	}
+	timeIdx, err := builder.AddCol(flux.ColMeta{Label: execute.DefaultTimeColLabel, Type: flux.TTime})
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(g.types))
+	for field := range g.types {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	fieldIdxs := make([]int, len(fields))
+	for i, field := range fields {
+		if fieldIdxs[i], err = builder.AddCol(flux.ColMeta{Label: field, Type: g.types[field]}); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(g.times, func(i, j int) bool { return g.times[i] < g.times[j] })
+	for _, t := range g.times {
+		for j := range g.key.Cols() {
+			if err := builder.AppendValue(j, g.key.Value(j)); err != nil {
+				return nil, err
+			}
+		}
+		if err := builder.AppendTime(timeIdx, execute.Time(t)); err != nil {
+			return nil, err
+		}
+		for i, field := range fields {
+			v, ok := g.values[field][t]
+			if !ok || v.IsNull() {
+				err = builder.AppendNil(fieldIdxs[i])
+			} else {
+				err = builder.AppendValue(fieldIdxs[i], v)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tbl, err := builder.Table()
+	if err != nil {
+		return nil, err
+	}
+	builder.ClearData()
+	return tbl, nil
+}
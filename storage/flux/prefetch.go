@@ -0,0 +1,51 @@
+package storageflux
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/influxdata/influxdb/v2/query"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+)
+
+// PrefetchReader reads the storage blocks of a ReadFilter ahead of
+// time, such as before a known heavy dashboard is loaded.
+type PrefetchReader interface {
+	// Prefetch pages every block within the bounds of spec that
+	// matches its predicate into memory. The blocks are not decoded
+	// and no tables are produced.
+	Prefetch(ctx context.Context, spec query.ReadFilterSpec) error
+}
+
+func (r *storeReader) Prefetch(ctx context.Context, spec query.ReadFilterSpec) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ps, ok := r.s.(storage.PrefetchStore)
+	if !ok {
+		return errors.New("storage does not support prefetch")
+	}
+
+	src := r.s.GetSource(
+		uint64(spec.OrganizationID),
+		uint64(spec.BucketID),
+	)
+
+	// Setup read request
+	any, err := types.MarshalAny(src)
+	if err != nil {
+		return err
+	}
+
+	var req datatypes.ReadFilterRequest
+	req.ReadSource = any
+	req.Predicate = spec.Predicate
+	req.Range.Start = int64(spec.Bounds.Start)
+	req.Range.End = int64(spec.Bounds.Stop)
+
+	_, err = ps.Prefetch(ctx, &req)
+	return err
+}
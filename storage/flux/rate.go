@@ -0,0 +1,157 @@
+package storageflux
+
+import (
+	"fmt"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// readRate computes the change per second of each window. The storage
+// engine does not support this aggregate so the raw values are read
+// and each window is computed here.
+func (wai *windowAggregateIterator) readRate(f func(flux.Table) error) error {
+	if wai.spec.WindowEvery <= 0 {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "rate aggregate requires a window period",
+		}
+	}
+
+	rs, err := wai.readFilter()
+	if err != nil || rs == nil {
+		return err
+	}
+
+	every, offset := wai.windowEveryAndOffset()
+	return wai.handleRead(f, &rateResultSet{
+		ResultSet:   rs,
+		every:       every,
+		offset:      offset,
+		nonNegative: wai.spec.NonNegative,
+	})
+}
+
+// rateResultSet wraps the cursors of a ResultSet so that
+// they produce the rate for each window.
+type rateResultSet struct {
+	storage.ResultSet
+	every, offset int64
+	nonNegative   bool
+	err           error
+}
+
+func (r *rateResultSet) Cursor() cursors.Cursor {
+	cur := r.ResultSet.Cursor()
+	if cur == nil {
+		return nil
+	}
+
+	var floatCur cursors.FloatArrayCursor
+	switch typedCur := cur.(type) {
+	case cursors.FloatArrayCursor:
+		floatCur = typedCur
+	case cursors.IntegerArrayCursor:
+		floatCur = newIntegerToFloatArrayCursor(typedCur)
+	case cursors.UnsignedArrayCursor:
+		floatCur = newUnsignedToFloatArrayCursor(typedCur)
+	default:
+		cur.Close()
+		if r.err == nil {
+			r.err = &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  fmt.Sprintf("unsupported for aggregate rate: %T", cur),
+			}
+		}
+		return nil
+	}
+	return newWindowRateCursor(floatCur, r.every, r.offset, r.nonNegative)
+}
+
+func (r *rateResultSet) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.ResultSet.Err()
+}
+
+// windowRateCursor produces the difference between the last and first
+// value of each window divided by the seconds between them. The
+// timestamp of each value is the window stop time. Windows with fewer
+// than two points have no rate. When nonNegative is set, a decrease is
+// treated as a counter reset and the value after the reset is added to
+// the difference.
+type windowRateCursor struct {
+	cursors.FloatArrayCursor
+	every, offset int64
+	nonNegative   bool
+	res           *cursors.FloatArray
+
+	// state of the current window
+	windowEnd     int64
+	first, last   int64
+	prev, acc     float64
+	n             int
+	windowHasData bool
+}
+
+func newWindowRateCursor(cur cursors.FloatArrayCursor, every, offset int64, nonNegative bool) *windowRateCursor {
+	return &windowRateCursor{
+		FloatArrayCursor: cur,
+		every:            every,
+		offset:           offset,
+		nonNegative:      nonNegative,
+		res:              cursors.NewFloatArrayLen(storage.MaxPointsPerBlock),
+	}
+}
+
+func (c *windowRateCursor) Next() *cursors.FloatArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	for c.res.Len() == 0 {
+		a := c.FloatArrayCursor.Next()
+		if a.Len() == 0 {
+			// Emit the final window.
+			c.emit()
+			c.windowHasData = false
+			break
+		}
+
+		for i, ts := range a.Timestamps {
+			v := a.Values[i]
+			if c.windowHasData && ts >= c.windowEnd {
+				c.emit()
+				c.windowHasData = false
+			}
+
+			if !c.windowHasData {
+				c.windowEnd = windowStop(ts, c.every, c.offset)
+				c.first, c.last = ts, ts
+				c.prev, c.acc, c.n = v, 0, 1
+				c.windowHasData = true
+				continue
+			}
+
+			if c.nonNegative && v < c.prev {
+				c.acc += v
+			} else {
+				c.acc += v - c.prev
+			}
+			c.prev, c.last = v, ts
+			c.n++
+		}
+	}
+	return c.res
+}
+
+func (c *windowRateCursor) emit() {
+	if !c.windowHasData || c.n < 2 {
+		// The rate of a window with a single point is undefined.
+		return
+	}
+	c.res.Timestamps = append(c.res.Timestamps, c.windowEnd)
+	c.res.Values = append(c.res.Values, c.acc/(float64(c.last-c.first)/1e9))
+}
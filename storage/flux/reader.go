@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/gogo/protobuf/types"
 	"github.com/influxdata/flux"
@@ -11,6 +13,7 @@ import (
 	"github.com/influxdata/flux/memory"
 	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/flux/values"
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/errors"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/query"
@@ -50,24 +53,90 @@ type storageTable interface {
 	Close()
 	Cancel()
 	Statistics() cursors.CursorStats
+	Err() error
 }
 
 type storeReader struct {
-	s storage.Store
+	s           storage.Store
+	parallelism int
+	decode      *decodePool
+	pw          pointsWriter
+	buckets     influxdb.BucketService
+	maxTables   int
+	maxCPUs     int
+}
+
+// Option configures a storageflux reader.
+type Option func(r *storeReader)
+
+// WithReadParallelism sets the number of tables that ReadFilter
+// prepares concurrently. Values less than or equal to one read
+// tables serially, which is the default.
+func WithReadParallelism(n int) Option {
+	return func(r *storeReader) {
+		r.parallelism = n
+	}
+}
+
+// WithDecodeParallelism sets the number of blocks that ReadFilter
+// decodes concurrently across all of its reads. The next block of each
+// series is decoded while the previous block is read, and the values
+// are produced in the same order. Values less than or equal to one
+// decode the blocks as they are read, which is the default.
+func WithDecodeParallelism(n int) Option {
+	return func(r *storeReader) {
+		r.decode = newDecodePool(n)
+	}
+}
+
+// WithMaxTables sets the maximum number of tables that a ReadFilter,
+// ReadGroup or ReadWindowAggregate produces. A read fails once it would
+// produce more tables. Values less than or equal to zero do not limit
+// the tables, which is the default.
+func WithMaxTables(n int) Option {
+	return func(r *storeReader) {
+		r.maxTables = n
+	}
+}
+
+// WithMaxCPUs bounds the workers that the reads use to prepare tables
+// and decode blocks to n, whatever the read and decode parallelism,
+// so that queries do not use more than n CPUs for storage reads
+// independently of GOMAXPROCS. Values less than or equal to zero do
+// not bound the workers, which is the default.
+func WithMaxCPUs(n int) Option {
+	return func(r *storeReader) {
+		r.maxCPUs = n
+	}
 }
 
 // NewReader returns a new storageflux reader
-func NewReader(s storage.Store) query.StorageReader {
-	return &storeReader{s: s}
+func NewReader(s storage.Store, opts ...Option) query.StorageReader {
+	r := &storeReader{s: s}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.maxCPUs > 0 {
+		if r.parallelism > r.maxCPUs {
+			r.parallelism = r.maxCPUs
+		}
+		if r.decode != nil && cap(r.decode.sem) > r.maxCPUs {
+			r.decode = newDecodePool(r.maxCPUs)
+		}
+	}
+	return r
 }
 
 func (r *storeReader) ReadFilter(ctx context.Context, spec query.ReadFilterSpec, alloc *memory.Allocator) (query.TableIterator, error) {
 	return &filterIterator{
-		ctx:   ctx,
-		s:     r.s,
-		spec:  spec,
-		cache: newTagsCache(0),
-		alloc: alloc,
+		ctx:         ctx,
+		s:           r.s,
+		spec:        spec,
+		cache:       newTagsCache(0),
+		alloc:       alloc,
+		parallelism: r.parallelism,
+		decode:      r.decode,
+		maxTables:   r.maxTables,
 	}, nil
 }
 
@@ -80,11 +149,12 @@ func (r *storeReader) GetGroupCapability(ctx context.Context) query.GroupCapabil
 
 func (r *storeReader) ReadGroup(ctx context.Context, spec query.ReadGroupSpec, alloc *memory.Allocator) (query.TableIterator, error) {
 	return &groupIterator{
-		ctx:   ctx,
-		s:     r.s,
-		spec:  spec,
-		cache: newTagsCache(0),
-		alloc: alloc,
+		ctx:       ctx,
+		s:         r.s,
+		spec:      spec,
+		cache:     newTagsCache(0),
+		alloc:     alloc,
+		maxTables: r.maxTables,
 	}, nil
 }
 
@@ -97,11 +167,14 @@ func (r *storeReader) GetWindowAggregateCapability(ctx context.Context) query.Wi
 
 func (r *storeReader) ReadWindowAggregate(ctx context.Context, spec query.ReadWindowAggregateSpec, alloc *memory.Allocator) (query.TableIterator, error) {
 	return &windowAggregateIterator{
-		ctx:   ctx,
-		s:     r.s,
-		spec:  spec,
-		cache: newTagsCache(0),
-		alloc: alloc,
+		ctx:       ctx,
+		s:         r.s,
+		spec:      spec,
+		cache:     newTagsCache(0),
+		alloc:     alloc,
+		pw:        r.pw,
+		buckets:   r.buckets,
+		maxTables: r.maxTables,
 	}, nil
 }
 
@@ -130,17 +203,44 @@ func (r *storeReader) ReadTagValues(ctx context.Context, spec query.ReadTagValue
 func (r *storeReader) Close() {}
 
 type filterIterator struct {
-	ctx   context.Context
-	s     storage.Store
-	spec  query.ReadFilterSpec
-	stats cursors.CursorStats
-	cache *tagsCache
-	alloc *memory.Allocator
+	ctx         context.Context
+	s           storage.Store
+	spec        query.ReadFilterSpec
+	stats       cursors.CursorStats
+	cache       *tagsCache
+	alloc       *memory.Allocator
+	parallelism int
+	decode      *decodePool
+	maxTables   int
 }
 
 func (fi *filterIterator) Statistics() cursors.CursorStats { return fi.stats }
 
 func (fi *filterIterator) Do(f func(flux.Table) error) error {
+	if fi.maxTables > 0 {
+		f = limitTables(f, fi.maxTables)
+	}
+	if add := query.CPUTimeFuncFromContext(fi.ctx); add != nil {
+		f = timeTables(f, add)
+	}
+	if fi.spec.MaxRows > 0 {
+		f = limitRows(f, fi.spec.MaxRows)
+	}
+	if fi.spec.ValueScale != 0 || fi.spec.ValueOffset != 0 {
+		f = scaleValues(f, execute.DefaultValueColLabel, fi.spec.ValueScale, fi.spec.ValueOffset, fi.alloc)
+	}
+
+	if fi.spec.FieldsAsTag {
+		return fi.readFieldsAsTag(f)
+	}
+	if len(fi.spec.SortTags) > 0 {
+		return fi.readSortedByTags(f)
+	}
+	return fi.read(f)
+}
+
+// read produces the tables of the spec in the order they are read.
+func (fi *filterIterator) read(f func(flux.Table) error) error {
 	src := fi.s.GetSource(
 		uint64(fi.spec.OrganizationID),
 		uint64(fi.spec.BucketID),
@@ -157,17 +257,149 @@ func (fi *filterIterator) Do(f func(flux.Table) error) error {
 	req.Predicate = fi.spec.Predicate
 	req.Range.Start = int64(fi.spec.Bounds.Start)
 	req.Range.End = int64(fi.spec.Bounds.Stop)
+	if wm := int64(fi.spec.Watermark); wm > req.Range.Start {
+		if wm >= req.Range.End {
+			// Every point of the bounds is before the watermark.
+			return nil
+		}
+		req.Range.Start = wm
+	}
 
-	rs, err := fi.s.ReadFilter(fi.ctx, &req)
+	read := func(n int) ([]storage.ResultSet, error) {
+		if fi.spec.SeriesKeys != nil {
+			return splitReads(n, func() (storage.ResultSet, error) {
+				return fi.readSeriesKeys(&req)
+			})
+		}
+		if ss, ok := fi.s.(storage.SplitReadStore); ok && n > 1 {
+			return ss.ReadFilterSplit(fi.ctx, &req, n)
+		}
+		return splitReads(n, func() (storage.ResultSet, error) {
+			return fi.s.ReadFilter(fi.ctx, &req)
+		})
+	}
+	if fi.spec.ChangedSince != 0 {
+		read = fi.changedSince(&req, read)
+	}
+
+	if fi.spec.ChunkDuration > 0 {
+		return fi.handleChunkedRead(f, &req, read)
+	}
+	return fi.readTables(f, read)
+}
+
+// readFunc reads the series of a request split between n result sets.
+// The ith series is produced by result set i modulo n, and the result
+// sets may be read concurrently. A nil result set produces no series.
+type readFunc func(n int) ([]storage.ResultSet, error)
+
+// splitReads splits the series of a read that cannot be split by the
+// store. Each of the n result sets is read with read and skips the
+// series produced by the others.
+func splitReads(n int, read func() (storage.ResultSet, error)) ([]storage.ResultSet, error) {
+	rss := make([]storage.ResultSet, n)
+	for w := range rss {
+		rs, err := read()
+		if err != nil {
+			for _, rs := range rss[:w] {
+				if rs != nil {
+					rs.Close()
+				}
+			}
+			return nil, err
+		}
+		if rs != nil && n > 1 {
+			rs = &nthResultSet{ResultSet: rs, n: n, w: w}
+		}
+		rss[w] = rs
+	}
+	return rss, nil
+}
+
+// nthResultSet produces every nth series of a ResultSet starting with w.
+type nthResultSet struct {
+	storage.ResultSet
+	n, w, i int
+}
+
+func (r *nthResultSet) Next() bool {
+	for r.ResultSet.Next() {
+		r.i++
+		if (r.i-1)%r.n == r.w {
+			return true
+		}
+	}
+	return false
+}
+
+// readTables produces the tables of a single read request.
+func (fi *filterIterator) readTables(f func(flux.Table) error, read readFunc) error {
+	if fi.spec.IncludeMissingFields {
+		fields := newSeriesFields(fi.spec.CoerceToFloat)
+		if err := fi.readSeriesTables(f, fields.track(read)); err != nil {
+			return err
+		}
+		return fi.missingFieldTables(f, fields)
+	}
+	return fi.readSeriesTables(f, read)
+}
+
+// readSeriesTables produces a table for each series of a single read request.
+func (fi *filterIterator) readSeriesTables(f func(flux.Table) error, read readFunc) error {
+	if fi.parallelism > 1 {
+		rss, err := read(fi.parallelism)
+		if err != nil {
+			return err
+		}
+		return fi.handleParallelRead(f, rss)
+	}
+
+	rss, err := read(1)
 	if err != nil {
 		return err
 	}
 
-	if rs == nil {
+	if rss[0] == nil {
 		return nil
 	}
 
-	return fi.handleRead(f, rs)
+	return fi.handleRead(f, rss[0])
+}
+
+// handleChunkedRead reads the range of req in consecutive chunks of
+// ChunkDuration so that only the points of one chunk are read at a time.
+// Each chunk produces its own tables, which have the group key of the
+// full bounds, so a series may produce a table for every chunk.
+func (fi *filterIterator) handleChunkedRead(f func(flux.Table) error, req *datatypes.ReadFilterRequest, read readFunc) error {
+	start, stop := req.Range.Start, req.Range.End
+	for chunkStart := start; chunkStart < stop && fi.ctx.Err() == nil; {
+		chunkStop := chunkStart + int64(fi.spec.ChunkDuration)
+		if chunkStop > stop || chunkStop < chunkStart {
+			chunkStop = stop
+		}
+
+		req.Range.Start, req.Range.End = chunkStart, chunkStop
+		if err := fi.readTables(f, read); err != nil {
+			return err
+		}
+		chunkStart = chunkStop
+	}
+	return nil
+}
+
+func (fi *filterIterator) readSeriesKeys(req *datatypes.ReadFilterRequest) (storage.ResultSet, error) {
+	if req.Predicate != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "cannot specify both a predicate and series keys",
+		}
+	}
+
+	ks, ok := fi.s.(storage.SeriesKeysStore)
+	if !ok {
+		return nil, errors.New("storage does not support series keys")
+	}
+	return ks.ReadSeriesKeys(fi.ctx, req, fi.spec.SeriesKeys)
 }
 
 func (fi *filterIterator) handleRead(f func(flux.Table) error, rs storage.ResultSet) error {
@@ -196,29 +428,8 @@ READ:
 			continue
 		}
 
-		bnds := fi.spec.Bounds
-		key := defaultGroupKeyForSeries(rs.Tags(), bnds)
 		done := make(chan struct{})
-		switch typedCur := cur.(type) {
-		case cursors.IntegerArrayCursor:
-			cols, defs := determineTableColsForSeries(rs.Tags(), flux.TInt)
-			table = newIntegerTable(done, typedCur, bnds, key, cols, rs.Tags(), defs, fi.cache, fi.alloc)
-		case cursors.FloatArrayCursor:
-			cols, defs := determineTableColsForSeries(rs.Tags(), flux.TFloat)
-			table = newFloatTable(done, typedCur, bnds, key, cols, rs.Tags(), defs, fi.cache, fi.alloc)
-		case cursors.UnsignedArrayCursor:
-			cols, defs := determineTableColsForSeries(rs.Tags(), flux.TUInt)
-			table = newUnsignedTable(done, typedCur, bnds, key, cols, rs.Tags(), defs, fi.cache, fi.alloc)
-		case cursors.BooleanArrayCursor:
-			cols, defs := determineTableColsForSeries(rs.Tags(), flux.TBool)
-			table = newBooleanTable(done, typedCur, bnds, key, cols, rs.Tags(), defs, fi.cache, fi.alloc)
-		case cursors.StringArrayCursor:
-			cols, defs := determineTableColsForSeries(rs.Tags(), flux.TString)
-			table = newStringTable(done, typedCur, bnds, key, cols, rs.Tags(), defs, fi.cache, fi.alloc)
-		default:
-			panic(fmt.Sprintf("unreachable: %T", typedCur))
-		}
-
+		table = fi.newTable(done, cur, rs.Tags())
 		cur = nil
 
 		if !table.Empty() {
@@ -238,12 +449,253 @@ READ:
 		stats := table.Statistics()
 		fi.stats.ScannedValues += stats.ScannedValues
 		fi.stats.ScannedBytes += stats.ScannedBytes
+		reportProgress(fi.spec.ProgressFn, stats)
 		table.Close()
 		table = nil
 	}
 	return rs.Err()
 }
 
+// reportProgress calls fn, if set, with the number of points read.
+func reportProgress(fn func(pointsRead int64), stats cursors.CursorStats) {
+	if fn != nil && stats.ScannedValues > 0 {
+		fn(int64(stats.ScannedValues))
+	}
+}
+
+// newTable creates a table for the cursor of a series.
+func (fi *filterIterator) newTable(done chan struct{}, cur cursors.Cursor, tags models.Tags) storageTable {
+	cur = fi.decode.decodeAhead(cur)
+
+	if fi.spec.DownsamplePoints > 0 {
+		cur = newDownsampleCursor(cur, fi.spec.DownsamplePoints)
+	}
+
+	if fi.spec.CoerceToFloat {
+		switch typedCur := cur.(type) {
+		case cursors.IntegerArrayCursor:
+			cur = newIntegerToFloatArrayCursor(typedCur)
+		case cursors.UnsignedArrayCursor:
+			cur = newUnsignedToFloatArrayCursor(typedCur)
+		}
+	}
+
+	bnds := fi.spec.Bounds
+	key := defaultGroupKeyForSeries(tags, bnds)
+	switch typedCur := cur.(type) {
+	case cursors.IntegerArrayCursor:
+		cols, defs := determineTableColsForSeries(tags, flux.TInt)
+		cols, defs = fi.withSeriesKeyColumn(cols, defs, tags)
+		return newIntegerTable(done, typedCur, bnds, key, cols, tags, defs, fi.cache, fi.alloc)
+	case cursors.FloatArrayCursor:
+		cols, defs := determineTableColsForSeries(tags, flux.TFloat)
+		cols, defs = fi.withSeriesKeyColumn(cols, defs, tags)
+		return newFloatTable(done, typedCur, bnds, key, cols, tags, defs, fi.cache, fi.alloc)
+	case cursors.UnsignedArrayCursor:
+		cols, defs := determineTableColsForSeries(tags, flux.TUInt)
+		cols, defs = fi.withSeriesKeyColumn(cols, defs, tags)
+		return newUnsignedTable(done, typedCur, bnds, key, cols, tags, defs, fi.cache, fi.alloc)
+	case cursors.BooleanArrayCursor:
+		cols, defs := determineTableColsForSeries(tags, flux.TBool)
+		cols, defs = fi.withSeriesKeyColumn(cols, defs, tags)
+		return newBooleanTable(done, typedCur, bnds, key, cols, tags, defs, fi.cache, fi.alloc)
+	case cursors.StringArrayCursor:
+		cols, defs := determineTableColsForSeries(tags, flux.TString)
+		cols, defs = fi.withSeriesKeyColumn(cols, defs, tags)
+		return newStringTable(done, typedCur, bnds, key, cols, tags, defs, fi.cache, fi.alloc)
+	default:
+		panic(fmt.Sprintf("unreachable: %T", typedCur))
+	}
+}
+
+// seriesKeyColLabel is the label of the column
+// added by ReadFilterSpec.SeriesKeyColumn.
+const seriesKeyColLabel = "_series"
+
+// withSeriesKeyColumn adds the _series column with the key of the
+// series identified by tags to the columns of a table, if the spec
+// requests it. The key uses the measurement as its name and the
+// remaining tags, including _field, as its tags.
+func (fi *filterIterator) withSeriesKeyColumn(cols []flux.ColMeta, defs [][]byte, tags models.Tags) ([]flux.ColMeta, [][]byte) {
+	if !fi.spec.SeriesKeyColumn {
+		return cols, defs
+	}
+
+	var name []byte
+	keyTags := make(models.Tags, 0, len(tags))
+	for _, tag := range tags {
+		if string(tag.Key) == datatypes.MeasurementKey {
+			name = tag.Value
+			continue
+		}
+		keyTags = append(keyTags, tag)
+	}
+	cols = append(cols, flux.ColMeta{Label: seriesKeyColLabel, Type: flux.TString})
+	defs = append(defs, models.MakeKey(name, keyTags))
+	return cols, defs
+}
+
+// preparedTable is a table created by a worker of handleParallelRead.
+// The table is nil when the series has no data for its field.
+type preparedTable struct {
+	table storageTable
+	done  chan struct{}
+
+	// released is closed once the table has been closed so
+	// that the worker may reuse the cursors of its result set.
+	released chan struct{}
+}
+
+// handleParallelRead creates tables using a worker for each of the result
+// sets of a split read. A ResultSet reuses its cursors, so each worker
+// creates the tables of its own result set, which produces every nth
+// series. Reading the workers in turn passes the tables to f in the same
+// order as handleRead. If the spec is unsorted,
+// the workers share a channel and tables are passed to f as soon as
+// any worker has prepared one.
+//
+// If the store limits the number of open cursors, opening a cursor may
+// wait for another to be closed. The workers of an ordered read then
+// take turns to open their cursors in series order so that the cursors
+// held by a read always include the next table to be passed to f.
+func (fi *filterIterator) handleParallelRead(f func(flux.Table) error, rss []storage.ResultSet) error {
+	ctx, cancel := context.WithCancel(fi.ctx)
+
+	n := len(rss)
+	tables := make([]chan preparedTable, n)
+	for w := range tables {
+		if fi.spec.Unsorted && w > 0 {
+			tables[w] = tables[0]
+		} else {
+			tables[w] = make(chan preparedTable)
+		}
+	}
+
+	// turns[w] receives a value when worker w may open its next cursor.
+	var turns []chan struct{}
+	if cls, ok := fi.s.(storage.CursorLimitStore); ok && cls.MaxOpenCursors() > 0 && !fi.spec.Unsorted {
+		turns = make([]chan struct{}, n)
+		for w := range turns {
+			turns[w] = make(chan struct{}, 1)
+		}
+		turns[0] <- struct{}{}
+	}
+	errs := make([]error, n)
+
+	// closeTables is called once worker w has no more tables.
+	// The shared channel of an unsorted read is closed once
+	// every worker is finished.
+	remaining := int32(n)
+	closeTables := func(w int) {
+		if !fi.spec.Unsorted {
+			close(tables[w])
+		} else if atomic.AddInt32(&remaining, -1) == 0 {
+			close(tables[0])
+		}
+	}
+
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+		fi.cache.Release()
+	}()
+
+	for w, rs := range rss {
+		if rs == nil {
+			closeTables(w)
+			continue
+		}
+
+		wg.Add(1)
+		go func(w int, rs storage.ResultSet) {
+			defer wg.Done()
+			defer closeTables(w)
+			defer rs.Close()
+
+			for rs.Next() {
+				if turns != nil {
+					select {
+					case <-turns[w]:
+					case <-ctx.Done():
+						return
+					}
+				}
+
+				var pt preparedTable
+				if cur := rs.Cursor(); cur != nil {
+					pt.done = make(chan struct{})
+					pt.released = make(chan struct{})
+					pt.table = fi.newTable(pt.done, cur, rs.Tags())
+				}
+
+				if turns != nil {
+					turns[(w+1)%n] <- struct{}{}
+				}
+
+				select {
+				case tables[w] <- pt:
+				case <-ctx.Done():
+					if pt.table != nil {
+						pt.table.Close()
+					}
+					return
+				}
+
+				if pt.table != nil {
+					select {
+					case <-pt.released:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			errs[w] = rs.Err()
+		}(w, rs)
+	}
+
+READ:
+	for i := 0; ; i++ {
+		pt, ok := <-tables[i%n]
+		if !ok {
+			break
+		} else if pt.table == nil {
+			// no data for series key + field combination
+			continue
+		}
+
+		table := pt.table
+		if !table.Empty() {
+			if err := f(table); err != nil {
+				table.Close()
+				return err
+			}
+			select {
+			case <-pt.done:
+			case <-fi.ctx.Done():
+				table.Cancel()
+				table.Close()
+				break READ
+			}
+		}
+
+		stats := table.Statistics()
+		fi.stats.ScannedValues += stats.ScannedValues
+		fi.stats.ScannedBytes += stats.ScannedBytes
+		reportProgress(fi.spec.ProgressFn, stats)
+		table.Close()
+		close(pt.released)
+	}
+
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type groupIterator struct {
 	ctx   context.Context
 	s     storage.Store
@@ -251,11 +703,27 @@ type groupIterator struct {
 	stats cursors.CursorStats
 	cache *tagsCache
 	alloc *memory.Allocator
+
+	maxTables int
 }
 
 func (gi *groupIterator) Statistics() cursors.CursorStats { return gi.stats }
 
 func (gi *groupIterator) Do(f func(flux.Table) error) error {
+	if gi.maxTables > 0 {
+		f = limitTables(f, gi.maxTables)
+	}
+	if add := query.CPUTimeFuncFromContext(gi.ctx); add != nil {
+		f = timeTables(f, add)
+	}
+
+	if gi.spec.IncludeTimeSpan && gi.spec.AggregateMethod != "" {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "cannot include the time span of a group with an aggregate",
+		}
+	}
+
 	src := gi.s.GetSource(
 		uint64(gi.spec.OrganizationID),
 		uint64(gi.spec.BucketID),
@@ -275,11 +743,28 @@ func (gi *groupIterator) Do(f func(flux.Table) error) error {
 
 	req.Group = convertGroupMode(gi.spec.GroupMode)
 	req.GroupKeys = gi.spec.GroupKeys
+	if gi.spec.CreateEmpty {
+		// Keep the series without points in the bounds
+		// so that their groups produce empty tables.
+		req.Hints.SetHintSchemaAllTime()
+	}
 
-	if agg, err := determineAggregateMethod(gi.spec.AggregateMethod); err != nil {
-		return err
-	} else if agg != datatypes.AggregateTypeNone {
-		req.Aggregate = &datatypes.Aggregate{Type: agg}
+	// The distinct values are counted by the reader from the rows of each group.
+	if !gi.countDistinct() {
+		if agg, err := determineAggregateMethod(gi.spec.AggregateMethod); err != nil {
+			return err
+		} else if agg != datatypes.AggregateTypeNone {
+			req.Aggregate = &datatypes.Aggregate{Type: agg}
+		}
+	}
+
+	if len(gi.spec.KeepColumns) > 0 {
+		if req.Aggregate == nil || (req.Aggregate.Type != datatypes.AggregateTypeFirst && req.Aggregate.Type != datatypes.AggregateTypeLast) {
+			return &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "keep columns is only supported with the first and last aggregates",
+			}
+		}
 	}
 
 	rs, err := gi.s.ReadGroup(gi.ctx, &req)
@@ -293,6 +778,11 @@ func (gi *groupIterator) Do(f func(flux.Table) error) error {
 	return gi.handleRead(f, rs)
 }
 
+// countDistinct reports whether the aggregate is CountDistinctKind.
+func (gi *groupIterator) countDistinct() bool {
+	return strings.EqualFold(gi.spec.AggregateMethod, CountDistinctKind)
+}
+
 func (gi *groupIterator) handleRead(f func(flux.Table) error, rs storage.GroupResultSet) error {
 	// these resources must be closed if not nil on return
 	var (
@@ -337,22 +827,28 @@ READ:
 		switch typedCur := cur.(type) {
 		case cursors.IntegerArrayCursor:
 			cols, defs := determineTableColsForGroup(gc.Keys(), flux.TInt, gc.Aggregate(), key)
-			table = newIntegerGroupTable(done, gc, typedCur, bnds, key, cols, gc.Tags(), defs, gi.cache, gi.alloc)
+			table = newIntegerGroupTable(done, gc, typedCur, bnds, key, cols, gc.Tags(), defs, gi.keepColumns(cols, key), gi.cache, gi.alloc)
 		case cursors.FloatArrayCursor:
 			cols, defs := determineTableColsForGroup(gc.Keys(), flux.TFloat, gc.Aggregate(), key)
-			table = newFloatGroupTable(done, gc, typedCur, bnds, key, cols, gc.Tags(), defs, gi.cache, gi.alloc)
+			table = newFloatGroupTable(done, gc, typedCur, bnds, key, cols, gc.Tags(), defs, gi.keepColumns(cols, key), gi.cache, gi.alloc)
 		case cursors.UnsignedArrayCursor:
 			cols, defs := determineTableColsForGroup(gc.Keys(), flux.TUInt, gc.Aggregate(), key)
-			table = newUnsignedGroupTable(done, gc, typedCur, bnds, key, cols, gc.Tags(), defs, gi.cache, gi.alloc)
+			table = newUnsignedGroupTable(done, gc, typedCur, bnds, key, cols, gc.Tags(), defs, gi.keepColumns(cols, key), gi.cache, gi.alloc)
 		case cursors.BooleanArrayCursor:
 			cols, defs := determineTableColsForGroup(gc.Keys(), flux.TBool, gc.Aggregate(), key)
-			table = newBooleanGroupTable(done, gc, typedCur, bnds, key, cols, gc.Tags(), defs, gi.cache, gi.alloc)
+			table = newBooleanGroupTable(done, gc, typedCur, bnds, key, cols, gc.Tags(), defs, gi.keepColumns(cols, key), gi.cache, gi.alloc)
 		case cursors.StringArrayCursor:
 			cols, defs := determineTableColsForGroup(gc.Keys(), flux.TString, gc.Aggregate(), key)
-			table = newStringGroupTable(done, gc, typedCur, bnds, key, cols, gc.Tags(), defs, gi.cache, gi.alloc)
+			table = newStringGroupTable(done, gc, typedCur, bnds, key, cols, gc.Tags(), defs, gi.keepColumns(cols, key), gi.cache, gi.alloc)
 		default:
 			panic(fmt.Sprintf("unreachable: %T", typedCur))
 		}
+		if gi.spec.IncludeTimeSpan {
+			table = newTimeSpanTable(table, gi.alloc)
+		}
+		if gi.countDistinct() {
+			table = newCountDistinctTable(table, gi.spec.ApproximateCountDistinct, gi.alloc)
+		}
 
 		// table owns these resources and is responsible for closing them
 		cur = nil
@@ -373,6 +869,7 @@ READ:
 		stats := table.Statistics()
 		gi.stats.ScannedValues += stats.ScannedValues
 		gi.stats.ScannedBytes += stats.ScannedBytes
+		reportProgress(gi.spec.ProgressFn, stats)
 		table.Close()
 		table = nil
 
@@ -381,6 +878,18 @@ READ:
 	return rs.Err()
 }
 
+// keepColumns returns the index of each column of KeepColumns
+// within cols that is not part of the group key.
+func (gi *groupIterator) keepColumns(cols []flux.ColMeta, key flux.GroupKey) []int {
+	var keep []int
+	for _, label := range gi.spec.KeepColumns {
+		if j := execute.ColIdx(label, cols); j >= 0 && !key.HasCol(label) {
+			keep = append(keep, j)
+		}
+	}
+	return keep
+}
+
 func determineAggregateMethod(agg string) (datatypes.Aggregate_AggregateType, error) {
 	if agg == "" {
 		return datatypes.AggregateTypeNone, nil
@@ -506,6 +1015,21 @@ func defaultGroupKeyForSeries(tags models.Tags, bnds execute.Bounds) flux.GroupK
 	return execute.NewGroupKey(cols, vs)
 }
 
+// groupKeyForSeries returns a group key for the series
+// without the _start and _stop columns.
+func groupKeyForSeries(tags models.Tags) flux.GroupKey {
+	cols := make([]flux.ColMeta, 0, len(tags))
+	vs := make([]values.Value, 0, len(tags))
+	for i := range tags {
+		cols = append(cols, flux.ColMeta{
+			Label: string(tags[i].Key),
+			Type:  flux.TString,
+		})
+		vs = append(vs, values.NewString(string(tags[i].Value)))
+	}
+	return execute.NewGroupKey(cols, vs)
+}
+
 func IsSelector(agg *datatypes.Aggregate) bool {
 	if agg == nil {
 		return false
@@ -616,11 +1140,181 @@ type windowAggregateIterator struct {
 	stats cursors.CursorStats
 	cache *tagsCache
 	alloc *memory.Allocator
+	pw    pointsWriter
+
+	// buckets finds the MaterializeTo bucket.
+	buckets influxdb.BucketService
+
+	maxTables int
+
+	// actualStarts are the times of the earliest point of each window
+	// of each series, read for the ActualStart of the spec.
+	actualStarts map[string]map[int64]int64
 }
 
 func (wai *windowAggregateIterator) Statistics() cursors.CursorStats { return wai.stats }
 
 func (wai *windowAggregateIterator) Do(f func(flux.Table) error) error {
+	if wai.maxTables > 0 {
+		f = limitTables(f, wai.maxTables)
+	}
+	if add := query.CPUTimeFuncFromContext(wai.ctx); add != nil {
+		f = timeTables(f, add)
+	}
+	return wai.do(f)
+}
+
+// do reads the windows and passes their tables to f. Unlike Do, it
+// does not limit or time the tables so that the reads nested in
+// another read pass their tables to the f of that read as they are.
+func (wai *windowAggregateIterator) do(f func(flux.Table) error) error {
+	if wai.spec.OffsetRelativeTo == query.OffsetRelativeToBoundsStart {
+		// The windows are read with the offset from the epoch that
+		// aligns them to the start of the bounds.
+		if wai.spec.WindowEvery > 0 {
+			wai.spec.Offset = storage.Modulo(int64(wai.spec.Bounds.Start)+wai.spec.Offset, wai.spec.WindowEvery)
+		}
+		wai.spec.OffsetRelativeTo = query.OffsetRelativeToEpoch
+	}
+
+	if wai.spec.CountTimeColumn != "" {
+		if err := wai.setCountTimeColumn(); err != nil {
+			return err
+		}
+	}
+
+	if wai.spec.BoundsAsColumns && wai.spec.TimeColumn != "" {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "cannot use bounds as columns with a time column",
+		}
+	}
+
+	if wai.spec.SelectorTimeColumn != "" {
+		if len(wai.spec.Aggregates) == 0 || (wai.spec.Aggregates[0] != MinKind && wai.spec.Aggregates[0] != MaxKind) {
+			return &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "selector time column is only supported with the min and max aggregates",
+			}
+		}
+	}
+
+	if wai.spec.FillPrevious {
+		if !wai.spec.CreateEmpty || wai.spec.TimeColumn != "" || len(wai.spec.WindowBounds) > 0 ||
+			len(wai.spec.Aggregates) == 0 || !isSelector(wai.spec.Aggregates[0]) {
+			return &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "fill previous is only supported with create empty and the first, last, min and max aggregates without a time column or window bounds",
+			}
+		}
+	}
+
+	// The count column and the moving average are only produced by the
+	// mean reads below, so they are validated before the reads of the
+	// other aggregates and window bounds.
+	if wai.spec.WithCount {
+		if len(wai.spec.Aggregates) == 0 || wai.spec.Aggregates[0] != MeanKind {
+			return &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "count column is only supported with the mean aggregate",
+			}
+		}
+	}
+
+	if wai.spec.MovingAverage > 0 {
+		if len(wai.spec.Aggregates) == 0 || wai.spec.Aggregates[0] != MeanKind || wai.spec.WithCount || len(wai.spec.WindowBounds) > 0 {
+			return &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "moving average is only supported with the mean aggregate without a count column or window bounds",
+			}
+		}
+	}
+
+	if wai.spec.ActualStart {
+		if len(wai.spec.WindowBounds) > 0 || wai.spec.Pivot {
+			return &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "actual start column cannot be used with window bounds or pivot",
+			}
+		}
+		starts, err := wai.readActualStarts()
+		if err != nil {
+			return err
+		}
+		wai.actualStarts = starts
+	}
+
+	scale := wai.spec.ValueScale != 0 || wai.spec.ValueOffset != 0
+	if scale && wai.spec.Pivot {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "cannot scale the values of pivoted windows",
+		}
+	}
+
+	if wai.spec.Pivot {
+		return wai.readPivot(f)
+	}
+
+	if wai.spec.MaterializeTo.Valid() {
+		materialize, err := wai.materialize(f)
+		if err != nil {
+			return err
+		}
+		f = materialize
+	}
+
+	// The values are scaled before they are materialized.
+	if scale && (len(wai.spec.Aggregates) == 0 || !isAggregateCount(wai.spec.Aggregates[0])) {
+		f = scaleValues(f, wai.valueColumn(), wai.spec.ValueScale, wai.spec.ValueOffset, wai.alloc)
+	}
+
+	if len(wai.spec.WindowBounds) > 0 {
+		return wai.readWindowBounds(f)
+	}
+
+	if wai.spec.ValuePredicate != nil {
+		filter, err := wai.filterValues(f)
+		if err != nil {
+			return err
+		}
+		f = filter
+	}
+
+	if wai.spec.CountTimeColumn == CountTimeFirst || wai.spec.CountTimeColumn == CountTimeLast {
+		return wai.readCountTime(f)
+	}
+
+	if len(wai.spec.Aggregates) > 0 && wai.spec.Aggregates[0] == DifferenceKind {
+		return wai.readDifference(f)
+	}
+
+	if len(wai.spec.Aggregates) > 0 && wai.spec.Aggregates[0] == IntegralKind {
+		return wai.readIntegral(f)
+	}
+
+	if len(wai.spec.Aggregates) > 0 && wai.spec.Aggregates[0] == RateKind {
+		return wai.readRate(f)
+	}
+
+	if len(wai.spec.Aggregates) > 0 && wai.spec.Aggregates[0] == ModeKind {
+		return wai.readMode(f)
+	}
+
+	if wai.spec.WeightField != "" {
+		if len(wai.spec.Aggregates) == 0 || wai.spec.Aggregates[0] != MeanKind || wai.spec.WithCount || wai.spec.MovingAverage > 0 {
+			return &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "weight field is only supported with the mean aggregate without a count column or moving average",
+			}
+		}
+		return wai.readWeightedMean(f)
+	}
+
+	if wai.spec.WithCount {
+		return wai.readMeanCount(f)
+	}
+
 	src := wai.s.GetSource(
 		uint64(wai.spec.OrganizationID),
 		uint64(wai.spec.BucketID),
@@ -662,6 +1356,9 @@ func (wai *windowAggregateIterator) Do(f func(flux.Table) error) error {
 	if rs == nil {
 		return nil
 	}
+	if wai.spec.MovingAverage > 0 {
+		rs = &movingAverageResultSet{ResultSet: rs, n: wai.spec.MovingAverage}
+	}
 	return wai.handleRead(f, rs)
 }
 
@@ -670,9 +1367,34 @@ const (
 	SumKind   = "sum"
 	FirstKind = "first"
 	LastKind  = "last"
-	MinKind   = "min"
-	MaxKind   = "max"
-	MeanKind  = "mean"
+
+	// MinKind and MaxKind select the point with the smallest or largest
+	// value in each window. When several points have that value, the
+	// point with the earliest time is selected.
+	MinKind = "min"
+	MaxKind = "max"
+
+	MeanKind = "mean"
+
+	// DifferenceKind is computed by the reader rather than the
+	// storage engine. See ReadWindowAggregateSpec.Rate and NonNegative.
+	DifferenceKind = "difference"
+
+	// IntegralKind is the trapezoidal area under the values of each
+	// window. It is computed by the reader rather than the storage engine.
+	// See ReadWindowAggregateSpec.IntegralUnit.
+	IntegralKind = "integral"
+
+	// RateKind is the difference between the last and first values of
+	// each window per second between them. It is computed by
+	// the reader rather than the storage engine. See
+	// ReadWindowAggregateSpec.NonNegative.
+	RateKind = "rate"
+
+	// ModeKind is the most frequent value of each window. When several
+	// values are equally frequent, the smallest of them is selected.
+	// It is computed by the reader rather than the storage engine.
+	ModeKind = "mode"
 )
 
 // isSelector returns true if given a procedure kind that represents a selector operator.
@@ -680,15 +1402,48 @@ func isSelector(kind plan.ProcedureKind) bool {
 	return kind == FirstKind || kind == LastKind || kind == MinKind || kind == MaxKind
 }
 
+// withValueColumn renames the value column to the
+// ValueColumn of the spec, if one was specified.
+func (wai *windowAggregateIterator) withValueColumn(cols []flux.ColMeta, defs [][]byte) ([]flux.ColMeta, [][]byte) {
+	if wai.spec.ValueColumn == "" {
+		return cols, defs
+	}
+	for j := range cols {
+		if cols[j].Label == execute.DefaultValueColLabel {
+			cols[j].Label = wai.spec.ValueColumn
+			break
+		}
+	}
+	return cols, defs
+}
+
+// valueColumn returns the label of the value column.
+func (wai *windowAggregateIterator) valueColumn() string {
+	if wai.spec.ValueColumn != "" {
+		return wai.spec.ValueColumn
+	}
+	return execute.DefaultValueColLabel
+}
+
 func (wai *windowAggregateIterator) handleRead(f func(flux.Table) error, rs storage.ResultSet) error {
 	windowEvery := wai.spec.WindowEvery
 	offset := wai.spec.Offset
+	if windowEvery > 0 {
+		// Normalize the offset so that an offset larger than the window
+		// period produces the same ascending window boundaries as the
+		// storage engine.
+		offset = storage.Modulo(offset, windowEvery)
+	}
 	createEmpty := wai.spec.CreateEmpty
 
 	selector := len(wai.spec.Aggregates) > 0 && isSelector(wai.spec.Aggregates[0])
+	if wai.spec.SelectorTimeColumn != "" {
+		rs = &selectorTimeResultSet{ResultSet: rs}
+	}
 
 	timeColumn := wai.spec.TimeColumn
-	if timeColumn == "" {
+	boundsAsColumns := wai.spec.BoundsAsColumns
+	if timeColumn == "" && !boundsAsColumns {
 		tableFn := f
 		f = func(table flux.Table) error {
 			return splitWindows(wai.ctx, wai.alloc, table, selector, tableFn)
@@ -722,87 +1477,110 @@ READ:
 
 		bnds := wai.spec.Bounds
 		key := defaultGroupKeyForSeries(rs.Tags(), bnds)
+		if boundsAsColumns {
+			// Each row carries the bounds of its window so they
+			// are removed from the group key.
+			key = groupKeyForSeries(rs.Tags())
+		}
 		done := make(chan struct{})
 		hasTimeCol := timeColumn != ""
 		switch typedCur := cur.(type) {
 		case cursors.IntegerArrayCursor:
 			if !selector {
+				// Only count fills empty windows with zero. Other aggregates,
+				// such as sum and mean, produce null for windows without points.
 				var fillValue *int64
 				if isAggregateCount(wai.spec.Aggregates[0]) {
 					fillValue = func(v int64) *int64 { return &v }(0)
 				}
-				cols, defs := determineTableColsForWindowAggregate(rs.Tags(), flux.TInt, hasTimeCol)
-				table = newIntegerWindowTable(done, typedCur, bnds, windowEvery, offset, createEmpty, timeColumn, fillValue, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
+				cols, defs := wai.withValueColumn(determineTableColsForWindowAggregate(rs.Tags(), flux.TInt, hasTimeCol))
+				table = newIntegerWindowTable(done, typedCur, bnds, windowEvery, offset, createEmpty, timeColumn, fillValue, wai.spec.AllowIntegerOverflow, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			} else if createEmpty && !hasTimeCol {
-				cols, defs := determineTableColsForSeries(rs.Tags(), flux.TInt)
+				cols, defs := wai.withValueColumn(determineTableColsForSeries(rs.Tags(), flux.TInt))
 				table = newIntegerEmptyWindowSelectorTable(done, typedCur, bnds, windowEvery, offset, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			} else {
 				// Note hasTimeCol == true means that aggregateWindow() was called.
 				// Because aggregateWindow() ultimately removes empty tables we
 				// don't bother creating them here.
-				cols, defs := determineTableColsForSeries(rs.Tags(), flux.TInt)
+				cols, defs := wai.withValueColumn(determineTableColsForSeries(rs.Tags(), flux.TInt))
 				table = newIntegerWindowSelectorTable(done, typedCur, bnds, windowEvery, offset, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			}
 		case cursors.FloatArrayCursor:
 			if !selector {
-				cols, defs := determineTableColsForWindowAggregate(rs.Tags(), flux.TFloat, hasTimeCol)
+				cols, defs := wai.withValueColumn(determineTableColsForWindowAggregate(rs.Tags(), flux.TFloat, hasTimeCol))
 				table = newFloatWindowTable(done, typedCur, bnds, windowEvery, offset, createEmpty, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			} else if createEmpty && !hasTimeCol {
-				cols, defs := determineTableColsForSeries(rs.Tags(), flux.TFloat)
+				cols, defs := wai.withValueColumn(determineTableColsForSeries(rs.Tags(), flux.TFloat))
 				table = newFloatEmptyWindowSelectorTable(done, typedCur, bnds, windowEvery, offset, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			} else {
 				// Note hasTimeCol == true means that aggregateWindow() was called.
 				// Because aggregateWindow() ultimately removes empty tables we
 				// don't bother creating them here.
-				cols, defs := determineTableColsForSeries(rs.Tags(), flux.TFloat)
+				cols, defs := wai.withValueColumn(determineTableColsForSeries(rs.Tags(), flux.TFloat))
 				table = newFloatWindowSelectorTable(done, typedCur, bnds, windowEvery, offset, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			}
 		case cursors.UnsignedArrayCursor:
 			if !selector {
-				cols, defs := determineTableColsForWindowAggregate(rs.Tags(), flux.TUInt, hasTimeCol)
+				cols, defs := wai.withValueColumn(determineTableColsForWindowAggregate(rs.Tags(), flux.TUInt, hasTimeCol))
 				table = newUnsignedWindowTable(done, typedCur, bnds, windowEvery, offset, createEmpty, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			} else if createEmpty && !hasTimeCol {
-				cols, defs := determineTableColsForSeries(rs.Tags(), flux.TUInt)
+				cols, defs := wai.withValueColumn(determineTableColsForSeries(rs.Tags(), flux.TUInt))
 				table = newUnsignedEmptyWindowSelectorTable(done, typedCur, bnds, windowEvery, offset, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			} else {
 				// Note hasTimeCol == true means that aggregateWindow() was called.
 				// Because aggregateWindow() ultimately removes empty tables we
 				// don't bother creating them here.
-				cols, defs := determineTableColsForSeries(rs.Tags(), flux.TUInt)
+				cols, defs := wai.withValueColumn(determineTableColsForSeries(rs.Tags(), flux.TUInt))
 				table = newUnsignedWindowSelectorTable(done, typedCur, bnds, windowEvery, offset, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			}
 		case cursors.BooleanArrayCursor:
 			if !selector {
-				cols, defs := determineTableColsForWindowAggregate(rs.Tags(), flux.TBool, hasTimeCol)
+				cols, defs := wai.withValueColumn(determineTableColsForWindowAggregate(rs.Tags(), flux.TBool, hasTimeCol))
 				table = newBooleanWindowTable(done, typedCur, bnds, windowEvery, offset, createEmpty, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			} else if createEmpty && !hasTimeCol {
-				cols, defs := determineTableColsForSeries(rs.Tags(), flux.TBool)
+				cols, defs := wai.withValueColumn(determineTableColsForSeries(rs.Tags(), flux.TBool))
 				table = newBooleanEmptyWindowSelectorTable(done, typedCur, bnds, windowEvery, offset, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			} else {
 				// Note hasTimeCol == true means that aggregateWindow() was called.
 				// Because aggregateWindow() ultimately removes empty tables we
 				// don't bother creating them here.
-				cols, defs := determineTableColsForSeries(rs.Tags(), flux.TBool)
+				cols, defs := wai.withValueColumn(determineTableColsForSeries(rs.Tags(), flux.TBool))
 				table = newBooleanWindowSelectorTable(done, typedCur, bnds, windowEvery, offset, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			}
 		case cursors.StringArrayCursor:
 			if !selector {
-				cols, defs := determineTableColsForWindowAggregate(rs.Tags(), flux.TString, hasTimeCol)
+				cols, defs := wai.withValueColumn(determineTableColsForWindowAggregate(rs.Tags(), flux.TString, hasTimeCol))
 				table = newStringWindowTable(done, typedCur, bnds, windowEvery, offset, createEmpty, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			} else if createEmpty && !hasTimeCol {
-				cols, defs := determineTableColsForSeries(rs.Tags(), flux.TString)
+				cols, defs := wai.withValueColumn(determineTableColsForSeries(rs.Tags(), flux.TString))
 				table = newStringEmptyWindowSelectorTable(done, typedCur, bnds, windowEvery, offset, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			} else {
 				// Note hasTimeCol == true means that aggregateWindow() was called.
 				// Because aggregateWindow() ultimately removes empty tables we
 				// don't bother creating them here.
-				cols, defs := determineTableColsForSeries(rs.Tags(), flux.TString)
+				cols, defs := wai.withValueColumn(determineTableColsForSeries(rs.Tags(), flux.TString))
 				table = newStringWindowSelectorTable(done, typedCur, bnds, windowEvery, offset, timeColumn, key, cols, rs.Tags(), defs, wai.cache, wai.alloc)
 			}
 		default:
 			panic(fmt.Sprintf("unreachable: %T", typedCur))
 		}
 
+		if wai.actualStarts != nil {
+			table = wai.newActualStartTable(table, wai.actualStarts[string(rs.Tags().HashKey())])
+		}
+		if mc, ok := cur.(*windowMeanCountCursor); ok {
+			table = newMeanCountTable(table, mc, execute.ColIdx(wai.valueColumn(), table.Cols()), wai.alloc)
+		}
+		if cc, ok := cur.(*windowCountTimeCursor); ok {
+			table = newCountTimeTable(table, cc, execute.ColIdx(wai.valueColumn(), table.Cols()), wai.alloc)
+		}
+		if sc, ok := cur.(interface{ selectorTimes() *selectorTimes }); ok {
+			table = newSelectorTimeTable(table, sc.selectorTimes(), wai.spec.SelectorTimeColumn, execute.ColIdx(wai.valueColumn(), table.Cols()), wai.alloc)
+		}
+		if wai.spec.FillPrevious {
+			table = newFillPreviousTable(table, execute.ColIdx(wai.valueColumn(), table.Cols()), wai.alloc)
+		}
+
 		cur = nil
 
 		if !table.Empty() {
@@ -817,11 +1595,14 @@ READ:
 				table.Cancel()
 				break READ
 			}
+		} else if err := table.Err(); err != nil {
+			return err
 		}
 
 		stats := table.Statistics()
 		wai.stats.ScannedValues += stats.ScannedValues
 		wai.stats.ScannedBytes += stats.ScannedBytes
+		reportProgress(wai.spec.ProgressFn, stats)
 		table.Close()
 		table = nil
 	}
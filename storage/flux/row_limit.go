@@ -0,0 +1,70 @@
+package storageflux
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2"
+)
+
+// limitRows wraps f so that the tables passed to it fail once
+// more than max rows have been read from them in total.
+func limitRows(f func(flux.Table) error, max int) func(flux.Table) error {
+	limit := &rowLimit{max: int64(max)}
+	return func(tbl flux.Table) error {
+		return f(&rowLimitTable{Table: tbl, limit: limit})
+	}
+}
+
+// rowLimit counts the rows read from the tables of a read.
+type rowLimit struct {
+	max  int64
+	rows int64
+}
+
+// add counts n rows and returns an error if that exceeds the limit.
+// The rows are not counted if they exceed the limit.
+func (l *rowLimit) add(n int) error {
+	if rows := atomic.AddInt64(&l.rows, int64(n)); rows > l.max {
+		atomic.AddInt64(&l.rows, -int64(n))
+		return &influxdb.Error{
+			Code: influxdb.ETooLarge,
+			Msg:  fmt.Sprintf("read exceeded the maximum of %d rows", l.max),
+		}
+	}
+	return nil
+}
+
+// rowLimitTable is a table whose buffers are only passed on
+// while the rows read from every table are within the limit.
+type rowLimitTable struct {
+	flux.Table
+	limit *rowLimit
+}
+
+func (t *rowLimitTable) Do(f func(flux.ColReader) error) error {
+	return t.Table.Do(func(cr flux.ColReader) error {
+		if err := t.limit.add(cr.Len()); err != nil {
+			return err
+		}
+		return f(cr)
+	})
+}
+
+// limitTables wraps f so that it fails once it would be
+// passed more than max tables. The table over the limit is
+// released without being passed to f.
+func limitTables(f func(flux.Table) error, max int) func(flux.Table) error {
+	var n int64
+	return func(tbl flux.Table) error {
+		if atomic.AddInt64(&n, 1) > int64(max) {
+			tbl.Done()
+			return &influxdb.Error{
+				Code: influxdb.ETooLarge,
+				Msg:  fmt.Sprintf("read exceeded the maximum of %d tables", max),
+			}
+		}
+		return f(tbl)
+	}
+}
@@ -0,0 +1,132 @@
+package storageflux
+
+import (
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/memory"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// selectorTimeResultSet wraps the cursors of a ResultSet so that
+// the times of the points selected in each window are recorded.
+type selectorTimeResultSet struct {
+	storage.ResultSet
+}
+
+func (r *selectorTimeResultSet) Cursor() cursors.Cursor {
+	switch cur := r.ResultSet.Cursor().(type) {
+	case cursors.IntegerArrayCursor:
+		return &integerSelectorTimeCursor{IntegerArrayCursor: cur}
+	case cursors.FloatArrayCursor:
+		return &floatSelectorTimeCursor{FloatArrayCursor: cur}
+	case cursors.UnsignedArrayCursor:
+		return &unsignedSelectorTimeCursor{UnsignedArrayCursor: cur}
+	case nil:
+		return nil
+	default:
+		// The storage engine does not select
+		// the min or max of other types.
+		return cur
+	}
+}
+
+// selectorTimes queues the times of the selected points, in the same
+// order as the values produced by a cursor, until they are consumed
+// by a selectorTimeTable.
+type selectorTimes struct {
+	times []int64
+}
+
+func (s *selectorTimes) selectorTimes() *selectorTimes { return s }
+
+type integerSelectorTimeCursor struct {
+	cursors.IntegerArrayCursor
+	selectorTimes
+}
+
+func (c *integerSelectorTimeCursor) Next() *cursors.IntegerArray {
+	a := c.IntegerArrayCursor.Next()
+	c.times = append(c.times, a.Timestamps...)
+	return a
+}
+
+type floatSelectorTimeCursor struct {
+	cursors.FloatArrayCursor
+	selectorTimes
+}
+
+func (c *floatSelectorTimeCursor) Next() *cursors.FloatArray {
+	a := c.FloatArrayCursor.Next()
+	c.times = append(c.times, a.Timestamps...)
+	return a
+}
+
+type unsignedSelectorTimeCursor struct {
+	cursors.UnsignedArrayCursor
+	selectorTimes
+}
+
+func (c *unsignedSelectorTimeCursor) Next() *cursors.UnsignedArray {
+	a := c.UnsignedArrayCursor.Next()
+	c.times = append(c.times, a.Timestamps...)
+	return a
+}
+
+// selectorTimeTable adds a time column with the time of the point
+// selected in each window to a table of min or max values using the
+// times queued by a selector time cursor. Windows without a selected
+// point have a null time.
+type selectorTimeTable struct {
+	storageTable
+	times    *selectorTimes
+	cols     []flux.ColMeta
+	valueIdx int
+	alloc    *memory.Allocator
+}
+
+func newSelectorTimeTable(table storageTable, times *selectorTimes, label string, valueIdx int, alloc *memory.Allocator) *selectorTimeTable {
+	cols := make([]flux.ColMeta, 0, len(table.Cols())+1)
+	cols = append(cols, table.Cols()...)
+	cols = append(cols, flux.ColMeta{Label: label, Type: flux.TTime})
+	return &selectorTimeTable{
+		storageTable: table,
+		times:        times,
+		cols:         cols,
+		valueIdx:     valueIdx,
+		alloc:        alloc,
+	}
+}
+
+func (t *selectorTimeTable) Cols() []flux.ColMeta { return t.cols }
+
+func (t *selectorTimeTable) Do(f func(flux.ColReader) error) error {
+	return t.storageTable.Do(func(cr flux.ColReader) error {
+		vs := getColumnValues(cr, t.valueIdx)
+		b := arrow.NewIntBuilder(t.alloc)
+		b.Resize(cr.Len())
+		for i, n := 0, cr.Len(); i < n; i++ {
+			if vs.IsNull(i) {
+				b.AppendNull()
+				continue
+			}
+			b.Append(t.times.times[0])
+			t.times.times = t.times.times[1:]
+		}
+
+		buffer := arrow.TableBuffer{
+			GroupKey: cr.Key(),
+			Columns:  t.cols,
+			Values:   make([]array.Interface, len(t.cols)),
+		}
+		for j := range cr.Cols() {
+			arr := getColumnValues(cr, j)
+			arr.Retain()
+			buffer.Values[j] = arr
+		}
+		buffer.Values[len(t.cols)-1] = b.NewInt64Array()
+		defer buffer.Release()
+		return f(&buffer)
+	})
+}
@@ -0,0 +1,87 @@
+package storageflux
+
+import (
+	"sort"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/semantic"
+	"github.com/influxdata/flux/values"
+)
+
+// readSortedByTags buffers the tables of the spec and passes
+// them to f ordered by the SortTags of the spec.
+func (fi *filterIterator) readSortedByTags(f func(flux.Table) error) error {
+	var tables []flux.BufferedTable
+	release := func(tables []flux.BufferedTable) {
+		for _, tbl := range tables {
+			tbl.Done()
+		}
+	}
+
+	if err := fi.read(func(tbl flux.Table) error {
+		buf, err := execute.CopyTable(tbl)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, buf)
+		return nil
+	}); err != nil {
+		release(tables)
+		return err
+	}
+
+	sort.SliceStable(tables, func(i, j int) bool {
+		c := compareTags(tables[i].Key(), tables[j].Key(), fi.spec.SortTags)
+		if fi.spec.SortTagsDescending {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	for i, tbl := range tables {
+		if err := f(tbl); err != nil {
+			release(tables[i+1:])
+			return err
+		}
+	}
+	return nil
+}
+
+// compareTags compares the values of tags of two group keys in turn.
+// A key without a tag is less than one with it.
+func compareTags(a, b flux.GroupKey, tags []string) int {
+	for _, tag := range tags {
+		if c := compareTagValues(a.LabelValue(tag), b.LabelValue(tag)); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareTagValues(a, b values.Value) int {
+	aNull, bNull := !isTagValue(a), !isTagValue(b)
+	switch {
+	case aNull && bNull:
+		return 0
+	case aNull:
+		return -1
+	case bNull:
+		return 1
+	}
+
+	as, bs := a.Str(), b.Str()
+	switch {
+	case as < bs:
+		return -1
+	case as > bs:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// isTagValue reports whether v is the value of a tag.
+func isTagValue(v values.Value) bool {
+	return v != nil && !v.IsNull() && v.Type().Nature() == semantic.String
+}
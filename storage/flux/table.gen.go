@@ -679,6 +679,7 @@ func newFloatGroupTable(
 	cols []flux.ColMeta,
 	tags models.Tags,
 	defs [][]byte,
+	keep []int,
 	cache *tagsCache,
 	alloc *memory.Allocator,
 ) *floatGroupTable {
@@ -687,6 +688,7 @@ func newFloatGroupTable(
 		gc:    gc,
 		cur:   cur,
 	}
+	t.keep = keep
 	t.readTags(tags)
 	t.init(t.advance)
 
@@ -772,11 +774,13 @@ func (t *floatGroupTable) advance() bool {
 				if arr.Timestamps[i] < timestamp {
 					timestamp = arr.Timestamps[i]
 					value = arr.Values[i]
+					t.recordSelectedTags()
 				}
 			case datatypes.AggregateTypeLast:
 				if arr.Timestamps[i] > timestamp {
 					timestamp = arr.Timestamps[i]
 					value = arr.Values[i]
+					t.recordSelectedTags()
 				}
 			}
 		}
@@ -793,6 +797,7 @@ func (t *floatGroupTable) advance() bool {
 	if IsSelector(t.gc.Aggregate()) {
 		colReader.cols[timeColIdx] = arrow.NewInt([]int64{timestamp}, t.alloc)
 		colReader.cols[valueColIdx] = t.toArrowBuffer([]float64{value})
+		t.useSelectedTags()
 	} else {
 		colReader.cols[valueColIdxWithoutTime] = t.toArrowBuffer([]float64{value})
 	}
@@ -922,14 +927,15 @@ func (t *integerTable) advance() bool {
 // window table
 type integerWindowTable struct {
 	integerTable
-	windowEvery int64
-	offset      int64
-	arr         *cursors.IntegerArray
-	nextTS      int64
-	idxInArr    int
-	createEmpty bool
-	timeColumn  string
-	fillValue   *int64
+	windowEvery   int64
+	offset        int64
+	arr           *cursors.IntegerArray
+	nextTS        int64
+	idxInArr      int
+	createEmpty   bool
+	timeColumn    string
+	fillValue     *int64
+	allowOverflow bool
 }
 
 func newIntegerWindowTable(
@@ -941,6 +947,7 @@ func newIntegerWindowTable(
 	createEmpty bool,
 	timeColumn string,
 	fillValue *int64,
+	allowOverflow bool,
 	key flux.GroupKey,
 	cols []flux.ColMeta,
 	tags models.Tags,
@@ -953,11 +960,12 @@ func newIntegerWindowTable(
 			table: newTable(done, bounds, key, cols, defs, cache, alloc),
 			cur:   cur,
 		},
-		windowEvery: every,
-		offset:      offset,
-		createEmpty: createEmpty,
-		timeColumn:  timeColumn,
-		fillValue:   fillValue,
+		windowEvery:   every,
+		offset:        offset,
+		createEmpty:   createEmpty,
+		timeColumn:    timeColumn,
+		fillValue:     fillValue,
+		allowOverflow: allowOverflow,
 	}
 	if t.createEmpty {
 		start := int64(bounds.Start)
@@ -1110,6 +1118,14 @@ func (t *integerWindowTable) advance() bool {
 	}
 	values := t.mergeValues(stop.Int64Values())
 
+	if err := t.checkOverflow(); err != nil {
+		start.Release()
+		stop.Release()
+		values.Release()
+		t.err = err
+		return false
+	}
+
 	// Retrieve the buffer for the data to avoid allocating
 	// additional slices. If the buffer is still being used
 	// because the references were retained, then we will
@@ -1500,6 +1516,7 @@ func newIntegerGroupTable(
 	cols []flux.ColMeta,
 	tags models.Tags,
 	defs [][]byte,
+	keep []int,
 	cache *tagsCache,
 	alloc *memory.Allocator,
 ) *integerGroupTable {
@@ -1508,6 +1525,7 @@ func newIntegerGroupTable(
 		gc:    gc,
 		cur:   cur,
 	}
+	t.keep = keep
 	t.readTags(tags)
 	t.init(t.advance)
 
@@ -1593,11 +1611,13 @@ func (t *integerGroupTable) advance() bool {
 				if arr.Timestamps[i] < timestamp {
 					timestamp = arr.Timestamps[i]
 					value = arr.Values[i]
+					t.recordSelectedTags()
 				}
 			case datatypes.AggregateTypeLast:
 				if arr.Timestamps[i] > timestamp {
 					timestamp = arr.Timestamps[i]
 					value = arr.Values[i]
+					t.recordSelectedTags()
 				}
 			}
 		}
@@ -1614,6 +1634,7 @@ func (t *integerGroupTable) advance() bool {
 	if IsSelector(t.gc.Aggregate()) {
 		colReader.cols[timeColIdx] = arrow.NewInt([]int64{timestamp}, t.alloc)
 		colReader.cols[valueColIdx] = t.toArrowBuffer([]int64{value})
+		t.useSelectedTags()
 	} else {
 		colReader.cols[valueColIdxWithoutTime] = t.toArrowBuffer([]int64{value})
 	}
@@ -2319,6 +2340,7 @@ func newUnsignedGroupTable(
 	cols []flux.ColMeta,
 	tags models.Tags,
 	defs [][]byte,
+	keep []int,
 	cache *tagsCache,
 	alloc *memory.Allocator,
 ) *unsignedGroupTable {
@@ -2327,6 +2349,7 @@ func newUnsignedGroupTable(
 		gc:    gc,
 		cur:   cur,
 	}
+	t.keep = keep
 	t.readTags(tags)
 	t.init(t.advance)
 
@@ -2412,11 +2435,13 @@ func (t *unsignedGroupTable) advance() bool {
 				if arr.Timestamps[i] < timestamp {
 					timestamp = arr.Timestamps[i]
 					value = arr.Values[i]
+					t.recordSelectedTags()
 				}
 			case datatypes.AggregateTypeLast:
 				if arr.Timestamps[i] > timestamp {
 					timestamp = arr.Timestamps[i]
 					value = arr.Values[i]
+					t.recordSelectedTags()
 				}
 			}
 		}
@@ -2433,6 +2458,7 @@ func (t *unsignedGroupTable) advance() bool {
 	if IsSelector(t.gc.Aggregate()) {
 		colReader.cols[timeColIdx] = arrow.NewInt([]int64{timestamp}, t.alloc)
 		colReader.cols[valueColIdx] = t.toArrowBuffer([]uint64{value})
+		t.useSelectedTags()
 	} else {
 		colReader.cols[valueColIdxWithoutTime] = t.toArrowBuffer([]uint64{value})
 	}
@@ -3138,6 +3164,7 @@ func newStringGroupTable(
 	cols []flux.ColMeta,
 	tags models.Tags,
 	defs [][]byte,
+	keep []int,
 	cache *tagsCache,
 	alloc *memory.Allocator,
 ) *stringGroupTable {
@@ -3146,6 +3173,7 @@ func newStringGroupTable(
 		gc:    gc,
 		cur:   cur,
 	}
+	t.keep = keep
 	t.readTags(tags)
 	t.init(t.advance)
 
@@ -3231,11 +3259,13 @@ func (t *stringGroupTable) advance() bool {
 				if arr.Timestamps[i] < timestamp {
 					timestamp = arr.Timestamps[i]
 					value = arr.Values[i]
+					t.recordSelectedTags()
 				}
 			case datatypes.AggregateTypeLast:
 				if arr.Timestamps[i] > timestamp {
 					timestamp = arr.Timestamps[i]
 					value = arr.Values[i]
+					t.recordSelectedTags()
 				}
 			}
 		}
@@ -3252,6 +3282,7 @@ func (t *stringGroupTable) advance() bool {
 	if IsSelector(t.gc.Aggregate()) {
 		colReader.cols[timeColIdx] = arrow.NewInt([]int64{timestamp}, t.alloc)
 		colReader.cols[valueColIdx] = t.toArrowBuffer([]string{value})
+		t.useSelectedTags()
 	} else {
 		colReader.cols[valueColIdxWithoutTime] = t.toArrowBuffer([]string{value})
 	}
@@ -3957,6 +3988,7 @@ func newBooleanGroupTable(
 	cols []flux.ColMeta,
 	tags models.Tags,
 	defs [][]byte,
+	keep []int,
 	cache *tagsCache,
 	alloc *memory.Allocator,
 ) *booleanGroupTable {
@@ -3965,6 +3997,7 @@ func newBooleanGroupTable(
 		gc:    gc,
 		cur:   cur,
 	}
+	t.keep = keep
 	t.readTags(tags)
 	t.init(t.advance)
 
@@ -4050,11 +4083,13 @@ func (t *booleanGroupTable) advance() bool {
 				if arr.Timestamps[i] < timestamp {
 					timestamp = arr.Timestamps[i]
 					value = arr.Values[i]
+					t.recordSelectedTags()
 				}
 			case datatypes.AggregateTypeLast:
 				if arr.Timestamps[i] > timestamp {
 					timestamp = arr.Timestamps[i]
 					value = arr.Values[i]
+					t.recordSelectedTags()
 				}
 			}
 		}
@@ -4071,6 +4106,7 @@ func (t *booleanGroupTable) advance() bool {
 	if IsSelector(t.gc.Aggregate()) {
 		colReader.cols[timeColIdx] = arrow.NewInt([]int64{timestamp}, t.alloc)
 		colReader.cols[valueColIdx] = t.toArrowBuffer([]bool{value})
+		t.useSelectedTags()
 	} else {
 		colReader.cols[valueColIdxWithoutTime] = t.toArrowBuffer([]bool{value})
 	}
@@ -4,6 +4,7 @@ package storageflux
 
 import (
 	"errors"
+	"fmt"
 	"sync/atomic"
 
 	"github.com/apache/arrow/go/arrow/array"
@@ -11,7 +12,10 @@ import (
 	"github.com/influxdata/flux/arrow"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/values"
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/models"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
 )
 
 type table struct {
@@ -24,6 +28,12 @@ type table struct {
 	tags [][]byte
 	defs [][]byte
 
+	// keep is the index of each column whose value is taken from
+	// the row chosen by a selector rather than from the last series
+	// read. selected holds those values.
+	keep     []int
+	selected [][]byte
+
 	done chan struct{}
 
 	colBufs *colReader
@@ -205,6 +215,32 @@ func (t *table) readTags(tags models.Tags) {
 	}
 }
 
+// recordSelectedTags records the values of the kept columns
+// for the current series when a selector chooses one of its rows.
+func (t *table) recordSelectedTags() {
+	if len(t.keep) == 0 {
+		return
+	}
+	if t.selected == nil {
+		t.selected = make([][]byte, len(t.cols))
+	}
+	for _, j := range t.keep {
+		// The tags are reused by the cursor of the next series.
+		t.selected[j] = append(make([]byte, 0, len(t.tags[j])), t.tags[j]...)
+	}
+}
+
+// useSelectedTags replaces the values of the kept columns
+// with those recorded for the selected row.
+func (t *table) useSelectedTags() {
+	if t.selected == nil {
+		return
+	}
+	for _, j := range t.keep {
+		t.tags[j] = t.selected[j]
+	}
+}
+
 // appendTags fills the colBufs for the tag columns with the tag value.
 func (t *table) appendTags(cr *colReader) {
 	for j := range t.cols {
@@ -268,6 +304,25 @@ func (t *integerWindowTable) mergeValues(intervals []int64) *array.Int64 {
 	t.appendValues(intervals, b.Append, appendNull)
 	return b.NewInt64Array()
 }
+
+// checkOverflow returns an error identifying the series and window
+// when the storage engine reports that an integer sum overflowed.
+// The wrapped around value is kept if overflow is allowed.
+func (t *integerWindowTable) checkOverflow() error {
+	if t.allowOverflow {
+		return nil
+	}
+	var oerr *storage.IntegerOverflowError
+	if !errors.As(t.cur.Err(), &oerr) {
+		return nil
+	}
+	start, stop := t.getWindowBoundsFor(oerr.Stop)
+	return &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg: fmt.Sprintf("integer overflow in sum for series %s in window [%s, %s)",
+			t.key, values.Time(start), values.Time(stop)),
+	}
+}
 func (t *integerEmptyWindowSelectorTable) arrowBuilder() *array.Int64Builder {
 	return arrow.NewIntBuilder(t.alloc)
 }
@@ -1,7 +1,21 @@
 package storageflux
 
-import "sync/atomic"
+import (
+	"sync/atomic"
+
+	"github.com/influxdata/influxdb/v2/query"
+)
 
 func (t *table) IsDone() bool {
 	return atomic.LoadInt32(&t.used) != 0
 }
+
+// ReaderWorkers returns the number of workers that r uses to
+// prepare tables and to decode blocks.
+func ReaderWorkers(r query.StorageReader) (read, decode int) {
+	sr := r.(*storeReader)
+	if sr.decode != nil {
+		decode = cap(sr.decode.sem)
+	}
+	return sr.parallelism, decode
+}
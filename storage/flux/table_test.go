@@ -1,18 +1,26 @@
 package storageflux_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io/ioutil"
 	"math"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/gogo/protobuf/types"
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/csv"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/execute/executetest"
 	"github.com/influxdata/flux/execute/table"
@@ -22,23 +30,31 @@ import (
 	"github.com/influxdata/flux/values"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/generate"
+	icontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/pkg/data/gen"
 	"github.com/influxdata/influxdb/v2/query"
 	"github.com/influxdata/influxdb/v2/storage"
 	storageflux "github.com/influxdata/influxdb/v2/storage/flux"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
 	"github.com/influxdata/influxdb/v2/storage/readservice"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
 	"go.uber.org/zap/zaptest"
 )
 
 type SetupFunc func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange)
 
 type StorageReader struct {
-	Org    influxdb.ID
-	Bucket influxdb.ID
-	Bounds execute.Bounds
-	Close  func()
+	Org           influxdb.ID
+	Bucket        influxdb.ID
+	Bounds        execute.Bounds
+	Close         func()
+	DeleteService influxdb.DeleteService
+	Viewer        reads.Viewer
+	Store         reads.Store
 	query.StorageReader
 }
 
@@ -66,7 +82,8 @@ func NewStorageReader(tb testing.TB, setupFn SetupFunc) *StorageReader {
 	if err := engine.Open(context.Background()); err != nil {
 		tb.Fatal(err)
 	}
-	reader := storageflux.NewReader(readservice.NewStore(engine))
+	store := readservice.NewStore(engine)
+	reader := storageflux.NewReader(store)
 	return &StorageReader{
 		Org:    org,
 		Bucket: bucket,
@@ -75,6 +92,9 @@ func NewStorageReader(tb testing.TB, setupFn SetupFunc) *StorageReader {
 			Stop:  values.ConvertTime(tr.End),
 		},
 		Close:         close,
+		DeleteService: engine,
+		Viewer:        engine,
+		Store:         store,
 		StorageReader: reader,
 	}
 }
@@ -156,395 +176,550 @@ func TestStorageReader_ReadFilter(t *testing.T) {
 	}
 }
 
-func TestStorageReader_Table(t *testing.T) {
+func TestStorageReader_ReadFilter_Deleted(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
 		spec := Spec(org, bucket,
 			MeasurementSpec("m0",
-				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
-				TagValuesSequence("t0", "a-%s", 0, 3),
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0, 5.0, 6.0}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
 			),
 		)
-		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
 		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
 	})
 	defer reader.Close()
 
-	for _, tc := range []struct {
-		name  string
-		newFn func(ctx context.Context, alloc *memory.Allocator) flux.TableIterator
-	}{
-		{
-			name: "ReadFilter",
-			newFn: func(ctx context.Context, alloc *memory.Allocator) flux.TableIterator {
-				ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
-					OrganizationID: reader.Org,
-					BucketID:       reader.Bucket,
-					Bounds:         reader.Bounds,
-				}, alloc)
-				if err != nil {
-					t.Fatal(err)
-				}
-				return ti
-			},
+	// Delete the points at 20s and 30s. The deleted range is inclusive.
+	min, max := int64(Time("2019-11-25T00:00:20Z")), int64(Time("2019-11-25T00:00:30Z"))
+	if err := reader.DeleteService.DeleteBucketRangePredicate(context.Background(), reader.Org, reader.Bucket, min, max, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       reader.Bucket,
+		Bounds:         reader.Bounds,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start, stop := reader.Bounds.Start, reader.Bounds.Stop
+	want := []*executetest.Table{{
+		KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
+		ColMeta: []flux.ColMeta{
+			{Label: "_start", Type: flux.TTime},
+			{Label: "_stop", Type: flux.TTime},
+			{Label: "_time", Type: flux.TTime},
+			{Label: "_value", Type: flux.TFloat},
+			{Label: "_field", Type: flux.TString},
+			{Label: "_measurement", Type: flux.TString},
+			{Label: "t0", Type: flux.TString},
 		},
-	} {
-		t.Run(tc.name, func(t *testing.T) {
-			executetest.RunTableTests(t, executetest.TableTest{
-				NewFn: tc.newFn,
-				IsDone: func(table flux.Table) bool {
-					return table.(interface {
-						IsDone() bool
-					}).IsDone()
-				},
-			})
-		})
+		Data: [][]interface{}{
+			{start, stop, Time("2019-11-25T00:00:00Z"), 1.0, "f0", "m0", "a-0"},
+			{start, stop, Time("2019-11-25T00:00:10Z"), 2.0, "f0", "m0", "a-0"},
+			{start, stop, Time("2019-11-25T00:00:40Z"), 5.0, "f0", "m0", "a-0"},
+			{start, stop, Time("2019-11-25T00:00:50Z"), 6.0, "f0", "m0", "a-0"},
+		},
+	}}
+	executetest.NormalizeTables(want)
+
+	var got []*executetest.Table
+	if err := ti.Do(func(table flux.Table) error {
+		t, err := executetest.ConvertTable(table)
+		if err != nil {
+			return err
+		}
+		got = append(got, t)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	executetest.NormalizeTables(got)
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
 	}
 }
 
-func TestStorageReader_ReadWindowAggregate(t *testing.T) {
+func TestStorageReader_ReadFilter_EmptyRange(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
 		spec := Spec(org, bucket,
 			MeasurementSpec("m0",
-				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
 				TagValuesSequence("t0", "a-%s", 0, 3),
 			),
 		)
-		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
 		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
 	})
 	defer reader.Close()
 
+	bounds := execute.Bounds{
+		Start: Time("2019-11-24T00:00:00Z"),
+		Stop:  Time("2019-11-24T01:00:00Z"),
+	}
+	filterSpec := query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       reader.Bucket,
+		Bounds:         bounds,
+	}
+
+	mem := &memory.Allocator{}
+	for name, read := range map[string]func() (query.TableIterator, error){
+		"ReadFilter": func() (query.TableIterator, error) {
+			return reader.ReadFilter(context.Background(), filterSpec, mem)
+		},
+		"ReadWindowAggregate": func() (query.TableIterator, error) {
+			return reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: filterSpec,
+				WindowEvery:    int64(10 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					storageflux.CountKind,
+				},
+			}, mem)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ti, err := read()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var n int
+			if err := ti.Do(func(table flux.Table) error {
+				table.Done()
+				n++
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if n != 0 {
+				t.Errorf("expected no tables, got %d", n)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadFilter_ValueTypes(t *testing.T) {
 	for _, tt := range []struct {
-		aggregate plan.ProcedureKind
-		want      flux.TableIterator
+		name  string
+		field *gen.FieldValuesSpec
+		typ   flux.ColType
+		want  []interface{}
 	}{
 		{
-			aggregate: storageflux.CountKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
-							static.Ints("_value", 3),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
-							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-							static.Ints("_value", 3),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:01:00Z"),
-							static.TimeKey("_stop", "2019-11-25T00:01:30Z"),
-							static.Ints("_value", 3),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:01:30Z"),
-							static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
-							static.Ints("_value", 3),
-						},
-					},
-				},
-			},
+			name:  "float",
+			field: FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+			typ:   flux.TFloat,
+			want:  []interface{}{1.0, 2.0, 3.0},
 		},
 		{
-			aggregate: storageflux.MinKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
-							static.Times("_time", "2019-11-25T00:00:00Z"),
-							static.Floats("_value", 1),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
-							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-							static.Times("_time", "2019-11-25T00:00:40Z"),
-							static.Floats("_value", 1),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:01:00Z"),
-							static.TimeKey("_stop", "2019-11-25T00:01:30Z"),
-							static.Times("_time", "2019-11-25T00:01:20Z"),
-							static.Floats("_value", 1),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:01:30Z"),
-							static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
-							static.Times("_time", "2019-11-25T00:01:30Z"),
-							static.Floats("_value", 2),
-						},
-					},
-				},
-			},
+			name:  "integer",
+			field: IntegerArrayValuesSequence("f0", 10*time.Second, []int64{1, 2, 3}),
+			typ:   flux.TInt,
+			want:  []interface{}{int64(1), int64(2), int64(3)},
 		},
 		{
-			aggregate: storageflux.MaxKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
-							static.Times("_time", "2019-11-25T00:00:20Z"),
-							static.Floats("_value", 3),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
-							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-							static.Times("_time", "2019-11-25T00:00:30Z"),
-							static.Floats("_value", 4),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:01:00Z"),
-							static.TimeKey("_stop", "2019-11-25T00:01:30Z"),
-							static.Times("_time", "2019-11-25T00:01:10Z"),
-							static.Floats("_value", 4),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:01:30Z"),
-							static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
-							static.Times("_time", "2019-11-25T00:01:50Z"),
-							static.Floats("_value", 4),
-						},
-					},
-				},
-			},
+			name:  "unsigned",
+			field: UnsignedArrayValuesSequence("f0", 10*time.Second, []uint64{1, 2, 3}),
+			typ:   flux.TUInt,
+			want:  []interface{}{uint64(1), uint64(2), uint64(3)},
+		},
+		{
+			name:  "boolean",
+			field: BooleanArrayValuesSequence("f0", 10*time.Second, []bool{true, false}),
+			typ:   flux.TBool,
+			want:  []interface{}{true, false, true},
+		},
+		{
+			name:  "string",
+			field: StringArrayValuesSequence("f0", 10*time.Second, []string{"a", "b", "c"}),
+			typ:   flux.TString,
+			want:  []interface{}{"a", "b", "c"},
 		},
 	} {
-		t.Run(string(tt.aggregate), func(t *testing.T) {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+				spec := Spec(org, bucket,
+					MeasurementSpec("m0",
+						tt.field,
+						TagValuesSequence("t0", "a-%s", 0, 1),
+					),
+				)
+				tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+				return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+			})
+			defer reader.Close()
+
 			mem := &memory.Allocator{}
-			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
-				ReadFilterSpec: query.ReadFilterSpec{
-					OrganizationID: reader.Org,
-					BucketID:       reader.Bucket,
-					Bounds:         reader.Bounds,
-				},
-				WindowEvery: int64(30 * time.Second),
-				Aggregates: []plan.ProcedureKind{
-					tt.aggregate,
-				},
+			ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+				OrganizationID: reader.Org,
+				BucketID:       reader.Bucket,
+				Bounds:         reader.Bounds,
 			}, mem)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			if diff := table.Diff(tt.want, got); diff != "" {
-				t.Fatalf("unexpected output -want/+got:\n%s", diff)
+			start, stop := reader.Bounds.Start, reader.Bounds.Stop
+			want := []*executetest.Table{{
+				KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
+				ColMeta: []flux.ColMeta{
+					{Label: "_start", Type: flux.TTime},
+					{Label: "_stop", Type: flux.TTime},
+					{Label: "_time", Type: flux.TTime},
+					{Label: "_value", Type: tt.typ},
+					{Label: "_field", Type: flux.TString},
+					{Label: "_measurement", Type: flux.TString},
+					{Label: "t0", Type: flux.TString},
+				},
+				Data: [][]interface{}{
+					{start, stop, Time("2019-11-25T00:00:00Z"), tt.want[0], "f0", "m0", "a-0"},
+					{start, stop, Time("2019-11-25T00:00:10Z"), tt.want[1], "f0", "m0", "a-0"},
+					{start, stop, Time("2019-11-25T00:00:20Z"), tt.want[2], "f0", "m0", "a-0"},
+				},
+			}}
+			executetest.NormalizeTables(want)
+
+			var got []*executetest.Table
+			if err := ti.Do(func(table flux.Table) error {
+				t, err := executetest.ConvertTable(table)
+				if err != nil {
+					return err
+				}
+				got = append(got, t)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			executetest.NormalizeTables(got)
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
 			}
 		})
 	}
 }
 
-func TestStorageReader_ReadWindowAggregate_ByStopTime(t *testing.T) {
-	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
-		spec := Spec(org, bucket,
-			MeasurementSpec("m0",
-				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
-				TagValuesSequence("t0", "a-%s", 0, 3),
-			),
-		)
-		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
-		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
-	})
-	defer reader.Close()
-
+func TestStorageReader_ReadFilter_CoerceToFloat(t *testing.T) {
 	for _, tt := range []struct {
-		aggregate plan.ProcedureKind
-		want      flux.TableIterator
+		name  string
+		field *gen.FieldValuesSpec
+		want  static.Table
 	}{
 		{
-			aggregate: storageflux.CountKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
-							static.Ints("_value", 3, 3, 3, 3),
-						},
-					},
-				},
+			name:  "integer",
+			field: IntegerArrayValuesSequence("f0", 10*time.Second, []int64{1, -2, 3}),
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:00Z", 10, 20),
+				static.Floats("_value", 1, -2, 3),
 			},
 		},
 		{
-			aggregate: storageflux.MinKind,
-			want: static.TableGroup{
+			name:  "unsigned",
+			field: UnsignedArrayValuesSequence("f0", 10*time.Second, []uint64{1, 2, 3}),
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:00Z", 10, 20),
+				static.Floats("_value", 1, 2, 3),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+				spec := Spec(org, bucket,
+					MeasurementSpec("m0",
+						tt.field,
+						TagValuesSequence("t0", "a-%s", 0, 1),
+					),
+				)
+				tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+				return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+			})
+			defer reader.Close()
+
+			mem := &memory.Allocator{}
+			ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+				OrganizationID: reader.Org,
+				BucketID:       reader.Bucket,
+				Bounds:         reader.Bounds,
+				CoerceToFloat:  true,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := static.TableGroup{
 				static.StringKey("_measurement", "m0"),
 				static.StringKey("_field", "f0"),
+				static.StringKey("t0", "a-0"),
 				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
-							static.Floats("_value", 1, 1, 1, 2),
-						},
-					},
-				},
+				static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+				tt.want,
+			}
+			if diff := table.Diff(want, ti); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadFilter_ValueScale(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		field  *gen.FieldValuesSpec
+		scale  float64
+		offset float64
+		want   static.Table
+	}{
+		{
+			name:  "float",
+			field: FloatArrayValuesSequence("f0", 10*time.Second, []float64{1000, 2500, 3000}),
+			scale: 0.001,
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:00Z", 10, 20),
+				static.Floats("_value", 1, 2.5, 3),
 			},
 		},
 		{
-			aggregate: storageflux.MaxKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
-							static.Floats("_value", 3, 4, 4, 4),
-						},
-					},
-				},
+			name:  "integer",
+			field: IntegerArrayValuesSequence("f0", 10*time.Second, []int64{1000, -2500, 3000}),
+			scale: 0.001,
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:00Z", 10, 20),
+				static.Floats("_value", 1, -2.5, 3),
+			},
+		},
+		{
+			name:   "integral",
+			field:  IntegerArrayValuesSequence("f0", 10*time.Second, []int64{1, 2, 3}),
+			scale:  1000,
+			offset: 1,
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:00Z", 10, 20),
+				static.Ints("_value", 1001, 2001, 3001),
 			},
 		},
 	} {
-		mem := &memory.Allocator{}
-		got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
-			ReadFilterSpec: query.ReadFilterSpec{
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+				spec := Spec(org, bucket,
+					MeasurementSpec("m0",
+						tt.field,
+						TagValuesSequence("t0", "a-%s", 0, 1),
+					),
+				)
+				tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+				return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+			})
+			defer reader.Close()
+
+			mem := &memory.Allocator{}
+			ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
 				OrganizationID: reader.Org,
 				BucketID:       reader.Bucket,
 				Bounds:         reader.Bounds,
-			},
-			TimeColumn:  execute.DefaultStopColLabel,
-			WindowEvery: int64(30 * time.Second),
-			Aggregates: []plan.ProcedureKind{
-				tt.aggregate,
-			},
-		}, mem)
-		if err != nil {
-			t.Fatal(err)
-		}
+				ValueScale:     tt.scale,
+				ValueOffset:    tt.offset,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-		if diff := table.Diff(tt.want, got); diff != "" {
-			t.Errorf("unexpected results -want/+got:\n%s", diff)
-		}
+			want := static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.StringKey("t0", "a-0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+				tt.want,
+			}
+			if diff := table.Diff(want, ti); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
 	}
 }
 
-func TestStorageReader_ReadWindowAggregate_ByStartTime(t *testing.T) {
+func TestStorageReader_ReadFilter_ChangedSince(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
 		spec := Spec(org, bucket,
 			MeasurementSpec("m0",
-				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
 				TagValuesSequence("t0", "a-%s", 0, 3),
 			),
 		)
-		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
 		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
 	})
 	defer reader.Close()
 
+	// Every series was written before the time of the sync.
+	since := Time("2019-11-25T00:00:25Z")
+
+	pt, err := models.NewPoint(
+		tsdb.EncodeNameString(reader.Org, reader.Bucket),
+		models.NewTags(map[string]string{
+			models.MeasurementTagKey: "m0",
+			models.FieldKeyTagKey:    "f0",
+			"t0":                     "a-1",
+		}),
+		models.Fields{"f0": 4.0},
+		time.Unix(0, int64(Time("2019-11-25T00:00:40Z"))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Viewer.(storage.PointsWriter).WritePoints(context.Background(), []models.Point{pt}); err != nil {
+		t.Fatal(err)
+	}
+
+	bounds := execute.Bounds{Start: reader.Bounds.Start, Stop: Time("2019-11-25T00:01:00Z")}
 	for _, tt := range []struct {
-		aggregate plan.ProcedureKind
-		want      flux.TableIterator
+		name  string
+		since values.Time
+		want  []string
 	}{
 		{
-			aggregate: storageflux.CountKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:00Z", 30, 60, 90),
-							static.Ints("_value", 3, 3, 3, 3),
-						},
-					},
-				},
-			},
-		},
-		{
-			aggregate: storageflux.MinKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:00Z", 30, 60, 90),
-							static.Floats("_value", 1, 1, 1, 2),
-						},
-					},
-				},
-			},
+			name:  "changed",
+			since: since,
+			want:  []string{"a-1"},
 		},
 		{
-			aggregate: storageflux.MaxKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:00Z", 30, 60, 90),
-							static.Floats("_value", 3, 4, 4, 4),
-						},
-					},
-				},
-			},
+			name:  "strictly after",
+			since: Time("2019-11-25T00:00:40Z"),
 		},
 	} {
-		t.Run(string(tt.aggregate), func(t *testing.T) {
+		t.Run(tt.name, func(t *testing.T) {
 			mem := &memory.Allocator{}
-			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
-				ReadFilterSpec: query.ReadFilterSpec{
-					OrganizationID: reader.Org,
-					BucketID:       reader.Bucket,
-					Bounds:         reader.Bounds,
-				},
-				TimeColumn:  execute.DefaultStartColLabel,
-				WindowEvery: int64(30 * time.Second),
-				Aggregates: []plan.ProcedureKind{
-					tt.aggregate,
-				},
+			ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+				OrganizationID: reader.Org,
+				BucketID:       reader.Bucket,
+				Bounds:         bounds,
+				ChangedSince:   tt.since,
 			}, mem)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			if diff := table.Diff(tt.want, got); diff != "" {
-				t.Fatalf("unexpected output -want/+got:\n%s", diff)
+			var got []string
+			if err := ti.Do(func(table flux.Table) error {
+				got = append(got, table.Key().LabelValue("t0").Str())
+				var rows int
+				if err := table.Do(func(cr flux.ColReader) error {
+					rows += cr.Len()
+					return nil
+				}); err != nil {
+					return err
+				}
+				// The table has every point of the series, not
+				// only those written after the sync.
+				if want := 4; rows != want {
+					t.Errorf("unexpected number of rows -want/+got:\n\t- %d\n\t+ %d", want, rows)
+				}
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected series -want/+got:\n%s", diff)
 			}
 		})
 	}
 }
 
-func TestStorageReader_ReadWindowAggregate_CreateEmpty(t *testing.T) {
+func TestStorageReader_ReadFilter_Parallel(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
 		spec := Spec(org, bucket,
 			MeasurementSpec("m0",
-				FloatArrayValuesSequence("f0", 15*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
-				TagValuesSequence("t0", "a-%s", 0, 3),
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 10),
+			),
+			MeasurementSpec("m1",
+				IntegerArrayValuesSequence("f1", 10*time.Second, []int64{1, 2, 3}),
+				TagValuesSequence("t1", "b-%s", 0, 7),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	readTables := func(t *testing.T, r query.StorageReader, unsorted bool) []*executetest.Table {
+		t.Helper()
+
+		mem := &memory.Allocator{}
+		ti, err := r.ReadFilter(context.Background(), query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+			Unsorted:       unsorted,
+		}, mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var tables []*executetest.Table
+		if err := ti.Do(func(table flux.Table) error {
+			t, err := executetest.ConvertTable(table)
+			if err != nil {
+				return err
+			}
+			tables = append(tables, t)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		executetest.NormalizeTables(tables)
+		return tables
+	}
+
+	want := readTables(t, reader.StorageReader, false)
+	if got, exp := len(want), 17; got != exp {
+		t.Fatalf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", exp, got)
+	}
+
+	for _, n := range []int{2, 3, 4, 32} {
+		t.Run(fmt.Sprintf("parallelism=%d", n), func(t *testing.T) {
+			got := readTables(t, storageflux.NewReader(reader.Store, storageflux.WithReadParallelism(n)), false)
+
+			// The tables are not sorted so the order must also match.
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+
+	sortedWant := append([]*executetest.Table(nil), want...)
+	sort.Sort(executetest.SortedTables(sortedWant))
+	for _, n := range []int{1, 2, 3, 4, 32} {
+		t.Run(fmt.Sprintf("unsorted/parallelism=%d", n), func(t *testing.T) {
+			got := readTables(t, storageflux.NewReader(reader.Store, storageflux.WithReadParallelism(n)), true)
+
+			// The order is not deterministic so every table
+			// must be present once the tables are sorted.
+			sort.Sort(executetest.SortedTables(got))
+			if diff := cmp.Diff(sortedWant, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadFilter_SortTags(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 2, 4),
+			),
+			MeasurementSpec("m1",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 2),
 			),
 		)
 		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
@@ -553,277 +728,179 @@ func TestStorageReader_ReadWindowAggregate_CreateEmpty(t *testing.T) {
 	defer reader.Close()
 
 	for _, tt := range []struct {
-		aggregate plan.ProcedureKind
-		want      flux.TableIterator
+		name       string
+		descending bool
+		want       []string
 	}{
 		{
-			aggregate: storageflux.CountKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
-							static.Ints("_value", 1),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
-							static.Ints("_value", 1),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
-							static.Ints("_value", 0),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:40Z"),
-							static.Ints("_value", 1),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:40Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:50Z"),
-							static.Ints("_value", 1),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:50Z"),
-							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-							static.Ints("_value", 0),
-						},
-					},
-				},
-			},
-		},
-		{
-			aggregate: storageflux.MinKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
-							static.Times("_time", "2019-11-25T00:00:00Z"),
-							static.Floats("_value", 1),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
-							static.Times("_time", "2019-11-25T00:00:15Z"),
-							static.Floats("_value", 2),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
-							static.Times("_time"),
-							static.Floats("_value"),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:40Z"),
-							static.Times("_time", "2019-11-25T00:00:30Z"),
-							static.Floats("_value", 3),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:40Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:50Z"),
-							static.Times("_time", "2019-11-25T00:00:45Z"),
-							static.Floats("_value", 4),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:50Z"),
-							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-							static.Times("_time"),
-							static.Floats("_value"),
-						},
-					},
-				},
-			},
+			name: "ascending",
+			want: []string{"m1,a-0", "m1,a-1", "m0,a-2", "m0,a-3"},
 		},
 		{
-			aggregate: storageflux.MaxKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
-							static.Times("_time", "2019-11-25T00:00:00Z"),
-							static.Floats("_value", 1),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
-							static.Times("_time", "2019-11-25T00:00:15Z"),
-							static.Floats("_value", 2),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
-							static.Times("_time"),
-							static.Floats("_value"),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:40Z"),
-							static.Times("_time", "2019-11-25T00:00:30Z"),
-							static.Floats("_value", 3),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:40Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:50Z"),
-							static.Times("_time", "2019-11-25T00:00:45Z"),
-							static.Floats("_value", 4),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:50Z"),
-							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-							static.Times("_time"),
-							static.Floats("_value"),
-						},
-					},
-				},
-			},
+			name:       "descending",
+			descending: true,
+			want:       []string{"m0,a-3", "m0,a-2", "m1,a-1", "m1,a-0"},
 		},
 	} {
-		t.Run(string(tt.aggregate), func(t *testing.T) {
+		t.Run(tt.name, func(t *testing.T) {
 			mem := &memory.Allocator{}
-			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
-				ReadFilterSpec: query.ReadFilterSpec{
-					OrganizationID: reader.Org,
-					BucketID:       reader.Bucket,
-					Bounds:         reader.Bounds,
-				},
-				WindowEvery: int64(10 * time.Second),
-				Aggregates: []plan.ProcedureKind{
-					tt.aggregate,
-				},
-				CreateEmpty: true,
+			ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+				OrganizationID:     reader.Org,
+				BucketID:           reader.Bucket,
+				Bounds:             reader.Bounds,
+				SortTags:           []string{"t0"},
+				SortTagsDescending: tt.descending,
 			}, mem)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			if diff := table.Diff(tt.want, got); diff != "" {
-				t.Fatalf("unexpected output -want/+got:\n%s", diff)
+			var got []string
+			if err := ti.Do(func(table flux.Table) error {
+				key := table.Key()
+				got = append(got, key.LabelValue("_measurement").Str()+","+key.LabelValue("t0").Str())
+				table.Done()
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected table order -want/+got:\n%s", diff)
 			}
 		})
 	}
 }
 
-func TestStorageReader_ReadWindowAggregate_CreateEmptyByStopTime(t *testing.T) {
+func TestStorageReader_ReadFilter_DecodeParallelism(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
 		spec := Spec(org, bucket,
 			MeasurementSpec("m0",
-				FloatArrayValuesSequence("f0", 15*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
-				TagValuesSequence("t0", "a-%s", 0, 3),
+				FloatArrayValuesSequence("f0", time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 10),
+			),
+			MeasurementSpec("m1",
+				IntegerArrayValuesSequence("f1", time.Second, []int64{1, 2, 3}),
+				TagValuesSequence("t1", "b-%s", 0, 7),
+			),
+			MeasurementSpec("m2",
+				StringArrayValuesSequence("f2", time.Second, []string{"a", "b", "c"}),
+				TagValuesSequence("t2", "c-%s", 0, 3),
 			),
 		)
-		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		// Enough points for several blocks per series.
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T01:00:00Z")
 		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
 	})
 	defer reader.Close()
 
+	readTables := func(t *testing.T, r query.StorageReader) []*executetest.Table {
+		t.Helper()
+
+		mem := &memory.Allocator{}
+		ti, err := r.ReadFilter(context.Background(), query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		}, mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var tables []*executetest.Table
+		if err := ti.Do(func(table flux.Table) error {
+			t, err := executetest.ConvertTable(table)
+			if err != nil {
+				return err
+			}
+			tables = append(tables, t)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		executetest.NormalizeTables(tables)
+		return tables
+	}
+
+	want := readTables(t, reader.StorageReader)
+	if got, exp := len(want), 20; got != exp {
+		t.Fatalf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", exp, got)
+	}
+
+	for _, n := range []int{2, 4, 32} {
+		t.Run(fmt.Sprintf("decode_parallelism=%d", n), func(t *testing.T) {
+			got := readTables(t, storageflux.NewReader(reader.Store, storageflux.WithDecodeParallelism(n)))
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+
+	// Decoding ahead may be combined with preparing tables concurrently.
+	got := readTables(t, storageflux.NewReader(reader.Store,
+		storageflux.WithReadParallelism(4),
+		storageflux.WithDecodeParallelism(4),
+	))
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_MaxCPUs(t *testing.T) {
 	for _, tt := range []struct {
-		aggregate plan.ProcedureKind
-		want      flux.TableIterator
+		name                 string
+		opts                 []storageflux.Option
+		wantRead, wantDecode int
 	}{
 		{
-			aggregate: storageflux.CountKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:10Z", 10, 20, 30, 40, 50),
-							static.Ints("_value", 1, 1, 0, 1, 1, 0),
-						},
-					},
-				},
+			name: "bounded",
+			opts: []storageflux.Option{
+				storageflux.WithReadParallelism(8),
+				storageflux.WithDecodeParallelism(8),
+				storageflux.WithMaxCPUs(2),
 			},
+			wantRead:   2,
+			wantDecode: 2,
 		},
 		{
-			aggregate: storageflux.MinKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:10Z", 10, 30, 40),
-							static.Floats("_value", 1, 2, 3, 4),
-						},
-					},
-				},
+			name: "within bound",
+			opts: []storageflux.Option{
+				storageflux.WithMaxCPUs(4),
+				storageflux.WithReadParallelism(2),
+				storageflux.WithDecodeParallelism(3),
 			},
+			wantRead:   2,
+			wantDecode: 3,
 		},
 		{
-			aggregate: storageflux.MaxKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:10Z", 10, 30, 40),
-							static.Floats("_value", 1, 2, 3, 4),
-						},
-					},
-				},
+			name: "unbounded",
+			opts: []storageflux.Option{
+				storageflux.WithReadParallelism(8),
+				storageflux.WithDecodeParallelism(8),
 			},
+			wantRead:   8,
+			wantDecode: 8,
 		},
 	} {
-		t.Run(string(tt.aggregate), func(t *testing.T) {
-			mem := &memory.Allocator{}
-			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
-				ReadFilterSpec: query.ReadFilterSpec{
-					OrganizationID: reader.Org,
-					BucketID:       reader.Bucket,
-					Bounds:         reader.Bounds,
-				},
-				TimeColumn:  execute.DefaultStopColLabel,
-				WindowEvery: int64(10 * time.Second),
-				Aggregates: []plan.ProcedureKind{
-					tt.aggregate,
-				},
-				CreateEmpty: true,
-			}, mem)
-			if err != nil {
-				t.Fatal(err)
+		t.Run(tt.name, func(t *testing.T) {
+			read, decode := storageflux.ReaderWorkers(storageflux.NewReader(nil, tt.opts...))
+			if read != tt.wantRead {
+				t.Errorf("unexpected read workers -want/+got:\n\t- %d\n\t+ %d", tt.wantRead, read)
 			}
-
-			if diff := table.Diff(tt.want, got); diff != "" {
-				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			if decode != tt.wantDecode {
+				t.Errorf("unexpected decode workers -want/+got:\n\t- %d\n\t+ %d", tt.wantDecode, decode)
 			}
 		})
 	}
 }
 
-func TestStorageReader_ReadWindowAggregate_CreateEmptyByStartTime(t *testing.T) {
+func TestStorageReader_ReadFilter_RegexPredicate(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
 		spec := Spec(org, bucket,
 			MeasurementSpec("m0",
-				FloatArrayValuesSequence("f0", 15*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
-				TagValuesSequence("t0", "a-%s", 0, 3),
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 5),
+				TagValuesSequence("t1", "b-%s", 0, 2),
 			),
 		)
 		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
@@ -832,96 +909,83 @@ func TestStorageReader_ReadWindowAggregate_CreateEmptyByStartTime(t *testing.T)
 	defer reader.Close()
 
 	for _, tt := range []struct {
-		aggregate plan.ProcedureKind
-		want      flux.TableIterator
+		name       string
+		comparison datatypes.Node_Comparison
+		pattern    string
+		want       []string
 	}{
 		{
-			aggregate: storageflux.CountKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:00Z", 10, 20, 30, 40, 50),
-							static.Ints("_value", 1, 1, 0, 1, 1, 0),
-						},
-					},
-				},
-			},
+			name:       "anchored alternation",
+			comparison: datatypes.ComparisonRegex,
+			pattern:    "^(a-1|a-3)$",
+			want:       []string{"a-1", "a-1", "a-3", "a-3"},
 		},
 		{
-			aggregate: storageflux.MinKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:00Z", 10, 30, 40),
-							static.Floats("_value", 1, 2, 3, 4),
-						},
-					},
-				},
-			},
+			name:       "not anchored character class",
+			comparison: datatypes.ComparisonNotRegex,
+			pattern:    "^a-[0-2]$",
+			want:       []string{"a-3", "a-3", "a-4", "a-4"},
 		},
 		{
-			aggregate: storageflux.MaxKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.Times("_time", "2019-11-25T00:00:00Z", 10, 30, 40),
-							static.Floats("_value", 1, 2, 3, 4),
+			name:       "unanchored",
+			comparison: datatypes.ComparisonRegex,
+			pattern:    "a-[01]",
+			want:       []string{"a-0", "a-0", "a-1", "a-1"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			predicate := &datatypes.Predicate{
+				Root: &datatypes.Node{
+					NodeType: datatypes.NodeTypeComparisonExpression,
+					Value:    &datatypes.Node_Comparison_{Comparison: tt.comparison},
+					Children: []*datatypes.Node{
+						{
+							NodeType: datatypes.NodeTypeTagRef,
+							Value:    &datatypes.Node_TagRefValue{TagRefValue: "t0"},
+						},
+						{
+							NodeType: datatypes.NodeTypeLiteral,
+							Value:    &datatypes.Node_RegexValue{RegexValue: tt.pattern},
 						},
 					},
 				},
-			},
-		},
-	} {
-		t.Run(string(tt.aggregate), func(t *testing.T) {
+			}
+
 			mem := &memory.Allocator{}
-			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
-				ReadFilterSpec: query.ReadFilterSpec{
-					OrganizationID: reader.Org,
-					BucketID:       reader.Bucket,
-					Bounds:         reader.Bounds,
-				},
-				TimeColumn:  execute.DefaultStartColLabel,
-				WindowEvery: int64(10 * time.Second),
-				Aggregates: []plan.ProcedureKind{
-					tt.aggregate,
-				},
-				CreateEmpty: true,
+			ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+				OrganizationID: reader.Org,
+				BucketID:       reader.Bucket,
+				Bounds:         reader.Bounds,
+				Predicate:      predicate,
 			}, mem)
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			if diff := table.Diff(tt.want, got); diff != "" {
-				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			var got []string
+			if err := ti.Do(func(table flux.Table) error {
+				table.Done()
+				got = append(got, table.Key().LabelValue("t0").Str())
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+
+			if !cmp.Equal(tt.want, got) {
+				t.Errorf("unexpected tables -want/+got:\n%s", cmp.Diff(tt.want, got))
 			}
 		})
 	}
 }
 
-func TestStorageReader_ReadWindowAggregate_TruncatedBounds(t *testing.T) {
+func TestStorageReader_ReadFilter_MaxOpenCursors(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
 		spec := Spec(org, bucket,
 			MeasurementSpec("m0",
-				FloatArrayValuesSequence("f0", 5*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
-				TagValuesSequence("t0", "a-%s", 0, 3),
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 10),
+				TagValuesSequence("t1", "b-%s", 0, 10),
 			),
 		)
 		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
@@ -929,144 +993,3771 @@ func TestStorageReader_ReadWindowAggregate_TruncatedBounds(t *testing.T) {
 	})
 	defer reader.Close()
 
-	for _, tt := range []struct {
-		aggregate plan.ProcedureKind
-		want      flux.TableIterator
-	}{
-		{
-			aggregate: storageflux.CountKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:05Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
-							static.Ints("_value", 1),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
-							static.Ints("_value", 2),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
-							static.Ints("_value", 1),
-						},
-					},
-				},
-			},
-		},
-		{
-			aggregate: storageflux.MinKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:05Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
-							static.Times("_time", "2019-11-25T00:00:05Z"),
-							static.Floats("_value", 2),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
-							static.Times("_time", "2019-11-25T00:00:10Z"),
-							static.Floats("_value", 3),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
-							static.Times("_time", "2019-11-25T00:00:20Z"),
-							static.Floats("_value", 1),
-						},
-					},
-				},
-			},
-		},
-		{
-			aggregate: storageflux.MaxKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:05Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
-							static.Times("_time", "2019-11-25T00:00:05Z"),
-							static.Floats("_value", 2),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
-							static.Times("_time", "2019-11-25T00:00:15Z"),
-							static.Floats("_value", 4),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
-							static.Times("_time", "2019-11-25T00:00:20Z"),
-							static.Floats("_value", 1),
-						},
-					},
-				},
-			},
-		},
-	} {
-		t.Run(string(tt.aggregate), func(t *testing.T) {
-			mem := &memory.Allocator{}
-			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
-				ReadFilterSpec: query.ReadFilterSpec{
-					OrganizationID: reader.Org,
-					BucketID:       reader.Bucket,
-					Bounds: execute.Bounds{
-						Start: values.ConvertTime(mustParseTime("2019-11-25T00:00:05Z")),
-						Stop:  values.ConvertTime(mustParseTime("2019-11-25T00:00:25Z")),
-					},
-				},
-				WindowEvery: int64(10 * time.Second),
-				Aggregates: []plan.ProcedureKind{
-					tt.aggregate,
-				},
-			}, mem)
+	readTables := func(r query.StorageReader, unsorted bool) ([]*executetest.Table, error) {
+		mem := &memory.Allocator{}
+		ti, err := r.ReadFilter(context.Background(), query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+			Unsorted:       unsorted,
+		}, mem)
+		if err != nil {
+			return nil, err
+		}
+
+		var tables []*executetest.Table
+		if err := ti.Do(func(table flux.Table) error {
+			t, err := executetest.ConvertTable(table)
 			if err != nil {
-				t.Fatal(err)
+				return err
 			}
+			tables = append(tables, t)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		executetest.NormalizeTables(tables)
+		if unsorted {
+			sort.Sort(executetest.SortedTables(tables))
+		}
+		return tables, nil
+	}
 
-			if diff := table.Diff(tt.want, got); diff != "" {
-				t.Errorf("unexpected results -want/+got:\n%s", diff)
-			}
+	want, err := readTables(reader.StorageReader, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := len(want), 100; got != exp {
+		t.Fatalf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", exp, got)
+	}
+
+	for _, unsorted := range []bool{false, true} {
+		for _, n := range []int{1, 2, 4, 8} {
+			t.Run(fmt.Sprintf("unsorted=%t/parallelism=%d", unsorted, n), func(t *testing.T) {
+				store := readservice.NewStore(reader.Viewer, readservice.WithMaxOpenCursors(2))
+				r := storageflux.NewReader(store, storageflux.WithReadParallelism(n))
+
+				// Concurrent reads each have their own limit of open cursors.
+				const concurrency = 4
+				var (
+					wg   sync.WaitGroup
+					got  [concurrency][]*executetest.Table
+					errs [concurrency]error
+				)
+				for i := 0; i < concurrency; i++ {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						got[i], errs[i] = readTables(r, unsorted)
+					}(i)
+				}
+				wg.Wait()
+
+				for i := 0; i < concurrency; i++ {
+					if errs[i] != nil {
+						t.Fatal(errs[i])
+					}
+					if diff := cmp.Diff(want, got[i]); diff != "" {
+						t.Errorf("unexpected results -want/+got:\n%s", diff)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestStorageReader_ReadFilter_MaxOpenCursorsPerRead(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 2),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	store := readservice.NewStore(reader.Viewer, readservice.WithMaxOpenCursors(1))
+	any, err := types.MarshalAny(store.GetSource(uint64(reader.Org), uint64(reader.Bucket)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req datatypes.ReadFilterRequest
+	req.ReadSource = any
+	req.Range.Start = int64(reader.Bounds.Start)
+	req.Range.End = int64(reader.Bounds.Stop)
+
+	// openCursor opens the cursor of the first series of a new read.
+	openCursor := func(ctx context.Context) (reads.ResultSet, cursors.Cursor) {
+		t.Helper()
+		rs, err := store.ReadFilter(ctx, &req)
+		if err != nil {
+			t.Fatal(err)
+		} else if rs == nil || !rs.Next() {
+			t.Fatal("expected a series")
+		}
+		return rs, rs.Cursor()
+	}
+
+	rs1, cur1 := openCursor(context.Background())
+	defer rs1.Close()
+	if cur1 == nil {
+		t.Fatal("expected a cursor for the first read")
+	}
+	defer cur1.Close()
+
+	// The cursor held by the first read does not block the second.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	rs2, cur2 := openCursor(ctx)
+	defer rs2.Close()
+	if cur2 == nil {
+		t.Fatal("expected a cursor for the second read")
+	}
+	cur2.Close()
+}
+
+func TestStorageReader_ReadFilter_MaxRows(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       reader.Bucket,
+		Bounds:         reader.Bounds,
+		MaxRows:        5,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each series has 3 rows so the second table exceeds the limit.
+	var rows int
+	err = ti.Do(func(table flux.Table) error {
+		return table.Do(func(cr flux.ColReader) error {
+			rows += cr.Len()
+			return nil
 		})
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if want := "read exceeded the maximum of 5 rows"; !strings.Contains(err.Error(), want) {
+		t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, err)
+	}
+	if want := 3; rows != want {
+		t.Errorf("unexpected number of rows -want/+got:\n\t- %d\n\t+ %d", want, rows)
 	}
 }
 
-func TestStorageReader_ReadWindowAggregate_TruncatedBoundsCreateEmpty(t *testing.T) {
+func TestStorageReader_ReadFilter_MaxTables(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
 		spec := Spec(org, bucket,
 			MeasurementSpec("m0",
-				FloatArrayValuesSequence("f0", 15*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
 				TagValuesSequence("t0", "a-%s", 0, 3),
 			),
 		)
-		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
 		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
 	})
 	defer reader.Close()
 
-	for _, tt := range []struct {
-		aggregate plan.ProcedureKind
+	sr := storageflux.NewReader(reader.Store, storageflux.WithMaxTables(2))
+	mem := &memory.Allocator{}
+	ti, err := sr.ReadFilter(context.Background(), query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       reader.Bucket,
+		Bounds:         reader.Bounds,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// There are 3 series so the third table exceeds the limit.
+	var tables int
+	err = ti.Do(func(table flux.Table) error {
+		tables++
+		table.Done()
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if want := "read exceeded the maximum of 2 tables"; !strings.Contains(err.Error(), want) {
+		t.Errorf("unexpected error -want/+got:\n\t- %s\n\t+ %s", want, err)
+	}
+	if want := 2; tables != want {
+		t.Errorf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", want, tables)
+	}
+}
+
+func TestStorageReader_ReadFilter_ChunkDuration(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	// readRows returns the number of rows read for each group key.
+	readRows := func(t *testing.T, chunk time.Duration) map[string]int {
+		t.Helper()
+
+		mem := &memory.Allocator{}
+		ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+			ChunkDuration:  chunk,
+		}, mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows := make(map[string]int)
+		if err := ti.Do(func(table flux.Table) error {
+			key := table.Key().String()
+			return table.Do(func(cr flux.ColReader) error {
+				rows[key] += cr.Len()
+				return nil
+			})
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return rows
+	}
+
+	want := readRows(t, 0)
+	if got, exp := len(want), 3; got != exp {
+		t.Fatalf("unexpected number of group keys -want/+got:\n\t- %d\n\t+ %d", exp, got)
+	}
+
+	for _, chunk := range []time.Duration{
+		5 * time.Second,
+		30 * time.Second,
+		45 * time.Second,
+		time.Hour,
+	} {
+		t.Run(chunk.String(), func(t *testing.T) {
+			if diff := cmp.Diff(want, readRows(t, chunk)); diff != "" {
+				t.Errorf("unexpected rows per group key -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadFilter_SeriesKeyColumn(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				// The tag values contain characters that must be escaped.
+				TagValuesSequence("t0", "a %s,b=c", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+		OrganizationID:  reader.Org,
+		BucketID:        reader.Bucket,
+		Bounds:          reader.Bounds,
+		SeriesKeyColumn: true,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := ti.Do(func(table flux.Table) error {
+		n++
+
+		// The tags of the series are the string columns of the group key.
+		var name string
+		var tags models.Tags
+		for j, c := range table.Key().Cols() {
+			if c.Type != flux.TString {
+				continue
+			}
+			if c.Label == "_measurement" {
+				name = table.Key().ValueString(j)
+				continue
+			}
+			tags = append(tags, models.NewTag([]byte(c.Label), []byte(table.Key().ValueString(j))))
+		}
+		sort.Sort(tags)
+
+		idx := execute.ColIdx("_series", table.Cols())
+		if idx < 0 {
+			return fmt.Errorf("missing _series column in table %v", table.Key())
+		}
+		return table.Do(func(cr flux.ColReader) error {
+			vs := cr.Strings(idx)
+			for i := 0; i < vs.Len(); i++ {
+				gotName, gotTags := models.ParseKey([]byte(vs.ValueString(i)))
+				if gotName != name {
+					t.Errorf("unexpected measurement in %q -want/+got:\n\t- %s\n\t+ %s", vs.ValueString(i), name, gotName)
+				}
+				if diff := cmp.Diff(tags, gotTags); diff != "" {
+					t.Errorf("unexpected tags in %q -want/+got:\n%s", vs.ValueString(i), diff)
+				}
+			}
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := 3; n != want {
+		t.Errorf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", want, n)
+	}
+}
+
+func TestStorageReader_ReadFilter_IncludeMissingFields(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 2),
+			),
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f1", 10*time.Second, []int64{1, 2, 3}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+		OrganizationID:       reader.Org,
+		BucketID:             reader.Bucket,
+		Bounds:               reader.Bounds,
+		IncludeMissingFields: true,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]*executetest.Table)
+	if err := ti.Do(func(table flux.Table) error {
+		tbl, err := executetest.ConvertTable(table)
+		if err != nil {
+			return err
+		}
+		key := tbl.Key()
+		got[key.LabelValue("t0").Str()+","+key.LabelValue("_field").Str()] = tbl
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := 4; len(got) != want {
+		t.Fatalf("unexpected number of tables: got %d want %d", len(got), want)
+	}
+	for _, key := range []string{"a-0,f0", "a-1,f0", "a-0,f1"} {
+		if tbl, ok := got[key]; !ok {
+			t.Errorf("missing table %s", key)
+		} else if len(tbl.Data) != 3 {
+			t.Errorf("unexpected number of rows in table %s: got %d want 3", key, len(tbl.Data))
+		}
+	}
+
+	// The series a-1 lacks f1, which has the type of f1 in a-0.
+	tbl, ok := got["a-1,f1"]
+	if !ok {
+		t.Fatal("missing table a-1,f1")
+	}
+	valueIdx := execute.ColIdx("_value", tbl.Cols())
+	if valueIdx < 0 || tbl.Cols()[valueIdx].Type != flux.TInt {
+		t.Errorf("unexpected columns of null table: %v", tbl.Cols())
+	}
+	timeIdx := execute.ColIdx("_time", tbl.Cols())
+	if len(tbl.Data) != 1 || timeIdx < 0 || valueIdx < 0 {
+		t.Fatalf("unexpected null table: %v", tbl.Data)
+	}
+	if row := tbl.Data[0]; row[timeIdx] != nil || row[valueIdx] != nil {
+		t.Errorf("expected null _time and _value, got %v and %v", row[timeIdx], row[valueIdx])
+	}
+}
+
+func TestStorageReader_ReadFilter_DownsamplePoints(t *testing.T) {
+	vs := make([]float64, 100)
+	for i := range vs {
+		vs[i] = math.Sin(float64(i) / 10)
+	}
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, vs),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:16:40Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+		OrganizationID:   reader.Org,
+		BucketID:         reader.Bucket,
+		Bounds:           reader.Bounds,
+		DownsamplePoints: 10,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tables []*executetest.Table
+	if err := ti.Do(func(table flux.Table) error {
+		tbl, err := executetest.ConvertTable(table)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, tbl)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tables) != 1 {
+		t.Fatalf("unexpected number of tables: got %d want 1", len(tables))
+	}
+	tbl := tables[0]
+	if got, want := len(tbl.Data), 10; got != want {
+		t.Fatalf("unexpected number of rows: got %d want %d", got, want)
+	}
+
+	// The first and last points are kept.
+	timeIdx := execute.ColIdx("_time", tbl.Cols())
+	valueIdx := execute.ColIdx("_value", tbl.Cols())
+	start := mustParseTime("2019-11-25T00:00:00Z")
+	for _, p := range []struct {
+		row   int
+		time  time.Time
+		value float64
+	}{
+		{row: 0, time: start, value: vs[0]},
+		{row: 9, time: start.Add(990 * time.Second), value: vs[99]},
+	} {
+		row := tbl.Data[p.row]
+		if got := row[timeIdx].(values.Time).Time(); !got.Equal(p.time) {
+			t.Errorf("unexpected time of row %d: got %s want %s", p.row, got, p.time)
+		}
+		if got := row[valueIdx].(float64); got != p.value {
+			t.Errorf("unexpected value of row %d: got %v want %v", p.row, got, p.value)
+		}
+	}
+}
+
+func TestStorageReader_ReadFilter_Watermark(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0, 5.0, 6.0}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	read := func(watermark string) query.TableIterator {
+		mem := &memory.Allocator{}
+		ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+			Watermark:      values.ConvertTime(mustParseTime(watermark)),
+		}, mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ti
+	}
+
+	// The points before the watermark arrived late and are dropped.
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("_field", "f0"),
+		static.StringKey("t0", "a-0"),
+		static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+		static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+		static.Table{
+			static.Times("_time", "2019-11-25T00:00:30Z", 10, 20),
+			static.Floats("_value", 4, 5, 6),
+		},
+	}
+	if diff := table.Diff(want, read("2019-11-25T00:00:25Z")); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+
+	// A watermark after the bounds drops every point.
+	var n int
+	if err := read("2019-11-25T00:01:00Z").Do(func(table flux.Table) error {
+		n++
+		table.Done()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", 0, n)
+	}
+}
+
+func TestStorageReader_ReadFilter_FieldsAsTag(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f1", 10*time.Second, []float64{4.0, 5.0, 6.0}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       reader.Bucket,
+		Bounds:         reader.Bounds,
+		FieldsAsTag:    true,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("t0", "a-0"),
+		static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+		static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+		static.Table{
+			static.Times("_time", "2019-11-25T00:00:00Z", 0, 10, 10, 20, 20),
+			static.Floats("_value", 1, 4, 2, 5, 3, 6),
+			static.Strings("field", "f0", "f1", "f0", "f1", "f0", "f1"),
+		},
+	}
+	if diff := table.Diff(want, ti); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_ReadFilter_ProgressFn(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	var calls, total int64
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       reader.Bucket,
+		Bounds:         reader.Bounds,
+		ProgressFn: func(pointsRead int64) {
+			calls++
+			total += pointsRead
+		},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows int64
+	if err := ti.Do(func(tbl flux.Table) error {
+		return tbl.Do(func(cr flux.ColReader) error {
+			rows += int64(cr.Len())
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 3 series with a point every 10s for 2m.
+	if got, want := total, int64(3*12); got != want {
+		t.Errorf("unexpected number of points reported -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if got, want := rows, total; got != want {
+		t.Errorf("unexpected number of rows read -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	if calls == 0 {
+		t.Error("expected progress to be reported")
+	}
+}
+
+func TestStorageReader_ReadFilter_SeriesKeys(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       reader.Bucket,
+		Bounds:         reader.Bounds,
+		SeriesKeys: [][]byte{
+			[]byte("m0,t0=a-0,_field=f0"),
+			[]byte("m0,t0=a-2,_field=f0"),
+			[]byte("m0,t0=a-9,_field=f0"),
+			[]byte("m0,t0=a-1"),
+		},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	makeTable := func(t0 string) *executetest.Table {
+		start, stop := reader.Bounds.Start, reader.Bounds.Stop
+		return &executetest.Table{
+			KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
+				{Label: "_field", Type: flux.TString},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "t0", Type: flux.TString},
+			},
+			Data: [][]interface{}{
+				{start, stop, Time("2019-11-25T00:00:00Z"), 1.0, "f0", "m0", t0},
+				{start, stop, Time("2019-11-25T00:00:10Z"), 2.0, "f0", "m0", t0},
+				{start, stop, Time("2019-11-25T00:00:20Z"), 3.0, "f0", "m0", t0},
+			},
+		}
+	}
+
+	want := []*executetest.Table{
+		makeTable("a-0"),
+		makeTable("a-2"),
+	}
+	executetest.NormalizeTables(want)
+	sort.Sort(executetest.SortedTables(want))
+
+	var got []*executetest.Table
+	if err := ti.Do(func(table flux.Table) error {
+		t, err := executetest.ConvertTable(table)
+		if err != nil {
+			return err
+		}
+		got = append(got, t)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	executetest.NormalizeTables(got)
+	sort.Sort(executetest.SortedTables(got))
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_Prefetch(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 2),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	spec := query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       reader.Bucket,
+		Bounds:         reader.Bounds,
+	}
+	if err := reader.StorageReader.(storageflux.PrefetchReader).Prefetch(context.Background(), spec); err != nil {
+		t.Fatal(err)
+	}
+
+	// A prefetch does not change the results of a read.
+	mem := &memory.Allocator{}
+	got, err := reader.ReadFilter(context.Background(), spec, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("_field", "f0"),
+		static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+		static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+		static.TableMatrix{
+			static.StringKeys("t0", "a-0", "a-1"),
+			{
+				static.Table{
+					static.Times("_time", "2019-11-25T00:00:00Z", 10, 20),
+					static.Floats("_value", 1, 2, 3),
+				},
+			},
+		},
+	}
+	if diff := table.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+	if stats := got.Statistics(); stats.ScannedValues != 6 {
+		t.Errorf("unexpected scanned values: got %d want 6", stats.ScannedValues)
+	}
+
+	// Only the blocks of the series matching the predicate are paged in.
+	prefetch := func(predicate *datatypes.Predicate) int64 {
+		t.Helper()
+		any, err := types.MarshalAny(reader.Store.GetSource(uint64(reader.Org), uint64(reader.Bucket)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var req datatypes.ReadFilterRequest
+		req.ReadSource = any
+		req.Predicate = predicate
+		req.Range.Start = int64(reader.Bounds.Start)
+		req.Range.End = int64(reader.Bounds.Stop)
+		n, err := reader.Store.(reads.PrefetchStore).Prefetch(context.Background(), &req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	all := prefetch(nil)
+	one := prefetch(&datatypes.Predicate{
+		Root: &datatypes.Node{
+			NodeType: datatypes.NodeTypeComparisonExpression,
+			Value:    &datatypes.Node_Comparison_{Comparison: datatypes.ComparisonEqual},
+			Children: []*datatypes.Node{
+				{
+					NodeType: datatypes.NodeTypeTagRef,
+					Value:    &datatypes.Node_TagRefValue{TagRefValue: "t0"},
+				},
+				{
+					NodeType: datatypes.NodeTypeLiteral,
+					Value:    &datatypes.Node_StringValue{StringValue: "a-0"},
+				},
+			},
+		},
+	})
+	if one <= 0 || one >= all {
+		t.Errorf("unexpected bytes paged in: got %d for one series and %d for all series", one, all)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := reader.StorageReader.(storageflux.PrefetchReader).Prefetch(ctx, spec); err == nil {
+		t.Error("expected an error from a canceled prefetch")
+	}
+}
+
+func TestStorageReader_ReadHistogram(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0, 5.0, 6.0}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.StorageReader.(storageflux.HistogramReader).ReadHistogram(context.Background(), query.ReadHistogramSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowEvery: int64(30 * time.Second),
+	}, []float64{2, 4, 10}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	makeTable := func(start, stop values.Time, counts ...float64) *executetest.Table {
+		tbl := &executetest.Table{
+			KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_field", Type: flux.TString},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "t0", Type: flux.TString},
+				{Label: "le", Type: flux.TFloat},
+				{Label: "_value", Type: flux.TFloat},
+			},
+		}
+		for i, le := range []float64{2, 4, 10} {
+			tbl.Data = append(tbl.Data, []interface{}{start, stop, "f0", "m0", "a-0", le, counts[i]})
+		}
+		return tbl
+	}
+	want := []*executetest.Table{
+		makeTable(Time("2019-11-25T00:00:00Z"), Time("2019-11-25T00:00:30Z"), 2, 3, 3),
+		makeTable(Time("2019-11-25T00:00:30Z"), Time("2019-11-25T00:01:00Z"), 0, 1, 3),
+	}
+	executetest.NormalizeTables(want)
+	sort.Sort(executetest.SortedTables(want))
+
+	var got []*executetest.Table
+	if err := ti.Do(func(table flux.Table) error {
+		t, err := executetest.ConvertTable(table)
+		if err != nil {
+			return err
+		}
+		got = append(got, t)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	executetest.NormalizeTables(got)
+	sort.Sort(executetest.SortedTables(got))
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_ReadFilterArrow(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+			MeasurementSpec("m1",
+				StringArrayValuesSequence("f1", time.Minute, []string{"a", "b", "c"}),
+				TagValuesSequence("t1", "b-%s", 0, 2),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:10:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	spec := query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       reader.Bucket,
+		Bounds:         reader.Bounds,
+	}
+
+	var buf bytes.Buffer
+	if err := reader.StorageReader.(storageflux.ArrowReader).ReadFilterArrow(context.Background(), spec, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read each of the streams back as a table.
+	fluxTypes := map[string]flux.ColType{
+		flux.TInt.String():    flux.TInt,
+		flux.TUInt.String():   flux.TUInt,
+		flux.TFloat.String():  flux.TFloat,
+		flux.TString.String(): flux.TString,
+		flux.TBool.String():   flux.TBool,
+		flux.TTime.String():   flux.TTime,
+	}
+	var got []*executetest.Table
+	for buf.Len() > 0 {
+		r, err := ipc.NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tbl := &executetest.Table{}
+		for _, f := range r.Schema().Fields() {
+			md := f.Metadata
+			typ := fluxTypes[md.Values()[md.FindKey(storageflux.ArrowFluxTypeKey)]]
+			tbl.ColMeta = append(tbl.ColMeta, flux.ColMeta{Label: f.Name, Type: typ})
+			if md.Values()[md.FindKey(storageflux.ArrowGroupKeyKey)] == "true" {
+				tbl.KeyCols = append(tbl.KeyCols, f.Name)
+			}
+		}
+		for r.Next() {
+			rec := r.Record()
+			for i := 0; i < int(rec.NumRows()); i++ {
+				row := make([]interface{}, rec.NumCols())
+				for j, col := range rec.Columns() {
+					if col.IsNull(i) {
+						continue
+					}
+					switch col := col.(type) {
+					case *array.Int64:
+						if tbl.ColMeta[j].Type == flux.TTime {
+							row[j] = values.Time(col.Value(i))
+						} else {
+							row[j] = col.Value(i)
+						}
+					case *array.Uint64:
+						row[j] = col.Value(i)
+					case *array.Float64:
+						row[j] = col.Value(i)
+					case *array.Boolean:
+						row[j] = col.Value(i)
+					case *array.String:
+						row[j] = col.Value(i)
+					default:
+						t.Fatalf("unexpected array type %T", col)
+					}
+				}
+				tbl.Data = append(tbl.Data, row)
+			}
+		}
+		if err := r.Err(); err != nil {
+			t.Fatal(err)
+		}
+		r.Release()
+		got = append(got, tbl)
+	}
+
+	ti, err := reader.ReadFilter(context.Background(), spec, &memory.Allocator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []*executetest.Table
+	if err := ti.Do(func(table flux.Table) error {
+		t, err := executetest.ConvertTable(table)
+		if err != nil {
+			return err
+		}
+		want = append(want, t)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// encode returns the tables encoded as CSV.
+	encode := func(tables []*executetest.Table) string {
+		t.Helper()
+		executetest.NormalizeTables(tables)
+		sort.Sort(executetest.SortedTables(tables))
+
+		var buf bytes.Buffer
+		enc := csv.NewResultEncoder(csv.DefaultEncoderConfig())
+		if _, err := enc.Encode(&buf, executetest.NewResult(tables)); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	if len(want) == 0 {
+		t.Fatal("expected the bucket to have data")
+	}
+	if diff := cmp.Diff(encode(want), encode(got)); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_ReadBlockStats(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 2),
+			),
+		)
+		// 2500 points for each series are written as
+		// blocks of 1000, 1000 and 500 points.
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:41:40Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.StorageReader.(storageflux.BlockStatsReader).ReadBlockStats(context.Background(), query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       reader.Bucket,
+		Bounds:         reader.Bounds,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := static.TableGroup{
+		static.Table{
+			static.Strings("series",
+				"m0,_field=f0,t0=a-0", "m0,_field=f0,t0=a-0", "m0,_field=f0,t0=a-0",
+				"m0,_field=f0,t0=a-1", "m0,_field=f0,t0=a-1", "m0,_field=f0,t0=a-1",
+			),
+			static.Times("min_time", "2019-11-25T00:00:00Z", 1000, 2000, 0, 1000, 2000),
+			static.Times("max_time", "2019-11-25T00:16:39Z", 1000, 1500, 0, 1000, 1500),
+			static.Ints("count", 1000, 1000, 500, 1000, 1000, 500),
+			static.Strings("type", "float", "float", "float", "float", "float", "float"),
+		},
+	}
+	if diff := table.Diff(want, ti); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_ReadFieldKeys(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+			MeasurementSpec("m1",
+				IntegerArrayValuesSequence("f1", 10*time.Second, []int64{1, 2, 3}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.StorageReader.(storageflux.FieldKeysReader).ReadFieldKeys(context.Background(), query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       reader.Bucket,
+		Bounds:         reader.Bounds,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := static.TableGroup{
+		static.Table{
+			static.Strings("_fieldKey", "f0", "f1"),
+			static.Strings("_fieldType", "float", "integer"),
+		},
+	}
+	if diff := table.Diff(want, ti); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_ReadMeasurements(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+			MeasurementSpec("m1",
+				IntegerArrayValuesSequence("f1", 10*time.Second, []int64{1, 2, 3}),
+				TagValuesSequence("t1", "b-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tt := range []struct {
+		name      string
+		predicate *datatypes.Predicate
+		want      static.Table
+	}{
+		{
+			name: "All",
+			want: static.Table{
+				static.Strings("_measurement", "m0", "m1"),
+			},
+		},
+		{
+			// t1 == "b-1"
+			name: "Predicate",
+			predicate: &datatypes.Predicate{
+				Root: &datatypes.Node{
+					NodeType: datatypes.NodeTypeComparisonExpression,
+					Value:    &datatypes.Node_Comparison_{Comparison: datatypes.ComparisonEqual},
+					Children: []*datatypes.Node{
+						{
+							NodeType: datatypes.NodeTypeTagRef,
+							Value:    &datatypes.Node_TagRefValue{TagRefValue: "t1"},
+						},
+						{
+							NodeType: datatypes.NodeTypeLiteral,
+							Value:    &datatypes.Node_StringValue{StringValue: "b-1"},
+						},
+					},
+				},
+			},
+			want: static.Table{
+				static.Strings("_measurement", "m1"),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := &memory.Allocator{}
+			ti, err := reader.StorageReader.(storageflux.MeasurementsReader).ReadMeasurements(context.Background(), query.ReadFilterSpec{
+				OrganizationID: reader.Org,
+				BucketID:       reader.Bucket,
+				Bounds:         reader.Bounds,
+				Predicate:      tt.predicate,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := static.TableGroup{tt.want}
+			if diff := table.Diff(want, ti); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_Table(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tc := range []struct {
+		name  string
+		newFn func(ctx context.Context, alloc *memory.Allocator) flux.TableIterator
+	}{
+		{
+			name: "ReadFilter",
+			newFn: func(ctx context.Context, alloc *memory.Allocator) flux.TableIterator {
+				ti, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				}, alloc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return ti
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			executetest.RunTableTests(t, executetest.TableTest{
+				NewFn: tc.newFn,
+				IsDone: func(table flux.Table) bool {
+					return table.(interface {
+						IsDone() bool
+					}).IsDone()
+				},
+			})
+		})
+	}
+}
+
+func TestStorageReader_ReadGroup_Predicate(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+				TagValuesSequence("t1", "b-%s", 0, 2),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	// t0 =~ /a-[01]/
+	predicate := &datatypes.Predicate{
+		Root: &datatypes.Node{
+			NodeType: datatypes.NodeTypeComparisonExpression,
+			Value:    &datatypes.Node_Comparison_{Comparison: datatypes.ComparisonRegex},
+			Children: []*datatypes.Node{
+				{
+					NodeType: datatypes.NodeTypeTagRef,
+					Value:    &datatypes.Node_TagRefValue{TagRefValue: "t0"},
+				},
+				{
+					NodeType: datatypes.NodeTypeLiteral,
+					Value:    &datatypes.Node_RegexValue{RegexValue: "a-[01]"},
+				},
+			},
+		},
+	}
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadGroup(context.Background(), query.ReadGroupSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+			Predicate:      predicate,
+		},
+		GroupMode: query.GroupModeBy,
+		GroupKeys: []string{"t0"},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	if err := ti.Do(func(table flux.Table) error {
+		defer table.Done()
+		got = append(got, table.Key().LabelValue("t0").Str())
+		return table.Do(func(cr flux.ColReader) error {
+			j := execute.ColIdx("t0", cr.Cols())
+			for i := 0; i < cr.Len(); i++ {
+				if v := string(cr.Strings(j).Value(i)); v == "a-2" {
+					t.Errorf("unexpected series with t0=%s in group %v", v, table.Key())
+				}
+			}
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+
+	if want := []string{"a-0", "a-1"}; !cmp.Equal(want, got) {
+		t.Fatalf("unexpected groups -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestStorageReader_ReadGroup_CreateEmpty(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+				TagValuesSequence("t1", "b-%s", 0, 2),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	// The bounds exclude all of the data.
+	bounds := execute.Bounds{
+		Start: Time("2019-11-24T00:00:00Z"),
+		Stop:  Time("2019-11-24T01:00:00Z"),
+	}
+
+	for _, tt := range []struct {
+		createEmpty bool
+		want        []string
+	}{
+		{createEmpty: false},
+		{createEmpty: true, want: []string{"a-0", "a-1", "a-2"}},
+	} {
+		t.Run(fmt.Sprintf("createEmpty=%v", tt.createEmpty), func(t *testing.T) {
+			mem := &memory.Allocator{}
+			ti, err := reader.ReadGroup(context.Background(), query.ReadGroupSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         bounds,
+				},
+				GroupMode:   query.GroupModeBy,
+				GroupKeys:   []string{"t0"},
+				CreateEmpty: tt.createEmpty,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			if err := ti.Do(func(table flux.Table) error {
+				defer table.Done()
+				got = append(got, table.Key().LabelValue("t0").Str())
+				if start := table.Key().LabelValue(execute.DefaultStartColLabel).Time(); start != bounds.Start {
+					t.Errorf("got start %v, expected %v", start, bounds.Start)
+				}
+				return table.Do(func(cr flux.ColReader) error {
+					if cr.Len() > 0 {
+						t.Errorf("unexpected rows in group %v", table.Key())
+					}
+					return nil
+				})
+			}); err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+
+			if !cmp.Equal(tt.want, got) {
+				t.Fatalf("unexpected groups -want/+got:\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadGroup_IncludeTimeSpan(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+				TagValuesSequence("t1", "b-%s", 0, 2),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	// The time span is that of the points read rather than the bounds.
+	bounds := execute.Bounds{
+		Start: Time("2019-11-25T00:00:25Z"),
+		Stop:  Time("2019-11-25T00:01:25Z"),
+	}
+	wantStart, wantStop := Time("2019-11-25T00:00:30Z"), Time("2019-11-25T00:01:20Z")
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadGroup(context.Background(), query.ReadGroupSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         bounds,
+		},
+		GroupMode:       query.GroupModeBy,
+		GroupKeys:       []string{"t0"},
+		IncludeTimeSpan: true,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var groups, rows int
+	if err := ti.Do(func(table flux.Table) error {
+		groups++
+		return table.Do(func(cr flux.ColReader) error {
+			startIdx := execute.ColIdx("_start_time", cr.Cols())
+			stopIdx := execute.ColIdx("_stop_time", cr.Cols())
+			if startIdx < 0 || stopIdx < 0 {
+				t.Fatalf("missing time span columns in group %v", table.Key())
+			}
+			for i := 0; i < cr.Len(); i++ {
+				rows++
+				if got := values.Time(cr.Times(startIdx).Value(i)); got != wantStart {
+					t.Errorf("unexpected _start_time in group %v -want/+got:\n\t- %v\n\t+ %v", table.Key(), wantStart, got)
+				}
+				if got := values.Time(cr.Times(stopIdx).Value(i)); got != wantStop {
+					t.Errorf("unexpected _stop_time in group %v -want/+got:\n\t- %v\n\t+ %v", table.Key(), wantStop, got)
+				}
+			}
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := groups, 3; got != want {
+		t.Errorf("unexpected number of groups -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+	// 2 series per group with 6 points in the bounds.
+	if got, want := rows, 3*2*6; got != want {
+		t.Errorf("unexpected number of rows -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestStorageReader_ReadGroup_KeepColumns(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		// The last point of the group belongs to the series with
+		// t0=a-0, which is read before the series with t0=b-0.
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 7*time.Second, []float64{1.0}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{2.0}),
+				TagValuesSequence("t0", "b-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadGroup(context.Background(), query.ReadGroupSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		GroupMode:       query.GroupModeBy,
+		GroupKeys:       []string{"_measurement"},
+		AggregateMethod: storageflux.LastKind,
+		KeepColumns:     []string{"t0"},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows int
+	if err := ti.Do(func(table flux.Table) error {
+		return table.Do(func(cr flux.ColReader) error {
+			timeIdx := execute.ColIdx(execute.DefaultTimeColLabel, cr.Cols())
+			valueIdx := execute.ColIdx(execute.DefaultValueColLabel, cr.Cols())
+			tagIdx := execute.ColIdx("t0", cr.Cols())
+			if timeIdx < 0 || valueIdx < 0 || tagIdx < 0 {
+				t.Fatalf("missing columns in group %v", table.Key())
+			}
+			for i := 0; i < cr.Len(); i++ {
+				rows++
+				if got, want := values.Time(cr.Times(timeIdx).Value(i)), Time("2019-11-25T00:01:59Z"); got != want {
+					t.Errorf("unexpected _time -want/+got:\n\t- %v\n\t+ %v", want, got)
+				}
+				if got, want := cr.Floats(valueIdx).Value(i), 1.0; got != want {
+					t.Errorf("unexpected _value -want/+got:\n\t- %v\n\t+ %v", want, got)
+				}
+				if got, want := cr.Strings(tagIdx).ValueString(i), "a-0"; got != want {
+					t.Errorf("unexpected t0 -want/+got:\n\t- %v\n\t+ %v", want, got)
+				}
+			}
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := rows, 1; got != want {
+		t.Errorf("unexpected number of rows -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestStorageReader_ReadGroup_CountDistinct(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 10*time.Second, []int64{1, 2, 2, 3}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 10*time.Second, []int64{3, 4}),
+				TagValuesSequence("t0", "b-%s", 0, 1),
+			),
+			MeasurementSpec("m1",
+				IntegerArrayValuesSequence("f0", 10*time.Second, []int64{5}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, approx := range []bool{false, true} {
+		t.Run(fmt.Sprintf("approximate=%t", approx), func(t *testing.T) {
+			mem := &memory.Allocator{}
+			got, err := reader.ReadGroup(context.Background(), query.ReadGroupSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				GroupMode:                query.GroupModeBy,
+				GroupKeys:                []string{"_measurement"},
+				AggregateMethod:          storageflux.CountDistinctKind,
+				ApproximateCountDistinct: approx,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The values of both series of m0 are counted once.
+			want := static.TableGroup{
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				static.Table{
+					static.StringKey("_measurement", "m0"),
+					static.Ints("_value", 4),
+				},
+				static.Table{
+					static.StringKey("_measurement", "m1"),
+					static.Ints("_value", 1),
+				},
+			}
+			if diff := table.Diff(want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tt := range []struct {
+		aggregate plan.ProcedureKind
+		want      flux.TableIterator
+	}{
+		{
+			aggregate: storageflux.CountKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+							static.Ints("_value", 3),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
+							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+							static.Ints("_value", 3),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:01:00Z"),
+							static.TimeKey("_stop", "2019-11-25T00:01:30Z"),
+							static.Ints("_value", 3),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:01:30Z"),
+							static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+							static.Ints("_value", 3),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MinKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+							static.Times("_time", "2019-11-25T00:00:00Z"),
+							static.Floats("_value", 1),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
+							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+							static.Times("_time", "2019-11-25T00:00:40Z"),
+							static.Floats("_value", 1),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:01:00Z"),
+							static.TimeKey("_stop", "2019-11-25T00:01:30Z"),
+							static.Times("_time", "2019-11-25T00:01:20Z"),
+							static.Floats("_value", 1),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:01:30Z"),
+							static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+							static.Times("_time", "2019-11-25T00:01:30Z"),
+							static.Floats("_value", 2),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MaxKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+							static.Times("_time", "2019-11-25T00:00:20Z"),
+							static.Floats("_value", 3),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
+							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+							static.Times("_time", "2019-11-25T00:00:30Z"),
+							static.Floats("_value", 4),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:01:00Z"),
+							static.TimeKey("_stop", "2019-11-25T00:01:30Z"),
+							static.Times("_time", "2019-11-25T00:01:10Z"),
+							static.Floats("_value", 4),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:01:30Z"),
+							static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+							static.Times("_time", "2019-11-25T00:01:50Z"),
+							static.Floats("_value", 4),
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(string(tt.aggregate), func(t *testing.T) {
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				WindowEvery: int64(30 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					tt.aggregate,
+				},
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := table.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected output -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_ByWindowGroupKey(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowEvery: int64(30 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.CountKind,
+		},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := ti.Do(func(table flux.Table) error {
+		defer table.Done()
+		n++
+
+		key := table.Key()
+		for _, label := range []string{execute.DefaultStartColLabel, execute.DefaultStopColLabel} {
+			if !key.HasCol(label) {
+				t.Errorf("group key is missing the %q column: %v", label, key)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := 12; n != want {
+		t.Fatalf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", want, n)
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_BoundsAsColumns(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowEvery: int64(30 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.CountKind,
+		},
+		BoundsAsColumns: true,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("_field", "f0"),
+		static.TableMatrix{
+			static.StringKeys("t0", "a-0", "a-1", "a-2"),
+			{
+				static.Table{
+					static.Times("_start", "2019-11-25T00:00:00Z", 30, 60, 90),
+					static.Times("_stop", "2019-11-25T00:00:30Z", 30, 60, 90),
+					static.Ints("_value", 3, 3, 3, 3),
+				},
+			},
+		},
+	}
+	if diff := table.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_ValueColumn(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowEvery: int64(30 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.CountKind,
+		},
+		ValueColumn: "total",
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := ti.Do(func(tbl flux.Table) error {
+		defer tbl.Done()
+		n++
+
+		if idx := execute.ColIdx(execute.DefaultValueColLabel, tbl.Cols()); idx >= 0 {
+			t.Errorf("unexpected %s column in table %v", execute.DefaultValueColLabel, tbl.Key())
+		}
+		idx := execute.ColIdx("total", tbl.Cols())
+		if idx < 0 {
+			t.Errorf("missing total column in table %v", tbl.Key())
+		} else if got, want := tbl.Cols()[idx].Type, flux.TInt; got != want {
+			t.Errorf("unexpected type for total column -want/+got:\n\t- %v\n\t+ %v", want, got)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := n, 12; got != want {
+		t.Errorf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", want, got)
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_MeanWithCount(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 10*time.Second, []int64{1, 2, 3, 4, 5}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		TimeColumn:  execute.DefaultStopColLabel,
+		WindowEvery: int64(50 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.MeanKind,
+		},
+		WithCount: true,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("_field", "f0"),
+		static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+		static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+		static.TableMatrix{
+			static.StringKeys("t0", "a-0", "a-1", "a-2"),
+			{
+				static.Table{
+					static.Times("_time", "2019-11-25T00:00:50Z", 50, 70),
+					static.Floats("_value", 3, 3, 1.5),
+					static.Ints("_count", 5, 5, 2),
+				},
+			},
+		},
+	}
+	if diff := table.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+
+	// The count column is only supported with mean.
+	for _, aggregate := range []plan.ProcedureKind{
+		storageflux.SumKind,
+		storageflux.DifferenceKind,
+		storageflux.IntegralKind,
+		storageflux.RateKind,
+		storageflux.ModeKind,
+	} {
+		t.Run(string(aggregate), func(t *testing.T) {
+			ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				WindowEvery: int64(50 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					aggregate,
+				},
+				WithCount: true,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ti.Do(func(tbl flux.Table) error {
+				tbl.Done()
+				return nil
+			})
+			if got, want := influxdb.ErrorCode(err), influxdb.EInvalid; got != want {
+				t.Errorf("unexpected error code -want/+got:\n\t- %q\n\t+ %q (%v)", want, got, err)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_WeightedMean(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1, 2, 3, 4, 5, 6}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("w", 10*time.Second, []int64{1, 1, 2, 3, 0, 1}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		TimeColumn:  execute.DefaultStopColLabel,
+		WindowEvery: int64(30 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.MeanKind,
+		},
+		WeightField: "w",
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// (1*1 + 2*1 + 3*2) / 4 and (4*3 + 5*0 + 6*1) / 4.
+	// The weight field is not aggregated itself.
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("_field", "f0"),
+		static.StringKey("t0", "a-0"),
+		static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+		static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+		static.Table{
+			static.Times("_time", "2019-11-25T00:00:30Z", 30),
+			static.Floats("_value", 2.25, 4.5),
+		},
+	}
+	if diff := table.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+
+	// The weight field is only supported with mean.
+	ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowEvery: int64(30 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.SumKind,
+		},
+		WeightField: "w",
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ti.Do(func(tbl flux.Table) error {
+		tbl.Done()
+		return nil
+	}); err == nil {
+		t.Error("expected error for weight field with sum aggregate")
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_CountTimeLast(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 10*time.Second, []int64{1, 2, 3, 4, 5}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowEvery: int64(50 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.CountKind,
+		},
+		CountTimeColumn: storageflux.CountTimeLast,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("_field", "f0"),
+		static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+		static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+		static.TableMatrix{
+			static.StringKeys("t0", "a-0", "a-1", "a-2"),
+			{
+				static.Table{
+					static.Times("_time", "2019-11-25T00:00:40Z", 50, 70),
+					static.Ints("_value", 5, 5, 2),
+				},
+			},
+		},
+	}
+	if diff := table.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+
+	// The count time column is only supported with count.
+	ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowEvery: int64(50 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.SumKind,
+		},
+		CountTimeColumn: storageflux.CountTimeLast,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ti.Do(func(tbl flux.Table) error {
+		tbl.Done()
+		return nil
+	}); err == nil {
+		t.Error("expected error for count time column with sum aggregate")
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_MovingAverage(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 10*time.Second, []int64{1, 2, 3, 4, 5}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		TimeColumn:  execute.DefaultStopColLabel,
+		WindowEvery: int64(20 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.MeanKind,
+		},
+		MovingAverage: 3,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The means of the windows are 1.5, 3.5, 3, 2.5, 4.5 and 1.5.
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("_field", "f0"),
+		static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+		static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+		static.TableMatrix{
+			static.StringKeys("t0", "a-0", "a-1", "a-2"),
+			{
+				static.Table{
+					static.Times("_time", "2019-11-25T00:01:00Z", 20, 40, 60),
+					static.Floats("_value", 8.0/3, 9.0/3, 10.0/3, 8.5/3),
+				},
+			},
+		},
+	}
+	if diff := table.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+
+	// The moving average is only supported with mean without window bounds.
+	for _, tt := range []struct {
+		name         string
+		aggregate    plan.ProcedureKind
+		windowBounds []execute.Bounds
+	}{
+		{name: "sum", aggregate: storageflux.SumKind},
+		{name: "difference", aggregate: storageflux.DifferenceKind},
+		{name: "integral", aggregate: storageflux.IntegralKind},
+		{name: "rate", aggregate: storageflux.RateKind},
+		{name: "mode", aggregate: storageflux.ModeKind},
+		{
+			name:      "window bounds",
+			aggregate: storageflux.MeanKind,
+			windowBounds: []execute.Bounds{
+				{Start: Time("2019-11-25T00:00:00Z"), Stop: Time("2019-11-25T00:01:00Z")},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				Aggregates: []plan.ProcedureKind{
+					tt.aggregate,
+				},
+				WindowBounds:  tt.windowBounds,
+				MovingAverage: 3,
+			}
+			if tt.windowBounds == nil {
+				spec.WindowEvery = int64(20 * time.Second)
+			}
+			ti, err := reader.ReadWindowAggregate(context.Background(), spec, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ti.Do(func(tbl flux.Table) error {
+				tbl.Done()
+				return nil
+			})
+			if got, want := influxdb.ErrorCode(err), influxdb.EInvalid; got != want {
+				t.Errorf("unexpected error code -want/+got:\n\t- %q\n\t+ %q (%v)", want, got, err)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_SelectorTimeColumn(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tt := range []struct {
+		aggregate plan.ProcedureKind
+		label     string
+		want      flux.TableIterator
+	}{
+		{
+			aggregate: storageflux.MinKind,
+			label:     "_min_time",
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+							static.Floats("_value", 1, 1, 1, 2),
+							static.Times("_min_time", "2019-11-25T00:00:00Z", 40, 80, 90),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MaxKind,
+			label:     "_max_time",
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+							static.Floats("_value", 3, 4, 4, 4),
+							static.Times("_max_time", "2019-11-25T00:00:20Z", 10, 50, 90),
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(string(tt.aggregate), func(t *testing.T) {
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				TimeColumn:  execute.DefaultStopColLabel,
+				WindowEvery: int64(30 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					tt.aggregate,
+				},
+				SelectorTimeColumn: tt.label,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := table.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+
+	// The selector time column is only supported with min and max.
+	ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowEvery: int64(30 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.FirstKind,
+		},
+		SelectorTimeColumn: "_first_time",
+	}, &memory.Allocator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ti.Do(func(tbl flux.Table) error {
+		tbl.Done()
+		return nil
+	}); err == nil {
+		t.Error("expected error for selector time column with first aggregate")
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_ByStopTime(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tt := range []struct {
+		aggregate plan.ProcedureKind
+		want      flux.TableIterator
+	}{
+		{
+			aggregate: storageflux.CountKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+							static.Ints("_value", 3, 3, 3, 3),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MinKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+							static.Floats("_value", 1, 1, 1, 2),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MaxKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+							static.Floats("_value", 3, 4, 4, 4),
+						},
+					},
+				},
+			},
+		},
+	} {
+		mem := &memory.Allocator{}
+		got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+			ReadFilterSpec: query.ReadFilterSpec{
+				OrganizationID: reader.Org,
+				BucketID:       reader.Bucket,
+				Bounds:         reader.Bounds,
+			},
+			TimeColumn:  execute.DefaultStopColLabel,
+			WindowEvery: int64(30 * time.Second),
+			Aggregates: []plan.ProcedureKind{
+				tt.aggregate,
+			},
+		}, mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := table.Diff(tt.want, got); diff != "" {
+			t.Errorf("unexpected results -want/+got:\n%s", diff)
+		}
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_SortedTimeWithOffset(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, timeColumn := range []string{execute.DefaultStopColLabel, execute.DefaultStartColLabel} {
+		for _, aggregate := range []plan.ProcedureKind{storageflux.CountKind, storageflux.SumKind, storageflux.MeanKind} {
+			t.Run(fmt.Sprintf("%s/%s", timeColumn, aggregate), func(t *testing.T) {
+				mem := &memory.Allocator{}
+				ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+					ReadFilterSpec: query.ReadFilterSpec{
+						OrganizationID: reader.Org,
+						BucketID:       reader.Bucket,
+						Bounds:         reader.Bounds,
+					},
+					WindowEvery: int64(30 * time.Second),
+					Offset:      int64(24*time.Hour + 17*time.Second),
+					Aggregates: []plan.ProcedureKind{
+						aggregate,
+					},
+					CreateEmpty: true,
+					TimeColumn:  timeColumn,
+				}, mem)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var n int
+				if err := ti.Do(func(table flux.Table) error {
+					n++
+					idx := execute.ColIdx(execute.DefaultTimeColLabel, table.Cols())
+					if idx < 0 {
+						return fmt.Errorf("missing %q column", execute.DefaultTimeColLabel)
+					}
+
+					prev := int64(math.MinInt64)
+					return table.Do(func(cr flux.ColReader) error {
+						ts := cr.Times(idx)
+						for i := 0; i < ts.Len(); i++ {
+							if ts.Value(i) <= prev {
+								t.Errorf("_time is not sorted in table %v: %s follows %s",
+									table.Key(), values.Time(ts.Value(i)), values.Time(prev))
+							}
+							prev = ts.Value(i)
+						}
+						return nil
+					})
+				}); err != nil {
+					t.Fatal(err)
+				}
+
+				if want := 3; n != want {
+					t.Errorf("unexpected number of tables -want/+got:\n\t- %d\n\t+ %d", want, n)
+				}
+			})
+		}
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_Difference(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		values      []int64
+		rate        bool
+		nonNegative bool
+		want        static.Table
+	}{
+		{
+			name:   "difference",
+			values: []int64{1, 2, 4, 8, 16, 32},
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 30),
+				static.Ints("_value", 3, 24),
+			},
+		},
+		{
+			name:   "rate",
+			values: []int64{1, 2, 4, 8, 16, 32},
+			rate:   true,
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 30),
+				static.Floats("_value", 0.15, 1.2),
+			},
+		},
+		{
+			name:   "counter reset",
+			values: []int64{5, 10, 2, 6, 7, 1},
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 30),
+				static.Ints("_value", -3, -5),
+			},
+		},
+		{
+			name:        "counter reset non negative",
+			values:      []int64{5, 10, 2, 6, 7, 1},
+			nonNegative: true,
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 30),
+				static.Ints("_value", 7, 2),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+				spec := Spec(org, bucket,
+					MeasurementSpec("m0",
+						IntegerArrayValuesSequence("f0", 10*time.Second, tt.values),
+						TagValuesSequence("t0", "a-%s", 0, 1),
+					),
+				)
+				tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+				return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+			})
+			defer reader.Close()
+
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				TimeColumn:  execute.DefaultStopColLabel,
+				WindowEvery: int64(30 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					storageflux.DifferenceKind,
+				},
+				Rate:        tt.rate,
+				NonNegative: tt.nonNegative,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.StringKey("t0", "a-0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+				tt.want,
+			}
+			if diff := table.Diff(want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_Integral(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		// A linear ramp where the value is the number of seconds
+		// since the start of the range.
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 10*time.Second, []int64{0, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 110}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tt := range []struct {
+		name   string
+		offset time.Duration
+		unit   time.Duration
+		want   static.Table
+	}{
+		{
+			name: "seconds",
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+				static.Floats("_value", 450, 1350, 2250, 2000),
+			},
+		},
+		{
+			name: "unit",
+			unit: 10 * time.Second,
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+				static.Floats("_value", 45, 135, 225, 200),
+			},
+		},
+		{
+			// The window boundaries fall between points
+			// so the values are interpolated at the edges.
+			name:   "interpolated edges",
+			offset: 15 * time.Second,
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:15Z", 30, 60, 90, 105),
+				static.Floats("_value", 112.5, 900, 1800, 2700, 537.5),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				TimeColumn:  execute.DefaultStopColLabel,
+				WindowEvery: int64(30 * time.Second),
+				Offset:      int64(tt.offset),
+				Aggregates: []plan.ProcedureKind{
+					storageflux.IntegralKind,
+				},
+				IntegralUnit: int64(tt.unit),
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.StringKey("t0", "a-0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				tt.want,
+			}
+			if diff := table.Diff(want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_Rate(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		interval    time.Duration
+		values      []int64
+		nonNegative bool
+		want        static.Table
+	}{
+		{
+			// An increasing sequence with a slope of one per second.
+			name:     "slope",
+			interval: 10 * time.Second,
+			values:   []int64{0, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 110},
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+				static.Floats("_value", 1, 1, 1, 1),
+			},
+		},
+		{
+			// Every other window only has a single point
+			// so it has no rate.
+			name:     "single point windows",
+			interval: 20 * time.Second,
+			values:   []int64{0, 20, 40, 60, 80, 100},
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 60),
+				static.Floats("_value", 1, 1),
+			},
+		},
+		{
+			name:     "counter reset",
+			interval: 10 * time.Second,
+			values:   []int64{5, 10, 2, 6, 7, 1, 5, 10, 2, 6, 7, 1},
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+				static.Floats("_value", -0.15, -0.25, -0.15, -0.25),
+			},
+		},
+		{
+			name:        "counter reset non negative",
+			interval:    10 * time.Second,
+			values:      []int64{5, 10, 2, 6, 7, 1, 5, 10, 2, 6, 7, 1},
+			nonNegative: true,
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+				static.Floats("_value", 0.35, 0.1, 0.35, 0.1),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+				spec := Spec(org, bucket,
+					MeasurementSpec("m0",
+						IntegerArrayValuesSequence("f0", tt.interval, tt.values),
+						TagValuesSequence("t0", "a-%s", 0, 1),
+					),
+				)
+				tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+				return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+			})
+			defer reader.Close()
+
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				TimeColumn:  execute.DefaultStopColLabel,
+				WindowEvery: int64(30 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					storageflux.RateKind,
+				},
+				NonNegative: tt.nonNegative,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.StringKey("t0", "a-0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				tt.want,
+			}
+			if diff := table.Diff(want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_Mode(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		interval    time.Duration
+		values      []int64
+		every       time.Duration
+		createEmpty bool
+		want        static.Table
+	}{
+		{
+			name:     "repeating pattern",
+			interval: 10 * time.Second,
+			values:   []int64{1, 2, 2, 3, 3, 3},
+			every:    30 * time.Second,
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+				static.Ints("_value", 2, 3, 2, 3),
+			},
+		},
+		{
+			// Each window has one of each value so
+			// the smallest value is selected.
+			name:     "ties",
+			interval: 10 * time.Second,
+			values:   []int64{3, 1, 2},
+			every:    30 * time.Second,
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:30Z", 30, 60, 90),
+				static.Ints("_value", 1, 1, 1, 1),
+			},
+		},
+		{
+			name:        "create empty",
+			interval:    20 * time.Second,
+			values:      []int64{1, 2},
+			every:       10 * time.Second,
+			createEmpty: true,
+			want: static.Table{
+				static.Times("_time", "2019-11-25T00:00:10Z", 10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 110),
+				static.Ints("_value", 1, nil, 2, nil, 1, nil, 2, nil, 1, nil, 2, nil),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+				spec := Spec(org, bucket,
+					MeasurementSpec("m0",
+						IntegerArrayValuesSequence("f0", tt.interval, tt.values),
+						TagValuesSequence("t0", "a-%s", 0, 1),
+					),
+				)
+				tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+				return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+			})
+			defer reader.Close()
+
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				TimeColumn:  execute.DefaultStopColLabel,
+				WindowEvery: int64(tt.every),
+				Aggregates: []plan.ProcedureKind{
+					storageflux.ModeKind,
+				},
+				CreateEmpty: tt.createEmpty,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.StringKey("t0", "a-0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				tt.want,
+			}
+			if diff := table.Diff(want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_ValuePredicate(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 20*time.Second, []int64{1}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	// _value > 0
+	predicate := &datatypes.Predicate{
+		Root: &datatypes.Node{
+			NodeType: datatypes.NodeTypeComparisonExpression,
+			Value:    &datatypes.Node_Comparison_{Comparison: datatypes.ComparisonGreater},
+			Children: []*datatypes.Node{
+				{
+					NodeType: datatypes.NodeTypeFieldRef,
+					Value:    &datatypes.Node_FieldRefValue{FieldRefValue: "_value"},
+				},
+				{
+					NodeType: datatypes.NodeTypeLiteral,
+					Value:    &datatypes.Node_IntegerValue{IntegerValue: 0},
+				},
+			},
+		},
+	}
+
+	mem := &memory.Allocator{}
+	got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		TimeColumn:  execute.DefaultStopColLabel,
+		WindowEvery: int64(10 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.CountKind,
+		},
+		CreateEmpty:    true,
+		ValuePredicate: predicate,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every other window is empty and has a count of zero.
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("_field", "f0"),
+		static.StringKey("t0", "a-0"),
+		static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+		static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+		static.Table{
+			static.Times("_time", "2019-11-25T00:00:10Z", 20, 40, 60, 80, 100),
+			static.Ints("_value", 1, 1, 1, 1, 1, 1),
+		},
+	}
+	if diff := table.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_WindowBounds(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 10*time.Second, []int64{1, 2, 3}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowBounds: []execute.Bounds{
+			{Start: Time("2019-11-25T00:00:00Z"), Stop: Time("2019-11-25T00:00:30Z")},
+			{Start: Time("2019-11-25T00:01:00Z"), Stop: Time("2019-11-25T00:01:20Z")},
+		},
+		Aggregates: []plan.ProcedureKind{
+			storageflux.CountKind,
+		},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The points between the windows are not counted.
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("_field", "f0"),
+		static.StringKey("t0", "a-0"),
+		static.Table{
+			static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+			static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+			static.Ints("_value", 3),
+		},
+		static.Table{
+			static.TimeKey("_start", "2019-11-25T00:01:00Z"),
+			static.TimeKey("_stop", "2019-11-25T00:01:20Z"),
+			static.Ints("_value", 2),
+		},
+	}
+	if diff := table.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+
+	// The CPU time of each table is reported once for each of its
+	// buffers and once when it is done, rather than once for each
+	// window read as well.
+	var adds int
+	ctx := query.ContextWithCPUTimeFunc(context.Background(), func(d time.Duration) error {
+		adds++
+		return nil
+	})
+	ti, err := reader.ReadWindowAggregate(ctx, query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowBounds: []execute.Bounds{
+			{Start: Time("2019-11-25T00:00:00Z"), Stop: Time("2019-11-25T00:00:30Z")},
+			{Start: Time("2019-11-25T00:01:00Z"), Stop: Time("2019-11-25T00:01:20Z")},
+		},
+		Aggregates: []plan.ProcedureKind{
+			storageflux.CountKind,
+		},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tables, buffers int
+	if err := ti.Do(func(tbl flux.Table) error {
+		tables++
+		return tbl.Do(func(cr flux.ColReader) error {
+			buffers++
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := tables + buffers; adds != want {
+		t.Errorf("unexpected number of CPU time reports -want/+got:\n\t- %d\n\t+ %d", want, adds)
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_SelectorTies(t *testing.T) {
+	for _, tt := range []struct {
+		aggregate plan.ProcedureKind
+		values    []float64
+		want      float64
+	}{
+		{
+			aggregate: storageflux.MinKind,
+			values:    []float64{2, 1, 3, 1, 2, 1},
+			want:      1,
+		},
+		{
+			aggregate: storageflux.MaxKind,
+			values:    []float64{1, 3, 2, 3, 0, 3},
+			want:      3,
+		},
+	} {
+		t.Run(string(tt.aggregate), func(t *testing.T) {
+			reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+				spec := Spec(org, bucket,
+					MeasurementSpec("m0",
+						FloatArrayValuesSequence("f0", 10*time.Second, tt.values),
+						TagValuesSequence("t0", "a-%s", 0, 1),
+					),
+				)
+				tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+				return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+			})
+			defer reader.Close()
+
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				WindowEvery: int64(time.Minute),
+				Aggregates: []plan.ProcedureKind{
+					tt.aggregate,
+				},
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The value occurs three times in the window
+			// and the earliest time is selected.
+			want := static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.StringKey("t0", "a-0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+				static.Table{
+					static.Times("_time", "2019-11-25T00:00:10Z"),
+					static.Floats("_value", tt.want),
+				},
+			}
+			if diff := table.Diff(want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_ByStartTime(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:02:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tt := range []struct {
+		aggregate plan.ProcedureKind
+		want      flux.TableIterator
+	}{
+		{
+			aggregate: storageflux.CountKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:00Z", 30, 60, 90),
+							static.Ints("_value", 3, 3, 3, 3),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MinKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:00Z", 30, 60, 90),
+							static.Floats("_value", 1, 1, 1, 2),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MaxKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:02:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:00Z", 30, 60, 90),
+							static.Floats("_value", 3, 4, 4, 4),
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(string(tt.aggregate), func(t *testing.T) {
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				TimeColumn:  execute.DefaultStartColLabel,
+				WindowEvery: int64(30 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					tt.aggregate,
+				},
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := table.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected output -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_CreateEmpty(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 15*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tt := range []struct {
+		aggregate plan.ProcedureKind
+		want      flux.TableIterator
+	}{
+		{
+			aggregate: storageflux.CountKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
+							static.Ints("_value", 1),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
+							static.Ints("_value", 1),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+							static.Ints("_value", 0),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:40Z"),
+							static.Ints("_value", 1),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:40Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:50Z"),
+							static.Ints("_value", 1),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:50Z"),
+							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+							static.Ints("_value", 0),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MinKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
+							static.Times("_time", "2019-11-25T00:00:00Z"),
+							static.Floats("_value", 1),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
+							static.Times("_time", "2019-11-25T00:00:15Z"),
+							static.Floats("_value", 2),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+							static.Times("_time"),
+							static.Floats("_value"),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:40Z"),
+							static.Times("_time", "2019-11-25T00:00:30Z"),
+							static.Floats("_value", 3),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:40Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:50Z"),
+							static.Times("_time", "2019-11-25T00:00:45Z"),
+							static.Floats("_value", 4),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:50Z"),
+							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+							static.Times("_time"),
+							static.Floats("_value"),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MaxKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
+							static.Times("_time", "2019-11-25T00:00:00Z"),
+							static.Floats("_value", 1),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
+							static.Times("_time", "2019-11-25T00:00:15Z"),
+							static.Floats("_value", 2),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+							static.Times("_time"),
+							static.Floats("_value"),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:30Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:40Z"),
+							static.Times("_time", "2019-11-25T00:00:30Z"),
+							static.Floats("_value", 3),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:40Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:50Z"),
+							static.Times("_time", "2019-11-25T00:00:45Z"),
+							static.Floats("_value", 4),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:50Z"),
+							static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+							static.Times("_time"),
+							static.Floats("_value"),
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(string(tt.aggregate), func(t *testing.T) {
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				WindowEvery: int64(10 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					tt.aggregate,
+				},
+				CreateEmpty: true,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := table.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected output -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_CreateEmptyByStopTime(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 15*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tt := range []struct {
+		aggregate plan.ProcedureKind
+		want      flux.TableIterator
+	}{
+		{
+			aggregate: storageflux.CountKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:10Z", 10, 20, 30, 40, 50),
+							static.Ints("_value", 1, 1, 0, 1, 1, 0),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MinKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:10Z", 10, 30, 40),
+							static.Floats("_value", 1, 2, 3, 4),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MaxKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:10Z", 10, 30, 40),
+							static.Floats("_value", 1, 2, 3, 4),
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(string(tt.aggregate), func(t *testing.T) {
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				TimeColumn:  execute.DefaultStopColLabel,
+				WindowEvery: int64(10 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					tt.aggregate,
+				},
+				CreateEmpty: true,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := table.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_CreateEmptyByStartTime(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 15*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tt := range []struct {
+		aggregate plan.ProcedureKind
+		want      flux.TableIterator
+	}{
+		{
+			aggregate: storageflux.CountKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:00Z", 10, 20, 30, 40, 50),
+							static.Ints("_value", 1, 1, 0, 1, 1, 0),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MinKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:00Z", 10, 30, 40),
+							static.Floats("_value", 1, 2, 3, 4),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MaxKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.Times("_time", "2019-11-25T00:00:00Z", 10, 30, 40),
+							static.Floats("_value", 1, 2, 3, 4),
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(string(tt.aggregate), func(t *testing.T) {
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				TimeColumn:  execute.DefaultStartColLabel,
+				WindowEvery: int64(10 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					tt.aggregate,
+				},
+				CreateEmpty: true,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := table.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_TruncatedBounds(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 5*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tt := range []struct {
+		aggregate plan.ProcedureKind
+		want      flux.TableIterator
+	}{
+		{
+			aggregate: storageflux.CountKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:05Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
+							static.Ints("_value", 1),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
+							static.Ints("_value", 2),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
+							static.Ints("_value", 1),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MinKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:05Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
+							static.Times("_time", "2019-11-25T00:00:05Z"),
+							static.Floats("_value", 2),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
+							static.Times("_time", "2019-11-25T00:00:10Z"),
+							static.Floats("_value", 3),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
+							static.Times("_time", "2019-11-25T00:00:20Z"),
+							static.Floats("_value", 1),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MaxKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:05Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
+							static.Times("_time", "2019-11-25T00:00:05Z"),
+							static.Floats("_value", 2),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
+							static.Times("_time", "2019-11-25T00:00:15Z"),
+							static.Floats("_value", 4),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
+							static.Times("_time", "2019-11-25T00:00:20Z"),
+							static.Floats("_value", 1),
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(string(tt.aggregate), func(t *testing.T) {
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds: execute.Bounds{
+						Start: values.ConvertTime(mustParseTime("2019-11-25T00:00:05Z")),
+						Stop:  values.ConvertTime(mustParseTime("2019-11-25T00:00:25Z")),
+					},
+				},
+				WindowEvery: int64(10 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					tt.aggregate,
+				},
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := table.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_TruncatedBoundsActualStart(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 5*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds: execute.Bounds{
+				Start: values.ConvertTime(mustParseTime("2019-11-25T00:00:02Z")),
+				Stop:  values.ConvertTime(mustParseTime("2019-11-25T00:00:25Z")),
+			},
+		},
+		WindowEvery: int64(10 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.CountKind,
+		},
+		ActualStart: true,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first window is truncated by the bounds and
+	// its earliest point is later than its start.
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("_field", "f0"),
+		static.StringKey("t0", "a-0"),
+		static.Table{
+			static.TimeKey("_start", "2019-11-25T00:00:02Z"),
+			static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
+			static.Ints("_value", 1),
+			static.Times("_actual_start", "2019-11-25T00:00:05Z"),
+		},
+		static.Table{
+			static.TimeKey("_start", "2019-11-25T00:00:10Z"),
+			static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
+			static.Ints("_value", 2),
+			static.Times("_actual_start", "2019-11-25T00:00:10Z"),
+		},
+		static.Table{
+			static.TimeKey("_start", "2019-11-25T00:00:20Z"),
+			static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
+			static.Ints("_value", 1),
+			static.Times("_actual_start", "2019-11-25T00:00:20Z"),
+		},
+	}
+	if diff := table.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_TruncatedBoundsCreateEmpty(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 15*time.Second, []float64{1.0, 2.0, 3.0, 4.0}),
+				TagValuesSequence("t0", "a-%s", 0, 3),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	for _, tt := range []struct {
+		aggregate plan.ProcedureKind
 		want      flux.TableIterator
 	}{
 		{
-			aggregate: storageflux.CountKind,
+			aggregate: storageflux.CountKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:05Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
+							static.Ints("_value", 0),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
+							static.Ints("_value", 1),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
+							static.Ints("_value", 0),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MinKind,
+			want: static.TableGroup{
+				static.StringKey("_measurement", "m0"),
+				static.StringKey("_field", "f0"),
+				static.TableMatrix{
+					static.StringKeys("t0", "a-0", "a-1", "a-2"),
+					{
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:05Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
+							static.Times("_time"),
+							static.Floats("_value"),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
+							static.Times("_time", "2019-11-25T00:00:15Z"),
+							static.Floats("_value", 2),
+						},
+						static.Table{
+							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
+							static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
+							static.Times("_time"),
+							static.Floats("_value"),
+						},
+					},
+				},
+			},
+		},
+		{
+			aggregate: storageflux.MaxKind,
 			want: static.TableGroup{
 				static.StringKey("_measurement", "m0"),
 				static.StringKey("_field", "f0"),
@@ -1076,112 +4767,473 @@ func TestStorageReader_ReadWindowAggregate_TruncatedBoundsCreateEmpty(t *testing
 						static.Table{
 							static.TimeKey("_start", "2019-11-25T00:00:05Z"),
 							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
-							static.Ints("_value", 0),
+							static.Times("_time"),
+							static.Floats("_value"),
 						},
 						static.Table{
 							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
 							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
-							static.Ints("_value", 1),
+							static.Times("_time", "2019-11-25T00:00:15Z"),
+							static.Floats("_value", 2),
 						},
 						static.Table{
 							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
 							static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
-							static.Ints("_value", 0),
+							static.Times("_time"),
+							static.Floats("_value"),
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(string(tt.aggregate), func(t *testing.T) {
+			mem := &memory.Allocator{}
+			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds: execute.Bounds{
+						Start: values.ConvertTime(mustParseTime("2019-11-25T00:00:05Z")),
+						Stop:  values.ConvertTime(mustParseTime("2019-11-25T00:00:25Z")),
+					},
+				},
+				WindowEvery: int64(10 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					tt.aggregate,
+				},
+				CreateEmpty: true,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := table.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_Mean(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		tagsSpec := &gen.TagsSpec{
+			Tags: []*gen.TagValuesSpec{
+				{
+					TagKey: "t0",
+					Values: func() gen.CountableSequence {
+						return gen.NewCounterByteSequence("a%s", 0, 1)
+					},
+				},
+			},
+		}
+		spec := gen.Spec{
+			OrgID:    org,
+			BucketID: bucket,
+			Measurements: []gen.MeasurementSpec{
+				{
+					Name:     "m0",
+					TagsSpec: tagsSpec,
+					FieldValuesSpec: &gen.FieldValuesSpec{
+						Name: "f0",
+						TimeSequenceSpec: gen.TimeSequenceSpec{
+							Count: math.MaxInt32,
+							Delta: 5 * time.Second,
+						},
+						DataType: models.Integer,
+						Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
+							return gen.NewTimeIntegerValuesSequence(
+								spec.Count,
+								gen.NewTimestampSequenceFromSpec(spec),
+								gen.NewIntegerArrayValuesSequence([]int64{1, 2, 3, 4}),
+							)
 						},
 					},
 				},
 			},
-		},
-		{
-			aggregate: storageflux.MinKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:05Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
-							static.Times("_time"),
-							static.Floats("_value"),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
-							static.Times("_time", "2019-11-25T00:00:15Z"),
-							static.Floats("_value", 2),
+		}
+		tr := gen.TimeRange{
+			Start: mustParseTime("2019-11-25T00:00:00Z"),
+			End:   mustParseTime("2019-11-25T00:01:00Z"),
+		}
+		return gen.NewSeriesGeneratorFromSpec(&spec, tr), tr
+	})
+	defer reader.Close()
+
+	t.Run("unwindowed mean", func(t *testing.T) {
+		mem := &memory.Allocator{}
+		ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+			ReadFilterSpec: query.ReadFilterSpec{
+				OrganizationID: reader.Org,
+				BucketID:       reader.Bucket,
+				Bounds:         reader.Bounds,
+			},
+			WindowEvery: math.MaxInt64,
+			Aggregates: []plan.ProcedureKind{
+				storageflux.MeanKind,
+			},
+		}, mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := static.Table{
+			static.StringKey("_measurement", "m0"),
+			static.StringKey("_field", "f0"),
+			static.StringKey("t0", "a0"),
+			static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+			static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+			static.Floats("_value", 2.5),
+		}
+		if diff := table.Diff(want, ti); diff != "" {
+			t.Fatalf("table iterators do not match; -want/+got:\n%s", diff)
+		}
+	})
+
+	t.Run("windowed mean", func(t *testing.T) {
+		mem := &memory.Allocator{}
+		ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+			ReadFilterSpec: query.ReadFilterSpec{
+				OrganizationID: reader.Org,
+				BucketID:       reader.Bucket,
+				Bounds:         reader.Bounds,
+			},
+			WindowEvery: int64(10 * time.Second),
+			Aggregates: []plan.ProcedureKind{
+				storageflux.MeanKind,
+			},
+		}, mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := static.TableGroup{
+			static.StringKey("_measurement", "m0"),
+			static.StringKey("_field", "f0"),
+			static.StringKey("t0", "a0"),
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
+				static.Floats("_value", 1.5),
+			},
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:10Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
+				static.Floats("_value", 3.5),
+			},
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:20Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
+				static.Floats("_value", 1.5),
+			},
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:30Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:40Z"),
+				static.Floats("_value", 3.5),
+			},
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:40Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:50Z"),
+				static.Floats("_value", 1.5),
+			},
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:50Z"),
+				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+				static.Floats("_value", 3.5),
+			},
+		}
+		if diff := table.Diff(want, ti); diff != "" {
+			t.Fatalf("table iterators do not match; -want/+got:\n%s", diff)
+		}
+	})
+
+	t.Run("windowed mean with offset", func(t *testing.T) {
+		mem := &memory.Allocator{}
+		ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+			ReadFilterSpec: query.ReadFilterSpec{
+				OrganizationID: reader.Org,
+				BucketID:       reader.Bucket,
+				Bounds:         reader.Bounds,
+			},
+			WindowEvery: int64(10 * time.Second),
+			Offset:      int64(2 * time.Second),
+			Aggregates: []plan.ProcedureKind{
+				storageflux.MeanKind,
+			},
+		}, mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := static.TableGroup{
+			static.StringKey("_measurement", "m0"),
+			static.StringKey("_field", "f0"),
+			static.StringKey("t0", "a0"),
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:02Z"),
+				static.Floats("_value", 1.0),
+			},
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:02Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:12Z"),
+				static.Floats("_value", 2.5),
+			},
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:12Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:22Z"),
+				static.Floats("_value", 2.5),
+			},
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:22Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:32Z"),
+				static.Floats("_value", 2.5),
+			},
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:32Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:42Z"),
+				static.Floats("_value", 2.5),
+			},
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:42Z"),
+				static.TimeKey("_stop", "2019-11-25T00:00:52Z"),
+				static.Floats("_value", 2.5),
+			},
+			static.Table{
+				static.TimeKey("_start", "2019-11-25T00:00:52Z"),
+				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
+				static.Floats("_value", 4),
+			},
+		}
+		if diff := table.Diff(want, ti); diff != "" {
+			t.Fatalf("table iterators do not match; -want/+got:\n%s", diff)
+		}
+	})
+}
+
+func TestStorageReader_ReadWindowFirst(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		tagsSpec := &gen.TagsSpec{
+			Tags: []*gen.TagValuesSpec{
+				{
+					TagKey: "t0",
+					Values: func() gen.CountableSequence {
+						return gen.NewCounterByteSequence("a%s", 0, 1)
+					},
+				},
+			},
+		}
+		spec := gen.Spec{
+			OrgID:    org,
+			BucketID: bucket,
+			Measurements: []gen.MeasurementSpec{
+				{
+					Name:     "m0",
+					TagsSpec: tagsSpec,
+					FieldValuesSpec: &gen.FieldValuesSpec{
+						Name: "f0",
+						TimeSequenceSpec: gen.TimeSequenceSpec{
+							Count: math.MaxInt32,
+							Delta: 5 * time.Second,
 						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
-							static.Times("_time"),
-							static.Floats("_value"),
+						DataType: models.Integer,
+						Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
+							return gen.NewTimeIntegerValuesSequence(
+								spec.Count,
+								gen.NewTimestampSequenceFromSpec(spec),
+								gen.NewIntegerArrayValuesSequence([]int64{1, 2, 3, 4}),
+							)
 						},
 					},
 				},
 			},
+		}
+		tr := gen.TimeRange{
+			Start: mustParseTime("2019-11-25T00:00:00Z"),
+			End:   mustParseTime("2019-11-25T00:01:00Z"),
+		}
+		return gen.NewSeriesGeneratorFromSpec(&spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
 		},
-		{
-			aggregate: storageflux.MaxKind,
-			want: static.TableGroup{
-				static.StringKey("_measurement", "m0"),
-				static.StringKey("_field", "f0"),
-				static.TableMatrix{
-					static.StringKeys("t0", "a-0", "a-1", "a-2"),
-					{
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:05Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
-							static.Times("_time"),
-							static.Floats("_value"),
-						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:10Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
-							static.Times("_time", "2019-11-25T00:00:15Z"),
-							static.Floats("_value", 2),
+		WindowEvery: int64(10 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.FirstKind,
+		},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	makeWindowTable := func(start, stop, time values.Time, v int64) *executetest.Table {
+		return &executetest.Table{
+			KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TInt},
+				{Label: "_field", Type: flux.TString},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "t0", Type: flux.TString},
+			},
+			Data: [][]interface{}{
+				{start, stop, time, v, "f0", "m0", "a0"},
+			},
+		}
+	}
+	want := []*executetest.Table{
+		makeWindowTable(Time("2019-11-25T00:00:00Z"), Time("2019-11-25T00:00:10Z"), Time("2019-11-25T00:00:00Z"), 1),
+		makeWindowTable(Time("2019-11-25T00:00:10Z"), Time("2019-11-25T00:00:20Z"), Time("2019-11-25T00:00:10Z"), 3),
+		makeWindowTable(Time("2019-11-25T00:00:20Z"), Time("2019-11-25T00:00:30Z"), Time("2019-11-25T00:00:20Z"), 1),
+		makeWindowTable(Time("2019-11-25T00:00:30Z"), Time("2019-11-25T00:00:40Z"), Time("2019-11-25T00:00:30Z"), 3),
+		makeWindowTable(Time("2019-11-25T00:00:40Z"), Time("2019-11-25T00:00:50Z"), Time("2019-11-25T00:00:40Z"), 1),
+		makeWindowTable(Time("2019-11-25T00:00:50Z"), Time("2019-11-25T00:01:00Z"), Time("2019-11-25T00:00:50Z"), 3),
+	}
+
+	executetest.NormalizeTables(want)
+	sort.Sort(executetest.SortedTables(want))
+
+	var got []*executetest.Table
+	if err := ti.Do(func(table flux.Table) error {
+		t, err := executetest.ConvertTable(table)
+		if err != nil {
+			return err
+		}
+		got = append(got, t)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	executetest.NormalizeTables(got)
+	sort.Sort(executetest.SortedTables(got))
+
+	// compare these two
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_WindowFirstOffset(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		tagsSpec := &gen.TagsSpec{
+			Tags: []*gen.TagValuesSpec{
+				{
+					TagKey: "t0",
+					Values: func() gen.CountableSequence {
+						return gen.NewCounterByteSequence("a%s", 0, 1)
+					},
+				},
+			},
+		}
+		spec := gen.Spec{
+			OrgID:    org,
+			BucketID: bucket,
+			Measurements: []gen.MeasurementSpec{
+				{
+					Name:     "m0",
+					TagsSpec: tagsSpec,
+					FieldValuesSpec: &gen.FieldValuesSpec{
+						Name: "f0",
+						TimeSequenceSpec: gen.TimeSequenceSpec{
+							Count: math.MaxInt32,
+							Delta: 5 * time.Second,
 						},
-						static.Table{
-							static.TimeKey("_start", "2019-11-25T00:00:20Z"),
-							static.TimeKey("_stop", "2019-11-25T00:00:25Z"),
-							static.Times("_time"),
-							static.Floats("_value"),
+						DataType: models.Integer,
+						Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
+							return gen.NewTimeIntegerValuesSequence(
+								spec.Count,
+								gen.NewTimestampSequenceFromSpec(spec),
+								gen.NewIntegerArrayValuesSequence([]int64{1, 2, 3, 4}),
+							)
 						},
 					},
 				},
 			},
+		}
+		tr := gen.TimeRange{
+			Start: mustParseTime("2019-11-25T00:00:00Z"),
+			End:   mustParseTime("2019-11-25T00:01:00Z"),
+		}
+		return gen.NewSeriesGeneratorFromSpec(&spec, tr), tr
+	})
+	defer reader.Close()
+
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
 		},
-	} {
-		t.Run(string(tt.aggregate), func(t *testing.T) {
-			mem := &memory.Allocator{}
-			got, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
-				ReadFilterSpec: query.ReadFilterSpec{
-					OrganizationID: reader.Org,
-					BucketID:       reader.Bucket,
-					Bounds: execute.Bounds{
-						Start: values.ConvertTime(mustParseTime("2019-11-25T00:00:05Z")),
-						Stop:  values.ConvertTime(mustParseTime("2019-11-25T00:00:25Z")),
-					},
-				},
-				WindowEvery: int64(10 * time.Second),
-				Aggregates: []plan.ProcedureKind{
-					tt.aggregate,
-				},
-				CreateEmpty: true,
-			}, mem)
-			if err != nil {
-				t.Fatal(err)
-			}
+		WindowEvery: int64(10 * time.Second),
+		Offset:      int64(5 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.FirstKind,
+		},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	makeWindowTable := func(start, stop, time values.Time, v int64) *executetest.Table {
+		return &executetest.Table{
+			KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TInt},
+				{Label: "_field", Type: flux.TString},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "t0", Type: flux.TString},
+			},
+			Data: [][]interface{}{
+				{start, stop, time, v, "f0", "m0", "a0"},
+			},
+		}
+	}
+	want := []*executetest.Table{
+		makeWindowTable(Time("2019-11-25T00:00:00Z"), Time("2019-11-25T00:00:05Z"), Time("2019-11-25T00:00:00Z"), 1),
+		makeWindowTable(Time("2019-11-25T00:00:05Z"), Time("2019-11-25T00:00:15Z"), Time("2019-11-25T00:00:05Z"), 2),
+		makeWindowTable(Time("2019-11-25T00:00:15Z"), Time("2019-11-25T00:00:25Z"), Time("2019-11-25T00:00:15Z"), 4),
+		makeWindowTable(Time("2019-11-25T00:00:25Z"), Time("2019-11-25T00:00:35Z"), Time("2019-11-25T00:00:25Z"), 2),
+		makeWindowTable(Time("2019-11-25T00:00:35Z"), Time("2019-11-25T00:00:45Z"), Time("2019-11-25T00:00:35Z"), 4),
+		makeWindowTable(Time("2019-11-25T00:00:45Z"), Time("2019-11-25T00:00:55Z"), Time("2019-11-25T00:00:45Z"), 2),
+		makeWindowTable(Time("2019-11-25T00:00:55Z"), Time("2019-11-25T00:01:00Z"), Time("2019-11-25T00:00:55Z"), 4),
+	}
 
-			if diff := table.Diff(tt.want, got); diff != "" {
-				t.Errorf("unexpected results -want/+got:\n%s", diff)
-			}
-		})
+	executetest.NormalizeTables(want)
+	sort.Sort(executetest.SortedTables(want))
+
+	var got []*executetest.Table
+	if err := ti.Do(func(table flux.Table) error {
+		t, err := executetest.ConvertTable(table)
+		if err != nil {
+			return err
+		}
+		got = append(got, t)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	executetest.NormalizeTables(got)
+	sort.Sort(executetest.SortedTables(got))
+
+	// compare these two
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
 	}
 }
 
-func TestStorageReader_ReadWindowAggregate_Mean(t *testing.T) {
+func TestStorageReader_WindowSumOffset(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
 		tagsSpec := &gen.TagsSpec{
 			Tags: []*gen.TagValuesSpec{
@@ -1226,158 +5278,155 @@ func TestStorageReader_ReadWindowAggregate_Mean(t *testing.T) {
 	})
 	defer reader.Close()
 
-	t.Run("unwindowed mean", func(t *testing.T) {
-		mem := &memory.Allocator{}
-		ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
-			ReadFilterSpec: query.ReadFilterSpec{
-				OrganizationID: reader.Org,
-				BucketID:       reader.Bucket,
-				Bounds:         reader.Bounds,
+	mem := &memory.Allocator{}
+	ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowEvery: int64(10 * time.Second),
+		Offset:      int64(2 * time.Second),
+		Aggregates: []plan.ProcedureKind{
+			storageflux.SumKind,
+		},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	makeWindowTable := func(start, stop values.Time, v int64) *executetest.Table {
+		return &executetest.Table{
+			KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_value", Type: flux.TInt},
+				{Label: "_field", Type: flux.TString},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "t0", Type: flux.TString},
 			},
-			WindowEvery: math.MaxInt64,
-			Aggregates: []plan.ProcedureKind{
-				storageflux.MeanKind,
+			Data: [][]interface{}{
+				{start, stop, v, "f0", "m0", "a0"},
 			},
-		}, mem)
-		if err != nil {
-			t.Fatal(err)
 		}
+	}
+	want := []*executetest.Table{
+		makeWindowTable(Time("2019-11-25T00:00:00Z"), Time("2019-11-25T00:00:02Z"), 1),
+		makeWindowTable(Time("2019-11-25T00:00:02Z"), Time("2019-11-25T00:00:12Z"), 5),
+		makeWindowTable(Time("2019-11-25T00:00:12Z"), Time("2019-11-25T00:00:22Z"), 5),
+		makeWindowTable(Time("2019-11-25T00:00:22Z"), Time("2019-11-25T00:00:32Z"), 5),
+		makeWindowTable(Time("2019-11-25T00:00:32Z"), Time("2019-11-25T00:00:42Z"), 5),
+		makeWindowTable(Time("2019-11-25T00:00:42Z"), Time("2019-11-25T00:00:52Z"), 5),
+		makeWindowTable(Time("2019-11-25T00:00:52Z"), Time("2019-11-25T00:01:00Z"), 4),
+	}
 
-		want := static.Table{
-			static.StringKey("_measurement", "m0"),
-			static.StringKey("_field", "f0"),
-			static.StringKey("t0", "a0"),
-			static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-			static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-			static.Floats("_value", 2.5),
-		}
-		if diff := table.Diff(want, ti); diff != "" {
-			t.Fatalf("table iterators do not match; -want/+got:\n%s", diff)
-		}
-	})
+	executetest.NormalizeTables(want)
+	sort.Sort(executetest.SortedTables(want))
 
-	t.Run("windowed mean", func(t *testing.T) {
-		mem := &memory.Allocator{}
-		ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
-			ReadFilterSpec: query.ReadFilterSpec{
-				OrganizationID: reader.Org,
-				BucketID:       reader.Bucket,
-				Bounds:         reader.Bounds,
-			},
-			WindowEvery: int64(10 * time.Second),
-			Aggregates: []plan.ProcedureKind{
-				storageflux.MeanKind,
-			},
-		}, mem)
+	var got []*executetest.Table
+	if err := ti.Do(func(table flux.Table) error {
+		t, err := executetest.ConvertTable(table)
 		if err != nil {
-			t.Fatal(err)
+			return err
 		}
+		got = append(got, t)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	executetest.NormalizeTables(got)
+	sort.Sort(executetest.SortedTables(got))
 
-		want := static.TableGroup{
-			static.StringKey("_measurement", "m0"),
-			static.StringKey("_field", "f0"),
-			static.StringKey("t0", "a0"),
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:00:10Z"),
-				static.Floats("_value", 1.5),
-			},
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:10Z"),
-				static.TimeKey("_stop", "2019-11-25T00:00:20Z"),
-				static.Floats("_value", 3.5),
-			},
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:20Z"),
-				static.TimeKey("_stop", "2019-11-25T00:00:30Z"),
-				static.Floats("_value", 1.5),
-			},
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:30Z"),
-				static.TimeKey("_stop", "2019-11-25T00:00:40Z"),
-				static.Floats("_value", 3.5),
-			},
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:40Z"),
-				static.TimeKey("_stop", "2019-11-25T00:00:50Z"),
-				static.Floats("_value", 1.5),
-			},
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:50Z"),
-				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-				static.Floats("_value", 3.5),
-			},
-		}
-		if diff := table.Diff(want, ti); diff != "" {
-			t.Fatalf("table iterators do not match; -want/+got:\n%s", diff)
-		}
+	// compare these two
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_WindowSumOffsetRelativeTo(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 5*time.Second, []int64{1, 2, 3, 4}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
 	})
+	defer reader.Close()
 
-	t.Run("windowed mean with offset", func(t *testing.T) {
-		mem := &memory.Allocator{}
-		ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
-			ReadFilterSpec: query.ReadFilterSpec{
-				OrganizationID: reader.Org,
-				BucketID:       reader.Bucket,
-				Bounds:         reader.Bounds,
+	// The bounds do not start at a multiple of the window period.
+	bounds := execute.Bounds{Start: Time("2019-11-25T00:00:05Z"), Stop: reader.Bounds.Stop}
+	for _, tt := range []struct {
+		name       string
+		relativeTo query.OffsetRelativeTo
+		want       [][2]values.Time
+	}{
+		{
+			name:       "epoch",
+			relativeTo: query.OffsetRelativeToEpoch,
+			want: [][2]values.Time{
+				{Time("2019-11-25T00:00:05Z"), Time("2019-11-25T00:00:12Z")},
+				{Time("2019-11-25T00:00:12Z"), Time("2019-11-25T00:00:22Z")},
 			},
-			WindowEvery: int64(10 * time.Second),
-			Offset:      int64(2 * time.Second),
-			Aggregates: []plan.ProcedureKind{
-				storageflux.MeanKind,
+		},
+		{
+			name:       "bounds start",
+			relativeTo: query.OffsetRelativeToBoundsStart,
+			want: [][2]values.Time{
+				{Time("2019-11-25T00:00:05Z"), Time("2019-11-25T00:00:07Z")},
+				{Time("2019-11-25T00:00:07Z"), Time("2019-11-25T00:00:17Z")},
 			},
-		}, mem)
-		if err != nil {
-			t.Fatal(err)
-		}
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := &memory.Allocator{}
+			ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         bounds,
+				},
+				WindowEvery:      int64(10 * time.Second),
+				Offset:           int64(2 * time.Second),
+				OffsetRelativeTo: tt.relativeTo,
+				Aggregates: []plan.ProcedureKind{
+					storageflux.SumKind,
+				},
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-		want := static.TableGroup{
-			static.StringKey("_measurement", "m0"),
-			static.StringKey("_field", "f0"),
-			static.StringKey("t0", "a0"),
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:00Z"),
-				static.TimeKey("_stop", "2019-11-25T00:00:02Z"),
-				static.Floats("_value", 1.0),
-			},
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:02Z"),
-				static.TimeKey("_stop", "2019-11-25T00:00:12Z"),
-				static.Floats("_value", 2.5),
-			},
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:12Z"),
-				static.TimeKey("_stop", "2019-11-25T00:00:22Z"),
-				static.Floats("_value", 2.5),
-			},
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:22Z"),
-				static.TimeKey("_stop", "2019-11-25T00:00:32Z"),
-				static.Floats("_value", 2.5),
-			},
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:32Z"),
-				static.TimeKey("_stop", "2019-11-25T00:00:42Z"),
-				static.Floats("_value", 2.5),
-			},
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:42Z"),
-				static.TimeKey("_stop", "2019-11-25T00:00:52Z"),
-				static.Floats("_value", 2.5),
-			},
-			static.Table{
-				static.TimeKey("_start", "2019-11-25T00:00:52Z"),
-				static.TimeKey("_stop", "2019-11-25T00:01:00Z"),
-				static.Floats("_value", 4),
-			},
-		}
-		if diff := table.Diff(want, ti); diff != "" {
-			t.Fatalf("table iterators do not match; -want/+got:\n%s", diff)
-		}
-	})
+			var got [][2]values.Time
+			if err := ti.Do(func(table flux.Table) error {
+				key := table.Key()
+				got = append(got, [2]values.Time{
+					key.LabelValue(execute.DefaultStartColLabel).Time(),
+					key.LabelValue(execute.DefaultStopColLabel).Time(),
+				})
+				table.Done()
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			sort.Slice(got, func(i, j int) bool {
+				return got[i][0] < got[j][0]
+			})
+
+			if len(got) < len(tt.want) {
+				t.Fatalf("unexpected number of windows: got %d want at least %d", len(got), len(tt.want))
+			}
+			if diff := cmp.Diff(tt.want, got[:len(tt.want)]); diff != "" {
+				t.Errorf("unexpected first windows -want/+got:\n%s", diff)
+			}
+		})
+	}
 }
 
-func TestStorageReader_ReadWindowFirst(t *testing.T) {
+func TestStorageReader_ReadWindowFirstCreateEmpty(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
 		tagsSpec := &gen.TagsSpec{
 			Tags: []*gen.TagValuesSpec{
@@ -1400,14 +5449,14 @@ func TestStorageReader_ReadWindowFirst(t *testing.T) {
 						Name: "f0",
 						TimeSequenceSpec: gen.TimeSequenceSpec{
 							Count: math.MaxInt32,
-							Delta: 5 * time.Second,
+							Delta: 20 * time.Second,
 						},
 						DataType: models.Integer,
 						Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
 							return gen.NewTimeIntegerValuesSequence(
 								spec.Count,
 								gen.NewTimestampSequenceFromSpec(spec),
-								gen.NewIntegerArrayValuesSequence([]int64{1, 2, 3, 4}),
+								gen.NewIntegerArrayValuesSequence([]int64{1, 2}),
 							)
 						},
 					},
@@ -1433,11 +5482,28 @@ func TestStorageReader_ReadWindowFirst(t *testing.T) {
 		Aggregates: []plan.ProcedureKind{
 			storageflux.FirstKind,
 		},
+		CreateEmpty: true,
 	}, mem)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	makeEmptyTable := func(start, stop values.Time) *executetest.Table {
+		return &executetest.Table{
+			KeyCols:   []string{"_start", "_stop", "_field", "_measurement", "t0"},
+			KeyValues: []interface{}{start, stop, "f0", "m0", "a0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TInt},
+				{Label: "_field", Type: flux.TString},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "t0", Type: flux.TString},
+			},
+			Data: nil,
+		}
+	}
 	makeWindowTable := func(start, stop, time values.Time, v int64) *executetest.Table {
 		return &executetest.Table{
 			KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
@@ -1456,12 +5522,24 @@ func TestStorageReader_ReadWindowFirst(t *testing.T) {
 		}
 	}
 	want := []*executetest.Table{
-		makeWindowTable(Time("2019-11-25T00:00:00Z"), Time("2019-11-25T00:00:10Z"), Time("2019-11-25T00:00:00Z"), 1),
-		makeWindowTable(Time("2019-11-25T00:00:10Z"), Time("2019-11-25T00:00:20Z"), Time("2019-11-25T00:00:10Z"), 3),
-		makeWindowTable(Time("2019-11-25T00:00:20Z"), Time("2019-11-25T00:00:30Z"), Time("2019-11-25T00:00:20Z"), 1),
-		makeWindowTable(Time("2019-11-25T00:00:30Z"), Time("2019-11-25T00:00:40Z"), Time("2019-11-25T00:00:30Z"), 3),
-		makeWindowTable(Time("2019-11-25T00:00:40Z"), Time("2019-11-25T00:00:50Z"), Time("2019-11-25T00:00:40Z"), 1),
-		makeWindowTable(Time("2019-11-25T00:00:50Z"), Time("2019-11-25T00:01:00Z"), Time("2019-11-25T00:00:50Z"), 3),
+		makeWindowTable(
+			Time("2019-11-25T00:00:00Z"), Time("2019-11-25T00:00:10Z"), Time("2019-11-25T00:00:00Z"), 1,
+		),
+		makeEmptyTable(
+			Time("2019-11-25T00:00:10Z"), Time("2019-11-25T00:00:20Z"),
+		),
+		makeWindowTable(
+			Time("2019-11-25T00:00:20Z"), Time("2019-11-25T00:00:30Z"), Time("2019-11-25T00:00:20Z"), 2,
+		),
+		makeEmptyTable(
+			Time("2019-11-25T00:00:30Z"), Time("2019-11-25T00:00:40Z"),
+		),
+		makeWindowTable(
+			Time("2019-11-25T00:00:40Z"), Time("2019-11-25T00:00:50Z"), Time("2019-11-25T00:00:40Z"), 1,
+		),
+		makeEmptyTable(
+			Time("2019-11-25T00:00:50Z"), Time("2019-11-25T00:01:00Z"),
+		),
 	}
 
 	executetest.NormalizeTables(want)
@@ -1487,93 +5565,91 @@ func TestStorageReader_ReadWindowFirst(t *testing.T) {
 	}
 }
 
-func TestStorageReader_WindowFirstOffset(t *testing.T) {
+func TestStorageReader_ReadWindowFirstCreateEmpty_FillPrevious(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
-		tagsSpec := &gen.TagsSpec{
-			Tags: []*gen.TagValuesSpec{
-				{
-					TagKey: "t0",
-					Values: func() gen.CountableSequence {
-						return gen.NewCounterByteSequence("a%s", 0, 1)
-					},
-				},
-			},
-		}
-		spec := gen.Spec{
-			OrgID:    org,
-			BucketID: bucket,
-			Measurements: []gen.MeasurementSpec{
-				{
-					Name:     "m0",
-					TagsSpec: tagsSpec,
-					FieldValuesSpec: &gen.FieldValuesSpec{
-						Name: "f0",
-						TimeSequenceSpec: gen.TimeSequenceSpec{
-							Count: math.MaxInt32,
-							Delta: 5 * time.Second,
-						},
-						DataType: models.Integer,
-						Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
-							return gen.NewTimeIntegerValuesSequence(
-								spec.Count,
-								gen.NewTimestampSequenceFromSpec(spec),
-								gen.NewIntegerArrayValuesSequence([]int64{1, 2, 3, 4}),
-							)
-						},
-					},
-				},
-			},
-		}
-		tr := gen.TimeRange{
-			Start: mustParseTime("2019-11-25T00:00:00Z"),
-			End:   mustParseTime("2019-11-25T00:01:00Z"),
-		}
-		return gen.NewSeriesGeneratorFromSpec(&spec, tr), tr
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 30*time.Second, []int64{1, 2}),
+				TagValuesSequence("t0", "a%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
 	})
 	defer reader.Close()
 
+	// Start a window before the first point so that
+	// there is an empty window with nothing to fill it.
+	bounds := reader.Bounds
+	bounds.Start = Time("2019-11-24T23:59:50Z")
+
 	mem := &memory.Allocator{}
 	ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
 		ReadFilterSpec: query.ReadFilterSpec{
 			OrganizationID: reader.Org,
 			BucketID:       reader.Bucket,
-			Bounds:         reader.Bounds,
+			Bounds:         bounds,
 		},
 		WindowEvery: int64(10 * time.Second),
-		Offset:      int64(5 * time.Second),
 		Aggregates: []plan.ProcedureKind{
 			storageflux.FirstKind,
 		},
+		CreateEmpty:  true,
+		FillPrevious: true,
 	}, mem)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	makeWindowTable := func(start, stop, time values.Time, v int64) *executetest.Table {
+	cols := []flux.ColMeta{
+		{Label: "_start", Type: flux.TTime},
+		{Label: "_stop", Type: flux.TTime},
+		{Label: "_time", Type: flux.TTime},
+		{Label: "_value", Type: flux.TInt},
+		{Label: "_field", Type: flux.TString},
+		{Label: "_measurement", Type: flux.TString},
+		{Label: "t0", Type: flux.TString},
+	}
+	makeEmptyTable := func(start, stop values.Time) *executetest.Table {
+		return &executetest.Table{
+			KeyCols:   []string{"_start", "_stop", "_field", "_measurement", "t0"},
+			KeyValues: []interface{}{start, stop, "f0", "m0", "a0"},
+			ColMeta:   cols,
+		}
+	}
+	// makeWindowTable makes the table of a window with a point
+	// at time or, if time is nil, a window filled with v.
+	makeWindowTable := func(start, stop values.Time, time interface{}, v int64) *executetest.Table {
 		return &executetest.Table{
 			KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
-			ColMeta: []flux.ColMeta{
-				{Label: "_start", Type: flux.TTime},
-				{Label: "_stop", Type: flux.TTime},
-				{Label: "_time", Type: flux.TTime},
-				{Label: "_value", Type: flux.TInt},
-				{Label: "_field", Type: flux.TString},
-				{Label: "_measurement", Type: flux.TString},
-				{Label: "t0", Type: flux.TString},
-			},
+			ColMeta: cols,
 			Data: [][]interface{}{
 				{start, stop, time, v, "f0", "m0", "a0"},
 			},
 		}
 	}
 	want := []*executetest.Table{
-		makeWindowTable(Time("2019-11-25T00:00:00Z"), Time("2019-11-25T00:00:05Z"), Time("2019-11-25T00:00:00Z"), 1),
-		makeWindowTable(Time("2019-11-25T00:00:05Z"), Time("2019-11-25T00:00:15Z"), Time("2019-11-25T00:00:05Z"), 2),
-		makeWindowTable(Time("2019-11-25T00:00:15Z"), Time("2019-11-25T00:00:25Z"), Time("2019-11-25T00:00:15Z"), 4),
-		makeWindowTable(Time("2019-11-25T00:00:25Z"), Time("2019-11-25T00:00:35Z"), Time("2019-11-25T00:00:25Z"), 2),
-		makeWindowTable(Time("2019-11-25T00:00:35Z"), Time("2019-11-25T00:00:45Z"), Time("2019-11-25T00:00:35Z"), 4),
-		makeWindowTable(Time("2019-11-25T00:00:45Z"), Time("2019-11-25T00:00:55Z"), Time("2019-11-25T00:00:45Z"), 2),
-		makeWindowTable(Time("2019-11-25T00:00:55Z"), Time("2019-11-25T00:01:00Z"), Time("2019-11-25T00:00:55Z"), 4),
+		makeEmptyTable(
+			Time("2019-11-24T23:59:50Z"), Time("2019-11-25T00:00:00Z"),
+		),
+		makeWindowTable(
+			Time("2019-11-25T00:00:00Z"), Time("2019-11-25T00:00:10Z"), Time("2019-11-25T00:00:00Z"), 1,
+		),
+		makeWindowTable(
+			Time("2019-11-25T00:00:10Z"), Time("2019-11-25T00:00:20Z"), nil, 1,
+		),
+		makeWindowTable(
+			Time("2019-11-25T00:00:20Z"), Time("2019-11-25T00:00:30Z"), nil, 1,
+		),
+		makeWindowTable(
+			Time("2019-11-25T00:00:30Z"), Time("2019-11-25T00:00:40Z"), Time("2019-11-25T00:00:30Z"), 2,
+		),
+		makeWindowTable(
+			Time("2019-11-25T00:00:40Z"), Time("2019-11-25T00:00:50Z"), nil, 2,
+		),
+		makeWindowTable(
+			Time("2019-11-25T00:00:50Z"), Time("2019-11-25T00:01:00Z"), nil, 2,
+		),
 	}
 
 	executetest.NormalizeTables(want)
@@ -1593,100 +5669,91 @@ func TestStorageReader_WindowFirstOffset(t *testing.T) {
 	executetest.NormalizeTables(got)
 	sort.Sort(executetest.SortedTables(got))
 
-	// compare these two
 	if diff := cmp.Diff(want, got); diff != "" {
 		t.Errorf("unexpected results -want/+got:\n%s", diff)
 	}
 }
 
-func TestStorageReader_WindowSumOffset(t *testing.T) {
+func TestStorageReader_ReadWindowMean_MaterializeTo(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
-		tagsSpec := &gen.TagsSpec{
-			Tags: []*gen.TagValuesSpec{
-				{
-					TagKey: "t0",
-					Values: func() gen.CountableSequence {
-						return gen.NewCounterByteSequence("a%s", 0, 1)
-					},
-				},
-			},
-		}
-		spec := gen.Spec{
-			OrgID:    org,
-			BucketID: bucket,
-			Measurements: []gen.MeasurementSpec{
-				{
-					Name:     "m0",
-					TagsSpec: tagsSpec,
-					FieldValuesSpec: &gen.FieldValuesSpec{
-						Name: "f0",
-						TimeSequenceSpec: gen.TimeSequenceSpec{
-							Count: math.MaxInt32,
-							Delta: 5 * time.Second,
-						},
-						DataType: models.Integer,
-						Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
-							return gen.NewTimeIntegerValuesSequence(
-								spec.Count,
-								gen.NewTimestampSequenceFromSpec(spec),
-								gen.NewIntegerArrayValuesSequence([]int64{1, 2, 3, 4}),
-							)
-						},
-					},
-				},
-			},
-		}
-		tr := gen.TimeRange{
-			Start: mustParseTime("2019-11-25T00:00:00Z"),
-			End:   mustParseTime("2019-11-25T00:01:00Z"),
-		}
-		return gen.NewSeriesGeneratorFromSpec(&spec, tr), tr
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0, 5.0, 6.0}),
+				TagValuesSequence("t0", "a-%s", 0, 2),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
 	})
 	defer reader.Close()
 
+	dest := reader.Bucket + 1
+	sr := newMaterializeReader(reader)
+
 	mem := &memory.Allocator{}
-	ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+	ti, err := sr.ReadWindowAggregate(materializeContext(true), query.ReadWindowAggregateSpec{
 		ReadFilterSpec: query.ReadFilterSpec{
 			OrganizationID: reader.Org,
 			BucketID:       reader.Bucket,
 			Bounds:         reader.Bounds,
 		},
-		WindowEvery: int64(10 * time.Second),
-		Offset:      int64(2 * time.Second),
+		WindowEvery: int64(30 * time.Second),
 		Aggregates: []plan.ProcedureKind{
-			storageflux.SumKind,
+			storageflux.MeanKind,
 		},
+		TimeColumn:    execute.DefaultStopColLabel,
+		MaterializeTo: dest,
 	}, mem)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	makeWindowTable := func(start, stop values.Time, v int64) *executetest.Table {
+	// The aggregated windows are still produced for the caller.
+	var rows int
+	if err := ti.Do(func(table flux.Table) error {
+		return table.Do(func(cr flux.ColReader) error {
+			rows += cr.Len()
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := 4; rows != want {
+		t.Errorf("unexpected number of rows -want/+got:\n\t- %d\n\t+ %d", want, rows)
+	}
+
+	ti, err = reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       dest,
+		Bounds:         execute.Bounds{Start: reader.Bounds.Start, Stop: Time("2019-11-25T00:01:01Z")},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	makeTable := func(t0 string) *executetest.Table {
+		start, stop := reader.Bounds.Start, Time("2019-11-25T00:01:01Z")
 		return &executetest.Table{
 			KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
 			ColMeta: []flux.ColMeta{
 				{Label: "_start", Type: flux.TTime},
 				{Label: "_stop", Type: flux.TTime},
-				{Label: "_value", Type: flux.TInt},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TFloat},
 				{Label: "_field", Type: flux.TString},
 				{Label: "_measurement", Type: flux.TString},
 				{Label: "t0", Type: flux.TString},
 			},
 			Data: [][]interface{}{
-				{start, stop, v, "f0", "m0", "a0"},
+				{start, stop, Time("2019-11-25T00:00:30Z"), 2.0, "f0", "m0", t0},
+				{start, stop, Time("2019-11-25T00:01:00Z"), 5.0, "f0", "m0", t0},
 			},
 		}
 	}
 	want := []*executetest.Table{
-		makeWindowTable(Time("2019-11-25T00:00:00Z"), Time("2019-11-25T00:00:02Z"), 1),
-		makeWindowTable(Time("2019-11-25T00:00:02Z"), Time("2019-11-25T00:00:12Z"), 5),
-		makeWindowTable(Time("2019-11-25T00:00:12Z"), Time("2019-11-25T00:00:22Z"), 5),
-		makeWindowTable(Time("2019-11-25T00:00:22Z"), Time("2019-11-25T00:00:32Z"), 5),
-		makeWindowTable(Time("2019-11-25T00:00:32Z"), Time("2019-11-25T00:00:42Z"), 5),
-		makeWindowTable(Time("2019-11-25T00:00:42Z"), Time("2019-11-25T00:00:52Z"), 5),
-		makeWindowTable(Time("2019-11-25T00:00:52Z"), Time("2019-11-25T00:01:00Z"), 4),
+		makeTable("a-0"),
+		makeTable("a-1"),
 	}
-
 	executetest.NormalizeTables(want)
 	sort.Sort(executetest.SortedTables(want))
 
@@ -1701,57 +5768,172 @@ func TestStorageReader_WindowSumOffset(t *testing.T) {
 	}); err != nil {
 		t.Fatal(err)
 	}
-	executetest.NormalizeTables(got)
-	sort.Sort(executetest.SortedTables(got))
+	executetest.NormalizeTables(got)
+	sort.Sort(executetest.SortedTables(got))
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+func TestStorageReader_ReadWindowMean_MaterializeToWindowBounds(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0, 5.0, 6.0}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	dest := reader.Bucket + 1
+	sr := newMaterializeReader(reader)
+
+	mem := &memory.Allocator{}
+	ti, err := sr.ReadWindowAggregate(materializeContext(true), query.ReadWindowAggregateSpec{
+		ReadFilterSpec: query.ReadFilterSpec{
+			OrganizationID: reader.Org,
+			BucketID:       reader.Bucket,
+			Bounds:         reader.Bounds,
+		},
+		WindowBounds: []execute.Bounds{
+			{Start: Time("2019-11-25T00:00:00Z"), Stop: Time("2019-11-25T00:00:20Z")},
+			{Start: Time("2019-11-25T00:00:30Z"), Stop: Time("2019-11-25T00:01:00Z")},
+		},
+		Aggregates: []plan.ProcedureKind{
+			storageflux.MeanKind,
+		},
+		TimeColumn:    execute.DefaultStopColLabel,
+		MaterializeTo: dest,
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ti.Do(func(table flux.Table) error {
+		table.Done()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
 
-	// compare these two
-	if diff := cmp.Diff(want, got); diff != "" {
+	// Each window is materialized once with the time of its stop.
+	ti, err = reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+		OrganizationID: reader.Org,
+		BucketID:       dest,
+		Bounds:         execute.Bounds{Start: reader.Bounds.Start, Stop: Time("2019-11-25T00:01:01Z")},
+	}, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := static.TableGroup{
+		static.StringKey("_measurement", "m0"),
+		static.StringKey("_field", "f0"),
+		static.StringKey("t0", "a-0"),
+		static.TimeKey("_start", "2019-11-25T00:00:00Z"),
+		static.TimeKey("_stop", "2019-11-25T00:01:01Z"),
+		static.Table{
+			static.Times("_time", "2019-11-25T00:00:20Z", 40),
+			static.Floats("_value", 1.5, 5),
+		},
+	}
+	if diff := table.Diff(want, ti); diff != "" {
 		t.Errorf("unexpected results -want/+got:\n%s", diff)
 	}
 }
-
-func TestStorageReader_ReadWindowFirstCreateEmpty(t *testing.T) {
+func TestStorageReader_ReadWindowMean_MaterializeToRejected(t *testing.T) {
 	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
-		tagsSpec := &gen.TagsSpec{
-			Tags: []*gen.TagValuesSpec{
-				{
-					TagKey: "t0",
-					Values: func() gen.CountableSequence {
-						return gen.NewCounterByteSequence("a%s", 0, 1)
-					},
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:00:30Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	sr := newMaterializeReader(reader)
+	for _, tt := range []struct {
+		name    string
+		ctx     context.Context
+		orgID   influxdb.ID
+		errCode string
+	}{
+		{
+			name:    "bucket of another org",
+			ctx:     materializeContext(true),
+			orgID:   reader.Org + 100,
+			errCode: influxdb.ENotFound,
+		},
+		{
+			name:    "not permitted to write",
+			ctx:     materializeContext(false),
+			orgID:   reader.Org,
+			errCode: influxdb.EUnauthorized,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ti, err := sr.ReadWindowAggregate(tt.ctx, query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: tt.orgID,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
 				},
-			},
-		}
-		spec := gen.Spec{
-			OrgID:    org,
-			BucketID: bucket,
-			Measurements: []gen.MeasurementSpec{
-				{
-					Name:     "m0",
-					TagsSpec: tagsSpec,
-					FieldValuesSpec: &gen.FieldValuesSpec{
-						Name: "f0",
-						TimeSequenceSpec: gen.TimeSequenceSpec{
-							Count: math.MaxInt32,
-							Delta: 20 * time.Second,
-						},
-						DataType: models.Integer,
-						Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
-							return gen.NewTimeIntegerValuesSequence(
-								spec.Count,
-								gen.NewTimestampSequenceFromSpec(spec),
-								gen.NewIntegerArrayValuesSequence([]int64{1, 2}),
-							)
-						},
-					},
+				WindowEvery: int64(30 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					storageflux.MeanKind,
 				},
-			},
-		}
-		tr := gen.TimeRange{
-			Start: mustParseTime("2019-11-25T00:00:00Z"),
-			End:   mustParseTime("2019-11-25T00:01:00Z"),
-		}
-		return gen.NewSeriesGeneratorFromSpec(&spec, tr), tr
+				MaterializeTo: reader.Bucket + 1,
+			}, &memory.Allocator{})
+			if err == nil {
+				err = ti.Do(func(table flux.Table) error {
+					table.Done()
+					return nil
+				})
+			}
+			if got := influxdb.ErrorCode(err); got != tt.errCode {
+				t.Fatalf("unexpected error code -want/+got:\n\t- %q\n\t+ %q (%v)", tt.errCode, got, err)
+			}
+		})
+	}
+}
+
+// newMaterializeReader returns a reader of the store of reader that
+// materializes windows to the buckets of its organization.
+func newMaterializeReader(reader *StorageReader) query.StorageReader {
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+		return &influxdb.Bucket{ID: id, OrgID: reader.Org}, nil
+	}
+	return storageflux.NewReader(reader.Store, storageflux.WithPointsWriter(reader.Viewer.(storage.PointsWriter), buckets))
+}
+
+// materializeContext returns a context with an authorizer that is
+// permitted to write to every bucket if allowed is true and to none
+// of them otherwise.
+func materializeContext(allowed bool) context.Context {
+	return icontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(allowed, nil))
+}
+
+func TestStorageReader_ReadWindowMean_Pivot(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				FloatArrayValuesSequence("f0", 10*time.Second, []float64{1.0, 2.0, 3.0, 4.0, 5.0, 6.0}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f1", 10*time.Second, []int64{10, 20, 30, 40, 50, 60}),
+				TagValuesSequence("t0", "a-%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
 	})
 	defer reader.Close()
 
@@ -1762,72 +5944,37 @@ func TestStorageReader_ReadWindowFirstCreateEmpty(t *testing.T) {
 			BucketID:       reader.Bucket,
 			Bounds:         reader.Bounds,
 		},
-		WindowEvery: int64(10 * time.Second),
+		WindowEvery: int64(30 * time.Second),
 		Aggregates: []plan.ProcedureKind{
-			storageflux.FirstKind,
+			storageflux.MeanKind,
 		},
-		CreateEmpty: true,
+		TimeColumn: execute.DefaultStopColLabel,
+		Pivot:      true,
 	}, mem)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	makeEmptyTable := func(start, stop values.Time) *executetest.Table {
-		return &executetest.Table{
-			KeyCols:   []string{"_start", "_stop", "_field", "_measurement", "t0"},
-			KeyValues: []interface{}{start, stop, "f0", "m0", "a0"},
+	start, stop := reader.Bounds.Start, reader.Bounds.Stop
+	want := []*executetest.Table{
+		{
+			KeyCols: []string{"_start", "_stop", "_measurement", "t0"},
 			ColMeta: []flux.ColMeta{
 				{Label: "_start", Type: flux.TTime},
 				{Label: "_stop", Type: flux.TTime},
-				{Label: "_time", Type: flux.TTime},
-				{Label: "_value", Type: flux.TInt},
-				{Label: "_field", Type: flux.TString},
 				{Label: "_measurement", Type: flux.TString},
 				{Label: "t0", Type: flux.TString},
-			},
-			Data: nil,
-		}
-	}
-	makeWindowTable := func(start, stop, time values.Time, v int64) *executetest.Table {
-		return &executetest.Table{
-			KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
-			ColMeta: []flux.ColMeta{
-				{Label: "_start", Type: flux.TTime},
-				{Label: "_stop", Type: flux.TTime},
 				{Label: "_time", Type: flux.TTime},
-				{Label: "_value", Type: flux.TInt},
-				{Label: "_field", Type: flux.TString},
-				{Label: "_measurement", Type: flux.TString},
-				{Label: "t0", Type: flux.TString},
+				{Label: "f0", Type: flux.TFloat},
+				{Label: "f1", Type: flux.TFloat},
 			},
 			Data: [][]interface{}{
-				{start, stop, time, v, "f0", "m0", "a0"},
+				{start, stop, "m0", "a-0", Time("2019-11-25T00:00:30Z"), 2.0, 20.0},
+				{start, stop, "m0", "a-0", Time("2019-11-25T00:01:00Z"), 5.0, 50.0},
 			},
-		}
-	}
-	want := []*executetest.Table{
-		makeWindowTable(
-			Time("2019-11-25T00:00:00Z"), Time("2019-11-25T00:00:10Z"), Time("2019-11-25T00:00:00Z"), 1,
-		),
-		makeEmptyTable(
-			Time("2019-11-25T00:00:10Z"), Time("2019-11-25T00:00:20Z"),
-		),
-		makeWindowTable(
-			Time("2019-11-25T00:00:20Z"), Time("2019-11-25T00:00:30Z"), Time("2019-11-25T00:00:20Z"), 2,
-		),
-		makeEmptyTable(
-			Time("2019-11-25T00:00:30Z"), Time("2019-11-25T00:00:40Z"),
-		),
-		makeWindowTable(
-			Time("2019-11-25T00:00:40Z"), Time("2019-11-25T00:00:50Z"), Time("2019-11-25T00:00:40Z"), 1,
-		),
-		makeEmptyTable(
-			Time("2019-11-25T00:00:50Z"), Time("2019-11-25T00:01:00Z"),
-		),
+		},
 	}
-
 	executetest.NormalizeTables(want)
-	sort.Sort(executetest.SortedTables(want))
 
 	var got []*executetest.Table
 	if err := ti.Do(func(table flux.Table) error {
@@ -1841,9 +5988,7 @@ func TestStorageReader_ReadWindowFirstCreateEmpty(t *testing.T) {
 		t.Fatal(err)
 	}
 	executetest.NormalizeTables(got)
-	sort.Sort(executetest.SortedTables(got))
 
-	// compare these two
 	if diff := cmp.Diff(want, got); diff != "" {
 		t.Errorf("unexpected results -want/+got:\n%s", diff)
 	}
@@ -2430,27 +6575,248 @@ func TestStorageReader_WindowSumOffsetTimeColumn(t *testing.T) {
 		},
 	}}
 
-	executetest.NormalizeTables(want)
-	sort.Sort(executetest.SortedTables(want))
+	executetest.NormalizeTables(want)
+	sort.Sort(executetest.SortedTables(want))
+
+	var got []*executetest.Table
+	if err := ti.Do(func(table flux.Table) error {
+		t, err := executetest.ConvertTable(table)
+		if err != nil {
+			return err
+		}
+		got = append(got, t)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	executetest.NormalizeTables(got)
+	sort.Sort(executetest.SortedTables(got))
+
+	// compare these two
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected results -want/+got:\n%s", diff)
+	}
+}
+
+// Windows without any points must produce null for sum and mean
+// rather than zero, regardless of CreateEmpty.
+func TestStorageReader_ReadWindowAggregate_GappySumMean(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 20*time.Second, []int64{0, 2}),
+				TagValuesSequence("t0", "a%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	makeTable := func(typ flux.ColType, rows ...[]interface{}) []*executetest.Table {
+		start, stop := reader.Bounds.Start, reader.Bounds.Stop
+		data := make([][]interface{}, len(rows))
+		for i, row := range rows {
+			data[i] = []interface{}{start, stop, row[0], row[1], "f0", "m0", "a0"}
+		}
+		return []*executetest.Table{{
+			KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: typ},
+				{Label: "_field", Type: flux.TString},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "t0", Type: flux.TString},
+			},
+			Data: data,
+		}}
+	}
+
+	for _, tt := range []struct {
+		name        string
+		aggregate   plan.ProcedureKind
+		createEmpty bool
+		want        []*executetest.Table
+	}{
+		{
+			name:        "sum",
+			aggregate:   storageflux.SumKind,
+			createEmpty: true,
+			want: makeTable(flux.TInt,
+				[]interface{}{Time("2019-11-25T00:00:10Z"), int64(0)},
+				[]interface{}{Time("2019-11-25T00:00:20Z"), nil},
+				[]interface{}{Time("2019-11-25T00:00:30Z"), int64(2)},
+				[]interface{}{Time("2019-11-25T00:00:40Z"), nil},
+				[]interface{}{Time("2019-11-25T00:00:50Z"), int64(0)},
+				[]interface{}{Time("2019-11-25T00:01:00Z"), nil},
+			),
+		},
+		{
+			name:      "sum without empty windows",
+			aggregate: storageflux.SumKind,
+			want: makeTable(flux.TInt,
+				[]interface{}{Time("2019-11-25T00:00:10Z"), int64(0)},
+				[]interface{}{Time("2019-11-25T00:00:30Z"), int64(2)},
+				[]interface{}{Time("2019-11-25T00:00:50Z"), int64(0)},
+			),
+		},
+		{
+			name:        "mean",
+			aggregate:   storageflux.MeanKind,
+			createEmpty: true,
+			want: makeTable(flux.TFloat,
+				[]interface{}{Time("2019-11-25T00:00:10Z"), 0.0},
+				[]interface{}{Time("2019-11-25T00:00:20Z"), nil},
+				[]interface{}{Time("2019-11-25T00:00:30Z"), 2.0},
+				[]interface{}{Time("2019-11-25T00:00:40Z"), nil},
+				[]interface{}{Time("2019-11-25T00:00:50Z"), 0.0},
+				[]interface{}{Time("2019-11-25T00:01:00Z"), nil},
+			),
+		},
+		{
+			name:      "mean without empty windows",
+			aggregate: storageflux.MeanKind,
+			want: makeTable(flux.TFloat,
+				[]interface{}{Time("2019-11-25T00:00:10Z"), 0.0},
+				[]interface{}{Time("2019-11-25T00:00:30Z"), 2.0},
+				[]interface{}{Time("2019-11-25T00:00:50Z"), 0.0},
+			),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := &memory.Allocator{}
+			ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+				ReadFilterSpec: query.ReadFilterSpec{
+					OrganizationID: reader.Org,
+					BucketID:       reader.Bucket,
+					Bounds:         reader.Bounds,
+				},
+				WindowEvery: int64(10 * time.Second),
+				Aggregates: []plan.ProcedureKind{
+					tt.aggregate,
+				},
+				CreateEmpty: tt.createEmpty,
+				TimeColumn:  execute.DefaultStopColLabel,
+			}, mem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*executetest.Table
+			if err := ti.Do(func(table flux.Table) error {
+				t, err := executetest.ConvertTable(table)
+				if err != nil {
+					return err
+				}
+				got = append(got, t)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			executetest.NormalizeTables(got)
+			executetest.NormalizeTables(tt.want)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected results -want/+got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStorageReader_ReadWindowAggregate_IntegerSumOverflow(t *testing.T) {
+	reader := NewStorageReader(t, func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+		spec := Spec(org, bucket,
+			MeasurementSpec("m0",
+				IntegerArrayValuesSequence("f0", 10*time.Second, []int64{math.MaxInt64 - 1, 2}),
+				TagValuesSequence("t0", "a%s", 0, 1),
+			),
+		)
+		tr := TimeRange("2019-11-25T00:00:00Z", "2019-11-25T00:01:00Z")
+		return gen.NewSeriesGeneratorFromSpec(spec, tr), tr
+	})
+	defer reader.Close()
+
+	readWindowSum := func(allowOverflow bool) ([]*executetest.Table, error) {
+		mem := &memory.Allocator{}
+		ti, err := reader.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+			ReadFilterSpec: query.ReadFilterSpec{
+				OrganizationID: reader.Org,
+				BucketID:       reader.Bucket,
+				Bounds:         reader.Bounds,
+			},
+			WindowEvery: int64(20 * time.Second),
+			Aggregates: []plan.ProcedureKind{
+				storageflux.SumKind,
+			},
+			TimeColumn:           execute.DefaultStopColLabel,
+			AllowIntegerOverflow: allowOverflow,
+		}, mem)
+		if err != nil {
+			return nil, err
+		}
+
+		var got []*executetest.Table
+		if err := ti.Do(func(table flux.Table) error {
+			t, err := executetest.ConvertTable(table)
+			if err != nil {
+				return err
+			}
+			got = append(got, t)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		return got, nil
+	}
 
-	var got []*executetest.Table
-	if err := ti.Do(func(table flux.Table) error {
-		t, err := executetest.ConvertTable(table)
+	t.Run("error", func(t *testing.T) {
+		_, err := readWindowSum(false)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if got, want := err.Error(), "integer overflow in sum for series"; !strings.Contains(got, want) {
+			t.Errorf("unexpected error -want/+got:\n\t- %q\n\t+ %q", want, got)
+		}
+		if got, want := err.Error(), "2019-11-25T00:00:00Z, 2019-11-25T00:00:20Z"; !strings.Contains(got, want) {
+			t.Errorf("expected error to identify the window -want/+got:\n\t- %q\n\t+ %q", want, got)
+		}
+	})
+
+	t.Run("allow overflow", func(t *testing.T) {
+		got, err := readWindowSum(true)
 		if err != nil {
-			return err
+			t.Fatal(err)
 		}
-		got = append(got, t)
-		return nil
-	}); err != nil {
-		t.Fatal(err)
-	}
-	executetest.NormalizeTables(got)
-	sort.Sort(executetest.SortedTables(got))
 
-	// compare these two
-	if diff := cmp.Diff(want, got); diff != "" {
-		t.Errorf("unexpected results -want/+got:\n%s", diff)
-	}
+		// (math.MaxInt64 - 1) + 2 wraps around to math.MinInt64.
+		var wrapped int64 = math.MinInt64
+		start, stop := reader.Bounds.Start, reader.Bounds.Stop
+		want := []*executetest.Table{{
+			KeyCols: []string{"_start", "_stop", "_field", "_measurement", "t0"},
+			ColMeta: []flux.ColMeta{
+				{Label: "_start", Type: flux.TTime},
+				{Label: "_stop", Type: flux.TTime},
+				{Label: "_time", Type: flux.TTime},
+				{Label: "_value", Type: flux.TInt},
+				{Label: "_field", Type: flux.TString},
+				{Label: "_measurement", Type: flux.TString},
+				{Label: "t0", Type: flux.TString},
+			},
+			Data: [][]interface{}{
+				{start, stop, Time("2019-11-25T00:00:20Z"), wrapped, "f0", "m0", "a0"},
+				{start, stop, Time("2019-11-25T00:00:40Z"), wrapped, "f0", "m0", "a0"},
+				{start, stop, Time("2019-11-25T00:01:00Z"), wrapped, "f0", "m0", "a0"},
+			},
+		}}
+		executetest.NormalizeTables(got)
+		executetest.NormalizeTables(want)
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected results -want/+got:\n%s", diff)
+		}
+	})
 }
 
 func TestStorageReader_EmptyTableNoEmptyWindows(t *testing.T) {
@@ -2565,101 +6931,193 @@ func TestStorageReader_EmptyTableNoEmptyWindows(t *testing.T) {
 }
 
 func BenchmarkReadFilter(b *testing.B) {
-	setupFn := func(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
-		tagsSpec := &gen.TagsSpec{
-			Tags: []*gen.TagValuesSpec{
-				{
-					TagKey: "t0",
-					Values: func() gen.CountableSequence {
-						return gen.NewCounterByteSequence("a-%s", 0, 5)
-					},
-				},
-				{
-					TagKey: "t1",
-					Values: func() gen.CountableSequence {
-						return gen.NewCounterByteSequence("b-%s", 0, 1000)
-					},
-				},
-			},
+	benchmarkRead(b, setupReadFilterBenchmark, func(r *StorageReader) error {
+		mem := &memory.Allocator{}
+		tables, err := r.ReadFilter(context.Background(), query.ReadFilterSpec{
+			OrganizationID: r.Org,
+			BucketID:       r.Bucket,
+			Bounds:         r.Bounds,
+		}, mem)
+		if err != nil {
+			return err
 		}
-		spec := gen.Spec{
-			OrgID:    org,
-			BucketID: bucket,
-			Measurements: []gen.MeasurementSpec{
-				{
-					Name:     "m0",
-					TagsSpec: tagsSpec,
-					FieldValuesSpec: &gen.FieldValuesSpec{
-						Name: "f0",
-						TimeSequenceSpec: gen.TimeSequenceSpec{
-							Count: math.MaxInt32,
-							Delta: time.Minute,
-						},
-						DataType: models.Float,
-						Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
-							r := rand.New(rand.NewSource(10))
-							return gen.NewTimeFloatValuesSequence(
-								spec.Count,
-								gen.NewTimestampSequenceFromSpec(spec),
-								gen.NewFloatRandomValuesSequence(0, 90, r),
-							)
-						},
-					},
-				},
-				{
-					Name:     "m0",
-					TagsSpec: tagsSpec,
-					FieldValuesSpec: &gen.FieldValuesSpec{
-						Name: "f1",
-						TimeSequenceSpec: gen.TimeSequenceSpec{
-							Count: math.MaxInt32,
-							Delta: time.Minute,
-						},
-						DataType: models.Float,
-						Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
-							r := rand.New(rand.NewSource(11))
-							return gen.NewTimeFloatValuesSequence(
-								spec.Count,
-								gen.NewTimestampSequenceFromSpec(spec),
-								gen.NewFloatRandomValuesSequence(0, 180, r),
-							)
-						},
-					},
-				},
-				{
-					Name:     "m0",
-					TagsSpec: tagsSpec,
-					FieldValuesSpec: &gen.FieldValuesSpec{
-						Name: "f1",
-						TimeSequenceSpec: gen.TimeSequenceSpec{
-							Count: math.MaxInt32,
-							Delta: time.Minute,
+		return tables.Do(func(table flux.Table) error {
+			table.Done()
+			return nil
+		})
+	})
+}
+
+func BenchmarkReadFilter_Parallel(b *testing.B) {
+	for _, n := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("parallelism=%d", n), func(b *testing.B) {
+			benchmarkRead(b, setupReadFilterBenchmark, func(r *StorageReader) error {
+				mem := &memory.Allocator{}
+				reader := storageflux.NewReader(r.Store, storageflux.WithReadParallelism(n))
+				tables, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+					OrganizationID: r.Org,
+					BucketID:       r.Bucket,
+					Bounds:         r.Bounds,
+				}, mem)
+				if err != nil {
+					return err
+				}
+				return tables.Do(func(table flux.Table) error {
+					return table.Do(func(flux.ColReader) error {
+						return nil
+					})
+				})
+			})
+		})
+	}
+}
+
+// BenchmarkReadFilter_DecodeParallelism compares decoding the blocks
+// of each series as they are read with decoding them ahead in a pool.
+func BenchmarkReadFilter_DecodeParallelism(b *testing.B) {
+	for _, n := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("decode_parallelism=%d", n), func(b *testing.B) {
+			benchmarkRead(b, setupReadFilterBenchmark, func(r *StorageReader) error {
+				mem := &memory.Allocator{}
+				reader := storageflux.NewReader(r.Store, storageflux.WithDecodeParallelism(n))
+				tables, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+					OrganizationID: r.Org,
+					BucketID:       r.Bucket,
+					Bounds:         r.Bounds,
+				}, mem)
+				if err != nil {
+					return err
+				}
+				return tables.Do(func(table flux.Table) error {
+					return table.Do(func(flux.ColReader) error {
+						return nil
+					})
+				})
+			})
+		})
+	}
+}
+
+// BenchmarkReadFilter_RegexPredicate reads the series matching a
+// selective regular expression on the tag with 1000 values.
+func BenchmarkReadFilter_RegexPredicate(b *testing.B) {
+	for _, pattern := range []string{
+		"^b-00[0-9]$",
+		"^(b-001|b-500|b-999)$",
+		"^b-00",
+	} {
+		b.Run(pattern, func(b *testing.B) {
+			predicate := &datatypes.Predicate{
+				Root: &datatypes.Node{
+					NodeType: datatypes.NodeTypeComparisonExpression,
+					Value:    &datatypes.Node_Comparison_{Comparison: datatypes.ComparisonRegex},
+					Children: []*datatypes.Node{
+						{
+							NodeType: datatypes.NodeTypeTagRef,
+							Value:    &datatypes.Node_TagRefValue{TagRefValue: "t1"},
 						},
-						DataType: models.Float,
-						Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
-							r := rand.New(rand.NewSource(12))
-							return gen.NewTimeFloatValuesSequence(
-								spec.Count,
-								gen.NewTimestampSequenceFromSpec(spec),
-								gen.NewFloatRandomValuesSequence(10, 10000, r),
-							)
+						{
+							NodeType: datatypes.NodeTypeLiteral,
+							Value:    &datatypes.Node_RegexValue{RegexValue: pattern},
 						},
 					},
 				},
-			},
-		}
-		tr := gen.TimeRange{
-			Start: mustParseTime("2019-11-25T00:00:00Z"),
-			End:   mustParseTime("2019-11-26T00:00:00Z"),
-		}
-		return gen.NewSeriesGeneratorFromSpec(&spec, tr), tr
+			}
+			benchmarkRead(b, setupReadFilterBenchmark, func(r *StorageReader) error {
+				mem := &memory.Allocator{}
+				tables, err := r.ReadFilter(context.Background(), query.ReadFilterSpec{
+					OrganizationID: r.Org,
+					BucketID:       r.Bucket,
+					Bounds:         r.Bounds,
+					Predicate:      predicate,
+				}, mem)
+				if err != nil {
+					return err
+				}
+				return tables.Do(func(table flux.Table) error {
+					return table.Do(func(flux.ColReader) error {
+						return nil
+					})
+				})
+			})
+		})
+	}
+}
+
+// BenchmarkReadFilter_Unsorted compares producing the tables of a
+// parallel read in order with producing them as they are ready.
+func BenchmarkReadFilter_Unsorted(b *testing.B) {
+	for _, unsorted := range []bool{false, true} {
+		b.Run(fmt.Sprintf("unsorted=%t", unsorted), func(b *testing.B) {
+			benchmarkRead(b, setupReadFilterBenchmark, func(r *StorageReader) error {
+				mem := &memory.Allocator{}
+				reader := storageflux.NewReader(r.Store, storageflux.WithReadParallelism(4))
+				tables, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+					OrganizationID: r.Org,
+					BucketID:       r.Bucket,
+					Bounds:         r.Bounds,
+					Unsorted:       unsorted,
+				}, mem)
+				if err != nil {
+					return err
+				}
+				return tables.Do(func(table flux.Table) error {
+					return table.Do(func(flux.ColReader) error {
+						return nil
+					})
+				})
+			})
+		})
+	}
+}
+
+// BenchmarkReadFilter_MaxOpenCursors reports the peak memory allocated
+// by a parallel read when the store limits the number of open cursors.
+func BenchmarkReadFilter_MaxOpenCursors(b *testing.B) {
+	for _, limit := range []int{0, 1, 2, 4} {
+		b.Run(fmt.Sprintf("max_open_cursors=%d", limit), func(b *testing.B) {
+			var peak int64
+			benchmarkRead(b, setupReadFilterBenchmark, func(r *StorageReader) error {
+				mem := &memory.Allocator{}
+				store := readservice.NewStore(r.Viewer, readservice.WithMaxOpenCursors(limit))
+				reader := storageflux.NewReader(store, storageflux.WithReadParallelism(8))
+				tables, err := reader.ReadFilter(context.Background(), query.ReadFilterSpec{
+					OrganizationID: r.Org,
+					BucketID:       r.Bucket,
+					Bounds:         r.Bounds,
+				}, mem)
+				if err != nil {
+					return err
+				}
+				if err := tables.Do(func(table flux.Table) error {
+					return table.Do(func(flux.ColReader) error {
+						return nil
+					})
+				}); err != nil {
+					return err
+				}
+				if n := mem.MaxAllocated(); n > peak {
+					peak = n
+				}
+				return nil
+			})
+			b.ReportMetric(float64(peak), "peak-bytes")
+		})
 	}
-	benchmarkRead(b, setupFn, func(r *StorageReader) error {
+}
+
+// BenchmarkReadFilter_EmptyRange reads a range with no data, which
+// should return without creating any cursors.
+func BenchmarkReadFilter_EmptyRange(b *testing.B) {
+	benchmarkRead(b, setupReadFilterBenchmark, func(r *StorageReader) error {
 		mem := &memory.Allocator{}
 		tables, err := r.ReadFilter(context.Background(), query.ReadFilterSpec{
 			OrganizationID: r.Org,
 			BucketID:       r.Bucket,
-			Bounds:         r.Bounds,
+			Bounds: execute.Bounds{
+				Start: r.Bounds.Start - execute.Time(24*time.Hour),
+				Stop:  r.Bounds.Start - execute.Time(time.Hour),
+			},
 		}, mem)
 		if err != nil {
 			return err
@@ -2671,6 +7129,130 @@ func BenchmarkReadFilter(b *testing.B) {
 	})
 }
 
+// BenchmarkReadWindowAggregate compares count, which only
+// decodes timestamps, with sum, which must also decode values.
+func BenchmarkReadWindowAggregate(b *testing.B) {
+	for _, aggregate := range []plan.ProcedureKind{
+		storageflux.CountKind,
+		storageflux.SumKind,
+	} {
+		b.Run(string(aggregate), func(b *testing.B) {
+			benchmarkRead(b, setupReadFilterBenchmark, func(r *StorageReader) error {
+				mem := &memory.Allocator{}
+				tables, err := r.ReadWindowAggregate(context.Background(), query.ReadWindowAggregateSpec{
+					ReadFilterSpec: query.ReadFilterSpec{
+						OrganizationID: r.Org,
+						BucketID:       r.Bucket,
+						Bounds:         r.Bounds,
+					},
+					TimeColumn:  execute.DefaultStopColLabel,
+					WindowEvery: int64(time.Hour),
+					Aggregates: []plan.ProcedureKind{
+						aggregate,
+					},
+				}, mem)
+				if err != nil {
+					return err
+				}
+				return tables.Do(func(table flux.Table) error {
+					table.Done()
+					return nil
+				})
+			})
+		})
+	}
+}
+
+func setupReadFilterBenchmark(org, bucket influxdb.ID) (gen.SeriesGenerator, gen.TimeRange) {
+	tagsSpec := &gen.TagsSpec{
+		Tags: []*gen.TagValuesSpec{
+			{
+				TagKey: "t0",
+				Values: func() gen.CountableSequence {
+					return gen.NewCounterByteSequence("a-%s", 0, 5)
+				},
+			},
+			{
+				TagKey: "t1",
+				Values: func() gen.CountableSequence {
+					return gen.NewCounterByteSequence("b-%s", 0, 1000)
+				},
+			},
+		},
+	}
+	spec := gen.Spec{
+		OrgID:    org,
+		BucketID: bucket,
+		Measurements: []gen.MeasurementSpec{
+			{
+				Name:     "m0",
+				TagsSpec: tagsSpec,
+				FieldValuesSpec: &gen.FieldValuesSpec{
+					Name: "f0",
+					TimeSequenceSpec: gen.TimeSequenceSpec{
+						Count: math.MaxInt32,
+						Delta: time.Minute,
+					},
+					DataType: models.Float,
+					Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
+						r := rand.New(rand.NewSource(10))
+						return gen.NewTimeFloatValuesSequence(
+							spec.Count,
+							gen.NewTimestampSequenceFromSpec(spec),
+							gen.NewFloatRandomValuesSequence(0, 90, r),
+						)
+					},
+				},
+			},
+			{
+				Name:     "m0",
+				TagsSpec: tagsSpec,
+				FieldValuesSpec: &gen.FieldValuesSpec{
+					Name: "f1",
+					TimeSequenceSpec: gen.TimeSequenceSpec{
+						Count: math.MaxInt32,
+						Delta: time.Minute,
+					},
+					DataType: models.Float,
+					Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
+						r := rand.New(rand.NewSource(11))
+						return gen.NewTimeFloatValuesSequence(
+							spec.Count,
+							gen.NewTimestampSequenceFromSpec(spec),
+							gen.NewFloatRandomValuesSequence(0, 180, r),
+						)
+					},
+				},
+			},
+			{
+				Name:     "m0",
+				TagsSpec: tagsSpec,
+				FieldValuesSpec: &gen.FieldValuesSpec{
+					Name: "f1",
+					TimeSequenceSpec: gen.TimeSequenceSpec{
+						Count: math.MaxInt32,
+						Delta: time.Minute,
+					},
+					DataType: models.Float,
+					Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
+						r := rand.New(rand.NewSource(12))
+						return gen.NewTimeFloatValuesSequence(
+							spec.Count,
+							gen.NewTimestampSequenceFromSpec(spec),
+							gen.NewFloatRandomValuesSequence(10, 10000, r),
+						)
+					},
+				},
+			},
+		},
+	}
+	tr := gen.TimeRange{
+		Start: mustParseTime("2019-11-25T00:00:00Z"),
+		End:   mustParseTime("2019-11-26T00:00:00Z"),
+	}
+	return gen.NewSeriesGeneratorFromSpec(&spec, tr), tr
+}
+
 func benchmarkRead(b *testing.B, setupFn SetupFunc, f func(r *StorageReader) error) {
 	reader := NewStorageReader(b, setupFn)
 	defer reader.Close()
@@ -2731,6 +7313,78 @@ func FloatArrayValuesSequence(name string, delta time.Duration, values []float64
 	}
 }
 
+func IntegerArrayValuesSequence(name string, delta time.Duration, values []int64) *gen.FieldValuesSpec {
+	return &gen.FieldValuesSpec{
+		Name: name,
+		TimeSequenceSpec: gen.TimeSequenceSpec{
+			Count: math.MaxInt32,
+			Delta: delta,
+		},
+		DataType: models.Integer,
+		Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
+			return gen.NewTimeIntegerValuesSequence(
+				spec.Count,
+				gen.NewTimestampSequenceFromSpec(spec),
+				gen.NewIntegerArrayValuesSequence(values),
+			)
+		},
+	}
+}
+
+func UnsignedArrayValuesSequence(name string, delta time.Duration, values []uint64) *gen.FieldValuesSpec {
+	return &gen.FieldValuesSpec{
+		Name: name,
+		TimeSequenceSpec: gen.TimeSequenceSpec{
+			Count: math.MaxInt32,
+			Delta: delta,
+		},
+		DataType: models.Unsigned,
+		Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
+			return gen.NewTimeUnsignedValuesSequence(
+				spec.Count,
+				gen.NewTimestampSequenceFromSpec(spec),
+				gen.NewUnsignedArrayValuesSequence(values),
+			)
+		},
+	}
+}
+
+func BooleanArrayValuesSequence(name string, delta time.Duration, values []bool) *gen.FieldValuesSpec {
+	return &gen.FieldValuesSpec{
+		Name: name,
+		TimeSequenceSpec: gen.TimeSequenceSpec{
+			Count: math.MaxInt32,
+			Delta: delta,
+		},
+		DataType: models.Boolean,
+		Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
+			return gen.NewTimeBooleanValuesSequence(
+				spec.Count,
+				gen.NewTimestampSequenceFromSpec(spec),
+				gen.NewBooleanArrayValuesSequence(values),
+			)
+		},
+	}
+}
+
+func StringArrayValuesSequence(name string, delta time.Duration, values []string) *gen.FieldValuesSpec {
+	return &gen.FieldValuesSpec{
+		Name: name,
+		TimeSequenceSpec: gen.TimeSequenceSpec{
+			Count: math.MaxInt32,
+			Delta: delta,
+		},
+		DataType: models.String,
+		Values: func(spec gen.TimeSequenceSpec) gen.TimeValuesSequence {
+			return gen.NewTimeStringValuesSequence(
+				spec.Count,
+				gen.NewTimestampSequenceFromSpec(spec),
+				gen.NewStringArrayValuesSequence(values),
+			)
+		},
+	}
+}
+
 func TagsSpec(specs ...*gen.TagValuesSpec) *gen.TagsSpec {
 	return &gen.TagsSpec{Tags: specs}
 }
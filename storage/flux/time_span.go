@@ -0,0 +1,109 @@
+package storageflux
+
+import (
+	"math"
+
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/arrow"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+)
+
+const (
+	startTimeColLabel = "_start_time"
+	stopTimeColLabel  = "_stop_time"
+)
+
+// timeSpanTable adds _start_time and _stop_time columns with the
+// earliest and latest _time of a table to each row. The span is only
+// known once every row has been read so the table is buffered in memory.
+type timeSpanTable struct {
+	storageTable
+	cols  []flux.ColMeta
+	alloc *memory.Allocator
+}
+
+func newTimeSpanTable(table storageTable, alloc *memory.Allocator) *timeSpanTable {
+	cols := make([]flux.ColMeta, 0, len(table.Cols())+2)
+	cols = append(cols, table.Cols()...)
+	cols = append(cols,
+		flux.ColMeta{Label: startTimeColLabel, Type: flux.TTime},
+		flux.ColMeta{Label: stopTimeColLabel, Type: flux.TTime},
+	)
+	return &timeSpanTable{
+		storageTable: table,
+		cols:         cols,
+		alloc:        alloc,
+	}
+}
+
+func (t *timeSpanTable) Cols() []flux.ColMeta { return t.cols }
+
+func (t *timeSpanTable) Do(f func(flux.ColReader) error) error {
+	timeIdx := execute.ColIdx(execute.DefaultTimeColLabel, t.storageTable.Cols())
+
+	var (
+		buffers  []arrow.TableBuffer
+		min, max int64 = math.MaxInt64, math.MinInt64
+	)
+	defer func() {
+		// The span columns are not set if reading the table failed.
+		for _, buffer := range buffers {
+			for _, arr := range buffer.Values {
+				if arr != nil {
+					arr.Release()
+				}
+			}
+		}
+	}()
+
+	if err := t.storageTable.Do(func(cr flux.ColReader) error {
+		ts := cr.Times(timeIdx)
+		for i, n := 0, ts.Len(); i < n; i++ {
+			v := ts.Value(i)
+			if v < min {
+				min = v
+			}
+			if v > max {
+				max = v
+			}
+		}
+
+		buffer := arrow.TableBuffer{
+			GroupKey: cr.Key(),
+			Columns:  t.cols,
+			Values:   make([]array.Interface, len(t.cols)),
+		}
+		for j := range cr.Cols() {
+			arr := getColumnValues(cr, j)
+			arr.Retain()
+			buffer.Values[j] = arr
+		}
+		buffers = append(buffers, buffer)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for i := range buffers {
+		buffer := &buffers[i]
+		l := buffer.Values[0].Len()
+		buffer.Values[len(t.cols)-2] = t.constTimes(min, l)
+		buffer.Values[len(t.cols)-1] = t.constTimes(max, l)
+		if err := f(buffer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// constTimes returns an array of length l where every value is v.
+func (t *timeSpanTable) constTimes(v int64, l int) *array.Int64 {
+	b := arrow.NewIntBuilder(t.alloc)
+	b.Resize(l)
+	for i := 0; i < l; i++ {
+		b.Append(v)
+	}
+	return b.NewInt64Array()
+}
@@ -0,0 +1,133 @@
+package storageflux
+
+import (
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/influxdb/v2"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxql"
+)
+
+// filterValues wraps f so that it only receives the rows of each
+// table whose aggregated value matches the ValuePredicate of the spec.
+// Tables without any matching rows are dropped.
+func (wai *windowAggregateIterator) filterValues(f func(flux.Table) error) (func(flux.Table) error, error) {
+	expr, err := valuePredicateExpr(wai.spec.ValuePredicate)
+	if err != nil {
+		return nil, err
+	}
+
+	label := wai.valueColumn()
+	return func(tbl flux.Table) error {
+		valueIdx := execute.ColIdx(label, tbl.Cols())
+		if valueIdx < 0 {
+			tbl.Done()
+			return nil
+		}
+
+		builder := execute.NewColListTableBuilder(tbl.Key(), wai.alloc)
+		defer builder.ClearData()
+		if err := execute.AddTableCols(tbl, builder); err != nil {
+			return err
+		}
+
+		var v columnValue
+		if err := tbl.Do(func(cr flux.ColReader) error {
+			for i := 0; i < cr.Len(); i++ {
+				if v.set(cr, i, valueIdx); !storage.EvalExprBool(expr, &v) {
+					continue
+				}
+				for j := range cr.Cols() {
+					if err := builder.AppendValue(j, execute.ValueForRow(cr, i, j)); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if builder.NRows() == 0 {
+			return nil
+		}
+		out, err := builder.Table()
+		if err != nil {
+			return err
+		}
+		builder.ClearData()
+		return f(out)
+	}, nil
+}
+
+// valuePredicateExpr converts a value predicate to an expression.
+// The predicate may only compare the value with literals.
+func valuePredicateExpr(p *datatypes.Predicate) (influxql.Expr, error) {
+	var hasTagRef bool
+	storage.WalkNode(nodeVisitorFunc(func(n *datatypes.Node) {
+		if n.NodeType == datatypes.NodeTypeTagRef {
+			hasTagRef = true
+		}
+	}), p.Root)
+	if hasTagRef {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "value predicate may only reference the value",
+		}
+	}
+
+	expr, err := storage.NodeToExpr(p.Root, nil)
+	if err != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "invalid value predicate",
+			Err:  err,
+		}
+	}
+	return expr, nil
+}
+
+type nodeVisitorFunc func(n *datatypes.Node)
+
+func (fn nodeVisitorFunc) Visit(n *datatypes.Node) storage.NodeVisitor {
+	fn(n)
+	return fn
+}
+
+// columnValue is the value of a single row of a column. It
+// is the value of any reference within an expression.
+type columnValue struct {
+	v     interface{}
+	valid bool
+}
+
+func (v *columnValue) set(cr flux.ColReader, i, j int) {
+	v.v, v.valid = nil, false
+	switch cr.Cols()[j].Type {
+	case flux.TInt:
+		if vs := cr.Ints(j); vs.IsValid(i) {
+			v.v, v.valid = vs.Value(i), true
+		}
+	case flux.TUInt:
+		if vs := cr.UInts(j); vs.IsValid(i) {
+			v.v, v.valid = vs.Value(i), true
+		}
+	case flux.TFloat:
+		if vs := cr.Floats(j); vs.IsValid(i) {
+			v.v, v.valid = vs.Value(i), true
+		}
+	case flux.TString:
+		if vs := cr.Strings(j); vs.IsValid(i) {
+			v.v, v.valid = vs.ValueString(i), true
+		}
+	case flux.TBool:
+		if vs := cr.Bools(j); vs.IsValid(i) {
+			v.v, v.valid = vs.Value(i), true
+		}
+	}
+}
+
+func (v *columnValue) Value(key string) (interface{}, bool) {
+	return v.v, v.valid
+}
@@ -0,0 +1,132 @@
+package storageflux
+
+import (
+	"math"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/values"
+)
+
+// scaleValues wraps f so that the numeric values of the column label
+// of each table are converted to v*scale+offset. A zero scale is read
+// as one. Integer and unsigned values are promoted to floats unless
+// the conversion keeps them integral.
+func scaleValues(f func(flux.Table) error, label string, scale, offset float64, alloc *memory.Allocator) func(flux.Table) error {
+	if scale == 0 {
+		scale = 1
+	}
+	return func(tbl flux.Table) error {
+		cols := tbl.Cols()
+		valueIdx := execute.ColIdx(label, cols)
+		if valueIdx < 0 {
+			return f(tbl)
+		}
+		typ := scaledType(cols[valueIdx].Type, scale, offset)
+		if typ == flux.TInvalid {
+			// Values that are not numeric are not converted.
+			return f(tbl)
+		}
+
+		builder := execute.NewColListTableBuilder(tbl.Key(), alloc)
+		defer builder.ClearData()
+		for j, col := range cols {
+			if j == valueIdx {
+				col.Type = typ
+			}
+			if _, err := builder.AddCol(col); err != nil {
+				return err
+			}
+		}
+
+		if err := tbl.Do(func(cr flux.ColReader) error {
+			for i := 0; i < cr.Len(); i++ {
+				for j := range cr.Cols() {
+					if j != valueIdx {
+						if err := builder.AppendValue(j, execute.ValueForRow(cr, i, j)); err != nil {
+							return err
+						}
+						continue
+					}
+
+					v, ok := scaleValue(cr, i, j, typ, scale, offset)
+					if !ok {
+						if err := builder.AppendNil(j); err != nil {
+							return err
+						}
+						continue
+					}
+					if err := builder.AppendValue(j, v); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		out, err := builder.Table()
+		if err != nil {
+			return err
+		}
+		builder.ClearData()
+		return f(out)
+	}
+}
+
+// scaledType returns the type of the values of type typ once they are
+// scaled, or flux.TInvalid if values of the type are not scaled.
+func scaledType(typ flux.ColType, scale, offset float64) flux.ColType {
+	integral := scale == math.Trunc(scale) && offset == math.Trunc(offset)
+	switch typ {
+	case flux.TFloat:
+		return flux.TFloat
+	case flux.TInt:
+		if integral {
+			return flux.TInt
+		}
+		return flux.TFloat
+	case flux.TUInt:
+		if integral && scale >= 0 && offset >= 0 {
+			return flux.TUInt
+		}
+		return flux.TFloat
+	default:
+		return flux.TInvalid
+	}
+}
+
+// scaleValue returns the value of row i of column j scaled to a value
+// of type typ. It returns false if the value is null.
+func scaleValue(cr flux.ColReader, i, j int, typ flux.ColType, scale, offset float64) (values.Value, bool) {
+	switch cr.Cols()[j].Type {
+	case flux.TFloat:
+		vs := cr.Floats(j)
+		if vs.IsNull(i) {
+			return nil, false
+		}
+		return values.NewFloat(vs.Value(i)*scale + offset), true
+	case flux.TInt:
+		vs := cr.Ints(j)
+		if vs.IsNull(i) {
+			return nil, false
+		}
+		if typ == flux.TInt {
+			return values.NewInt(vs.Value(i)*int64(scale) + int64(offset)), true
+		}
+		return values.NewFloat(float64(vs.Value(i))*scale + offset), true
+	case flux.TUInt:
+		vs := cr.UInts(j)
+		if vs.IsNull(i) {
+			return nil, false
+		}
+		if typ == flux.TUInt {
+			return values.NewUInt(vs.Value(i)*uint64(scale) + uint64(offset)), true
+		}
+		return values.NewFloat(float64(vs.Value(i))*scale + offset), true
+	default:
+		return nil, false
+	}
+}
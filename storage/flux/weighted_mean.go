@@ -0,0 +1,252 @@
+package storageflux
+
+import (
+	"fmt"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// readWeightedMean computes the mean of each window with each value
+// weighted by the value of the WeightField of its series at the same
+// time. The weights of every series are read into memory first, and
+// then the values are read and each window is computed here. Points
+// without a weight at their time are skipped, and the series of the
+// weight field are not aggregated themselves.
+func (wai *windowAggregateIterator) readWeightedMean(f func(flux.Table) error) error {
+	weights, err := wai.readWeights()
+	if err != nil {
+		return err
+	}
+
+	rs, err := wai.readFilter()
+	if err != nil || rs == nil {
+		return err
+	}
+
+	every, offset := wai.windowEveryAndOffset()
+	return wai.handleRead(f, &weightedMeanResultSet{
+		ResultSet: rs,
+		field:     wai.spec.WeightField,
+		weights:   weights,
+		every:     every,
+		offset:    offset,
+	})
+}
+
+// readWeights returns the values of the WeightField within the bounds
+// of the spec by time for each series, identified by the hash key of
+// its tags without the _field tag. The predicate of the spec is not
+// applied as it may exclude the weight field; the weights are only
+// looked up for the series it selects.
+func (wai *windowAggregateIterator) readWeights() (map[string]map[int64]float64, error) {
+	src := wai.s.GetSource(
+		uint64(wai.spec.OrganizationID),
+		uint64(wai.spec.BucketID),
+	)
+
+	any, err := types.MarshalAny(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var req datatypes.ReadFilterRequest
+	req.ReadSource = any
+	req.Predicate = &datatypes.Predicate{
+		Root: &datatypes.Node{
+			NodeType: datatypes.NodeTypeComparisonExpression,
+			Value:    &datatypes.Node_Comparison_{Comparison: datatypes.ComparisonEqual},
+			Children: []*datatypes.Node{
+				{
+					NodeType: datatypes.NodeTypeTagRef,
+					Value:    &datatypes.Node_TagRefValue{TagRefValue: models.FieldKeyTagKey},
+				},
+				{
+					NodeType: datatypes.NodeTypeLiteral,
+					Value:    &datatypes.Node_StringValue{StringValue: wai.spec.WeightField},
+				},
+			},
+		},
+	}
+	req.Range.Start = int64(wai.spec.Bounds.Start)
+	req.Range.End = int64(wai.spec.Bounds.Stop)
+
+	rs, err := wai.s.ReadFilter(wai.ctx, &req)
+	if err != nil || rs == nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	weights := make(map[string]map[int64]float64)
+	for rs.Next() {
+		cur := rs.Cursor()
+		if cur == nil {
+			continue
+		}
+
+		floatCur, err := toFloatArrayCursor(cur, "weight field")
+		if err != nil {
+			return nil, err
+		}
+
+		key := weightKey(rs.Tags())
+		ws := weights[key]
+		if ws == nil {
+			ws = make(map[int64]float64)
+			weights[key] = ws
+		}
+		for a := floatCur.Next(); a.Len() > 0; a = floatCur.Next() {
+			for i, ts := range a.Timestamps {
+				ws[ts] = a.Values[i]
+			}
+		}
+		stats := floatCur.Stats()
+		wai.stats.ScannedValues += stats.ScannedValues
+		wai.stats.ScannedBytes += stats.ScannedBytes
+		floatCur.Close()
+	}
+	return weights, rs.Err()
+}
+
+// weightKey returns the key of the weights of the series with tags.
+func weightKey(tags models.Tags) string {
+	tags = tags.Clone()
+	tags.Delete(fieldKeyBytes)
+	return string(tags.HashKey())
+}
+
+// toFloatArrayCursor converts a numeric cursor to a float cursor.
+// Other cursors are closed and reported as unsupported for what.
+func toFloatArrayCursor(cur cursors.Cursor, what string) (cursors.FloatArrayCursor, error) {
+	switch typedCur := cur.(type) {
+	case cursors.FloatArrayCursor:
+		return typedCur, nil
+	case cursors.IntegerArrayCursor:
+		return newIntegerToFloatArrayCursor(typedCur), nil
+	case cursors.UnsignedArrayCursor:
+		return newUnsignedToFloatArrayCursor(typedCur), nil
+	default:
+		cur.Close()
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  fmt.Sprintf("unsupported for %s: %T", what, cur),
+		}
+	}
+}
+
+// weightedMeanResultSet wraps the cursors of a ResultSet so that they
+// produce the weighted mean for each window. It skips the series of
+// the weight field.
+type weightedMeanResultSet struct {
+	storage.ResultSet
+	field         string
+	weights       map[string]map[int64]float64
+	every, offset int64
+	err           error
+}
+
+func (r *weightedMeanResultSet) Next() bool {
+	for r.ResultSet.Next() {
+		if string(r.ResultSet.Tags().Get(fieldKeyBytes)) != r.field {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *weightedMeanResultSet) Cursor() cursors.Cursor {
+	cur := r.ResultSet.Cursor()
+	if cur == nil {
+		return nil
+	}
+
+	floatCur, err := toFloatArrayCursor(cur, "aggregate mean")
+	if err != nil {
+		if r.err == nil {
+			r.err = err
+		}
+		return nil
+	}
+	return newWindowWeightedMeanCursor(floatCur, r.weights[weightKey(r.Tags())], r.every, r.offset)
+}
+
+func (r *weightedMeanResultSet) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.ResultSet.Err()
+}
+
+// windowWeightedMeanCursor produces the mean of each window with each
+// value weighted by the weight at its time. Windows whose weights sum
+// to zero produce no value.
+type windowWeightedMeanCursor struct {
+	cursors.FloatArrayCursor
+	weights       map[int64]float64
+	every, offset int64
+	res           *cursors.FloatArray
+
+	// state of the current window
+	windowEnd     int64
+	sum, weight   float64
+	windowHasData bool
+}
+
+func newWindowWeightedMeanCursor(cur cursors.FloatArrayCursor, weights map[int64]float64, every, offset int64) *windowWeightedMeanCursor {
+	return &windowWeightedMeanCursor{
+		FloatArrayCursor: cur,
+		weights:          weights,
+		every:            every,
+		offset:           offset,
+		res:              cursors.NewFloatArrayLen(storage.MaxPointsPerBlock),
+	}
+}
+
+func (c *windowWeightedMeanCursor) Next() *cursors.FloatArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	for c.res.Len() == 0 {
+		a := c.FloatArrayCursor.Next()
+		if a.Len() == 0 {
+			// Emit the final window.
+			c.emit()
+			c.windowHasData = false
+			break
+		}
+
+		for i, ts := range a.Timestamps {
+			w, ok := c.weights[ts]
+			if !ok {
+				continue
+			}
+
+			if c.windowHasData && ts >= c.windowEnd {
+				c.emit()
+				c.windowHasData = false
+			}
+
+			if !c.windowHasData {
+				c.windowEnd = windowStop(ts, c.every, c.offset)
+				c.sum, c.weight = 0, 0
+				c.windowHasData = true
+			}
+			c.sum += a.Values[i] * w
+			c.weight += w
+		}
+	}
+	return c.res
+}
+
+func (c *windowWeightedMeanCursor) emit() {
+	if !c.windowHasData || c.weight == 0 {
+		return
+	}
+	c.res.Timestamps = append(c.res.Timestamps, c.windowEnd)
+	c.res.Values = append(c.res.Values, c.sum/c.weight)
+}
@@ -163,16 +163,30 @@ func (w *windowTableRow) Empty() bool {
 	return false
 }
 
+// groupKeyForWindow returns a group key with the _start and _stop
+// columns set to start and stop. The _start and _stop columns are
+// added to the front of the group key if they are not already present.
 func groupKeyForWindow(key flux.GroupKey, start, stop int64) flux.GroupKey {
-	cols := key.Cols()
-	vs := make([]values.Value, len(cols))
-	for j, c := range cols {
+	hasStart, hasStop := key.HasCol(execute.DefaultStartColLabel), key.HasCol(execute.DefaultStopColLabel)
+
+	cols := make([]flux.ColMeta, 0, len(key.Cols())+2)
+	vs := make([]values.Value, 0, len(key.Cols())+2)
+	if !hasStart {
+		cols = append(cols, flux.ColMeta{Label: execute.DefaultStartColLabel, Type: flux.TTime})
+		vs = append(vs, values.NewTime(values.Time(start)))
+	}
+	if !hasStop {
+		cols = append(cols, flux.ColMeta{Label: execute.DefaultStopColLabel, Type: flux.TTime})
+		vs = append(vs, values.NewTime(values.Time(stop)))
+	}
+	for j, c := range key.Cols() {
+		cols = append(cols, c)
 		if c.Label == execute.DefaultStartColLabel {
-			vs[j] = values.NewTime(values.Time(start))
+			vs = append(vs, values.NewTime(values.Time(start)))
 		} else if c.Label == execute.DefaultStopColLabel {
-			vs[j] = values.NewTime(values.Time(stop))
+			vs = append(vs, values.NewTime(values.Time(stop)))
 		} else {
-			vs[j] = key.Value(j)
+			vs = append(vs, key.Value(j))
 		}
 	}
 	return execute.NewGroupKey(cols, vs)
@@ -0,0 +1,67 @@
+package storageflux
+
+import (
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2"
+	storage "github.com/influxdata/influxdb/v2/storage/reads"
+)
+
+// readWindowBounds aggregates each of the WindowBounds of the spec with
+// a separate read. Each read covers a single window, which is clipped to
+// the bounds of the spec, so points outside of every window are never
+// read. As with a chunked read, a series produces its own tables for
+// each window.
+func (wai *windowAggregateIterator) readWindowBounds(f func(flux.Table) error) error {
+	if wai.spec.WindowEvery != 0 || wai.spec.Offset != 0 {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "cannot specify both window bounds and a window period or offset",
+		}
+	}
+	for _, bounds := range wai.spec.WindowBounds {
+		if bounds.Start >= bounds.Stop {
+			return &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "window bounds must start before they stop",
+			}
+		}
+	}
+
+	for _, bounds := range wai.spec.WindowBounds {
+		if bounds.Start < wai.spec.Bounds.Start {
+			bounds.Start = wai.spec.Bounds.Start
+		}
+		if bounds.Stop > wai.spec.Bounds.Stop {
+			bounds.Stop = wai.spec.Bounds.Stop
+		}
+		if bounds.Start >= bounds.Stop {
+			// The window is outside of the bounds of the spec.
+			continue
+		}
+
+		spec := wai.spec
+		spec.Bounds = bounds
+		spec.WindowBounds = nil
+		spec.WindowEvery = int64(bounds.Stop - bounds.Start)
+		spec.Offset = storage.Modulo(int64(bounds.Start), spec.WindowEvery)
+		// The values of the windows are scaled and materialized by f.
+		spec.ValueScale, spec.ValueOffset = 0, 0
+		spec.MaterializeTo = 0
+
+		window := &windowAggregateIterator{
+			ctx:   wai.ctx,
+			s:     wai.s,
+			spec:  spec,
+			cache: wai.cache,
+			alloc: wai.alloc,
+		}
+		// f is already limited and timed by the Do of wai.
+		err := window.do(f)
+		wai.stats.ScannedValues += window.stats.ScannedValues
+		wai.stats.ScannedBytes += window.stats.ScannedBytes
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -34,9 +34,10 @@ const retentionSubsystem = "retention" // sub-system associated with metrics for
 
 // retentionMetrics is a set of metrics concerned with tracking data about retention policies.
 type retentionMetrics struct {
-	labels        prometheus.Labels
-	Checks        *prometheus.CounterVec
-	CheckDuration *prometheus.HistogramVec
+	labels            prometheus.Labels
+	Checks            *prometheus.CounterVec
+	MeasurementChecks *prometheus.CounterVec
+	CheckDuration     *prometheus.HistogramVec
 }
 
 func newRetentionMetrics(labels prometheus.Labels) *retentionMetrics {
@@ -61,6 +62,13 @@ func newRetentionMetrics(labels prometheus.Labels) *retentionMetrics {
 			Help:      "Number of retention check operations performed.",
 		}, checksNames),
 
+		MeasurementChecks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: retentionSubsystem,
+			Name:      "measurement_checks_total",
+			Help:      "Number of retention check operations performed for the retention periods of measurements.",
+		}, checksNames),
+
 		CheckDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: namespace,
 			Subsystem: retentionSubsystem,
@@ -85,6 +93,7 @@ func (m *retentionMetrics) Labels() prometheus.Labels {
 func (rm *retentionMetrics) PrometheusCollectors() []prometheus.Collector {
 	return []prometheus.Collector{
 		rm.Checks,
+		rm.MeasurementChecks,
 		rm.CheckDuration,
 	}
 }
@@ -3,11 +3,14 @@ package storage
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
 )
 
 // PointsWriter describes the ability to write points into a storage engine.
@@ -26,6 +29,14 @@ type LoggingPointsWriter struct {
 
 	// Name of the bucket to log to.
 	LogBucketName string
+
+	// Logger, if set, logs the number of points and the latency
+	// of each write at the debug level.
+	Logger *zap.Logger
+
+	// Metrics, if set, records the number of points
+	// and the latency of each write.
+	Metrics *WriteBatchMetrics
 }
 
 // WritePoints writes points to the underlying PointsWriter. Logs on error.
@@ -35,7 +46,9 @@ func (w *LoggingPointsWriter) WritePoints(ctx context.Context, p []models.Point)
 	}
 
 	// Write to underlying writer and exit immediately if successful.
+	start := time.Now()
 	err := w.Underlying.WritePoints(ctx, p)
+	w.recordWrite(len(p), time.Since(start), err)
 	if err == nil {
 		return nil
 	}
@@ -75,6 +88,242 @@ func (w *LoggingPointsWriter) WritePoints(ctx context.Context, p []models.Point)
 	return err
 }
 
+// recordWrite logs and records the size and latency of a write.
+func (w *LoggingPointsWriter) recordWrite(n int, d time.Duration, err error) {
+	if w.Logger != nil {
+		w.Logger.Debug("Wrote points",
+			zap.Int("points", n),
+			zap.Duration("duration", d),
+			zap.Error(err),
+		)
+	}
+	if w.Metrics != nil {
+		w.Metrics.batchPoints.Observe(float64(n))
+		w.Metrics.writeDuration.Observe(d.Seconds())
+	}
+}
+
+const writerSubsystem = "writer" // sub-system associated with metrics for writing points.
+
+// WriteBatchMetrics holds the metrics recorded by a LoggingPointsWriter
+// for the size and latency of each write.
+type WriteBatchMetrics struct {
+	batchPoints   prometheus.Histogram
+	writeDuration prometheus.Histogram
+}
+
+// NewWriteBatchMetrics returns a new WriteBatchMetrics.
+func NewWriteBatchMetrics() *WriteBatchMetrics {
+	return &WriteBatchMetrics{
+		batchPoints: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: writerSubsystem,
+			Name:      "batch_points",
+			Help:      "Histogram of the number of points in each write.",
+			Buckets:   prometheus.ExponentialBuckets(1, 10, 6),
+		}),
+		writeDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: writerSubsystem,
+			Name:      "write_duration_seconds",
+			Help:      "Histogram of the time taken by each write.",
+			Buckets:   prometheus.ExponentialBuckets(1e-3, 5, 7),
+		}),
+	}
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (m *WriteBatchMetrics) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.batchPoints,
+		m.writeDuration,
+	}
+}
+
+// MetricsPointsWriter wraps an underlying points writer and records the
+// number of points and bytes written, and the number of failed writes,
+// for each bucket.
+type MetricsPointsWriter struct {
+	// Wrapped points writer. Writes to it are recorded.
+	Underlying PointsWriter
+
+	pointsWritten *prometheus.CounterVec
+	bytesWritten  *prometheus.CounterVec
+	writeErrors   *prometheus.CounterVec
+}
+
+// NewMetricsPointsWriter returns a new MetricsPointsWriter that wraps underlying.
+func NewMetricsPointsWriter(underlying PointsWriter) *MetricsPointsWriter {
+	labels := []string{"bucket"}
+	return &MetricsPointsWriter{
+		Underlying: underlying,
+		pointsWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: writerSubsystem,
+			Name:      "points_written_total",
+			Help:      "Number of points written.",
+		}, labels),
+		bytesWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: writerSubsystem,
+			Name:      "bytes_written_total",
+			Help:      "Number of line protocol bytes written.",
+		}, labels),
+		writeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: writerSubsystem,
+			Name:      "write_errors_total",
+			Help:      "Number of writes that returned an error.",
+		}, labels),
+	}
+}
+
+// PrometheusCollectors satisfies the prom.PrometheusCollector interface.
+func (w *MetricsPointsWriter) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		w.pointsWritten,
+		w.bytesWritten,
+		w.writeErrors,
+	}
+}
+
+// WritePoints writes points to the underlying PointsWriter and records
+// metrics for each bucket the points belong to.
+func (w *MetricsPointsWriter) WritePoints(ctx context.Context, p []models.Point) error {
+	if len(p) == 0 {
+		return nil
+	}
+
+	type stats struct {
+		points, bytes int
+	}
+	buckets := make(map[influxdb.ID]*stats)
+	for _, pt := range p {
+		_, bucketID := tsdb.DecodeNameSlice(pt.Name())
+		s := buckets[bucketID]
+		if s == nil {
+			s = &stats{}
+			buckets[bucketID] = s
+		}
+		s.points++
+		s.bytes += pt.StringSize()
+	}
+
+	if err := w.Underlying.WritePoints(ctx, p); err != nil {
+		for bucketID := range buckets {
+			w.writeErrors.WithLabelValues(bucketID.String()).Inc()
+		}
+		return err
+	}
+
+	for bucketID, s := range buckets {
+		bucket := bucketID.String()
+		w.pointsWritten.WithLabelValues(bucket).Add(float64(s.points))
+		w.bytesWritten.WithLabelValues(bucket).Add(float64(s.bytes))
+	}
+	return nil
+}
+
+// DefaultSchemaCacheTTL is how long a SchemaPointsWriter caches
+// the schema of a bucket by default.
+const DefaultSchemaCacheTTL = 10 * time.Second
+
+// SchemaPointsWriter wraps an underlying points writer and rejects
+// writes of fields that are not declared by a bucket with an explicit
+// schema. Writes to buckets with an implicit schema are not checked.
+type SchemaPointsWriter struct {
+	// Wrapped points writer. Only writes of declared fields reach it.
+	Underlying PointsWriter
+
+	// Service used to look up the schema of each bucket written to.
+	BucketService influxdb.BucketService
+
+	// CacheTTL is how long the schema of a bucket is cached for, so that
+	// updates to it are applied to writes within that time. If zero,
+	// DefaultSchemaCacheTTL is used.
+	CacheTTL time.Duration
+
+	mu      sync.Mutex
+	schemas map[influxdb.ID]*bucketSchema
+}
+
+// bucketSchema is the cached schema of a bucket.
+type bucketSchema struct {
+	name    string
+	fields  map[string]struct{} // nil if the schema is implicit
+	expires time.Time
+}
+
+// WritePoints writes the points to the underlying PointsWriter if every
+// field is declared by its bucket. Otherwise none of the points are written.
+func (w *SchemaPointsWriter) WritePoints(ctx context.Context, p []models.Point) error {
+	var (
+		lastID     influxdb.ID
+		lastSchema *bucketSchema
+	)
+	for _, pt := range p {
+		_, bucketID := tsdb.DecodeNameSlice(pt.Name())
+		if lastSchema == nil || bucketID != lastID {
+			schema, err := w.schema(ctx, bucketID)
+			if err != nil {
+				return err
+			}
+			lastID, lastSchema = bucketID, schema
+		}
+		if lastSchema.fields == nil {
+			continue
+		}
+
+		itr := pt.FieldIterator()
+		for itr.Next() {
+			if _, ok := lastSchema.fields[string(itr.FieldKey())]; !ok {
+				return &influxdb.Error{
+					Code: influxdb.EInvalid,
+					Msg:  fmt.Sprintf("field %q is not part of the explicit schema of bucket %q", itr.FieldKey(), lastSchema.name),
+				}
+			}
+		}
+	}
+	return w.Underlying.WritePoints(ctx, p)
+}
+
+// schema returns the schema of the bucket, looking it up if it is
+// not cached or has expired.
+func (w *SchemaPointsWriter) schema(ctx context.Context, bucketID influxdb.ID) (*bucketSchema, error) {
+	now := time.Now()
+	w.mu.Lock()
+	schema := w.schemas[bucketID]
+	w.mu.Unlock()
+	if schema != nil && now.Before(schema.expires) {
+		return schema, nil
+	}
+
+	b, err := w.BucketService.FindBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := w.CacheTTL
+	if ttl == 0 {
+		ttl = DefaultSchemaCacheTTL
+	}
+	schema = &bucketSchema{name: b.Name, expires: now.Add(ttl)}
+	if b.SchemaType == influxdb.SchemaTypeExplicit {
+		schema.fields = make(map[string]struct{}, len(b.Fields))
+		for _, f := range b.Fields {
+			schema.fields[f] = struct{}{}
+		}
+	}
+
+	w.mu.Lock()
+	if w.schemas == nil {
+		w.schemas = make(map[influxdb.ID]*bucketSchema)
+	}
+	w.schemas[bucketID] = schema
+	w.mu.Unlock()
+	return schema, nil
+}
+
 type BufferedPointsWriter struct {
 	buf []models.Point
 	n   int
@@ -10,10 +10,15 @@ import (
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
 	"github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestLoggingPointsWriter(t *testing.T) {
@@ -130,6 +135,208 @@ func TestLoggingPointsWriter(t *testing.T) {
 			t.Fatalf("unexpected error: %#v", err)
 		}
 	})
+
+	// Ensure the size and latency of each write are recorded.
+	t.Run("Batches", func(t *testing.T) {
+		core, logs := observer.New(zapcore.DebugLevel)
+		metrics := storage.NewWriteBatchMetrics()
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(metrics.PrometheusCollectors()...)
+
+		lpw := &storage.LoggingPointsWriter{
+			Underlying: &mock.PointsWriter{
+				WritePointsFn: func(ctx context.Context, p []models.Point) error {
+					time.Sleep(time.Millisecond)
+					return nil
+				},
+			},
+			Logger:  zap.New(core),
+			Metrics: metrics,
+		}
+
+		sizes := []int{1, 5, 20}
+		for _, size := range sizes {
+			points := make([]models.Point, size)
+			for i := range points {
+				points[i] = models.MustNewPoint(
+					tsdb.EncodeNameString(1, 2),
+					models.NewTags(map[string]string{"t": "v"}),
+					models.Fields{"f": float64(i)},
+					time.Unix(int64(i), 0),
+				)
+			}
+			if err := lpw.WritePoints(context.Background(), points); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		entries := logs.All()
+		if got, want := len(entries), len(sizes); got != want {
+			t.Fatalf("unexpected number of log entries: got %d, want %d", got, want)
+		}
+		for i, entry := range entries {
+			fields := entry.ContextMap()
+			if got, want := fields["points"], int64(sizes[i]); got != want {
+				t.Errorf("[%d] unexpected points: got %v, want %v", i, got, want)
+			}
+			if d, _ := fields["duration"].(time.Duration); d < time.Millisecond {
+				t.Errorf("[%d] unexpected duration: got %v, want at least %v", i, d, time.Millisecond)
+			}
+		}
+
+		mfs := promtest.MustGather(t, reg)
+		batch := promtest.MustFindMetric(t, mfs, "storage_writer_batch_points", nil).GetHistogram()
+		if got, want := batch.GetSampleCount(), uint64(len(sizes)); got != want {
+			t.Errorf("unexpected number of batches: got %d, want %d", got, want)
+		}
+		if got, want := batch.GetSampleSum(), float64(1+5+20); got != want {
+			t.Errorf("unexpected number of points: got %v, want %v", got, want)
+		}
+		latency := promtest.MustFindMetric(t, mfs, "storage_writer_write_duration_seconds", nil).GetHistogram()
+		if got, want := latency.GetSampleCount(), uint64(len(sizes)); got != want {
+			t.Errorf("unexpected number of latencies: got %d, want %d", got, want)
+		}
+		if got, want := latency.GetSampleSum(), float64(len(sizes))*time.Millisecond.Seconds(); got < want {
+			t.Errorf("unexpected total latency: got %v, want at least %v", got, want)
+		}
+	})
+}
+
+func TestMetricsPointsWriter(t *testing.T) {
+	var writeErr error
+	mpw := storage.NewMetricsPointsWriter(&mock.PointsWriter{
+		WritePointsFn: func(ctx context.Context, p []models.Point) error {
+			return writeErr
+		},
+	})
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(mpw.PrometheusCollectors()...)
+
+	newPoint := func(bucketID influxdb.ID) models.Point {
+		return models.MustNewPoint(
+			tsdb.EncodeNameString(1, bucketID),
+			models.NewTags(map[string]string{"t": "v"}),
+			models.Fields{"f": float64(100)},
+			time.Unix(0, 0),
+		)
+	}
+	points := []models.Point{newPoint(2), newPoint(2), newPoint(3)}
+	if err := mpw.WritePoints(context.Background(), points); err != nil {
+		t.Fatal(err)
+	}
+
+	writeErr = errors.New("bad write")
+	if err := mpw.WritePoints(context.Background(), []models.Point{newPoint(3)}); err != writeErr {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mfs := promtest.MustGather(t, reg)
+	for _, tt := range []struct {
+		name   string
+		bucket influxdb.ID
+		want   float64
+	}{
+		{name: "storage_writer_points_written_total", bucket: 2, want: 2},
+		{name: "storage_writer_points_written_total", bucket: 3, want: 1},
+		{name: "storage_writer_bytes_written_total", bucket: 2, want: float64(2 * points[0].StringSize())},
+		{name: "storage_writer_bytes_written_total", bucket: 3, want: float64(points[2].StringSize())},
+		{name: "storage_writer_write_errors_total", bucket: 3, want: 1},
+	} {
+		labels := map[string]string{"bucket": tt.bucket.String()}
+		metric := promtest.MustFindMetric(t, mfs, tt.name, labels)
+		if got := metric.GetCounter().GetValue(); got != tt.want {
+			t.Errorf("[%s %v] got %v, expected %v", tt.name, labels, got, tt.want)
+		}
+	}
+
+	if metric := promtest.FindMetric(mfs, "storage_writer_write_errors_total", map[string]string{"bucket": influxdb.ID(2).String()}); metric != nil {
+		t.Errorf("unexpected write errors for bucket %s", influxdb.ID(2))
+	}
+}
+
+func TestSchemaPointsWriter(t *testing.T) {
+	ctx := context.Background()
+	inmemService := newInMemKVSVC(t)
+
+	org := &influxdb.Organization{Name: "org1"}
+	if err := inmemService.CreateOrganization(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	explicit := &influxdb.Bucket{OrgID: org.ID, Name: "explicit", SchemaType: influxdb.SchemaTypeExplicit, Fields: []string{"f"}}
+	implicit := &influxdb.Bucket{OrgID: org.ID, Name: "implicit"}
+	for _, b := range []*influxdb.Bucket{explicit, implicit} {
+		if err := inmemService.CreateBucket(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var written []models.Point
+	spw := &storage.SchemaPointsWriter{
+		Underlying: &mock.PointsWriter{
+			WritePointsFn: func(ctx context.Context, p []models.Point) error {
+				written = append(written, p...)
+				return nil
+			},
+		},
+		BucketService: inmemService,
+	}
+
+	for _, tt := range []struct {
+		name    string
+		bucket  *influxdb.Bucket
+		points  string
+		wantErr bool
+	}{
+		{name: "declared field", bucket: explicit, points: "m,t=v f=1 0"},
+		{name: "unknown field", bucket: explicit, points: "m,t=v f=1,g=2 0", wantErr: true},
+		{name: "implicit schema", bucket: implicit, points: "m,t=v g=2 0"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			written = written[:0]
+			points := mockPoints(org.ID, tt.bucket.ID, tt.points)
+			err := spw.WritePoints(ctx, points)
+			if tt.wantErr {
+				if code := influxdb.ErrorCode(err); code != influxdb.EInvalid {
+					t.Fatalf("got error code %q, expected %q: %v", code, influxdb.EInvalid, err)
+				}
+				if len(written) != 0 {
+					t.Fatalf("unexpected write of %d points", len(written))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(written) != len(points) {
+				t.Fatalf("got %d points written, expected %d", len(written), len(points))
+			}
+		})
+	}
+}
+
+func TestSchemaPointsWriter_CacheSchema(t *testing.T) {
+	ctx := context.Background()
+	orgID, bucketID := influxdb.ID(1), influxdb.ID(2)
+
+	var lookups int
+	spw := &storage.SchemaPointsWriter{
+		Underlying: &mock.PointsWriter{},
+		BucketService: &mock.BucketService{
+			FindBucketByIDFn: func(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+				lookups++
+				return &influxdb.Bucket{ID: id, OrgID: orgID, Name: "explicit", SchemaType: influxdb.SchemaTypeExplicit, Fields: []string{"f"}}, nil
+			},
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := spw.WritePoints(ctx, mockPoints(orgID, bucketID, "m,t=v f=1 0\nm,t=v f=2 1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lookups != 1 {
+		t.Fatalf("got %d lookups of the bucket, expected 1", lookups)
+	}
 }
 
 func TestBufferedPointsWriter(t *testing.T) {
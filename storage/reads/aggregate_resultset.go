@@ -49,6 +49,10 @@ func NewWindowAggregateResultSet(ctx context.Context, req *datatypes.ReadWindowA
 		cursor:       cursor,
 		arrayCursors: newArrayCursors(ctx, req.Range.Start, req.Range.End, ascending),
 	}
+
+	// Counting points only requires the timestamps of each block,
+	// so the storage engine may skip decoding the values.
+	results.arrayCursors.timestampsOnly = req.Aggregate[0].Type == datatypes.AggregateTypeCount
 	return results, nil
 }
 
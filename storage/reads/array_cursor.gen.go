@@ -795,7 +795,7 @@ WINDOWS:
 
 				continue WINDOWS
 			} else {
-				if !windowHasPoints || a.Values[rowIdx] < acc {
+				if !windowHasPoints || a.Values[rowIdx] < acc || (a.Values[rowIdx] == acc && a.Timestamps[rowIdx] < tsAcc) {
 					acc = a.Values[rowIdx]
 					tsAcc = a.Timestamps[rowIdx]
 				}
@@ -912,7 +912,7 @@ WINDOWS:
 
 				continue WINDOWS
 			} else {
-				if !windowHasPoints || a.Values[rowIdx] > acc {
+				if !windowHasPoints || a.Values[rowIdx] > acc || (a.Values[rowIdx] == acc && a.Timestamps[rowIdx] < tsAcc) {
 					acc = a.Values[rowIdx]
 					tsAcc = a.Timestamps[rowIdx]
 				}
@@ -1490,6 +1490,7 @@ type integerWindowSumArrayCursor struct {
 	every, offset int64
 	res           *cursors.IntegerArray
 	tmp           *cursors.IntegerArray
+	overflow      *IntegerOverflowError
 }
 
 func newIntegerWindowSumArrayCursor(cur cursors.IntegerArrayCursor, every, offset int64) *integerWindowSumArrayCursor {
@@ -1510,6 +1511,15 @@ func (c *integerWindowSumArrayCursor) Stats() cursors.CursorStats {
 	return c.IntegerArrayCursor.Stats()
 }
 
+// Err returns an *IntegerOverflowError if the sum of a window
+// overflowed. The sum for that window will have wrapped around.
+func (c *integerWindowSumArrayCursor) Err() error {
+	if c.overflow != nil {
+		return c.overflow
+	}
+	return c.IntegerArrayCursor.Err()
+}
+
 func (c *integerWindowSumArrayCursor) Next() *cursors.IntegerArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
@@ -1567,6 +1577,9 @@ WINDOWS:
 
 				continue WINDOWS
 			} else {
+				if c.overflow == nil && addOverflowsInt64(acc, a.Values[rowIdx]) {
+					c.overflow = &IntegerOverflowError{Stop: windowEnd}
+				}
 				acc += a.Values[rowIdx]
 				windowHasPoints = true
 			}
@@ -1681,7 +1694,7 @@ WINDOWS:
 
 				continue WINDOWS
 			} else {
-				if !windowHasPoints || a.Values[rowIdx] < acc {
+				if !windowHasPoints || a.Values[rowIdx] < acc || (a.Values[rowIdx] == acc && a.Timestamps[rowIdx] < tsAcc) {
 					acc = a.Values[rowIdx]
 					tsAcc = a.Timestamps[rowIdx]
 				}
@@ -1798,7 +1811,7 @@ WINDOWS:
 
 				continue WINDOWS
 			} else {
-				if !windowHasPoints || a.Values[rowIdx] > acc {
+				if !windowHasPoints || a.Values[rowIdx] > acc || (a.Values[rowIdx] == acc && a.Timestamps[rowIdx] < tsAcc) {
 					acc = a.Values[rowIdx]
 					tsAcc = a.Timestamps[rowIdx]
 				}
@@ -2567,7 +2580,7 @@ WINDOWS:
 
 				continue WINDOWS
 			} else {
-				if !windowHasPoints || a.Values[rowIdx] < acc {
+				if !windowHasPoints || a.Values[rowIdx] < acc || (a.Values[rowIdx] == acc && a.Timestamps[rowIdx] < tsAcc) {
 					acc = a.Values[rowIdx]
 					tsAcc = a.Timestamps[rowIdx]
 				}
@@ -2684,7 +2697,7 @@ WINDOWS:
 
 				continue WINDOWS
 			} else {
-				if !windowHasPoints || a.Values[rowIdx] > acc {
+				if !windowHasPoints || a.Values[rowIdx] > acc || (a.Values[rowIdx] == acc && a.Timestamps[rowIdx] < tsAcc) {
 					acc = a.Values[rowIdx]
 					tsAcc = a.Timestamps[rowIdx]
 				}
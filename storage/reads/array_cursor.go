@@ -3,6 +3,7 @@ package reads
 import (
 	"context"
 	"fmt"
+	"math"
 
 	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
 	"github.com/influxdata/influxdb/v2/tsdb/cursors"
@@ -50,6 +51,22 @@ func newWindowAggregateArrayCursor(ctx context.Context, agg *datatypes.Aggregate
 	}
 }
 
+// IntegerOverflowError is reported by the Err method of an integer
+// sum cursor when the sum of a window overflows an int64.
+type IntegerOverflowError struct {
+	// Stop is the stop time of the window that overflowed.
+	Stop int64
+}
+
+func (e *IntegerOverflowError) Error() string {
+	return fmt.Sprintf("integer overflow in sum for window ending at %d", e.Stop)
+}
+
+// addOverflowsInt64 reports whether a + b overflows an int64.
+func addOverflowsInt64(a, b int64) bool {
+	return (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b)
+}
+
 type cursorContext struct {
 	ctx            context.Context
 	req            *cursors.CursorRequest
@@ -61,6 +78,10 @@ type arrayCursors struct {
 	ctx context.Context
 	req cursors.CursorRequest
 
+	// timestampsOnly is set when the consumer of the cursors only
+	// requires timestamps, such as when counting points.
+	timestampsOnly bool
+
 	cursors struct {
 		i integerArrayCursor
 		f floatArrayCursor
@@ -98,6 +119,8 @@ func (m *arrayCursors) createCursor(seriesRow SeriesRow) cursors.Cursor {
 	m.req.Name = seriesRow.Name
 	m.req.Tags = seriesRow.SeriesTags
 	m.req.Field = seriesRow.Field
+	// Values must be decoded if they are to be filtered.
+	m.req.TimestampsOnly = m.timestampsOnly && seriesRow.ValueCond == nil
 
 	var cond expression
 	if seriesRow.ValueCond != nil {
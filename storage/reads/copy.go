@@ -0,0 +1,195 @@
+package reads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// DefaultCopyBatchSize is the number of points written at once
+// by CopyBucket when the batch size of the spec is not set.
+const DefaultCopyBatchSize = 5000
+
+// CopyBucketSpec describes the data copied by CopyBucket.
+type CopyBucketSpec struct {
+	SourceOrgID, SourceBucketID influxdb.ID
+	DestOrgID, DestBucketID     influxdb.ID
+
+	// Start and End limit the copy to the points within [Start, End).
+	// The whole bucket is copied when both are zero.
+	Start, End int64
+
+	// RewriteTags returns the tags of the destination series for the
+	// tags of a source series, which may be modified in place. The tags
+	// include the measurement and field, as models.MeasurementTagKey and
+	// models.FieldKeyTagKey, and are sorted once they are rewritten.
+	// Series are copied unchanged when it is nil.
+	RewriteTags func(tags models.Tags) models.Tags
+
+	// BatchSize is the number of points written at once.
+	// It defaults to DefaultCopyBatchSize.
+	BatchSize int
+}
+
+// CopyBucket reads every series of the source bucket from s and writes
+// their points to the destination bucket with w, preserving the time
+// of each point. It returns the number of points written.
+func CopyBucket(ctx context.Context, s Store, w storage.PointsWriter, spec CopyBucketSpec) (int, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	any, err := types.MarshalAny(s.GetSource(uint64(spec.SourceOrgID), uint64(spec.SourceBucketID)))
+	if err != nil {
+		return 0, err
+	}
+
+	var req datatypes.ReadFilterRequest
+	req.ReadSource = any
+	req.Range.Start, req.Range.End = spec.Start, spec.End
+	if spec.Start == 0 && spec.End == 0 {
+		req.Range.Start, req.Range.End = models.MinNanoTime, models.MaxNanoTime
+	}
+
+	rs, err := s.ReadFilter(ctx, &req)
+	if err != nil {
+		return 0, tracing.LogError(span, err)
+	} else if rs == nil {
+		return 0, nil
+	}
+	defer rs.Close()
+
+	c := &bucketCopier{
+		w:    w,
+		name: tsdb.EncodeNameString(spec.DestOrgID, spec.DestBucketID),
+		size: spec.BatchSize,
+	}
+	if c.size <= 0 {
+		c.size = DefaultCopyBatchSize
+	}
+
+	for rs.Next() {
+		if err := ctx.Err(); err != nil {
+			return c.n, err
+		}
+
+		tags := rs.Tags().Clone()
+		if spec.RewriteTags != nil {
+			tags = spec.RewriteTags(tags)
+			sort.Sort(tags)
+		}
+		field := tags.Get(models.FieldKeyTagKeyBytes)
+		if len(field) == 0 {
+			return c.n, errors.New("missing field key")
+		}
+
+		if err := c.copySeries(ctx, tags, string(field), rs.Cursor()); err != nil {
+			return c.n, tracing.LogError(span, err)
+		}
+	}
+	if err := rs.Err(); err != nil {
+		return c.n, tracing.LogError(span, err)
+	}
+	if err := c.flush(ctx); err != nil {
+		return c.n, tracing.LogError(span, err)
+	}
+	return c.n, nil
+}
+
+// bucketCopier writes the points of copied series in batches.
+type bucketCopier struct {
+	w      storage.PointsWriter
+	name   string
+	size   int
+	points []models.Point
+
+	// n is the number of points written.
+	n int
+}
+
+func (c *bucketCopier) copySeries(ctx context.Context, tags models.Tags, field string, cur cursors.Cursor) error {
+	if cur == nil {
+		return nil
+	}
+	defer cur.Close()
+
+	switch cur := cur.(type) {
+	case cursors.FloatArrayCursor:
+		for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+			for i, ts := range a.Timestamps {
+				if err := c.add(ctx, tags, field, a.Values[i], ts); err != nil {
+					return err
+				}
+			}
+		}
+	case cursors.IntegerArrayCursor:
+		for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+			for i, ts := range a.Timestamps {
+				if err := c.add(ctx, tags, field, a.Values[i], ts); err != nil {
+					return err
+				}
+			}
+		}
+	case cursors.UnsignedArrayCursor:
+		for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+			for i, ts := range a.Timestamps {
+				if err := c.add(ctx, tags, field, a.Values[i], ts); err != nil {
+					return err
+				}
+			}
+		}
+	case cursors.BooleanArrayCursor:
+		for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+			for i, ts := range a.Timestamps {
+				if err := c.add(ctx, tags, field, a.Values[i], ts); err != nil {
+					return err
+				}
+			}
+		}
+	case cursors.StringArrayCursor:
+		for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+			for i, ts := range a.Timestamps {
+				if err := c.add(ctx, tags, field, a.Values[i], ts); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported cursor type: %T", cur)
+	}
+	return cur.Err()
+}
+
+func (c *bucketCopier) add(ctx context.Context, tags models.Tags, field string, v interface{}, ts int64) error {
+	pt, err := models.NewPoint(c.name, tags, models.Fields{field: v}, time.Unix(0, ts))
+	if err != nil {
+		return err
+	}
+	c.points = append(c.points, pt)
+	if len(c.points) < c.size {
+		return nil
+	}
+	return c.flush(ctx)
+}
+
+func (c *bucketCopier) flush(ctx context.Context) error {
+	if len(c.points) == 0 {
+		return nil
+	}
+	if err := c.w.WritePoints(ctx, c.points); err != nil {
+		return err
+	}
+	c.n += len(c.points)
+	c.points = nil
+	return nil
+}
@@ -0,0 +1,183 @@
+package reads_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/storage/readservice"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+func TestCopyBucket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage-reads-copy-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	engine := storage.NewEngine(dir, storage.NewConfig())
+	if err := engine.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+
+	org, bucket := influxdb.ID(1), influxdb.ID(2)
+
+	// Write series of each type with several points.
+	var sb strings.Builder
+	for i := 0; i < 10; i++ {
+		ts := int64(i) * 1e9
+		for t0 := 0; t0 < 3; t0++ {
+			_, _ = fmt.Fprintf(&sb, "m0,t0=a-%d f0=%d.5 %d\n", t0, i, ts)
+		}
+		for t0 := 0; t0 < 2; t0++ {
+			_, _ = fmt.Fprintf(&sb, "m1,t0=b-%d f1=%di %d\n", t0, i, ts)
+		}
+		for t1 := 0; t1 < 2; t1++ {
+			_, _ = fmt.Fprintf(&sb, "m2,t1=c-%d f2=\"v%d\" %d\n", t1, i, ts)
+		}
+	}
+	pts, err := models.ParsePointsString(sb.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts, err = tsdb.ExplodePoints(org, bucket, pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.WritePoints(context.Background(), pts); err != nil {
+		t.Fatal(err)
+	}
+
+	store := readservice.NewStore(engine)
+	want := readBucket(t, store, org, bucket)
+	if len(want) != len(pts) {
+		t.Fatalf("unexpected number of points in the source bucket: got %d, exp %d", len(want), len(pts))
+	}
+
+	t.Run("identical", func(t *testing.T) {
+		dest := bucket + 1
+		n, err := reads.CopyBucket(context.Background(), store, engine, reads.CopyBucketSpec{
+			SourceOrgID:    org,
+			SourceBucketID: bucket,
+			DestOrgID:      org,
+			DestBucketID:   dest,
+			// Write several batches.
+			BatchSize: 7,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(pts) {
+			t.Errorf("unexpected number of points copied -want/+got:\n\t- %d\n\t+ %d", len(pts), n)
+		}
+
+		if diff := cmp.Diff(want, readBucket(t, store, org, dest)); diff != "" {
+			t.Errorf("unexpected points -want/+got:\n%s", diff)
+		}
+	})
+
+	t.Run("rewrite tags", func(t *testing.T) {
+		dest := bucket + 2
+		if _, err := reads.CopyBucket(context.Background(), store, engine, reads.CopyBucketSpec{
+			SourceOrgID:    org,
+			SourceBucketID: bucket,
+			DestOrgID:      org,
+			DestBucketID:   dest,
+			RewriteTags: func(tags models.Tags) models.Tags {
+				if v := tags.Get([]byte("t0")); v != nil {
+					tags.SetString("t0", "copy-"+string(v))
+				}
+				return tags
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// The copy has the same points with the t0 tag rewritten.
+		var exp []string
+		for _, p := range want {
+			exp = append(exp, strings.Replace(p, "{t0 ", "{t0 copy-", 1))
+		}
+		sort.Strings(exp)
+		if diff := cmp.Diff(exp, readBucket(t, store, org, dest)); diff != "" {
+			t.Errorf("unexpected points -want/+got:\n%s", diff)
+		}
+	})
+}
+
+// readBucket returns each point of a bucket as its tags,
+// value and time in sorted order.
+func readBucket(t *testing.T, s reads.Store, org, bucket influxdb.ID) []string {
+	t.Helper()
+
+	any, err := types.MarshalAny(s.GetSource(uint64(org), uint64(bucket)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req datatypes.ReadFilterRequest
+	req.ReadSource = any
+	req.Range.Start, req.Range.End = models.MinNanoTime, models.MaxNanoTime
+
+	rs, err := s.ReadFilter(context.Background(), &req)
+	if err != nil {
+		t.Fatal(err)
+	} else if rs == nil {
+		return nil
+	}
+	defer rs.Close()
+
+	var points []string
+	for rs.Next() {
+		tags := rs.Tags().String()
+		add := func(v interface{}, ts int64) {
+			points = append(points, fmt.Sprintf("%s %v %d", tags, v, ts))
+		}
+
+		switch cur := rs.Cursor().(type) {
+		case cursors.FloatArrayCursor:
+			for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+				for i, ts := range a.Timestamps {
+					add(a.Values[i], ts)
+				}
+			}
+			cur.Close()
+		case cursors.IntegerArrayCursor:
+			for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+				for i, ts := range a.Timestamps {
+					add(a.Values[i], ts)
+				}
+			}
+			cur.Close()
+		case cursors.StringArrayCursor:
+			for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+				for i, ts := range a.Timestamps {
+					add(a.Values[i], ts)
+				}
+			}
+			cur.Close()
+		case nil:
+		default:
+			t.Fatalf("unexpected cursor type: %T", cur)
+		}
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(points)
+	return points
+}
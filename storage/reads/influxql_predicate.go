@@ -34,7 +34,7 @@ func NodeToExpr(node *datatypes.Node, remap map[string]string) (influxql.Expr, e
 		Condition: v.exprs[0],
 	}
 	stmt.RewriteRegexConditions()
-	return stmt.Condition, nil
+	return rewriteRegexLiterals(stmt.Condition), nil
 }
 
 type nodeToExprVisitor struct {
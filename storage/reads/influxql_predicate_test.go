@@ -90,3 +90,74 @@ func TestHasFieldValueKey(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeToExpr_RegexLiterals(t *testing.T) {
+	regex := func(comparison datatypes.Node_Comparison, pattern string) *datatypes.Node {
+		return &datatypes.Node{
+			NodeType: datatypes.NodeTypeComparisonExpression,
+			Value:    &datatypes.Node_Comparison_{Comparison: comparison},
+			Children: []*datatypes.Node{
+				{
+					NodeType: datatypes.NodeTypeTagRef,
+					Value:    &datatypes.Node_TagRefValue{TagRefValue: "t0"},
+				},
+				{
+					NodeType: datatypes.NodeTypeLiteral,
+					Value:    &datatypes.Node_RegexValue{RegexValue: pattern},
+				},
+			},
+		}
+	}
+
+	for _, tt := range []struct {
+		name string
+		node *datatypes.Node
+		want string
+	}{
+		{
+			name: "alternation",
+			node: regex(datatypes.ComparisonRegex, "^(a-1|a-3)$"),
+			want: `(t0::tag = 'a-1' OR t0::tag = 'a-3')`,
+		},
+		{
+			name: "character class",
+			node: regex(datatypes.ComparisonRegex, "^host-0[1-2]$"),
+			want: `(t0::tag = 'host-01' OR t0::tag = 'host-02')`,
+		},
+		{
+			name: "not alternation",
+			node: regex(datatypes.ComparisonNotRegex, "^(a|b)$"),
+			want: `(t0::tag != 'a' AND t0::tag != 'b')`,
+		},
+		{
+			name: "single literal",
+			node: regex(datatypes.ComparisonRegex, "^a$"),
+			want: `t0::tag = 'a'`,
+		},
+		{
+			name: "unanchored",
+			node: regex(datatypes.ComparisonRegex, "a-[01]"),
+			want: `t0::tag =~ /a-[01]/`,
+		},
+		{
+			name: "repetition",
+			node: regex(datatypes.ComparisonRegex, "^a.*$"),
+			want: `t0::tag =~ /^a.*$/`,
+		},
+		{
+			name: "empty value",
+			node: regex(datatypes.ComparisonRegex, "^(a|)$"),
+			want: `t0::tag =~ /^(a|)$/`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := reads.NodeToExpr(tt.node, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expr.String(); got != tt.want {
+				t.Errorf("unexpected expression -want/+got:\n\t- %s\n\t+ %s", tt.want, got)
+			}
+		})
+	}
+}
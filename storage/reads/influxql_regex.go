@@ -0,0 +1,162 @@
+package reads
+
+import (
+	"regexp/syntax"
+
+	"github.com/influxdata/influxql"
+)
+
+// maxRegexLiterals is the largest number of values a regular expression
+// may match for it to be rewritten as comparisons to each of them.
+const maxRegexLiterals = 64
+
+// rewriteRegexLiterals rewrites the comparisons of tags to anchored regular
+// expressions that match a small set of values, such as /^(a|b)$/ or
+// /^host-0[1-4]$/, as comparisons to each of the values. The index finds
+// the series for each value directly rather than matching every value
+// of the tag with the regular expression.
+func rewriteRegexLiterals(expr influxql.Expr) influxql.Expr {
+	return influxql.RewriteExpr(expr, func(expr influxql.Expr) influxql.Expr {
+		be, ok := expr.(*influxql.BinaryExpr)
+		if !ok || (be.Op != influxql.EQREGEX && be.Op != influxql.NEQREGEX) {
+			return expr
+		}
+		ref, ok := be.LHS.(*influxql.VarRef)
+		if !ok || ref.Val == fieldRef {
+			return expr
+		}
+		re, ok := be.RHS.(*influxql.RegexLiteral)
+		if !ok || re.Val == nil {
+			return expr
+		}
+		values, ok := regexLiterals(re.Val.String())
+		if !ok {
+			return expr
+		}
+
+		op, logical := influxql.EQ, influxql.OR
+		if be.Op == influxql.NEQREGEX {
+			op, logical = influxql.NEQ, influxql.AND
+		}
+		var out influxql.Expr
+		for _, v := range values {
+			cmp := &influxql.BinaryExpr{
+				LHS: &influxql.VarRef{Val: ref.Val, Type: ref.Type},
+				Op:  op,
+				RHS: &influxql.StringLiteral{Val: v},
+			}
+			if out == nil {
+				out = cmp
+				continue
+			}
+			out = &influxql.BinaryExpr{LHS: out, Op: logical, RHS: cmp}
+		}
+		return &influxql.ParenExpr{Expr: out}
+	})
+}
+
+// regexLiterals returns the values matched by a regular expression that
+// is anchored at both ends and matches at most maxRegexLiterals non-empty
+// values. It returns false for any other regular expression.
+func regexLiterals(pattern string) ([]string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, false
+	}
+	re = re.Simplify()
+
+	if re.Op != syntax.OpConcat || len(re.Sub) < 3 {
+		return nil, false
+	}
+	first, last := re.Sub[0], re.Sub[len(re.Sub)-1]
+	if first.Op != syntax.OpBeginText || last.Op != syntax.OpEndText {
+		return nil, false
+	}
+
+	values := []string{""}
+	for _, sub := range re.Sub[1 : len(re.Sub)-1] {
+		vs, ok := regexValues(sub)
+		if !ok {
+			return nil, false
+		}
+		values, ok = concatValues(values, vs)
+		if !ok {
+			return nil, false
+		}
+	}
+	for _, v := range values {
+		// An empty value also matches series without the tag,
+		// which a comparison to it does not.
+		if v == "" {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// regexValues returns the values matched by an unanchored regular
+// expression of literals, character classes, alternations and
+// concatenations of them.
+func regexValues(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return []string{""}, true
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		return []string{string(re.Rune)}, true
+	case syntax.OpCharClass:
+		var values []string
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			lo, hi := re.Rune[i], re.Rune[i+1]
+			if int(hi-lo)+1+len(values) > maxRegexLiterals {
+				return nil, false
+			}
+			for r := lo; r <= hi; r++ {
+				values = append(values, string(r))
+			}
+		}
+		return values, true
+	case syntax.OpCapture:
+		return regexValues(re.Sub[0])
+	case syntax.OpConcat:
+		values := []string{""}
+		for _, sub := range re.Sub {
+			vs, ok := regexValues(sub)
+			if !ok {
+				return nil, false
+			}
+			if values, ok = concatValues(values, vs); !ok {
+				return nil, false
+			}
+		}
+		return values, true
+	case syntax.OpAlternate:
+		var values []string
+		for _, sub := range re.Sub {
+			vs, ok := regexValues(sub)
+			if !ok || len(values)+len(vs) > maxRegexLiterals {
+				return nil, false
+			}
+			values = append(values, vs...)
+		}
+		return values, true
+	default:
+		return nil, false
+	}
+}
+
+// concatValues returns every value of a followed by a value of b.
+func concatValues(a, b []string) ([]string, bool) {
+	if len(a)*len(b) > maxRegexLiterals {
+		return nil, false
+	}
+	values := make([]string, 0, len(a)*len(b))
+	for _, x := range a {
+		for _, y := range b {
+			values = append(values, x+y)
+		}
+	}
+	return values, true
+}
@@ -3,6 +3,7 @@ package reads
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
@@ -10,6 +11,7 @@ import (
 	"github.com/influxdata/influxdb/v2/query"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/influxdata/influxdb/v2/tsdb/cursors"
 	"github.com/influxdata/influxql"
 )
@@ -168,3 +170,75 @@ func (c *indexSeriesCursor) Value(key string) (interface{}, bool) {
 func (c *indexSeriesCursor) Err() error {
 	return c.err
 }
+
+type seriesKeysCursor struct {
+	name      []byte
+	keys      [][]byte
+	seriesRow SeriesRow
+	tags      models.Tags
+}
+
+// NewSeriesKeysCursor returns a SeriesCursor which emits a row for each of
+// the series keys without scanning the index. Each key is of the form
+// "m0,t0=v0,_field=f0"; keys that are missing a measurement or field are skipped.
+func NewSeriesKeysCursor(ctx context.Context, orgID, bucketID influxdb.ID, keys [][]byte, viewer Viewer) (SeriesCursor, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	cursorIterator, err := viewer.CreateCursorIterator(ctx)
+	if err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+
+	if cursorIterator == nil {
+		return nil, nil
+	}
+
+	return &seriesKeysCursor{
+		name:      tsdb.EncodeNameSlice(orgID, bucketID),
+		keys:      keys,
+		seriesRow: SeriesRow{Query: cursorIterator},
+	}, nil
+}
+
+func (c *seriesKeysCursor) Close() {
+	c.keys = nil
+}
+
+// Next emits a series row for the next valid series key.
+func (c *seriesKeysCursor) Next() *SeriesRow {
+	for len(c.keys) > 0 {
+		key := c.keys[0]
+		c.keys = c.keys[1:]
+
+		var measurement []byte
+		measurement, c.tags = models.ParseKeyBytesWithTags(key, c.tags[:0])
+		sort.Sort(c.tags)
+		field := c.tags.Get(fieldKeyBytes)
+		if len(measurement) == 0 || len(field) == 0 {
+			continue
+		}
+
+		c.seriesRow.Name = c.name
+		c.seriesRow.Field = string(field)
+
+		// The series tags use the special tag keys, as stored by the engine.
+		c.seriesRow.SeriesTags = copyTags(c.seriesRow.SeriesTags, c.tags)
+		c.seriesRow.SeriesTags.Delete(fieldKeyBytes)
+		c.seriesRow.SeriesTags = append(c.seriesRow.SeriesTags,
+			models.NewTag(models.MeasurementTagKeyBytes, measurement),
+			models.NewTag(models.FieldKeyTagKeyBytes, field),
+		)
+		sort.Sort(c.seriesRow.SeriesTags)
+
+		c.seriesRow.Tags = copyTags(c.seriesRow.Tags, c.tags)
+		c.seriesRow.Tags.Set(measurementKeyBytes, measurement)
+
+		return &c.seriesRow
+	}
+	return nil
+}
+
+func (c *seriesKeysCursor) Err() error {
+	return nil
+}
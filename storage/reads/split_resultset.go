@@ -0,0 +1,123 @@
+package reads
+
+import (
+	"context"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// NewSplitFilteredResultSets returns a result set for each of the cursor
+// iterators that produce the series of seriesCursor in turn. The ith
+// series is produced by result set i modulo the number of iterators, and
+// its cursor is created by the iterator of that result set, so that the
+// result sets may be read concurrently. The series cursor is closed once
+// every result set is closed.
+func NewSplitFilteredResultSets(ctx context.Context, req *datatypes.ReadFilterRequest, seriesCursor SeriesCursor, iterators []cursors.CursorIterator) []ResultSet {
+	s := &seriesSplitter{
+		cur:    seriesCursor,
+		queues: make([][]SeriesRow, len(iterators)),
+		closed: make([]bool, len(iterators)),
+		open:   len(iterators),
+	}
+	rss := make([]ResultSet, len(iterators))
+	for w, itr := range iterators {
+		rss[w] = NewFilteredResultSet(ctx, req, &splitSeriesCursor{s: s, w: w, query: itr})
+	}
+	return rss
+}
+
+// seriesSplitter reads the rows of a series cursor and queues each
+// row for the split series cursor that produces it.
+type seriesSplitter struct {
+	mu     sync.Mutex
+	cur    SeriesCursor
+	n      int // number of rows read from cur
+	queues [][]SeriesRow
+	closed []bool
+	eof    bool
+	open   int // number of split series cursors that are not closed
+}
+
+// next returns the next row of the split series cursor w.
+func (s *seriesSplitter) next(w int) (SeriesRow, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.queues[w]) == 0 {
+		if s.eof {
+			return SeriesRow{}, false
+		}
+		row := s.cur.Next()
+		if row == nil {
+			s.eof = true
+			return SeriesRow{}, false
+		}
+
+		i := s.n % len(s.queues)
+		s.n++
+		if s.closed[i] {
+			continue
+		}
+
+		// The series cursor reuses its row, so queued rows are copied.
+		s.queues[i] = append(s.queues[i], SeriesRow{
+			SortKey:    row.SortKey,
+			Name:       append([]byte(nil), row.Name...),
+			SeriesTags: row.SeriesTags.Clone(),
+			Tags:       row.Tags.Clone(),
+			Field:      row.Field,
+			ValueCond:  row.ValueCond,
+		})
+	}
+
+	row := s.queues[w][0]
+	s.queues[w] = s.queues[w][1:]
+	return row, true
+}
+
+func (s *seriesSplitter) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cur.Err()
+}
+
+func (s *seriesSplitter) close(w int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queues[w], s.closed[w] = nil, true
+	if s.open--; s.open == 0 {
+		s.cur.Close()
+	}
+}
+
+// splitSeriesCursor produces the rows of a seriesSplitter for w,
+// with the cursor iterator its cursors are created from.
+type splitSeriesCursor struct {
+	s      *seriesSplitter
+	w      int
+	query  cursors.CursorIterator
+	row    SeriesRow
+	closed bool
+}
+
+func (c *splitSeriesCursor) Next() *SeriesRow {
+	row, ok := c.s.next(c.w)
+	if !ok {
+		return nil
+	}
+	c.row = row
+	c.row.Query = c.query
+	return &c.row
+}
+
+func (c *splitSeriesCursor) Err() error { return c.s.err() }
+
+func (c *splitSeriesCursor) Close() {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.s.close(c.w)
+}
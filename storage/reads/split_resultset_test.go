@@ -0,0 +1,41 @@
+package reads_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+func TestNewSplitFilteredResultSets(t *testing.T) {
+	cur := &sliceSeriesCursor{
+		rows: newSeriesRows(
+			"cpu,tag0=val00",
+			"cpu,tag0=val01",
+			"cpu,tag0=val02",
+			"cpu,tag0=val03",
+			"cpu,tag0=val04",
+		)}
+	rss := reads.NewSplitFilteredResultSets(context.Background(), &datatypes.ReadFilterRequest{}, cur, make([]cursors.CursorIterator, 2))
+
+	// The result sets are read out of order, but each
+	// produces every other series in the order of the read.
+	got := make([][]string, len(rss))
+	for w := len(rss) - 1; w >= 0; w-- {
+		for rss[w].Next() {
+			got[w] = append(got[w], rss[w].Tags().String())
+		}
+		rss[w].Close()
+	}
+
+	exp := [][]string{
+		{"[{_m cpu} {tag0 val00}]", "[{_m cpu} {tag0 val02}]", "[{_m cpu} {tag0 val04}]"},
+		{"[{_m cpu} {tag0 val01}]", "[{_m cpu} {tag0 val03}]"},
+	}
+	if diff := cmp.Diff(exp, got); diff != "" {
+		t.Errorf("unexpected series -want/+got:\n%s", diff)
+	}
+}
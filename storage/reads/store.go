@@ -108,3 +108,48 @@ type WindowAggregateStore interface {
 	// WindowAggregate will invoke a ReadWindowAggregateRequest against the Store.
 	WindowAggregate(ctx context.Context, req *datatypes.ReadWindowAggregateRequest) (ResultSet, error)
 }
+
+// SplitReadStore implements splitting the series of a ReadFilter
+// between result sets that may be read concurrently.
+type SplitReadStore interface {
+	// ReadFilterSplit will read the series of req once and split them
+	// between n result sets. The ith series of the read is produced by
+	// result set i modulo n, and each result set has its own cursors.
+	ReadFilterSplit(ctx context.Context, req *datatypes.ReadFilterRequest, n int) ([]ResultSet, error)
+}
+
+// SeriesKeysStore implements reading an explicit set of series.
+type SeriesKeysStore interface {
+	// ReadSeriesKeys will read the series identified by keys, bypassing the index.
+	ReadSeriesKeys(ctx context.Context, req *datatypes.ReadFilterRequest, keys [][]byte) (ResultSet, error)
+}
+
+// BlockStatsStore implements describing the TSM blocks that would be read.
+type BlockStatsStore interface {
+	// ReadBlockStats will return the blocks within the range of req.
+	// The predicate of req is ignored.
+	ReadBlockStats(ctx context.Context, req *datatypes.ReadFilterRequest) ([]cursors.BlockStat, error)
+}
+
+// PrefetchStore implements reading the TSM blocks of a read ahead of time.
+type PrefetchStore interface {
+	// Prefetch pages the blocks of the series matching the predicate of
+	// req within its range into memory without decoding them. It returns
+	// the number of bytes paged in.
+	Prefetch(ctx context.Context, req *datatypes.ReadFilterRequest) (int64, error)
+}
+
+// FieldKeysStore implements listing the field keys of a bucket.
+type FieldKeysStore interface {
+	// FieldKeys will return the distinct field keys and types of every
+	// measurement within the range of req, sorted by key and then type.
+	FieldKeys(ctx context.Context, req *datatypes.ReadFilterRequest) ([]cursors.MeasurementField, error)
+}
+
+// CursorLimitStore implements limiting the number of cursors that are open at once.
+type CursorLimitStore interface {
+	// MaxOpenCursors returns the number of cursors that each read of
+	// the store may have open at once. Opening another cursor waits
+	// until an open cursor of the same read is closed.
+	MaxOpenCursors() int
+}
@@ -16,3 +16,31 @@ type Viewer interface {
 	TagKeys(ctx context.Context, orgID, bucketID influxdb.ID, start, end int64, predicate influxql.Expr) (cursors.StringIterator, error)
 	TagValues(ctx context.Context, orgID, bucketID influxdb.ID, tagKey string, start, end int64, predicate influxql.Expr) (cursors.StringIterator, error)
 }
+
+// RangeViewer is implemented by a Viewer that can cheaply determine
+// whether a bucket may contain data within the time range [start, end].
+// It must never return false when data exists within the range.
+type RangeViewer interface {
+	MayHaveDataInRange(orgID, bucketID influxdb.ID, start, end int64) bool
+}
+
+// BlockStatsViewer is implemented by a Viewer that can describe the
+// TSM blocks of a bucket within the time range [start, end].
+type BlockStatsViewer interface {
+	ReadBlockStats(ctx context.Context, orgID, bucketID influxdb.ID, start, end int64) ([]cursors.BlockStat, error)
+}
+
+// PrefetchViewer is implemented by a Viewer that can page the TSM blocks
+// of a bucket within the time range [start, end] into memory without
+// decoding them.
+type PrefetchViewer interface {
+	PrefetchBlocks(ctx context.Context, orgID, bucketID influxdb.ID, start, end int64, include func(seriesKey []byte) bool) (int64, error)
+}
+
+// FieldKeysViewer is implemented by a Viewer that can list the
+// measurements of a bucket and their fields within the time range
+// [start, end].
+type FieldKeysViewer interface {
+	MeasurementNames(ctx context.Context, orgID, bucketID influxdb.ID, start, end int64, predicate influxql.Expr) (cursors.StringIterator, error)
+	MeasurementFields(ctx context.Context, orgID, bucketID influxdb.ID, measurement string, start, end int64, predicate influxql.Expr) (cursors.MeasurementFieldsIterator, error)
+}
@@ -0,0 +1,126 @@
+package readservice
+
+import (
+	"context"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// cursorLimiter is a semaphore that bounds the number of open cursors
+// of a single read.
+type cursorLimiter chan struct{}
+
+// acquire waits until a cursor may be opened or ctx is done.
+func (l cursorLimiter) acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l cursorLimiter) release() { <-l }
+
+// limitedViewer is a viewer whose cursors each hold a slot
+// of the limiter until they are closed.
+type limitedViewer struct {
+	reads.Viewer
+	limit cursorLimiter
+}
+
+func (v *limitedViewer) CreateCursorIterator(ctx context.Context) (cursors.CursorIterator, error) {
+	itr, err := v.Viewer.CreateCursorIterator(ctx)
+	if err != nil || itr == nil {
+		return itr, err
+	}
+	return &limitedCursorIterator{CursorIterator: itr, limit: v.limit}, nil
+}
+
+type limitedCursorIterator struct {
+	cursors.CursorIterator
+	limit cursorLimiter
+}
+
+func (itr *limitedCursorIterator) Next(ctx context.Context, r *cursors.CursorRequest) (cursors.Cursor, error) {
+	if err := itr.limit.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	cur, err := itr.CursorIterator.Next(ctx, r)
+	if err != nil || cur == nil {
+		itr.limit.release()
+		return cur, err
+	}
+
+	var once sync.Once
+	release := func() { once.Do(itr.limit.release) }
+	switch c := cur.(type) {
+	case cursors.IntegerArrayCursor:
+		return &limitedIntegerArrayCursor{IntegerArrayCursor: c, release: release}, nil
+	case cursors.FloatArrayCursor:
+		return &limitedFloatArrayCursor{FloatArrayCursor: c, release: release}, nil
+	case cursors.UnsignedArrayCursor:
+		return &limitedUnsignedArrayCursor{UnsignedArrayCursor: c, release: release}, nil
+	case cursors.StringArrayCursor:
+		return &limitedStringArrayCursor{StringArrayCursor: c, release: release}, nil
+	case cursors.BooleanArrayCursor:
+		return &limitedBooleanArrayCursor{BooleanArrayCursor: c, release: release}, nil
+	default:
+		// The type of cursor is unknown, so it cannot hold a slot.
+		release()
+		return cur, nil
+	}
+}
+
+type limitedIntegerArrayCursor struct {
+	cursors.IntegerArrayCursor
+	release func()
+}
+
+func (c *limitedIntegerArrayCursor) Close() {
+	c.IntegerArrayCursor.Close()
+	c.release()
+}
+
+type limitedFloatArrayCursor struct {
+	cursors.FloatArrayCursor
+	release func()
+}
+
+func (c *limitedFloatArrayCursor) Close() {
+	c.FloatArrayCursor.Close()
+	c.release()
+}
+
+type limitedUnsignedArrayCursor struct {
+	cursors.UnsignedArrayCursor
+	release func()
+}
+
+func (c *limitedUnsignedArrayCursor) Close() {
+	c.UnsignedArrayCursor.Close()
+	c.release()
+}
+
+type limitedStringArrayCursor struct {
+	cursors.StringArrayCursor
+	release func()
+}
+
+func (c *limitedStringArrayCursor) Close() {
+	c.StringArrayCursor.Close()
+	c.release()
+}
+
+type limitedBooleanArrayCursor struct {
+	cursors.BooleanArrayCursor
+	release func()
+}
+
+func (c *limitedBooleanArrayCursor) Close() {
+	c.BooleanArrayCursor.Close()
+	c.release()
+}
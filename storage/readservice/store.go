@@ -3,8 +3,10 @@ package readservice
 import (
 	"context"
 	"errors"
+	"sort"
 
 	"github.com/gogo/protobuf/proto"
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/storage/reads"
@@ -17,11 +19,32 @@ type store struct {
 	viewer    reads.Viewer
 	groupCap  GroupCapability
 	windowCap WindowAggregateCapability
+
+	// maxOpenCursors is zero if open cursors are not limited.
+	maxOpenCursors int
+}
+
+// Option configures a store.
+type Option func(s *store)
+
+// WithMaxOpenCursors limits the number of cursors that each read of
+// the store may have open at once. Opening another cursor waits until
+// one of the open cursors of the same read is closed or the context of
+// the read is done, so reads do not wait on each other. Values less
+// than or equal to zero do not limit cursors, which is the default.
+func WithMaxOpenCursors(n int) Option {
+	return func(s *store) {
+		if n > 0 {
+			s.maxOpenCursors = n
+		} else {
+			s.maxOpenCursors = 0
+		}
+	}
 }
 
 // NewStore creates a store used to query time-series data.
-func NewStore(viewer reads.Viewer) reads.Store {
-	return &store{
+func NewStore(viewer reads.Viewer, opts ...Option) reads.Store {
+	s := &store{
 		viewer: viewer,
 		groupCap: GroupCapability{
 			Count: true,
@@ -42,6 +65,25 @@ func NewStore(viewer reads.Viewer) reads.Store {
 			Offset: true,
 		},
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// MaxOpenCursors returns the limit of open cursors or
+// zero if open cursors are not limited.
+func (s *store) MaxOpenCursors() int {
+	return s.maxOpenCursors
+}
+
+// seriesViewer returns the viewer that the cursors of a read are
+// created from. Each call limits its open cursors separately.
+func (s *store) seriesViewer() reads.Viewer {
+	if s.maxOpenCursors == 0 {
+		return s.viewer
+	}
+	return &limitedViewer{Viewer: s.viewer, limit: make(cursorLimiter, s.maxOpenCursors)}
 }
 
 func (s *store) ReadFilter(ctx context.Context, req *datatypes.ReadFilterRequest) (reads.ResultSet, error) {
@@ -57,8 +99,12 @@ func (s *store) ReadFilter(ctx context.Context, req *datatypes.ReadFilterRequest
 		return nil, tracing.LogError(span, err)
 	}
 
+	if !s.mayHaveDataInRange(source.GetOrgID(), source.GetBucketID(), req.Range) {
+		return nil, nil
+	}
+
 	var cur reads.SeriesCursor
-	if cur, err = reads.NewIndexSeriesCursor(ctx, source.GetOrgID(), source.GetBucketID(), req.Predicate, s.viewer); err != nil {
+	if cur, err = reads.NewIndexSeriesCursor(ctx, source.GetOrgID(), source.GetBucketID(), req.Predicate, s.seriesViewer()); err != nil {
 		return nil, tracing.LogError(span, err)
 	} else if cur == nil {
 		return nil, nil
@@ -67,6 +113,226 @@ func (s *store) ReadFilter(ctx context.Context, req *datatypes.ReadFilterRequest
 	return reads.NewFilteredResultSet(ctx, req, cur), nil
 }
 
+func (s *store) ReadFilterSplit(ctx context.Context, req *datatypes.ReadFilterRequest, n int) ([]reads.ResultSet, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if req.ReadSource == nil {
+		return nil, tracing.LogError(span, errors.New("missing read source"))
+	}
+
+	source, err := getReadSource(*req.ReadSource)
+	if err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+
+	if !s.mayHaveDataInRange(source.GetOrgID(), source.GetBucketID(), req.Range) {
+		return make([]reads.ResultSet, n), nil
+	}
+
+	viewer := s.seriesViewer()
+	var cur reads.SeriesCursor
+	if cur, err = reads.NewIndexSeriesCursor(ctx, source.GetOrgID(), source.GetBucketID(), req.Predicate, viewer); err != nil {
+		return nil, tracing.LogError(span, err)
+	} else if cur == nil {
+		return make([]reads.ResultSet, n), nil
+	}
+
+	iterators := make([]cursors.CursorIterator, n)
+	for i := range iterators {
+		if iterators[i], err = viewer.CreateCursorIterator(ctx); err != nil {
+			cur.Close()
+			return nil, tracing.LogError(span, err)
+		}
+	}
+	return reads.NewSplitFilteredResultSets(ctx, req, cur, iterators), nil
+}
+
+func (s *store) ReadSeriesKeys(ctx context.Context, req *datatypes.ReadFilterRequest, keys [][]byte) (reads.ResultSet, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if req.ReadSource == nil {
+		return nil, tracing.LogError(span, errors.New("missing read source"))
+	}
+
+	source, err := getReadSource(*req.ReadSource)
+	if err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+
+	if !s.mayHaveDataInRange(source.GetOrgID(), source.GetBucketID(), req.Range) {
+		return nil, nil
+	}
+
+	var cur reads.SeriesCursor
+	if cur, err = reads.NewSeriesKeysCursor(ctx, source.GetOrgID(), source.GetBucketID(), keys, s.seriesViewer()); err != nil {
+		return nil, tracing.LogError(span, err)
+	} else if cur == nil {
+		return nil, nil
+	}
+
+	return reads.NewFilteredResultSet(ctx, req, cur), nil
+}
+
+func (s *store) ReadBlockStats(ctx context.Context, req *datatypes.ReadFilterRequest) ([]cursors.BlockStat, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if req.ReadSource == nil {
+		return nil, tracing.LogError(span, errors.New("missing read source"))
+	}
+
+	source, err := getReadSource(*req.ReadSource)
+	if err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+
+	bv, ok := s.viewer.(reads.BlockStatsViewer)
+	if !ok {
+		return nil, tracing.LogError(span, errors.New("viewer does not support block stats"))
+	}
+	return bv.ReadBlockStats(ctx, source.GetOrgID(), source.GetBucketID(), req.Range.Start, req.Range.End)
+}
+
+func (s *store) Prefetch(ctx context.Context, req *datatypes.ReadFilterRequest) (int64, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if req.ReadSource == nil {
+		return 0, tracing.LogError(span, errors.New("missing read source"))
+	}
+
+	source, err := getReadSource(*req.ReadSource)
+	if err != nil {
+		return 0, tracing.LogError(span, err)
+	}
+
+	pv, ok := s.viewer.(reads.PrefetchViewer)
+	if !ok {
+		return 0, tracing.LogError(span, errors.New("viewer does not support prefetch"))
+	}
+
+	if !s.mayHaveDataInRange(source.GetOrgID(), source.GetBucketID(), req.Range) {
+		return 0, nil
+	}
+
+	// The series matching the predicate are found with the index
+	// so that only the blocks of those series are paged in.
+	var include func(seriesKey []byte) bool
+	if req.Predicate.GetRoot() != nil {
+		cur, err := reads.NewIndexSeriesCursor(ctx, source.GetOrgID(), source.GetBucketID(), req.Predicate, s.viewer)
+		if err != nil {
+			return 0, tracing.LogError(span, err)
+		} else if cur == nil {
+			return 0, nil
+		}
+
+		keys := make(map[string]struct{})
+		for row := cur.Next(); row != nil; row = cur.Next() {
+			keys[string(models.MakeKey(row.Name, row.SeriesTags))] = struct{}{}
+		}
+		err = cur.Err()
+		cur.Close()
+		if err != nil {
+			return 0, tracing.LogError(span, err)
+		}
+		include = func(seriesKey []byte) bool {
+			_, ok := keys[string(seriesKey)]
+			return ok
+		}
+	}
+	return pv.PrefetchBlocks(ctx, source.GetOrgID(), source.GetBucketID(), req.Range.Start, req.Range.End, include)
+}
+
+func (s *store) FieldKeys(ctx context.Context, req *datatypes.ReadFilterRequest) ([]cursors.MeasurementField, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if req.ReadSource == nil {
+		return nil, tracing.LogError(span, errors.New("missing read source"))
+	}
+
+	if req.Range.Start == 0 {
+		req.Range.Start = models.MinNanoTime
+	}
+	if req.Range.End == 0 {
+		req.Range.End = models.MaxNanoTime
+	}
+
+	var expr influxql.Expr
+	var err error
+	if root := req.Predicate.GetRoot(); root != nil {
+		expr, err = reads.NodeToExpr(root, nil)
+		if err != nil {
+			return nil, tracing.LogError(span, err)
+		}
+
+		if found := reads.HasFieldValueKey(expr); found {
+			return nil, tracing.LogError(span, errors.New("field values unsupported"))
+		}
+		expr = influxql.Reduce(influxql.CloneExpr(expr), nil)
+		if reads.IsTrueBooleanLiteral(expr) {
+			expr = nil
+		}
+	}
+
+	source, err := getReadSource(*req.ReadSource)
+	if err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+
+	fv, ok := s.viewer.(reads.FieldKeysViewer)
+	if !ok {
+		return nil, tracing.LogError(span, errors.New("viewer does not support field keys"))
+	}
+
+	orgID, bucketID := source.GetOrgID(), source.GetBucketID()
+	names, err := fv.MeasurementNames(ctx, orgID, bucketID, req.Range.Start, req.Range.End, expr)
+	if err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+
+	// A field key may have a different type in each measurement.
+	type fieldKey struct {
+		key string
+		typ cursors.FieldType
+	}
+	seen := make(map[fieldKey]bool)
+	var fields []cursors.MeasurementField
+	for names.Next() {
+		it, err := fv.MeasurementFields(ctx, orgID, bucketID, names.Value(), req.Range.Start, req.Range.End, expr)
+		if err != nil {
+			return nil, tracing.LogError(span, err)
+		}
+		for it.Next() {
+			for _, f := range it.Value().Fields {
+				k := fieldKey{key: f.Key, typ: f.Type}
+				if seen[k] {
+					continue
+				}
+				seen[k] = true
+				fields = append(fields, cursors.MeasurementField{Key: f.Key, Type: f.Type})
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].Key != fields[j].Key {
+			return fields[i].Key < fields[j].Key
+		}
+		return fields[i].Type < fields[j].Type
+	})
+	return fields, nil
+}
+
+// mayHaveDataInRange returns false when the viewer can determine that
+// the bucket has no data within the range, so that no cursors are created.
+func (s *store) mayHaveDataInRange(orgID, bucketID influxdb.ID, r datatypes.TimestampRange) bool {
+	rv, ok := s.viewer.(reads.RangeViewer)
+	return !ok || rv.MayHaveDataInRange(orgID, bucketID, r.Start, r.End)
+}
+
 func (s *store) GetGroupCapability(ctx context.Context) reads.GroupCapability {
 	return s.groupCap
 }
@@ -84,8 +350,9 @@ func (s *store) ReadGroup(ctx context.Context, req *datatypes.ReadGroupRequest)
 		return nil, tracing.LogError(span, err)
 	}
 
+	viewer := s.seriesViewer()
 	newCursor := func() (reads.SeriesCursor, error) {
-		return reads.NewIndexSeriesCursor(ctx, source.GetOrgID(), source.GetBucketID(), req.Predicate, s.viewer)
+		return reads.NewIndexSeriesCursor(ctx, source.GetOrgID(), source.GetBucketID(), req.Predicate, viewer)
 	}
 
 	return reads.NewGroupResultSet(ctx, req, newCursor), nil
@@ -198,8 +465,12 @@ func (s *store) WindowAggregate(ctx context.Context, req *datatypes.ReadWindowAg
 		return nil, tracing.LogError(span, err)
 	}
 
+	if !s.mayHaveDataInRange(source.GetOrgID(), source.GetBucketID(), req.Range) {
+		return nil, nil
+	}
+
 	var cur reads.SeriesCursor
-	if cur, err = reads.NewIndexSeriesCursor(ctx, source.GetOrgID(), source.GetBucketID(), req.Predicate, s.viewer); err != nil {
+	if cur, err = reads.NewIndexSeriesCursor(ctx, source.GetOrgID(), source.GetBucketID(), req.Predicate, s.seriesViewer()); err != nil {
 		return nil, tracing.LogError(span, err)
 	} else if cur == nil {
 		return nil, nil
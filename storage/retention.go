@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"math"
+	"sort"
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/predicate"
 	"github.com/influxdata/influxdb/v2/tsdb/tsm1"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
@@ -24,6 +26,12 @@ type Deleter interface {
 	DeleteBucketRange(ctx context.Context, orgID, bucketID influxdb.ID, min, max int64) error
 }
 
+// A MeasurementDeleter implementation is capable of deleting the data of
+// the series matching a predicate from a storage engine.
+type MeasurementDeleter interface {
+	DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID influxdb.ID, min, max int64, pred influxdb.Predicate) error
+}
+
 // A Snapshotter implementation can take snapshots of the entire engine.
 type Snapshotter interface {
 	WriteSnapshot(ctx context.Context, status tsm1.CacheStatus) error
@@ -136,7 +144,7 @@ func (s *retentionEnforcer) expireData(ctx context.Context, buckets []*influxdb.
 			zap.String("system_type", b.Type.String()),
 		}
 
-		if b.RetentionPeriod == 0 {
+		if b.RetentionPeriod == 0 && len(b.MeasurementRetention) == 0 {
 			logger.Debug("Skipping bucket with infinite retention", bucketFields...)
 			skipInf++
 			continue
@@ -146,6 +154,12 @@ func (s *retentionEnforcer) expireData(ctx context.Context, buckets []*influxdb.
 			continue
 		}
 
+		// Measurements that expire sooner than the bucket are deleted first.
+		s.expireMeasurements(ctx, logger, b, now, bucketFields)
+		if b.RetentionPeriod == 0 {
+			continue
+		}
+
 		min := int64(math.MinInt64)
 		max := now.Add(-b.RetentionPeriod).UnixNano()
 
@@ -175,6 +189,72 @@ func (s *retentionEnforcer) expireData(ctx context.Context, buckets []*influxdb.
 	}
 }
 
+// expireMeasurements deletes the data of each measurement of b that falls
+// outside the retention period overriding the one of the bucket. Overrides
+// that are not shorter than the retention period of the bucket are ignored,
+// and the measurements are expired in order of their retention period.
+func (s *retentionEnforcer) expireMeasurements(ctx context.Context, logger *zap.Logger, b *influxdb.Bucket, now time.Time, bucketFields []zapcore.Field) {
+	md, ok := s.Engine.(MeasurementDeleter)
+	if !ok || len(b.MeasurementRetention) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(b.MeasurementRetention))
+	for name, d := range b.MeasurementRetention {
+		if d <= 0 || (b.RetentionPeriod > 0 && d >= b.RetentionPeriod) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		di, dj := b.MeasurementRetention[names[i]], b.MeasurementRetention[names[j]]
+		if di != dj {
+			return di < dj
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		d := b.MeasurementRetention[name]
+		min := int64(math.MinInt64)
+		max := now.Add(-d).UnixNano()
+		fields := append(bucketFields[:len(bucketFields):len(bucketFields)],
+			zap.String("measurement", name),
+			zap.Duration("measurement_retention_period", d),
+		)
+
+		span, ctx := tracing.StartSpanFromContext(ctx)
+		span.LogKV(
+			"bucket_id", b.ID,
+			"org_id", b.OrgID,
+			"measurement", name,
+			"retention_period", d,
+			"from", time.Unix(0, min).UTC(),
+			"to", time.Unix(0, max).UTC(),
+		)
+
+		pred, err := measurementPredicate(name)
+		if err == nil {
+			err = md.DeleteBucketRangePredicate(ctx, b.OrgID, b.ID, min, max, pred)
+		}
+		if err != nil {
+			logger.Info("Unable to delete measurement range",
+				append(fields, zap.Time("min", time.Unix(0, min)), zap.Time("max", time.Unix(0, max)), zap.Error(err))...)
+			tracing.LogError(span, err)
+		}
+		s.tracker.IncMeasurementChecks(err == nil)
+		span.Finish()
+	}
+}
+
+// measurementPredicate returns a predicate matching the series of a measurement.
+func measurementPredicate(name string) (influxdb.Predicate, error) {
+	return predicate.New(predicate.TagRuleNode{
+		Tag:      influxdb.Tag{Key: "_measurement", Value: name},
+		Operator: influxdb.Equal,
+	})
+}
+
 // getBucketInformation returns a slice of buckets to run retention on.
 func (s *retentionEnforcer) getBucketInformation(ctx context.Context) ([]*influxdb.Bucket, error) {
 	ctx, cancel := context.WithTimeout(ctx, bucketAPITimeout)
@@ -219,6 +299,20 @@ func (t *retentionTracker) IncChecks(success bool) {
 	t.metrics.Checks.With(labels).Inc()
 }
 
+// IncMeasurementChecks signals that a check happened for the
+// retention period of some measurement.
+func (t *retentionTracker) IncMeasurementChecks(success bool) {
+	labels := t.Labels()
+
+	if success {
+		labels["status"] = "ok"
+	} else {
+		labels["status"] = "error"
+	}
+
+	t.metrics.MeasurementChecks.With(labels).Inc()
+}
+
 // CheckDuration records the overall duration of a full retention check.
 func (t *retentionTracker) CheckDuration(dur time.Duration, success bool) {
 	labels := t.Labels()
@@ -240,6 +240,71 @@ func TestRetentionService(t *testing.T) {
 	})
 }
 
+func TestRetentionService_MeasurementRetention(t *testing.T) {
+	t.Parallel()
+	engine := NewTestEngine()
+	service := newRetentionEnforcer(engine, &TestSnapshotter{}, NewTestBucketFinder())
+	now := time.Date(2018, 4, 10, 23, 12, 33, 0, time.UTC)
+
+	orgID, bucketID := influxdb.ID(1), influxdb.ID(2)
+	buckets := []*influxdb.Bucket{{
+		OrgID:           orgID,
+		ID:              bucketID,
+		RetentionPeriod: 24 * time.Hour,
+		MeasurementRetention: map[string]time.Duration{
+			"quiet": 6 * time.Hour,
+			"noisy": time.Hour,
+			// Overrides longer than the bucket retention are ignored.
+			"archive": 48 * time.Hour,
+		},
+	}}
+
+	type deletion struct {
+		name string
+		to   int64
+	}
+	var got []deletion
+	engine.DeleteBucketRangeFn = func(ctx context.Context, org, bucket influxdb.ID, from, to int64) error {
+		got = append(got, deletion{to: to})
+		return nil
+	}
+	engine.DeleteBucketRangePredicateFn = func(ctx context.Context, org, bucket influxdb.ID, from, to int64, pred influxdb.Predicate) error {
+		if org != orgID || bucket != bucketID {
+			t.Fatalf("got a delete for %s/%s", org, bucket)
+		}
+		if from != math.MinInt64 {
+			t.Fatalf("got from %d, expected %d", from, int64(math.MinInt64))
+		}
+		data, err := pred.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"noisy", "quiet", "archive"} {
+			exp, err := measurementPredicate(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expData, _ := exp.Marshal(); reflect.DeepEqual(data, expData) {
+				got = append(got, deletion{name: name, to: to})
+				return nil
+			}
+		}
+		t.Fatalf("got a delete for an unexpected predicate %q", data)
+		return nil
+	}
+
+	service.expireData(context.Background(), buckets, now)
+
+	exp := []deletion{
+		{name: "noisy", to: now.Add(-time.Hour).UnixNano()},
+		{name: "quiet", to: now.Add(-6 * time.Hour).UnixNano()},
+		{to: now.Add(-24 * time.Hour).UnixNano()},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got\n%#v\nexpected\n%#v", got, exp)
+	}
+}
+
 func TestMetrics_Retention(t *testing.T) {
 	t.Parallel()
 	// metrics to be shared by multiple file stores.
@@ -257,6 +322,8 @@ func TestMetrics_Retention(t *testing.T) {
 	for _, tracker := range []*retentionTracker{t1, t2} {
 		tracker.IncChecks(true)
 		tracker.IncChecks(false)
+		tracker.IncMeasurementChecks(true)
+		tracker.IncMeasurementChecks(false)
 		tracker.CheckDuration(time.Second, true)
 		tracker.CheckDuration(time.Second, false)
 	}
@@ -283,6 +350,12 @@ func TestMetrics_Retention(t *testing.T) {
 				t.Errorf("[%s %d %v] got %v, expected %v", name, i, labels, got, exp)
 			}
 
+			name = base + "measurement_checks_total"
+			metric = promtest.MustFindMetric(t, mfs, name, labels)
+			if got, exp := metric.GetCounter().GetValue(), float64(1); got != exp {
+				t.Errorf("[%s %d %v] got %v, expected %v", name, i, labels, got, exp)
+			}
+
 			name = base + "check_duration_seconds"
 			metric = promtest.MustFindMetric(t, mfs, name, labels)
 			if got, exp := metric.GetHistogram().GetSampleSum(), float64(1); got != exp {
@@ -314,12 +387,14 @@ func (r *MockRunner) run() {
 }
 
 type TestEngine struct {
-	DeleteBucketRangeFn func(context.Context, influxdb.ID, influxdb.ID, int64, int64) error
+	DeleteBucketRangeFn          func(context.Context, influxdb.ID, influxdb.ID, int64, int64) error
+	DeleteBucketRangePredicateFn func(context.Context, influxdb.ID, influxdb.ID, int64, int64, influxdb.Predicate) error
 }
 
 func NewTestEngine() *TestEngine {
 	return &TestEngine{
-		DeleteBucketRangeFn: func(context.Context, influxdb.ID, influxdb.ID, int64, int64) error { return nil },
+		DeleteBucketRangeFn:          func(context.Context, influxdb.ID, influxdb.ID, int64, int64) error { return nil },
+		DeleteBucketRangePredicateFn: func(context.Context, influxdb.ID, influxdb.ID, int64, int64, influxdb.Predicate) error { return nil },
 	}
 }
 
@@ -327,6 +402,10 @@ func (e *TestEngine) DeleteBucketRange(ctx context.Context, orgID, bucketID infl
 	return e.DeleteBucketRangeFn(ctx, orgID, bucketID, min, max)
 }
 
+func (e *TestEngine) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID influxdb.ID, min, max int64, pred influxdb.Predicate) error {
+	return e.DeleteBucketRangePredicateFn(ctx, orgID, bucketID, min, max, pred)
+}
+
 type TestSnapshotter struct{}
 
 func (s *TestSnapshotter) WriteSnapshot(ctx context.Context, status tsm1.CacheStatus) error {
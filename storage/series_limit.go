@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// limitSeries removes the points of collection that would create more
+// than Config.MaxSeriesPerBucket series in their bucket, and returns an
+// error describing them if there are any. Points of existing series are
+// always kept. The limit is checked before the series are created, so
+// concurrent writes of new series may exceed it slightly.
+func (e *Engine) limitSeries(collection *tsdb.SeriesCollection) error {
+	limit := e.config.MaxSeriesPerBucket
+	if limit <= 0 {
+		return nil
+	}
+
+	var (
+		// seriesN is the number of series of each bucket,
+		// including the new series kept so far.
+		seriesN = make(map[string]int)
+		created = make(map[string]struct{})
+		buf     []byte
+
+		bucket  []byte
+		dropped int
+	)
+
+	j := 0
+	for iter := collection.Iterator(); iter.Next(); {
+		name, key := iter.Name(), iter.Key()
+		if _, ok := created[string(key)]; ok || e.sfile.HasSeries(name, iter.Tags(), buf) {
+			collection.Copy(j, iter.Index())
+			j++
+			continue
+		}
+
+		n, ok := seriesN[string(name)]
+		if !ok {
+			var err error
+			if n, err = e.index.MeasurementSeriesN(name); err != nil {
+				return err
+			}
+		}
+		if n >= limit {
+			if dropped == 0 {
+				bucket = name
+			}
+			dropped++
+			seriesN[string(name)] = n
+			continue
+		}
+
+		seriesN[string(name)] = n + 1
+		created[string(key)] = struct{}{}
+		collection.Copy(j, iter.Index())
+		j++
+	}
+	collection.Truncate(j)
+
+	if dropped == 0 {
+		return nil
+	}
+	_, bucketID := tsdb.DecodeNameSlice(bucket)
+	return &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg: fmt.Sprintf("bucket %s has reached its limit of %d series: dropped %d points of new series",
+			bucketID, limit, dropped),
+	}
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"golang.org/x/time/rate"
+)
+
+// writeLimiterSweepInterval is how often the limiters of
+// the buckets that have stopped writing are removed.
+const writeLimiterSweepInterval = time.Minute
+
+// writeLimiter limits the rate at which points are written to each bucket.
+type writeLimiter struct {
+	limit int
+
+	mu        sync.Mutex
+	limiters  map[string]*bucketWriteLimiter // keyed by the encoded bucket name
+	lastSweep time.Time
+}
+
+// bucketWriteLimiter is the limiter of a bucket and the time it was last used.
+type bucketWriteLimiter struct {
+	*rate.Limiter
+	last time.Time
+}
+
+func newWriteLimiter(limit int) *writeLimiter {
+	return &writeLimiter{
+		limit:    limit,
+		limiters: make(map[string]*bucketWriteLimiter),
+	}
+}
+
+// bucketLimiter returns the limiter of the bucket with the encoded name
+// and removes the limiters of idle buckets once every sweep interval.
+func (l *writeLimiter) bucketLimiter(name []byte, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= writeLimiterSweepInterval {
+		l.sweep(now)
+	}
+	lim, ok := l.limiters[string(name)]
+	if !ok {
+		lim = &bucketWriteLimiter{Limiter: rate.NewLimiter(rate.Limit(l.limit), l.limit)}
+		l.limiters[string(name)] = lim
+	}
+	lim.last = now
+	return lim.Limiter
+}
+
+// sweep removes the limiters that have not been used for a second. The
+// limit of such a bucket is refilled, so a new limiter is the same.
+func (l *writeLimiter) sweep(now time.Time) {
+	for name, lim := range l.limiters {
+		if now.Sub(lim.last) >= time.Second {
+			delete(l.limiters, name)
+		}
+	}
+	l.lastSweep = now
+}
+
+// remove removes the limiter of a deleted bucket.
+func (l *writeLimiter) remove(orgID, bucketID influxdb.ID) {
+	name := tsdb.EncodeName(orgID, bucketID)
+	l.mu.Lock()
+	delete(l.limiters, string(name[:]))
+	l.mu.Unlock()
+}
+
+// allow reserves the points of collection from the limiters of their
+// buckets and returns an error if any of the buckets is over its limit.
+// Either all of the points are allowed or none of them are, so that a
+// rejected batch can be retried as a whole. A batch larger than the
+// limit is allowed when the bucket has not written for a full second.
+func (l *writeLimiter) allow(collection *tsdb.SeriesCollection) error {
+	pointsN := make(map[string]int)
+	for iter := collection.Iterator(); iter.Next(); {
+		pointsN[string(iter.Name())]++
+	}
+
+	now := time.Now()
+	reservations := make([]*rate.Reservation, 0, len(pointsN))
+	for name, n := range pointsN {
+		if n > l.limit {
+			n = l.limit
+		}
+		r := l.bucketLimiter([]byte(name), now).ReserveN(now, n)
+		if r.OK() && r.DelayFrom(now) == 0 {
+			reservations = append(reservations, r)
+			continue
+		}
+
+		r.CancelAt(now)
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+		_, bucketID := tsdb.DecodeNameSlice([]byte(name))
+		return &influxdb.Error{
+			Code: influxdb.ETooManyRequests,
+			Msg:  fmt.Sprintf("bucket %s has exceeded its write rate limit of %d points per second", bucketID, l.limit),
+		}
+	}
+	return nil
+}
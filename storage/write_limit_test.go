@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+func TestWriteLimiter_Evict(t *testing.T) {
+	l := newWriteLimiter(10)
+	name := func(bucketID influxdb.ID) []byte {
+		b := tsdb.EncodeName(1, bucketID)
+		return b[:]
+	}
+
+	start := time.Now()
+	l.bucketLimiter(name(1), start)
+	l.bucketLimiter(name(2), start)
+	l.bucketLimiter(name(3), start)
+
+	// Bucket 2 keeps writing, so only the idle buckets are swept.
+	l.bucketLimiter(name(2), start.Add(writeLimiterSweepInterval-time.Millisecond))
+	l.bucketLimiter(name(2), start.Add(writeLimiterSweepInterval))
+	if got, exp := len(l.limiters), 1; got != exp {
+		t.Fatalf("unexpected number of limiters after a sweep: got %d, exp %d", got, exp)
+	}
+	if _, ok := l.limiters[string(name(2))]; !ok {
+		t.Fatal("expected the limiter of the writing bucket to be kept")
+	}
+
+	// The limiter of a deleted bucket is removed.
+	l.remove(1, 2)
+	if got, exp := len(l.limiters), 0; got != exp {
+		t.Fatalf("unexpected number of limiters after a delete: got %d, exp %d", got, exp)
+	}
+}
@@ -381,6 +381,18 @@ func (s *Store) UpdateBucket(ctx context.Context, tx kv.Tx, id influxdb.ID, upd
 		bucket.RetentionPeriod = *upd.RetentionPeriod
 	}
 
+	if upd.SchemaType != nil {
+		bucket.SchemaType = *upd.SchemaType
+	}
+
+	if upd.Fields != nil {
+		bucket.Fields = *upd.Fields
+	}
+
+	if upd.MeasurementRetention != nil {
+		bucket.MeasurementRetention = *upd.MeasurementRetention
+	}
+
 	v, err := marshalBucket(bucket)
 	if err != nil {
 		return nil, err
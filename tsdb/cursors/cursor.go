@@ -46,6 +46,10 @@ type CursorRequest struct {
 	Ascending bool
 	StartTime int64
 	EndTime   int64
+
+	// TimestampsOnly indicates the caller only requires the timestamps
+	// of the returned cursor. Values may not be decoded.
+	TimestampsOnly bool
 }
 
 type CursorIterator interface {
@@ -64,3 +68,22 @@ func (s *CursorStats) Add(other CursorStats) {
 	s.ScannedValues += other.ScannedValues
 	s.ScannedBytes += other.ScannedBytes
 }
+
+// BlockStat describes a single block of data stored by the engine.
+type BlockStat struct {
+	// SeriesKey and Field identify the series of the block.
+	SeriesKey []byte
+	Field     []byte
+
+	// The min and max time of all points stored in the block.
+	MinTime, MaxTime int64
+
+	// Count is the number of points stored in the block.
+	Count int
+
+	// Size is the number of bytes of the block in its file.
+	Size uint32
+
+	// Type is the data type of the values of the block.
+	Type FieldType
+}
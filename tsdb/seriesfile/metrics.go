@@ -42,6 +42,7 @@ type seriesFileMetrics struct {
 	SeriesCreated *prometheus.CounterVec // Number of series created in Series File.
 	Series        *prometheus.GaugeVec   // Number of series.
 	DiskSize      *prometheus.GaugeVec   // Size occupied on disk.
+	SegmentSize   *prometheus.GaugeVec   // Size of series data written to segments.
 	Segments      *prometheus.GaugeVec   // Number of segment files.
 
 	CompactionsActive  *prometheus.GaugeVec     // Number of active compactions.
@@ -83,6 +84,12 @@ func newSeriesFileMetrics(labels prometheus.Labels) *seriesFileMetrics {
 			Name:      "disk_bytes",
 			Help:      "Number of bytes Series File is using on disk.",
 		}, names),
+		SegmentSize: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: seriesFileSubsystem,
+			Name:      "segment_bytes",
+			Help:      "Number of bytes of series data written to Series File segments.",
+		}, names),
 		Segments: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Subsystem: seriesFileSubsystem,
@@ -118,6 +125,7 @@ func (m *seriesFileMetrics) PrometheusCollectors() []prometheus.Collector {
 		m.SeriesCreated,
 		m.Series,
 		m.DiskSize,
+		m.SegmentSize,
 		m.Segments,
 		m.CompactionsActive,
 		m.CompactionDuration,
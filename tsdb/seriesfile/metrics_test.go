@@ -32,6 +32,7 @@ func TestMetrics_SeriesPartition(t *testing.T) {
 		base + "disk_bytes",
 		base + "segments_total",
 		base + "index_compactions_active",
+		base + "segment_bytes",
 	}
 
 	counters := []string{
@@ -52,6 +53,7 @@ func TestMetrics_SeriesPartition(t *testing.T) {
 		labels := tracker.Labels()
 		labels["component"] = "index"
 		tracker.metrics.CompactionsActive.With(labels).Add(float64(i + len(gauges[3])))
+		tracker.SetSegmentSize(uint64(i + len(gauges[4])))
 
 		tracker.AddSeriesCreated(uint64(i + len(counters[0])))
 		labels = tracker.Labels()
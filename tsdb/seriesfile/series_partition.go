@@ -111,6 +111,7 @@ func (p *SeriesPartition) Open() error {
 
 	p.tracker.SetSeries(p.index.Count()) // Set series count metric.
 	p.tracker.SetDiskSize(p.DiskSize())  // Set on-disk size metric.
+	p.tracker.SetSegmentSize(p.SegmentSize())
 	return nil
 }
 
@@ -332,6 +333,7 @@ func (p *SeriesPartition) CreateSeriesListIfNotExists(collection *tsdb.SeriesCol
 	}
 	p.tracker.AddSeriesCreated(uint64(len(newKeyRanges))) // Track new series in metric.
 	p.tracker.AddSeries(uint64(len(newKeyRanges)))
+	p.tracker.SetSegmentSize(p.segmentSize())
 
 	// Check if we've crossed the compaction threshold.
 	if p.compactionsEnabled() && !p.compacting && p.CompactThreshold != 0 && p.index.InMemCount() >= uint64(p.CompactThreshold) {
@@ -497,6 +499,29 @@ func (p *SeriesPartition) diskSize() uint64 {
 	return totalSize
 }
 
+// SegmentSize returns the number of bytes of series data written to
+// the segments of the partition. Unlike DiskSize, it grows as each
+// series is created rather than as each segment is allocated.
+func (p *SeriesPartition) SegmentSize() uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.segmentSize()
+}
+
+func (p *SeriesPartition) segmentSize() uint64 {
+	var totalSize uint64
+	for i, segment := range p.segments {
+		// Only the active segment tracks the size of its data.
+		// Earlier segments were filled before a new one was created.
+		if i == len(p.segments)-1 {
+			totalSize += uint64(segment.Size())
+		} else {
+			totalSize += uint64(len(segment.Data()))
+		}
+	}
+	return totalSize
+}
+
 func (p *SeriesPartition) DisableCompactions() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -586,6 +611,7 @@ func (p *SeriesPartition) createSegment() (*SeriesSegment, error) {
 	}
 	p.tracker.SetSegments(uint64(len(p.segments)))
 	p.tracker.SetDiskSize(p.diskSize()) // Disk size will change with new segment.
+	p.tracker.SetSegmentSize(p.segmentSize())
 	return segment, nil
 }
 
@@ -682,6 +708,17 @@ func (t *seriesPartitionTracker) SetDiskSize(sz uint64) {
 	t.metrics.DiskSize.With(labels).Set(float64(sz))
 }
 
+// SetSegmentSize sets the number of bytes of series data written to the
+// segments of the partition.
+func (t *seriesPartitionTracker) SetSegmentSize(sz uint64) {
+	if !t.enabled {
+		return
+	}
+
+	labels := t.Labels()
+	t.metrics.SegmentSize.With(labels).Set(float64(sz))
+}
+
 // SetSegments sets the number of segments files for the partition.
 func (t *seriesPartitionTracker) SetSegments(n uint64) {
 	if !t.enabled {
@@ -116,6 +116,12 @@ type Index struct {
 	partitionMetrics *partitionMetrics // Maintain a single set of partition metrics to be shared by partition.
 	metricsEnabled   bool
 
+	// Number of series of each measurement. A measurement is counted when
+	// it is first requested, and the count is then kept up to date as
+	// series are created. It is removed when series are dropped.
+	seriesNMu sync.Mutex
+	seriesN   map[string]int
+
 	// The following may be set when initializing an Index.
 	path               string        // Root directory of the index partitions.
 	disableCompactions bool          // Initially disables compactions on the index.
@@ -148,6 +154,7 @@ func NewIndex(sfile *seriesfile.SeriesFile, c Config, options ...IndexOption) *I
 	idx := &Index{
 		tagValueCache:    NewTagValueSeriesIDCache(c.SeriesIDSetCacheSize),
 		partitionMetrics: newPartitionMetrics(nil),
+		seriesN:          make(map[string]int),
 		metricsEnabled:   true,
 		maxLogFileSize:   int64(c.MaxIndexLogFileSize),
 		logger:           zap.NewNop(),
@@ -624,8 +631,9 @@ func (i *Index) DropMeasurement(name []byte) error {
 		}()
 	}
 
-	// Remove any cached bitmaps for the measurement.
+	// Remove any cached bitmaps and series count for the measurement.
 	i.tagValueCache.DeleteMeasurement(name)
+	i.resetSeriesN(name)
 
 	// Check for error
 	for i := 0; i < cap(errC); i++ {
@@ -717,6 +725,7 @@ func (i *Index) CreateSeriesListIfNotExists(collection *tsdb.SeriesCollection) e
 				}
 				i.tagValueCache.RUnlock()
 
+				i.addSeriesN(pCollections[idx].Names, ids)
 				errC <- err
 			}
 		}()
@@ -757,6 +766,10 @@ func (i *Index) DropSeries(items []DropSeriesItem, cascade bool) error {
 		return err
 	}
 
+	for _, item := range items {
+		i.resetSeriesN(models.ParseName(item.Key))
+	}
+
 	if !cascade {
 		return nil
 	}
@@ -814,6 +827,67 @@ func (i *Index) DropMeasurementIfSeriesNotExist(name []byte) error {
 	return i.DropMeasurement(name)
 }
 
+// MeasurementSeriesN returns the number of series of the measurement. The
+// series are counted the first time and the count is then kept up to date
+// by the index, so series created concurrently with the first count may be
+// counted twice.
+func (i *Index) MeasurementSeriesN(name []byte) (int, error) {
+	i.seriesNMu.Lock()
+	n, ok := i.seriesN[string(name)]
+	i.seriesNMu.Unlock()
+	if ok {
+		return n, nil
+	}
+
+	itr, err := i.MeasurementSeriesIDIterator(name)
+	if err != nil {
+		return 0, err
+	} else if itr != nil {
+		defer itr.Close()
+		for {
+			elem, err := itr.Next()
+			if err != nil {
+				return 0, err
+			} else if elem.SeriesID.IsZero() {
+				break
+			}
+			n++
+		}
+	}
+
+	i.seriesNMu.Lock()
+	defer i.seriesNMu.Unlock()
+	if m, ok := i.seriesN[string(name)]; ok {
+		// Counted concurrently.
+		return m, nil
+	}
+	i.seriesN[string(name)] = n
+	return n, nil
+}
+
+// addSeriesN adds the series created with the non-zero ids to the
+// counts of their measurements that have been counted.
+func (i *Index) addSeriesN(names [][]byte, ids []tsdb.SeriesID) {
+	i.seriesNMu.Lock()
+	defer i.seriesNMu.Unlock()
+	for j, id := range ids {
+		if id.IsZero() {
+			continue
+		}
+		if n, ok := i.seriesN[string(names[j])]; ok {
+			i.seriesN[string(names[j])] = n + 1
+		}
+	}
+}
+
+// resetSeriesN removes the count of series of the measurement,
+// so that its series are counted again when it is next requested.
+func (i *Index) resetSeriesN(name []byte) {
+	i.seriesNMu.Lock()
+	delete(i.seriesN, string(name))
+	i.seriesNMu.Unlock()
+}
+
 // SeriesN returns the series cardinality in the index. It is the sum of all
 // partition cardinalities.
 func (i *Index) SeriesN() int64 {
@@ -214,6 +214,53 @@ func TestIndex_DropMeasurement(t *testing.T) {
 	})
 }
 
+func TestIndex_MeasurementSeriesN(t *testing.T) {
+	idx := MustOpenIndex(2, tsi1.NewConfig())
+	defer idx.Close()
+
+	seriesN := func(name string) int {
+		t.Helper()
+		n, err := idx.MeasurementSeriesN([]byte(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if err := idx.CreateSeriesSliceIfNotExists([]Series{
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"region": "east"})},
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"region": "west"})},
+		{Name: []byte("disk"), Tags: models.NewTags(map[string]string{"region": "north"})},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := seriesN("cpu"), 2; got != exp {
+		t.Fatalf("got %d series of cpu, exp %d", got, exp)
+	}
+
+	// Series created after the first count are added to it.
+	if err := idx.CreateSeriesSliceIfNotExists([]Series{
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"region": "west"})},
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"region": "north"})},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := seriesN("cpu"), 3; got != exp {
+		t.Fatalf("got %d series of cpu, exp %d", got, exp)
+	}
+
+	// Dropped measurements are counted again.
+	if err := idx.DropMeasurement([]byte("cpu")); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := seriesN("cpu"), 0; got != exp {
+		t.Fatalf("got %d series of cpu, exp %d", got, exp)
+	}
+	if got, exp := seriesN("disk"), 1; got != exp {
+		t.Fatalf("got %d series of disk, exp %d", got, exp)
+	}
+}
+
 func TestIndex_Open(t *testing.T) {
 	// Opening a fresh index should set the MANIFEST version to current version.
 	idx := NewIndex(tsi1.DefaultPartitionN, tsi1.NewConfig())
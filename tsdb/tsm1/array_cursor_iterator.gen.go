@@ -15,10 +15,11 @@ import (
 )
 
 // buildFloatArrayCursor creates an array cursor for a float field.
-func (q *arrayCursorIterator) buildFloatArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) cursors.FloatArrayCursor {
+func (q *arrayCursorIterator) buildFloatArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions, timestampsOnly bool) cursors.FloatArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
 	cacheValues := q.e.Cache.Values(key)
 	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), opt.Ascending)
+	keyCursor.timestampsOnly = timestampsOnly
 
 	q.e.readTracker.AddSeeks(uint64(keyCursor.seekN()))
 
@@ -38,10 +39,11 @@ func (q *arrayCursorIterator) buildFloatArrayCursor(ctx context.Context, name []
 }
 
 // buildIntegerArrayCursor creates an array cursor for a integer field.
-func (q *arrayCursorIterator) buildIntegerArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) cursors.IntegerArrayCursor {
+func (q *arrayCursorIterator) buildIntegerArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions, timestampsOnly bool) cursors.IntegerArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
 	cacheValues := q.e.Cache.Values(key)
 	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), opt.Ascending)
+	keyCursor.timestampsOnly = timestampsOnly
 
 	q.e.readTracker.AddSeeks(uint64(keyCursor.seekN()))
 
@@ -61,10 +63,11 @@ func (q *arrayCursorIterator) buildIntegerArrayCursor(ctx context.Context, name
 }
 
 // buildUnsignedArrayCursor creates an array cursor for a unsigned field.
-func (q *arrayCursorIterator) buildUnsignedArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) cursors.UnsignedArrayCursor {
+func (q *arrayCursorIterator) buildUnsignedArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions, timestampsOnly bool) cursors.UnsignedArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
 	cacheValues := q.e.Cache.Values(key)
 	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), opt.Ascending)
+	keyCursor.timestampsOnly = timestampsOnly
 
 	q.e.readTracker.AddSeeks(uint64(keyCursor.seekN()))
 
@@ -84,10 +87,11 @@ func (q *arrayCursorIterator) buildUnsignedArrayCursor(ctx context.Context, name
 }
 
 // buildStringArrayCursor creates an array cursor for a string field.
-func (q *arrayCursorIterator) buildStringArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) cursors.StringArrayCursor {
+func (q *arrayCursorIterator) buildStringArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions, timestampsOnly bool) cursors.StringArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
 	cacheValues := q.e.Cache.Values(key)
 	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), opt.Ascending)
+	keyCursor.timestampsOnly = timestampsOnly
 
 	q.e.readTracker.AddSeeks(uint64(keyCursor.seekN()))
 
@@ -107,10 +111,11 @@ func (q *arrayCursorIterator) buildStringArrayCursor(ctx context.Context, name [
 }
 
 // buildBooleanArrayCursor creates an array cursor for a boolean field.
-func (q *arrayCursorIterator) buildBooleanArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) cursors.BooleanArrayCursor {
+func (q *arrayCursorIterator) buildBooleanArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions, timestampsOnly bool) cursors.BooleanArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
 	cacheValues := q.e.Cache.Values(key)
 	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), opt.Ascending)
+	keyCursor.timestampsOnly = timestampsOnly
 
 	q.e.readTracker.AddSeeks(uint64(keyCursor.seekN()))
 
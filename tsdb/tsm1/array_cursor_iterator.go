@@ -53,15 +53,15 @@ func (q *arrayCursorIterator) Next(ctx context.Context, r *cursors.CursorRequest
 	// Return appropriate cursor based on type.
 	switch typ := id.Type(); typ {
 	case models.Float:
-		return q.buildFloatArrayCursor(ctx, r.Name, r.Tags, r.Field, opt), nil
+		return q.buildFloatArrayCursor(ctx, r.Name, r.Tags, r.Field, opt, r.TimestampsOnly), nil
 	case models.Integer:
-		return q.buildIntegerArrayCursor(ctx, r.Name, r.Tags, r.Field, opt), nil
+		return q.buildIntegerArrayCursor(ctx, r.Name, r.Tags, r.Field, opt, r.TimestampsOnly), nil
 	case models.Unsigned:
-		return q.buildUnsignedArrayCursor(ctx, r.Name, r.Tags, r.Field, opt), nil
+		return q.buildUnsignedArrayCursor(ctx, r.Name, r.Tags, r.Field, opt, r.TimestampsOnly), nil
 	case models.String:
-		return q.buildStringArrayCursor(ctx, r.Name, r.Tags, r.Field, opt), nil
+		return q.buildStringArrayCursor(ctx, r.Name, r.Tags, r.Field, opt, r.TimestampsOnly), nil
 	case models.Boolean:
-		return q.buildBooleanArrayCursor(ctx, r.Name, r.Tags, r.Field, opt), nil
+		return q.buildBooleanArrayCursor(ctx, r.Name, r.Tags, r.Field, opt, r.TimestampsOnly), nil
 	default:
 		panic(fmt.Sprintf("unreachable: %v", typ))
 	}
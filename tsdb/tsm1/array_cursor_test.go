@@ -198,6 +198,61 @@ func TestFileStore_DuplicatePoints(t *testing.T) {
 	})
 }
 
+// Verifies a KeyCursor that only reads timestamps returns the same
+// timestamps, including when blocks overlap and tombstones exist.
+func TestFileStore_TimestampsOnly(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+	fs := NewFileStore(dir)
+
+	makeVals := func(ts ...int64) []Value {
+		vals := make([]Value, len(ts))
+		for i, t := range ts {
+			vals[i] = NewFloatValue(t, 1.01)
+		}
+		return vals
+	}
+
+	data := []keyValues{
+		{"m,_field=v#!~#v", makeVals(21, 30, 35)},
+		{"m,_field=v#!~#v", makeVals(44)},
+		{"m,_field=v#!~#v", makeVals(40, 46)},
+		{"m,_field=v#!~#v", makeVals(46, 51)},
+	}
+
+	files, err := newFiles(dir, data...)
+	if err != nil {
+		t.Fatalf("unexpected error creating files: %v", err)
+	}
+
+	_ = fs.Replace(nil, files)
+
+	if err := fs.DeleteRange([][]byte{[]byte("m,_field=v#!~#v")}, 30, 30); err != nil {
+		t.Fatalf("unexpected error deleting range: %v", err)
+	}
+
+	const START, END = 21, 100
+	kc := fs.KeyCursor(context.Background(), []byte("m,_field=v#!~#v"), START, true)
+	defer kc.Close()
+	kc.timestampsOnly = true
+	cur := newFloatArrayAscendingCursor()
+	cur.reset(START, END, nil, kc)
+
+	var got []int64
+	ar := cur.Next()
+	for ar.Len() > 0 {
+		if len(ar.Values) != len(ar.Timestamps) {
+			t.Fatalf("unexpected number of values; got %d, exp %d", len(ar.Values), len(ar.Timestamps))
+		}
+		got = append(got, ar.Timestamps...)
+		ar = cur.Next()
+	}
+
+	if exp := []int64{21, 35, 40, 44, 46, 51}; !cmp.Equal(got, exp) {
+		t.Errorf("unexpected values; -got/+exp\n%s", cmp.Diff(got, exp))
+	}
+}
+
 // Int64Slice attaches the methods of Interface to []int64, sorting in increasing order.
 type Int64Slice []int64
 
@@ -1,6 +1,7 @@
 package tsm1
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"math"
@@ -510,6 +511,34 @@ func (c *Cache) ApplyEntryFn(f func(key string, entry *entry) error) error {
 	return store.applySerial(f)
 }
 
+// errFoundKeyPrefix stops the scan of the cache once a key is found.
+var errFoundKeyPrefix = fmt.Errorf("found key prefix")
+
+// HasKeyPrefix reports whether the cache or its snapshot contains an entry
+// with a key beginning with prefix. The partitions of each store are
+// scanned concurrently. It is safe for use by multiple goroutines.
+func (c *Cache) HasKeyPrefix(prefix []byte) bool {
+	c.mu.RLock()
+	stores := []*ring{c.store}
+	if c.snapshot != nil {
+		stores = append(stores, c.snapshot.store)
+	}
+	c.mu.RUnlock()
+
+	for _, store := range stores {
+		err := store.apply(func(key []byte, e *entry) error {
+			if e.count() > 0 && bytes.HasPrefix(key, prefix) {
+				return errFoundKeyPrefix
+			}
+			return nil
+		})
+		if err == errFoundKeyPrefix {
+			return true
+		}
+	}
+	return false
+}
+
 // CacheLoader processes a set of WAL segment files, and loads a cache with the data
 // contained within those files.
 type CacheLoader struct {
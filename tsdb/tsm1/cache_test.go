@@ -276,6 +276,32 @@ func TestCache_DeleteBucketRange_NonExistent(t *testing.T) {
 	}
 }
 
+func TestCache_HasKeyPrefix(t *testing.T) {
+	c := NewCache(1024)
+	if err := c.Write([]byte("foo,a=1#!~#v"), Values{NewValue(1, 1.0)}); err != nil {
+		t.Fatalf("failed to write key foo to cache: %s", err.Error())
+	}
+	if !c.HasKeyPrefix([]byte("foo")) {
+		t.Fatal("expected key prefix foo in cache")
+	}
+
+	// Keys moved to the snapshot are still found.
+	if _, err := c.Snapshot(); err != nil {
+		t.Fatalf("failed to snapshot cache: %v", err)
+	}
+	if !c.HasKeyPrefix([]byte("foo")) {
+		t.Fatal("expected key prefix foo in snapshot")
+	}
+	if c.HasKeyPrefix([]byte("bar")) {
+		t.Fatal("unexpected key prefix bar in cache")
+	}
+
+	c.ClearSnapshot(true)
+	if c.HasKeyPrefix([]byte("foo")) {
+		t.Fatal("unexpected key prefix foo after clearing snapshot")
+	}
+}
+
 type stringPredicate string
 
 func (s stringPredicate) Clone() influxdb.Predicate { return s }
@@ -278,6 +278,10 @@ func (e *Engine) WithPageFaultLimiter(limiter *rate.Limiter) {
 	e.FileStore.WithPageFaultLimiter(limiter)
 }
 
+func (e *Engine) WithBucketPageFaultLimiter(limiter *BucketPageFaultLimiter) {
+	e.FileStore.WithBucketPageFaultLimiter(limiter)
+}
+
 func (e *Engine) WithCompactionPlanner(planner CompactionPlanner) {
 	planner.SetFileStore(e.FileStore)
 	e.CompactionPlan = planner
@@ -688,10 +692,12 @@ type compactionTracker struct {
 	active [6]uint64 // Gauge of TSM compactions (by level) currently running.
 	errors [6]uint64 // Counter of TSM compcations (by level) that have failed due to error.
 	queue  [6]uint64 // Gauge of TSM compactions queues (by level).
+
+	last int64 // Time in nanoseconds of the last successful TSM compaction.
 }
 
 func newCompactionTracker(metrics *compactionMetrics, defaultLables prometheus.Labels) *compactionTracker {
-	return &compactionTracker{metrics: metrics, labels: defaultLables}
+	return &compactionTracker{metrics: metrics, labels: defaultLables, last: time.Now().UnixNano()}
 }
 
 // Labels returns a copy of the default labels used by the tracker's metrics.
@@ -774,6 +780,12 @@ func (t *compactionTracker) Attempted(level compactionLevel, success bool, reaso
 		labels["reason"] = reason
 		labels["status"] = "ok"
 		t.metrics.Compactions.With(labels).Inc()
+
+		// Snapshots are not TSM compactions.
+		if level > 0 {
+			atomic.StoreInt64(&t.last, time.Now().UnixNano())
+			t.metrics.SinceLastCompaction.With(t.labels).Set(0)
+		}
 		return
 	}
 
@@ -785,6 +797,18 @@ func (t *compactionTracker) Attempted(level compactionLevel, success bool, reaso
 	t.metrics.Compactions.With(labels).Inc()
 }
 
+// SinceLastCompaction returns the time since the last successful TSM
+// compaction, or since the tracker was created if there has been none.
+func (t *compactionTracker) SinceLastCompaction(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, atomic.LoadInt64(&t.last)))
+}
+
+// UpdateSinceLastCompaction sets the seconds since the last successful
+// TSM compaction metric as of now.
+func (t *compactionTracker) UpdateSinceLastCompaction(now time.Time) {
+	t.metrics.SinceLastCompaction.With(t.labels).Set(t.SinceLastCompaction(now).Seconds())
+}
+
 // SnapshotAttempted updates the number of snapshots attempted.
 func (t *compactionTracker) SnapshotAttempted(success bool, reason CacheStatus, duration time.Duration) {
 	t.Attempted(0, success, reason.String(), duration)
@@ -945,6 +969,7 @@ const (
 	CacheStatusRetention                         // The cache was snapshotted before running retention.
 	CacheStatusFullCompaction                    // The cache was snapshotted as part of a full compaction.
 	CacheStatusBackup                            // The cache was snapshotted before running backup.
+	CacheStatusShutdown                          // The cache was snapshotted before the engine was closed.
 )
 
 // ShouldCompactCache returns a status indicating if the Cache should be
@@ -1005,6 +1030,8 @@ func (e *Engine) compact(wg *sync.WaitGroup) {
 
 			span, ctx := tracing.StartSpanFromContext(context.Background())
 
+			e.compactionTracker.UpdateSinceLastCompaction(time.Now())
+
 			// Find our compaction plans
 			level1Groups := e.CompactionPlan.PlanLevel(1)
 			level2Groups := e.CompactionPlan.PlanLevel(2)
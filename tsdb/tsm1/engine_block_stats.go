@@ -0,0 +1,162 @@
+package tsm1
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// ReadBlockStats returns the TSM blocks of the bucket that overlap the time
+// range [start, end], sorted by series key, field and min time. Only the
+// timestamps of each block are decoded. Data which has not been snapshotted
+// from the cache is not included.
+func (e *Engine) ReadBlockStats(ctx context.Context, orgID, bucketID influxdb.ID, start, end int64) ([]cursors.BlockStat, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	orgBucket := tsdb.EncodeName(orgID, bucketID)
+	prefix := models.EscapeMeasurement(orgBucket[:])
+
+	var (
+		mu    sync.Mutex
+		stats []cursors.BlockStat
+	)
+	if err := e.FileStore.Apply(func(r TSMFile) error {
+		if !r.OverlapsTimeRange(start, end) || !r.OverlapsKeyPrefixRange(prefix, prefix) {
+			return nil
+		}
+
+		var (
+			ts    cursors.TimestampArray
+			found []cursors.BlockStat
+		)
+		iter := r.Iterator(prefix)
+		for iter.Next() {
+			key := iter.Key()
+			if !bytes.HasPrefix(key, prefix) {
+				break
+			}
+
+			seriesKey, field := SeriesAndFieldFromCompositeKey(key)
+			entries := iter.Entries()
+			for i := range entries {
+				entry := &entries[i]
+				if !entry.OverlapsTimeRange(start, end) {
+					continue
+				}
+				if err := r.ReadTimestampArrayBlockAt(entry, &ts); err != nil {
+					return err
+				}
+				found = append(found, cursors.BlockStat{
+					SeriesKey: append([]byte(nil), seriesKey...),
+					Field:     append([]byte(nil), field...),
+					MinTime:   entry.MinTime,
+					MaxTime:   entry.MaxTime,
+					Count:     ts.Len(),
+					Size:      entry.Size,
+					Type:      BlockTypeToFieldType(iter.Type()),
+				})
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		stats = append(stats, found...)
+		mu.Unlock()
+		return nil
+	}); err != nil {
+		return nil, tracing.LogError(span, err)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if cmp := bytes.Compare(stats[i].SeriesKey, stats[j].SeriesKey); cmp != 0 {
+			return cmp < 0
+		}
+		if cmp := bytes.Compare(stats[i].Field, stats[j].Field); cmp != 0 {
+			return cmp < 0
+		}
+		return stats[i].MinTime < stats[j].MinTime
+	})
+	span.LogKV("blocks", len(stats))
+	return stats, nil
+}
+
+// PrefetchBlocks pages the TSM blocks of the bucket that overlap the time
+// range [start, end] into the OS page cache without decoding them. If
+// include is not nil, only the blocks of the series keys it returns true
+// for are paged in. It returns the number of bytes of the blocks paged in.
+// Data which has not been snapshotted from the cache is already in memory
+// and is not included.
+func (e *Engine) PrefetchBlocks(ctx context.Context, orgID, bucketID influxdb.ID, start, end int64, include func(seriesKey []byte) bool) (int64, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	orgBucket := tsdb.EncodeName(orgID, bucketID)
+	prefix := models.EscapeMeasurement(orgBucket[:])
+
+	var n int64
+	if err := e.FileStore.Apply(func(r TSMFile) error {
+		if !r.OverlapsTimeRange(start, end) || !r.OverlapsKeyPrefixRange(prefix, prefix) {
+			return nil
+		}
+
+		iter := r.Iterator(prefix)
+		for iter.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			key := iter.Key()
+			if !bytes.HasPrefix(key, prefix) {
+				break
+			}
+			if seriesKey, _ := SeriesAndFieldFromCompositeKey(key); include != nil && !include(seriesKey) {
+				continue
+			}
+
+			entries := iter.Entries()
+			for i := range entries {
+				entry := &entries[i]
+				if !entry.OverlapsTimeRange(start, end) {
+					continue
+				}
+				_, b, err := r.ReadBytes(entry, nil)
+				if err != nil {
+					return err
+				}
+				touchPages(b)
+				atomic.AddInt64(&n, int64(entry.Size))
+			}
+		}
+		return iter.Err()
+	}); err != nil {
+		return 0, tracing.LogError(span, err)
+	}
+
+	span.LogKV("bytes", n)
+	return n, nil
+}
+
+// touchPages reads a byte of each page of b so
+// that the pages are faulted into memory.
+func touchPages(b []byte) (sum byte) {
+	pageSize := os.Getpagesize()
+	for i := 0; i < len(b); i += pageSize {
+		sum += b[i]
+	}
+	if len(b) > 0 {
+		sum += b[len(b)-1]
+	}
+	return sum
+}
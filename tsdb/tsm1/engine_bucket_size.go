@@ -0,0 +1,52 @@
+package tsm1
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// BucketSize returns an estimate of the number of bytes used by the bucket.
+// A TSM file containing only keys of the bucket contributes its file size
+// and any other TSM file containing keys of the bucket contributes the size
+// of the blocks of those keys. The size of the values held in the cache for
+// the bucket is included. No blocks are read.
+func (e *Engine) BucketSize(orgID, bucketID influxdb.ID) int64 {
+	orgBucket := tsdb.EncodeName(orgID, bucketID)
+	prefix := models.EscapeMeasurement(orgBucket[:])
+
+	var size int64
+	e.FileStore.ForEachFile(func(f TSMFile) bool {
+		if !f.OverlapsKeyPrefixRange(prefix, prefix) {
+			return true
+		}
+
+		if min, max := f.KeyRange(); bytes.HasPrefix(min, prefix) && bytes.HasPrefix(max, prefix) {
+			size += int64(f.Size())
+			return true
+		}
+
+		iter := f.Iterator(prefix)
+		for iter.Next() {
+			if !bytes.HasPrefix(iter.Key(), prefix) {
+				break
+			}
+			for _, entry := range iter.Entries() {
+				size += int64(entry.Size)
+			}
+		}
+		return true
+	})
+
+	prefixStr := string(prefix)
+	_ = e.Cache.ApplyEntryFn(func(sfkey string, entry *entry) error {
+		if strings.HasPrefix(sfkey, prefixStr) {
+			size += int64(entry.size())
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,70 @@
+package tsm1_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/tsdb/tsm1"
+)
+
+func TestEngine_BucketSize(t *testing.T) {
+	e, err := NewEngine(tsm1.NewConfig(), t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	org, bucket1, bucket2 := influxdb.ID(0x5000), influxdb.ID(0x6000), influxdb.ID(0x7000)
+	write := func(bucket influxdb.ID, start, n int) {
+		t.Helper()
+		var buf strings.Builder
+		for i := start; i < start+n; i++ {
+			fmt.Fprintf(&buf, "cpu,host=A value=%d %d\n", i, i)
+		}
+		if err := e.writePoints(MustParseExplodePoints(org, bucket, buf.String())...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshot := func() {
+		t.Helper()
+		if err := e.WriteSnapshot(context.Background(), tsm1.CacheStatusColdNoWrites); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := e.BucketSize(org, bucket1); got != 0 {
+		t.Fatalf("got size %d for empty bucket, exp 0", got)
+	}
+
+	// Data in the cache.
+	write(bucket1, 0, 100)
+	cached := e.BucketSize(org, bucket1)
+	if cached <= 0 {
+		t.Fatalf("got size %d, exp size > 0", cached)
+	}
+
+	// Data in a TSM file holding only the bucket.
+	snapshot()
+	single := e.BucketSize(org, bucket1)
+	if single <= 0 {
+		t.Fatalf("got size %d after snapshot, exp size > 0", single)
+	}
+
+	// Data in a TSM file shared with another bucket.
+	write(bucket1, 100, 1000)
+	write(bucket2, 0, 100)
+	snapshot()
+	shared := e.BucketSize(org, bucket1)
+	if shared <= single {
+		t.Fatalf("got size %d after writing more data, exp size > %d", shared, single)
+	}
+	if got := e.BucketSize(org, bucket2); got <= 0 || got >= shared {
+		t.Fatalf("got size %d for other bucket, exp 0 < size < %d", got, shared)
+	}
+}
@@ -650,3 +650,37 @@ func ValidateMeasurementNamesTagPredicate(expr influxql.Expr) (err error) {
 	})
 	return err
 }
+
+// MayHaveDataInRange reports whether the bucket may contain data within
+// the time range [start, end]. Only the series of the index, the time and
+// key ranges of the TSM files and the keys in the cache are inspected, so
+// it may return true when there is no data within the range, but it never
+// returns false when there is.
+func (e *Engine) MayHaveDataInRange(orgID, bucketID influxdb.ID, start, end int64) bool {
+	orgBucket := tsdb.EncodeName(orgID, bucketID)
+
+	// A bucket without series in the index has no data at all.
+	if ok, err := e.index.MeasurementHasSeries(orgBucket[:]); err == nil && !ok {
+		return false
+	}
+
+	// Writes for the bucket that have not been written to a TSM file may be
+	// in the range. Checking the timestamps of every entry is too expensive,
+	// so any entry for the bucket is treated as a match. The cache is checked
+	// before the TSM files so that a snapshot written in between is seen in
+	// one of them.
+	tsmKeyPrefix := models.EscapeMeasurement(orgBucket[:])
+	if e.Cache.HasKeyPrefix(tsmKeyPrefix) {
+		return true
+	}
+
+	var found bool
+	e.FileStore.ForEachFile(func(f TSMFile) bool {
+		if f.OverlapsTimeRange(start, end) && f.OverlapsKeyPrefixRange(tsmKeyPrefix, tsmKeyPrefix) {
+			found = true
+			return false
+		}
+		return true
+	})
+	return found
+}
@@ -67,6 +67,12 @@ type TSMFile interface {
 	ReadBooleanBlockAt(entry *IndexEntry, values *[]BooleanValue) ([]BooleanValue, error)
 	ReadBooleanArrayBlockAt(entry *IndexEntry, values *cursors.BooleanArray) error
 
+	// ReadTimestampArrayBlockAt decodes only the timestamps of the block identified by entry.
+	ReadTimestampArrayBlockAt(entry *IndexEntry, values *cursors.TimestampArray) error
+
+	// ReadBytes returns the checksum and the encoded bytes of the block identified by entry.
+	ReadBytes(entry *IndexEntry, b []byte) (uint32, []byte, error)
+
 	// Entries returns the index entries for all blocks for the given key.
 	ReadEntries(key []byte, entries []IndexEntry) ([]IndexEntry, error)
 
@@ -225,7 +231,8 @@ type FileStore struct {
 
 	obs FileStoreObserver
 
-	pageFaultLimiter *rate.Limiter
+	pageFaultLimiter       *rate.Limiter
+	bucketPageFaultLimiter *BucketPageFaultLimiter
 }
 
 // FileStat holds information about a TSM file on disk.
@@ -300,6 +307,12 @@ func (f *FileStore) WithPageFaultLimiter(limiter *rate.Limiter) {
 	f.pageFaultLimiter = limiter
 }
 
+// WithBucketPageFaultLimiter sets the limiter used for limiting the page
+// faults of reads of each bucket separately. It must be set before Open.
+func (f *FileStore) WithBucketPageFaultLimiter(limiter *BucketPageFaultLimiter) {
+	f.bucketPageFaultLimiter = limiter
+}
+
 // WithLogger sets the logger on the file store.
 func (f *FileStore) WithLogger(log *zap.Logger) {
 	f.logger = log.With(zap.String("service", "filestore"))
@@ -679,6 +692,7 @@ func (f *FileStore) Open(ctx context.Context) error {
 			df, err := NewTSMReader(file,
 				WithMadviseWillNeed(f.tsmMMAPWillNeed),
 				WithTSMReaderPageFaultLimiter(f.pageFaultLimiter),
+				WithTSMReaderBucketPageFaults(f.bucketPageFaultLimiter != nil),
 				WithTSMReaderLogger(f.logger))
 			f.logger.Info("Opened file",
 				zap.String("path", file.Name()),
@@ -921,6 +935,7 @@ func (f *FileStore) replace(oldFiles, newFiles []string, updatedFn func(r []TSMF
 		tsm, err := NewTSMReader(fd,
 			WithMadviseWillNeed(f.tsmMMAPWillNeed),
 			WithTSMReaderPageFaultLimiter(f.pageFaultLimiter),
+			WithTSMReaderBucketPageFaults(f.bucketPageFaultLimiter != nil),
 			WithTSMReaderLogger(f.logger))
 		if err != nil {
 			return err
@@ -1353,10 +1368,18 @@ type KeyCursor struct {
 	ctx context.Context
 	col *metrics.Group
 
+	// pageFaultLimiter limits the page faults of the bucket of key.
+	// It is nil if the bucket is not limited separately.
+	pageFaultLimiter *rate.Limiter
+
 	// pos is the index within seeks.  Based on ascending, it will increment or
 	// decrement through the size of seeks slice.
 	pos       int
 	ascending bool
+
+	// timestampsOnly indicates the caller only requires the timestamps of
+	// each block and the values do not need to be decoded.
+	timestampsOnly bool
 }
 
 type location struct {
@@ -1413,6 +1436,8 @@ func newKeyCursor(ctx context.Context, fs *FileStore, key []byte, t int64, ascen
 		ctx:       ctx,
 		col:       metrics.GroupFromContext(ctx),
 		ascending: ascending,
+
+		pageFaultLimiter: fs.bucketPageFaultLimiter.Limiter(key),
 	}
 
 	if ascending {
@@ -1487,6 +1512,19 @@ func (c *KeyCursor) seekN() int {
 	return len(c.seeks)
 }
 
+// waitPageFaults rate limits the page faults of reading the block of l
+// with the limiter of the bucket of the cursor, if it has one.
+func (c *KeyCursor) waitPageFaults(l *location) error {
+	if c.pageFaultLimiter == nil {
+		return nil
+	}
+	r, ok := l.r.(*TSMReader)
+	if !ok {
+		return nil
+	}
+	return r.waitBlock(c.ctx, c.pageFaultLimiter, &l.entry)
+}
+
 // Next moves the cursor to the next position.
 // Data should be read by the ReadBlock functions.
 func (c *KeyCursor) Next() {
@@ -18,7 +18,7 @@ LOOP:
 
 	// First block is the oldest block containing the points we're searching for.
 	first := c.current[0]
-	err := first.r.ReadFloatArrayBlockAt(&first.entry, values)
+	err := c.readFloatArrayBlockAt(first, values)
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +90,7 @@ LOOP:
 			}
 
 			v := &cursors.FloatArray{}
-			err := cur.r.ReadFloatArrayBlockAt(&cur.entry, v)
+			err := c.readFloatArrayBlockAt(cur, v)
 			if err != nil {
 				return nil, err
 			}
@@ -152,7 +152,7 @@ LOOP:
 			}
 
 			v := &cursors.FloatArray{}
-			err := cur.r.ReadFloatArrayBlockAt(&cur.entry, v)
+			err := c.readFloatArrayBlockAt(cur, v)
 			if err != nil {
 				return nil, err
 			}
@@ -184,6 +184,31 @@ LOOP:
 	return values, err
 }
 
+// readFloatArrayBlockAt reads the block identified by l into values. If the
+// cursor only requires timestamps, the values of the block are not decoded and
+// values.Values is resized to match the number of timestamps.
+func (c *KeyCursor) readFloatArrayBlockAt(l *location, values *cursors.FloatArray) error {
+	if err := c.waitPageFaults(l); err != nil {
+		return err
+	}
+
+	if !c.timestampsOnly {
+		return l.r.ReadFloatArrayBlockAt(&l.entry, values)
+	}
+
+	ts := cursors.TimestampArray{Timestamps: values.Timestamps}
+	if err := l.r.ReadTimestampArrayBlockAt(&l.entry, &ts); err != nil {
+		return err
+	}
+	values.Timestamps = ts.Timestamps
+	if n := len(ts.Timestamps); cap(values.Values) >= n {
+		values.Values = values.Values[:n]
+	} else {
+		values.Values = make([]float64, n)
+	}
+	return nil
+}
+
 func excludeTombstonesFloatArray(t []TimeRange, values *cursors.FloatArray) {
 	for i := range t {
 		values.Exclude(t[i].Min, t[i].Max)
@@ -202,7 +227,7 @@ LOOP:
 
 	// First block is the oldest block containing the points we're searching for.
 	first := c.current[0]
-	err := first.r.ReadIntegerArrayBlockAt(&first.entry, values)
+	err := c.readIntegerArrayBlockAt(first, values)
 	if err != nil {
 		return nil, err
 	}
@@ -274,7 +299,7 @@ LOOP:
 			}
 
 			v := &cursors.IntegerArray{}
-			err := cur.r.ReadIntegerArrayBlockAt(&cur.entry, v)
+			err := c.readIntegerArrayBlockAt(cur, v)
 			if err != nil {
 				return nil, err
 			}
@@ -336,7 +361,7 @@ LOOP:
 			}
 
 			v := &cursors.IntegerArray{}
-			err := cur.r.ReadIntegerArrayBlockAt(&cur.entry, v)
+			err := c.readIntegerArrayBlockAt(cur, v)
 			if err != nil {
 				return nil, err
 			}
@@ -368,6 +393,31 @@ LOOP:
 	return values, err
 }
 
+// readIntegerArrayBlockAt reads the block identified by l into values. If the
+// cursor only requires timestamps, the values of the block are not decoded and
+// values.Values is resized to match the number of timestamps.
+func (c *KeyCursor) readIntegerArrayBlockAt(l *location, values *cursors.IntegerArray) error {
+	if err := c.waitPageFaults(l); err != nil {
+		return err
+	}
+
+	if !c.timestampsOnly {
+		return l.r.ReadIntegerArrayBlockAt(&l.entry, values)
+	}
+
+	ts := cursors.TimestampArray{Timestamps: values.Timestamps}
+	if err := l.r.ReadTimestampArrayBlockAt(&l.entry, &ts); err != nil {
+		return err
+	}
+	values.Timestamps = ts.Timestamps
+	if n := len(ts.Timestamps); cap(values.Values) >= n {
+		values.Values = values.Values[:n]
+	} else {
+		values.Values = make([]int64, n)
+	}
+	return nil
+}
+
 func excludeTombstonesIntegerArray(t []TimeRange, values *cursors.IntegerArray) {
 	for i := range t {
 		values.Exclude(t[i].Min, t[i].Max)
@@ -386,7 +436,7 @@ LOOP:
 
 	// First block is the oldest block containing the points we're searching for.
 	first := c.current[0]
-	err := first.r.ReadUnsignedArrayBlockAt(&first.entry, values)
+	err := c.readUnsignedArrayBlockAt(first, values)
 	if err != nil {
 		return nil, err
 	}
@@ -458,7 +508,7 @@ LOOP:
 			}
 
 			v := &cursors.UnsignedArray{}
-			err := cur.r.ReadUnsignedArrayBlockAt(&cur.entry, v)
+			err := c.readUnsignedArrayBlockAt(cur, v)
 			if err != nil {
 				return nil, err
 			}
@@ -520,7 +570,7 @@ LOOP:
 			}
 
 			v := &cursors.UnsignedArray{}
-			err := cur.r.ReadUnsignedArrayBlockAt(&cur.entry, v)
+			err := c.readUnsignedArrayBlockAt(cur, v)
 			if err != nil {
 				return nil, err
 			}
@@ -552,6 +602,31 @@ LOOP:
 	return values, err
 }
 
+// readUnsignedArrayBlockAt reads the block identified by l into values. If the
+// cursor only requires timestamps, the values of the block are not decoded and
+// values.Values is resized to match the number of timestamps.
+func (c *KeyCursor) readUnsignedArrayBlockAt(l *location, values *cursors.UnsignedArray) error {
+	if err := c.waitPageFaults(l); err != nil {
+		return err
+	}
+
+	if !c.timestampsOnly {
+		return l.r.ReadUnsignedArrayBlockAt(&l.entry, values)
+	}
+
+	ts := cursors.TimestampArray{Timestamps: values.Timestamps}
+	if err := l.r.ReadTimestampArrayBlockAt(&l.entry, &ts); err != nil {
+		return err
+	}
+	values.Timestamps = ts.Timestamps
+	if n := len(ts.Timestamps); cap(values.Values) >= n {
+		values.Values = values.Values[:n]
+	} else {
+		values.Values = make([]uint64, n)
+	}
+	return nil
+}
+
 func excludeTombstonesUnsignedArray(t []TimeRange, values *cursors.UnsignedArray) {
 	for i := range t {
 		values.Exclude(t[i].Min, t[i].Max)
@@ -570,7 +645,7 @@ LOOP:
 
 	// First block is the oldest block containing the points we're searching for.
 	first := c.current[0]
-	err := first.r.ReadStringArrayBlockAt(&first.entry, values)
+	err := c.readStringArrayBlockAt(first, values)
 	if err != nil {
 		return nil, err
 	}
@@ -642,7 +717,7 @@ LOOP:
 			}
 
 			v := &cursors.StringArray{}
-			err := cur.r.ReadStringArrayBlockAt(&cur.entry, v)
+			err := c.readStringArrayBlockAt(cur, v)
 			if err != nil {
 				return nil, err
 			}
@@ -704,7 +779,7 @@ LOOP:
 			}
 
 			v := &cursors.StringArray{}
-			err := cur.r.ReadStringArrayBlockAt(&cur.entry, v)
+			err := c.readStringArrayBlockAt(cur, v)
 			if err != nil {
 				return nil, err
 			}
@@ -736,6 +811,31 @@ LOOP:
 	return values, err
 }
 
+// readStringArrayBlockAt reads the block identified by l into values. If the
+// cursor only requires timestamps, the values of the block are not decoded and
+// values.Values is resized to match the number of timestamps.
+func (c *KeyCursor) readStringArrayBlockAt(l *location, values *cursors.StringArray) error {
+	if err := c.waitPageFaults(l); err != nil {
+		return err
+	}
+
+	if !c.timestampsOnly {
+		return l.r.ReadStringArrayBlockAt(&l.entry, values)
+	}
+
+	ts := cursors.TimestampArray{Timestamps: values.Timestamps}
+	if err := l.r.ReadTimestampArrayBlockAt(&l.entry, &ts); err != nil {
+		return err
+	}
+	values.Timestamps = ts.Timestamps
+	if n := len(ts.Timestamps); cap(values.Values) >= n {
+		values.Values = values.Values[:n]
+	} else {
+		values.Values = make([]string, n)
+	}
+	return nil
+}
+
 func excludeTombstonesStringArray(t []TimeRange, values *cursors.StringArray) {
 	for i := range t {
 		values.Exclude(t[i].Min, t[i].Max)
@@ -754,7 +854,7 @@ LOOP:
 
 	// First block is the oldest block containing the points we're searching for.
 	first := c.current[0]
-	err := first.r.ReadBooleanArrayBlockAt(&first.entry, values)
+	err := c.readBooleanArrayBlockAt(first, values)
 	if err != nil {
 		return nil, err
 	}
@@ -826,7 +926,7 @@ LOOP:
 			}
 
 			v := &cursors.BooleanArray{}
-			err := cur.r.ReadBooleanArrayBlockAt(&cur.entry, v)
+			err := c.readBooleanArrayBlockAt(cur, v)
 			if err != nil {
 				return nil, err
 			}
@@ -888,7 +988,7 @@ LOOP:
 			}
 
 			v := &cursors.BooleanArray{}
-			err := cur.r.ReadBooleanArrayBlockAt(&cur.entry, v)
+			err := c.readBooleanArrayBlockAt(cur, v)
 			if err != nil {
 				return nil, err
 			}
@@ -920,6 +1020,31 @@ LOOP:
 	return values, err
 }
 
+// readBooleanArrayBlockAt reads the block identified by l into values. If the
+// cursor only requires timestamps, the values of the block are not decoded and
+// values.Values is resized to match the number of timestamps.
+func (c *KeyCursor) readBooleanArrayBlockAt(l *location, values *cursors.BooleanArray) error {
+	if err := c.waitPageFaults(l); err != nil {
+		return err
+	}
+
+	if !c.timestampsOnly {
+		return l.r.ReadBooleanArrayBlockAt(&l.entry, values)
+	}
+
+	ts := cursors.TimestampArray{Timestamps: values.Timestamps}
+	if err := l.r.ReadTimestampArrayBlockAt(&l.entry, &ts); err != nil {
+		return err
+	}
+	values.Timestamps = ts.Timestamps
+	if n := len(ts.Timestamps); cap(values.Values) >= n {
+		values.Values = values.Values[:n]
+	} else {
+		values.Values = make([]bool, n)
+	}
+	return nil
+}
+
 func excludeTombstonesBooleanArray(t []TimeRange, values *cursors.BooleanArray) {
 	for i := range t {
 		values.Exclude(t[i].Min, t[i].Max)
@@ -74,6 +74,9 @@ type compactionMetrics struct {
 	CompactionDuration *prometheus.HistogramVec
 	CompactionQueue    *prometheus.GaugeVec
 
+	// SinceLastCompaction only has the default labels of the engine.
+	SinceLastCompaction *prometheus.GaugeVec
+
 	// The following metrics include a ``"status" = {ok, error}` label
 	Compactions *prometheus.CounterVec
 }
@@ -86,6 +89,12 @@ func newCompactionMetrics(labels prometheus.Labels) *compactionMetrics {
 	}
 	sort.Strings(names)
 
+	var engineNames []string
+	for k := range labels {
+		engineNames = append(engineNames, k)
+	}
+	sort.Strings(engineNames)
+
 	totalCompactionsNames := append(append([]string(nil), names...), []string{"reason", "status"}...)
 	sort.Strings(totalCompactionsNames)
 
@@ -116,6 +125,12 @@ func newCompactionMetrics(labels prometheus.Labels) *compactionMetrics {
 			Name:      "queued",
 			Help:      "Number of queued compactions.",
 		}, names),
+		SinceLastCompaction: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: compactionSubsystem,
+			Name:      "since_last_seconds",
+			Help:      "Number of seconds since the last successful TSM compaction, or since the engine was opened.",
+		}, engineNames),
 	}
 }
 
@@ -126,6 +141,7 @@ func (m *compactionMetrics) PrometheusCollectors() []prometheus.Collector {
 		m.CompactionsActive,
 		m.CompactionDuration,
 		m.CompactionQueue,
+		m.SinceLastCompaction,
 	}
 }
 
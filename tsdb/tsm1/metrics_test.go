@@ -2,6 +2,7 @@ package tsm1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
 	"github.com/prometheus/client_golang/prometheus"
@@ -216,3 +217,51 @@ func TestMetrics_Compactions(t *testing.T) {
 		}
 	}
 }
+
+func TestMetrics_SinceLastCompaction(t *testing.T) {
+	metrics := newCompactionMetrics(prometheus.Labels{"engine_id": "", "node_id": ""})
+	tracker := newCompactionTracker(metrics, prometheus.Labels{"engine_id": "0", "node_id": "0"})
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(metrics.PrometheusCollectors()...)
+
+	name := namespace + "_" + compactionSubsystem + "_since_last_seconds"
+	sinceLast := func() float64 {
+		t.Helper()
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		metric := promtest.MustFindMetric(t, mfs, name, prometheus.Labels{"engine_id": "0", "node_id": "0"})
+		return metric.GetGauge().GetValue()
+	}
+
+	// The engine has not compacted for a minute.
+	tracker.UpdateSinceLastCompaction(time.Now().Add(time.Minute))
+	if got := sinceLast(); got < 60 {
+		t.Fatalf("got %v seconds since last compaction, exp >= 60", got)
+	}
+
+	// Snapshots do not reset the metric.
+	tracker.SnapshotAttempted(true, CacheStatusColdNoWrites, time.Second)
+	if got := sinceLast(); got < 60 {
+		t.Fatalf("got %v seconds since last compaction after snapshot, exp >= 60", got)
+	}
+
+	// A compaction resets the metric.
+	tracker.Attempted(1, true, "", time.Second)
+	if got := sinceLast(); got != 0 {
+		t.Fatalf("got %v seconds since last compaction, exp 0", got)
+	}
+	tracker.UpdateSinceLastCompaction(time.Now())
+	if got := sinceLast(); got >= 1 {
+		t.Fatalf("got %v seconds since last compaction, exp < 1", got)
+	}
+
+	// Failed compactions do not reset the metric.
+	tracker.UpdateSinceLastCompaction(time.Now().Add(time.Minute))
+	tracker.Attempted(2, false, "", 0)
+	if got := sinceLast(); got < 60 {
+		t.Fatalf("got %v seconds since last compaction after failure, exp >= 60", got)
+	}
+}
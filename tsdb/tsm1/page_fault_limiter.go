@@ -0,0 +1,65 @@
+package tsm1
+
+import (
+	"sync"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"golang.org/x/time/rate"
+)
+
+// BucketPageFaultLimiter limits the page faults of reads of each bucket
+// with a limiter of its own, so that the reads of one bucket are not
+// throttled by the page faults of another. A bucket either has a limit
+// of its own or the default limit. Reads of buckets without a limit use
+// the page fault limiter of the engine, if any.
+type BucketPageFaultLimiter struct {
+	limit  rate.Limit
+	limits map[influxdb.ID]rate.Limit
+
+	mu       sync.Mutex
+	limiters map[influxdb.ID]*rate.Limiter
+}
+
+// NewBucketPageFaultLimiter returns a BucketPageFaultLimiter that allows
+// limit page faults per second for each bucket. The buckets in limits
+// allow their own number of page faults per second instead. A limit of
+// zero does not limit the buckets separately.
+func NewBucketPageFaultLimiter(limit rate.Limit, limits map[influxdb.ID]rate.Limit) *BucketPageFaultLimiter {
+	return &BucketPageFaultLimiter{
+		limit:    limit,
+		limits:   limits,
+		limiters: make(map[influxdb.ID]*rate.Limiter),
+	}
+}
+
+// Limiter returns the limiter of the bucket of key,
+// or nil if the bucket is not limited separately.
+func (l *BucketPageFaultLimiter) Limiter(key []byte) *rate.Limiter {
+	if l == nil || len(key) < 16 {
+		return nil
+	}
+	_, bucketID := tsdb.DecodeNameSlice(key[:16])
+	return l.BucketLimiter(bucketID)
+}
+
+// BucketLimiter returns the limiter of the bucket,
+// or nil if the bucket is not limited separately.
+func (l *BucketPageFaultLimiter) BucketLimiter(bucketID influxdb.ID) *rate.Limiter {
+	limit, ok := l.limits[bucketID]
+	if !ok {
+		limit = l.limit
+	}
+	if limit == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.limiters[bucketID]
+	if !ok {
+		limiter = rate.NewLimiter(limit, 1)
+		l.limiters[bucketID] = limiter
+	}
+	return limiter
+}
@@ -0,0 +1,62 @@
+package tsm1
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/pkg/mincore"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"golang.org/x/time/rate"
+)
+
+func TestBucketPageFaultLimiter(t *testing.T) {
+	const (
+		orgID       influxdb.ID = 0x10
+		slowBucket  influxdb.ID = 0x20
+		fastBucket  influxdb.ID = 0x30
+		otherBucket influxdb.ID = 0x40
+	)
+
+	l := NewBucketPageFaultLimiter(0, map[influxdb.ID]rate.Limit{
+		slowBucket: 1, // 1 fault per sec
+		fastBucket: rate.Inf,
+	})
+
+	key := func(bucketID influxdb.ID) []byte {
+		return append(tsdb.EncodeNameSlice(orgID, bucketID), ",tag0=v0"...)
+	}
+	slow, fast := l.Limiter(key(slowBucket)), l.Limiter(key(fastBucket))
+	if slow == nil || fast == nil {
+		t.Fatal("expected limiters for buckets with a limit")
+	} else if got := l.Limiter(key(slowBucket)); got != slow {
+		t.Fatal("expected the same limiter for every read of a bucket")
+	} else if got := l.Limiter(key(otherBucket)); got != nil {
+		t.Fatalf("unexpected limiter for bucket without a limit: %v", got)
+	}
+
+	// Every page of the data must be faulted in. The reads of each bucket
+	// share the in-core vector but are limited by their own limiters.
+	pageSize := os.Getpagesize()
+	data := make([]byte, 4*pageSize)
+	ml := mincore.NewLimiter(rate.NewLimiter(rate.Inf, 1), data)
+	ml.Mincore = func(data []byte) ([]byte, error) { return make([]byte, 4), nil }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// The slow bucket is throttled after its first page fault.
+	if err := ml.WaitRangeWith(ctx, slow, data[:2*pageSize]); err == nil {
+		t.Fatal("expected reads of the slow bucket to be throttled")
+	}
+
+	// The fast bucket proceeds while the slow bucket is throttled.
+	start := time.Now()
+	if err := ml.WaitRangeWith(ctx, fast, data[2*pageSize:]); err != nil {
+		t.Fatalf("unexpected error reading the fast bucket: %v", err)
+	} else if d := time.Since(start); d >= 100*time.Millisecond {
+		t.Fatalf("reads of the fast bucket were throttled: %s", d)
+	}
+}
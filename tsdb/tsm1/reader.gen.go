@@ -7,7 +7,10 @@
 package tsm1
 
 import (
+	"context"
+
 	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"golang.org/x/time/rate"
 )
 
 // ReadFloatBlockAt returns the float values corresponding to the given index entry.
@@ -108,6 +111,7 @@ type blockAccessor interface {
 	readBooleanBlock(entry *IndexEntry, values *[]BooleanValue) ([]BooleanValue, error)
 	readBooleanArrayBlock(entry *IndexEntry, values *cursors.BooleanArray) error
 	readBytes(entry *IndexEntry, buf []byte) (uint32, []byte, error)
+	waitBlock(ctx context.Context, limiter *rate.Limiter, entry *IndexEntry) error
 	rename(path string) error
 	path() string
 	close() error
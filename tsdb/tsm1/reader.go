@@ -2,12 +2,14 @@ package tsm1
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"sync"
 	"sync/atomic"
 
 	"github.com/influxdata/influxdb/v2/pkg/mincore"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 )
@@ -45,6 +47,10 @@ type TSMReader struct {
 
 	// limiter rate limits page faults by the underlying memory maps.
 	pageFaultLimiter *rate.Limiter
+
+	// bucketPageFaults is true if reads of some buckets limit
+	// their page faults with a limiter of their own.
+	bucketPageFaults bool
 }
 
 type tsmReaderOption func(*TSMReader)
@@ -62,6 +68,14 @@ var WithTSMReaderPageFaultLimiter = func(limiter *rate.Limiter) tsmReaderOption
 	}
 }
 
+// WithTSMReaderBucketPageFaults is an option for specifying whether reads of
+// some buckets limit their page faults separately. See BucketPageFaultLimiter.
+var WithTSMReaderBucketPageFaults = func(enabled bool) tsmReaderOption {
+	return func(r *TSMReader) {
+		r.bucketPageFaults = enabled
+	}
+}
+
 var WithTSMReaderLogger = func(logger *zap.Logger) tsmReaderOption {
 	return func(r *TSMReader) {
 		r.logger = logger
@@ -94,9 +108,14 @@ func NewTSMReader(f *os.File, options ...tsmReaderOption) (*TSMReader, error) {
 		return nil, err
 	}
 
-	// Set a limiter if passed in through options.
-	if t.pageFaultLimiter != nil {
-		accessor.pageFaultLimiter = mincore.NewLimiter(t.pageFaultLimiter, accessor.b)
+	// Set a limiter if passed in through options. Reads of buckets with their
+	// own limiter share the in-core vector of the accessor, so one is needed
+	// even if page faults are not limited otherwise.
+	if limiter := t.pageFaultLimiter; limiter != nil || t.bucketPageFaults {
+		if limiter == nil {
+			limiter = rate.NewLimiter(rate.Inf, 1)
+		}
+		accessor.pageFaultLimiter = mincore.NewLimiter(limiter, accessor.b)
 	}
 
 	t.accessor = accessor
@@ -189,6 +208,15 @@ func (t *TSMReader) ReadAt(entry *IndexEntry, vals []Value) ([]Value, error) {
 	return v, err
 }
 
+// waitBlock rate limits page faults to the block of entry with limiter
+// instead of the page fault limiter of the reader.
+func (t *TSMReader) waitBlock(ctx context.Context, limiter *rate.Limiter, entry *IndexEntry) error {
+	t.mu.RLock()
+	err := t.accessor.waitBlock(ctx, limiter, entry)
+	t.mu.RUnlock()
+	return err
+}
+
 // Read returns the values corresponding to the block at the given key and timestamp.
 func (t *TSMReader) Read(key []byte, timestamp int64) ([]Value, error) {
 	t.mu.RLock()
@@ -212,6 +240,18 @@ func (t *TSMReader) ReadBytes(e *IndexEntry, b []byte) (uint32, []byte, error) {
 	return n, v, err
 }
 
+// ReadTimestampArrayBlockAt fills vals with the timestamps of the block identified
+// by entry. The values of the block are not decompressed.
+func (t *TSMReader) ReadTimestampArrayBlockAt(entry *IndexEntry, vals *cursors.TimestampArray) error {
+	t.mu.RLock()
+	_, b, err := t.accessor.readBytes(entry, nil)
+	if err == nil {
+		err = DecodeTimestampArrayBlock(b, vals)
+	}
+	t.mu.RUnlock()
+	return err
+}
+
 // Type returns the type of values stored at the given key.
 func (t *TSMReader) Type(key []byte) (byte, error) {
 	return t.index.Type(key)
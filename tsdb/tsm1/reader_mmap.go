@@ -11,6 +11,7 @@ import (
 	"github.com/influxdata/influxdb/v2/pkg/fs"
 	"github.com/influxdata/influxdb/v2/pkg/mincore"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
 // mmapAccess is mmap based block accessor.  It access blocks through an
@@ -271,3 +272,23 @@ func (m *mmapAccessor) wait(b []byte) error {
 	}
 	return m.pageFaultLimiter.WaitRange(context.Background(), b)
 }
+
+// waitBlock rate limits page faults to the block of entry with limiter rather
+// than the limiter of the accessor. The pages of the block are then considered
+// memory resident so reading the block does not wait for them again.
+// Skipped if the accessor does not limit page faults.
+func (m *mmapAccessor) waitBlock(ctx context.Context, limiter *rate.Limiter, entry *IndexEntry) error {
+	if m.pageFaultLimiter == nil {
+		return nil
+	}
+
+	m.mu.RLock()
+	if int64(len(m.b)) < entry.Offset+int64(entry.Size) {
+		m.mu.RUnlock()
+		return ErrTSMClosed
+	}
+	block := m.b[entry.Offset : entry.Offset+int64(entry.Size)]
+	m.mu.RUnlock()
+
+	return m.pageFaultLimiter.WaitRangeWith(ctx, limiter, block)
+}